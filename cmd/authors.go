@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+// authorsOwnerVar limits `authors` without --repo to the repositories of one user, organization or group.
+var authorsOwnerVar string
+
+// authorsCmd prints per-author commit and line counts of one repository, or of every repository.
+var authorsCmd = &cobra.Command{
+	Use:   "authors",
+	Short: "Show commits, additions and deletions per author of --repo, or across all repositories.",
+	Long: `authors sums commits, additions and deletions per author over the commits of --repo.
+Without --repo it sums them across every repository you have access to, or those of --owner;
+repositories whose commits cannot be fetched are skipped with a warning.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gitService, repoIdentifier, err := gitServiceFromFlags(repoVar)
+		if err != nil {
+			return err
+		}
+		if repoVar == "" {
+			return cli.PrintAllAuthors(gitService, authorsOwnerVar, sinceTime, untilTime, os.Stdout, os.Stderr, outputOptions())
+		}
+		return cli.PrintAuthors(gitService, repoIdentifier, sinceTime, untilTime, os.Stdout, outputOptions())
+	},
+}
+
+func init() {
+	authorsCmd.Flags().StringVar(&authorsOwnerVar, "owner", "", "Without --repo, only include repositories of this organization (GitHub) or namespace (GitLab).")
+	rootCmd.AddCommand(authorsCmd)
+}
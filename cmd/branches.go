@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/api"
+	"github.com/ahmetk3436/git-stats-golang/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+// branchStaleDaysVar: branches without commits for this many days are reported as stale.
+var branchStaleDaysVar int
+
+// branchesCmd reports stale and merged branches of a single repository.
+var branchesCmd = &cobra.Command{
+	Use:   "branches",
+	Short: "Report branches with no recent commits or already merged into the default branch.",
+	Long: `branches lists the branches of --repo that have had no commits for --days days
+or that are already merged into the default branch.`,
+	Args: cobra.NoArgs,
+	RunE: runBranchReport,
+}
+
+func init() {
+	branchesCmd.Flags().IntVar(&branchStaleDaysVar, "days", api.DefaultStaleBranchDays, "Report branches with no commits in this many days (overrides analytics.stale_branch_days).")
+	rootCmd.AddCommand(branchesCmd)
+}
+
+// runBranchReport builds the GitService for the configured provider and prints the branch report.
+func runBranchReport(cmd *cobra.Command, args []string) error {
+	if err := requireRepo(); err != nil {
+		return err
+	}
+	gitService, repoIdentifier, err := gitServiceFromFlags(repoVar)
+	if err != nil {
+		return err
+	}
+	staleAfterDays := appConfig.Analytics.StaleBranchDays
+	if cmd.Flags().Changed("days") {
+		staleAfterDays = branchStaleDaysVar
+	}
+	return cli.PrintBranchReport(gitService, repoIdentifier, staleAfterDays, os.Stdout, outputOptions())
+}
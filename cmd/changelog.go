@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/changelog"
+	"github.com/ahmetk3436/git-stats-golang/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+// Flag values for the changelog subcommand.
+var (
+	changelogFromVar   string // Ref the changelog starts after.
+	changelogToVar     string // Ref the changelog ends at; empty for the default branch.
+	changelogFormatVar string // Output format, one of changelog.Formats.
+)
+
+// changelogCmd prints a grouped changelog of the commits between two refs.
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Generate a changelog from the commits between two refs.",
+	Long: `changelog groups the commits of --repo reachable from --to but not from --from by
+their Conventional Commits type and prints them as Markdown, JSON or Keep a Changelog.
+--output json and --output markdown select the matching --format.`,
+	Args: cobra.NoArgs,
+	RunE: runChangelog,
+}
+
+func init() {
+	changelogCmd.Flags().StringVar(&changelogFromVar, "from", "", "Tag or SHA the changelog starts after.")
+	changelogCmd.Flags().StringVar(&changelogToVar, "to", "", "Tag or SHA the changelog ends at (default branch if empty).")
+	changelogCmd.Flags().StringVar(&changelogFormatVar, "format", changelog.FormatMarkdown, "Output format: "+strings.Join(changelog.Formats, ", ")+".")
+	_ = changelogCmd.MarkFlagRequired("from")
+	rootCmd.AddCommand(changelogCmd)
+}
+
+// runChangelog builds the GitService for the configured provider and prints the changelog.
+func runChangelog(cmd *cobra.Command, args []string) error {
+	if err := requireRepo(); err != nil {
+		return err
+	}
+	format, err := changelogFormat(cmd)
+	if err != nil {
+		return err
+	}
+	gitService, repoIdentifier, err := gitServiceFromFlags(repoVar)
+	if err != nil {
+		return err
+	}
+	return cli.PrintChangelog(gitService, repoIdentifier, changelogFromVar, changelogToVar, format, os.Stdout)
+}
+
+// changelogFormat returns --format when it was given, otherwise the changelog format matching
+// --output. Table output is the default Markdown changelog.
+func changelogFormat(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Changed("format") {
+		return changelogFormatVar, nil
+	}
+	switch outputVar {
+	case cli.OutputTable, cli.OutputMarkdown:
+		return changelog.FormatMarkdown, nil
+	case cli.OutputJSON:
+		return changelog.FormatJSON, nil
+	default:
+		return "", fmt.Errorf("changelog does not support --output %s; use --format %s", outputVar, strings.Join(changelog.Formats, "|"))
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+// commitsCmd lists the commits of a repository.
+var commitsCmd = &cobra.Command{
+	Use:   "commits",
+	Short: "List the commits of --repo, optionally limited to --since/--until.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireRepo(); err != nil {
+			return err
+		}
+		gitService, repoIdentifier, err := gitServiceFromFlags(repoVar)
+		if err != nil {
+			return err
+		}
+		return cli.PrintCommits(gitService, repoIdentifier, sinceTime, untilTime, os.Stdout, outputOptions())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(commitsCmd)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+// locCmd prints the lines added and deleted in a repository.
+var locCmd = &cobra.Command{
+	Use:   "loc",
+	Short: "Show lines added, deleted and the net change in --repo over --since/--until.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireRepo(); err != nil {
+			return err
+		}
+		gitService, repoIdentifier, err := gitServiceFromFlags(repoVar)
+		if err != nil {
+			return err
+		}
+		return cli.PrintLinesOfCode(gitService, repoIdentifier, sinceTime, untilTime, os.Stdout, outputOptions())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(locCmd)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/cli"
+	"github.com/ahmetk3436/git-stats-golang/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+// Flag values for the report subcommand.
+var (
+	reportOutVar    string // File the report is written to; stdout if empty.
+	reportFormatVar string // Report format, one of report.Formats.
+)
+
+// reportCmd writes a self-contained HTML or Markdown report of a repository.
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate a self-contained HTML or Markdown report for --repo.",
+	Long: `report summarises --repo over --since/--until: commit activity and churn per month,
+top authors, the share of each language and the full contributor list. HTML reports embed
+their styles and SVG charts, so the file can be opened or shared without a server.
+Without --format, an --out file ending in .md is written as Markdown and anything else as HTML.`,
+	Args: cobra.NoArgs,
+	RunE: runReport,
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&reportOutVar, "out", "", "File to write the report to (stdout if empty).")
+	reportCmd.Flags().StringVar(&reportFormatVar, "format", report.FormatHTML, "Report format: "+strings.Join(report.Formats, ", ")+".")
+	rootCmd.AddCommand(reportCmd)
+}
+
+// runReport builds the GitService for the configured provider and writes the report to --out.
+func runReport(cmd *cobra.Command, args []string) error {
+	if err := requireRepo(); err != nil {
+		return err
+	}
+	format := reportFormat(cmd)
+	gitService, repoIdentifier, err := gitServiceFromFlags(repoVar)
+	if err != nil {
+		return err
+	}
+
+	if reportOutVar == "" {
+		return cli.WriteReport(gitService, repoIdentifier, sinceTime, untilTime, format, os.Stdout)
+	}
+	file, err := os.Create(reportOutVar)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", reportOutVar, err)
+	}
+	if err := cli.WriteReport(gitService, repoIdentifier, sinceTime, untilTime, format, file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", reportOutVar, err)
+	}
+	log.WithField("file", reportOutVar).Info("Report written.")
+	return nil
+}
+
+// reportFormat returns --format when it was given, otherwise the format implied by the
+// extension of --out.
+func reportFormat(cmd *cobra.Command) string {
+	if cmd.Flags().Changed("format") {
+		return reportFormatVar
+	}
+	switch strings.ToLower(filepath.Ext(reportOutVar)) {
+	case ".md", ".markdown":
+		return report.FormatMarkdown
+	default:
+		return report.FormatHTML
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+// reposOwnerVar limits `repos list` to the repositories of one user, organization or group.
+var reposOwnerVar string
+
+// reposCmd groups the repository subcommands.
+var reposCmd = &cobra.Command{
+	Use:   "repos",
+	Short: "Work with repositories.",
+}
+
+// reposListCmd lists the repositories visible to the configured token.
+var reposListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the repositories you have access to, or those of --owner.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gitService, _, err := gitServiceFromFlags("")
+		if err != nil {
+			return err
+		}
+		return cli.PrintRepos(gitService, reposOwnerVar, os.Stdout, outputOptions())
+	},
+}
+
+func init() {
+	reposListCmd.Flags().StringVar(&reposOwnerVar, "owner", "", "Only list repositories of this organization (GitHub) or namespace (GitLab).")
+	reposCmd.AddCommand(reposListCmd)
+	rootCmd.AddCommand(reposCmd)
+}
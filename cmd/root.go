@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/cli"
+	"github.com/ahmetk3436/git-stats-golang/pkg/config"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	"github.com/ahmetk3436/git-stats-golang/pkg/repository"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Global variables to hold persistent flag values. These are populated by Cobra.
+var (
+	configPathVar  string // Config file to read instead of the default location.
+	gitlabHostVar  string // Stores the GitLab host URL provided via flag.
+	gitlabTokenVar string // Stores the GitLab token provided via flag.
+	githubTokenVar string // Stores the GitHub token provided via flag.
+	providerVar    string // Git provider to query: "github" or "gitlab"; empty picks the one with a token.
+	repoVar        string // Repository to inspect: "owner/name", "namespace/path" or a numeric project ID.
+	sinceVar       string // Only consider commits authored at or after this time.
+	untilVar       string // Only consider commits authored before this time.
+	outputVar      string // Output format, one of cli.OutputFormats.
+	sortVar        string // Row order, one of cli.SortOrders; empty keeps each command's default.
+	topVar         int    // Keep only the first N rows; 0 keeps all.
+)
+
+// appConfig is the effective configuration: flags over environment over config file over defaults.
+// It is loaded and validated before any subcommand runs.
+var appConfig = config.Default()
+
+// Parsed values of --since and --until; zero when the flag is unset.
+var sinceTime, untilTime time.Time
+
+// rootCmd represents the base command when called without any subcommands.
+var rootCmd = &cobra.Command{
+	Use:     "gitstats",
+	Version: version,
+	Short:   "Fetch Git statistics from providers like GitHub and GitLab.",
+	Long: `gitstats retrieves repository information, commit history and other statistics
+from Git provider APIs, either from the command line or as an HTTP API (gitstats serve).
+
+Settings are read from flags, then environment variables, then the config file
+(--config, GITSTATS_CONFIG or ~/.config/gitstats/config.yaml), then built-in defaults.`,
+	SilenceUsage:      true,
+	PersistentPreRunE: parseGlobalFlags,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configPathVar, "config", "", "Config file (default ~/.config/gitstats/config.yaml). Can also be set via GITSTATS_CONFIG env var.")
+	rootCmd.PersistentFlags().StringVar(&gitlabHostVar, "gitlab-host", "", "Base URL for GitLab (e.g., https://gitlab.example.com). Can also be set via GITLAB_HOST env var.")
+	rootCmd.PersistentFlags().StringVar(&gitlabTokenVar, "gitlab-token", "", "GitLab Personal Access Token. Can also be set via GITLAB_TOKEN env var.")
+	rootCmd.PersistentFlags().StringVar(&githubTokenVar, "github-token", "", "GitHub Personal Access Token. Can also be set via GITHUB_TOKEN env var.")
+	rootCmd.PersistentFlags().StringVar(&providerVar, "provider", "", "Git provider to query: github or gitlab (default: the provider whose token is set, GitHub first). Can also be set via GITSTATS_PROVIDER env var.")
+	rootCmd.PersistentFlags().StringVar(&repoVar, "repo", "", "Repository to inspect: owner/name (GitHub), namespace/path or project ID (GitLab).")
+	rootCmd.PersistentFlags().StringVar(&sinceVar, "since", "", "Only include commits authored at or after this time (YYYY-MM-DD or RFC 3339).")
+	rootCmd.PersistentFlags().StringVar(&untilVar, "until", "", "Only include commits authored before this time (YYYY-MM-DD or RFC 3339).")
+	rootCmd.PersistentFlags().StringVarP(&outputVar, "output", "o", cli.OutputTable, "Output format: "+strings.Join(cli.OutputFormats, ", ")+".")
+	rootCmd.PersistentFlags().StringVar(&sortVar, "sort", "", "Sort rows by: "+strings.Join(cli.SortOrders, ", ")+" (counts descending, names ascending).")
+	rootCmd.PersistentFlags().IntVar(&topVar, "top", 0, "Only show the first N rows after sorting (0 shows all).")
+}
+
+// outputOptions collects the --output, --sort and --top flags.
+func outputOptions() cli.OutputOptions {
+	return cli.OutputOptions{Format: outputVar, Sort: sortVar, Top: topVar}
+}
+
+// parseGlobalFlags loads the configuration, validates the output flags and parses --since
+// and --until into sinceTime and untilTime.
+func parseGlobalFlags(cmd *cobra.Command, args []string) error {
+	if err := loadConfig(cmd.Flags()); err != nil {
+		return err
+	}
+	if err := outputOptions().Validate(); err != nil {
+		return err
+	}
+	var err error
+	if sinceTime, err = parseTimeFlag("since", sinceVar); err != nil {
+		return err
+	}
+	if untilTime, err = parseTimeFlag("until", untilVar); err != nil {
+		return err
+	}
+	if !sinceTime.IsZero() && !untilTime.IsZero() && !sinceTime.Before(untilTime) {
+		return fmt.Errorf("--since (%s) must be before --until (%s)", sinceVar, untilVar)
+	}
+	return nil
+}
+
+// loadConfig reads the config file and environment into appConfig, applies the flags that
+// were set explicitly on top and validates the result.
+func loadConfig(flags *pflag.FlagSet) error {
+	configPath := configPathVar
+	if configPath == "" {
+		configPath = os.Getenv("GITSTATS_CONFIG")
+	}
+	cfg, err := config.Load(configPath, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	overrides := map[string]struct {
+		target *string
+		value  string
+	}{
+		"github-token": {&cfg.Providers.GitHub.Token, githubTokenVar},
+		"gitlab-token": {&cfg.Providers.GitLab.Token, gitlabTokenVar},
+		"gitlab-host":  {&cfg.Providers.GitLab.Host, gitlabHostVar},
+		"provider":     {&cfg.Providers.Default, providerVar},
+	}
+	for name, override := range overrides {
+		if flags.Changed(name) {
+			*override.target = override.value
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	appConfig = cfg
+	return nil
+}
+
+// parseTimeFlag parses an RFC 3339 timestamp or a YYYY-MM-DD date (midnight UTC).
+// An empty value yields the zero time.
+func parseTimeFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %q: expected YYYY-MM-DD or RFC 3339", name, value)
+	}
+	return parsed, nil
+}
+
+// requireRepo returns an error when --repo was not given.
+func requireRepo() error {
+	if repoVar == "" {
+		return fmt.Errorf("--repo is required")
+	}
+	return nil
+}
+
+// identityResolver builds the resolver for the identity aliases in appConfig, or nil if there are none.
+func identityResolver() *analytics.IdentityResolver {
+	if len(appConfig.Identities) == 0 {
+		return nil
+	}
+	aliases := make([]analytics.IdentityAlias, 0, len(appConfig.Identities))
+	for _, identity := range appConfig.Identities {
+		aliases = append(aliases, analytics.IdentityAlias{Name: identity.Name, Email: identity.Email, Aliases: identity.Aliases})
+	}
+	return analytics.NewIdentityResolver(aliases)
+}
+
+// gitServiceFromFlags builds the GitService of the configured provider, or of the provider
+// whose token is set (GitHub first) when none is configured, and converts repo into the
+// identifier that service expects.
+func gitServiceFromFlags(repo string) (interfaces.GitService, interface{}, error) {
+	providers := appConfig.Providers
+	provider := providers.Default
+	if provider == "" {
+		switch {
+		case providers.GitHub.Token != "":
+			provider = config.ProviderGithub
+		case providers.GitLab.Token != "":
+			provider = config.ProviderGitlab
+		default:
+			return nil, nil, fmt.Errorf("please provide a GitLab or GitHub token using flags, environment variables or the config file")
+		}
+	}
+
+	switch provider {
+	case config.ProviderGithub:
+		if providers.GitHub.Token == "" {
+			return nil, nil, fmt.Errorf("provider github requires --github-token, GITHUB_TOKEN or providers.github.token")
+		}
+		ghRepoService, err := repository.NewGithubRepo(repository.ConnectGithub(providers.GitHub.Token, nil, nil))
+		if err != nil {
+			return nil, nil, err
+		}
+		ghRepoService.CommitsBackend = providers.GitHub.CommitsBackend
+		ghRepoService.DetailWorkers = providers.GitHub.DetailWorkers
+		return repository.WithIdentityAliases(ghRepoService, identityResolver()), repo, nil
+	case config.ProviderGitlab:
+		if providers.GitLab.Token == "" {
+			return nil, nil, fmt.Errorf("provider gitlab requires --gitlab-token, GITLAB_TOKEN or providers.gitlab.token")
+		}
+		var effectiveGitlabHost *string
+		if providers.GitLab.Host != "" {
+			effectiveGitlabHost = &providers.GitLab.Host
+		}
+		glSdkClient, err := repository.ConnectGitlab(providers.GitLab.Token, effectiveGitlabHost, nil, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		glRepoService, err := repository.NewGitlabClient(glSdkClient)
+		if err != nil {
+			return nil, nil, err
+		}
+		gitService := repository.WithIdentityAliases(glRepoService, identityResolver())
+		if projectID, parseErr := strconv.Atoi(repo); parseErr == nil {
+			return gitService, projectID, nil
+		}
+		return gitService, repo, nil
+	default:
+		// Unreachable: config.Validate rejects other providers.
+		return nil, nil, fmt.Errorf("unknown provider %q: expected %s or %s", provider, config.ProviderGithub, config.ProviderGitlab)
+	}
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/api"
+	"github.com/ahmetk3436/git-stats-golang/pkg/auth"
+	"github.com/ahmetk3436/git-stats-golang/pkg/config"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	"github.com/ahmetk3436/git-stats-golang/pkg/jobs"
+	"github.com/ahmetk3436/git-stats-golang/pkg/policy"
+	"github.com/ahmetk3436/git-stats-golang/pkg/repository"
+	"github.com/ahmetk3436/git-stats-golang/pkg/schedule"
+	"github.com/ahmetk3436/git-stats-golang/web"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// serveAddressVar is the address the HTTP API listens on.
+var serveAddressVar string
+
+// serveCmd starts the HTTP API. "api" is kept as an alias for existing deployments.
+var serveCmd = &cobra.Command{
+	Use:     "serve",
+	Aliases: []string{"api"},
+	Short:   "Start the HTTP API and the web dashboard.",
+	Long: `serve starts the HTTP API and serves the web dashboard at /. GitHub routes are registered when a GitHub token is set,
+GitLab routes when a GitLab token is set. Responses are cached in Redis (cache.redis_host, cache.redis_password)
+for cache.ttl, or the endpoint's cache.endpoint_ttls, and served stale for cache.stale_for while they are refreshed.
+Slow endpoints such as /loc run as background jobs, whose records are kept in jobs.dir. The schedules of the config
+refresh the cached responses of the repositories of an owner periodically.`,
+	Args: cobra.NoArgs,
+	Run:  runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddressVar, "addr", "", "Address to listen on (default :1323). Can also be set via SERVER_ADDRESS env var or server.address.")
+	rootCmd.AddCommand(serveCmd)
+}
+
+// newAuthenticator builds the API authenticator from appConfig.Auth. Without API keys or an OIDC
+// issuer the API is open, which is only safe behind a trusted network boundary.
+func newAuthenticator() *api.Authenticator {
+	authConfig := appConfig.Auth
+	options := api.AuthOptions{APIKeys: map[string]string{}, ForwardProviderTokens: authConfig.ForwardProviderTokens}
+	for _, key := range authConfig.APIKeys {
+		options.APIKeys[key.Name] = key.SHA256
+	}
+	if authConfig.OIDC.Issuer != "" {
+		options.Verifier = auth.NewOIDCVerifier(authConfig.OIDC.Issuer, authConfig.OIDC.Audience, authConfig.OIDC.JWKSURL, &http.Client{Timeout: 10 * time.Second})
+	}
+	authenticator, err := api.NewAuthenticator(options)
+	if err != nil {
+		log.WithField("error", err).Fatal("Failed to configure API authentication.")
+	}
+	if authenticator.Required() {
+		log.WithFields(logrus.Fields{"api_keys": len(authConfig.APIKeys), "oidc_issuer": authConfig.OIDC.Issuer}).Info("API authentication enabled.")
+	} else {
+		log.Warn("No API keys or OIDC issuer configured. The API is open to anyone who can reach it.")
+	}
+	return authenticator
+}
+
+// newAuthorizer loads the access policy of auth.policy_file, or returns nil when none is
+// configured and every caller may query everything.
+func newAuthorizer() *api.Authorizer {
+	policyFile := appConfig.Auth.PolicyFile
+	if policyFile == "" {
+		return nil
+	}
+	accessPolicy, err := policy.Load(policyFile)
+	if err != nil {
+		log.WithField("error", err).Fatal("Failed to load the access policy.")
+	}
+	log.WithFields(logrus.Fields{"policy_file": policyFile, "rules": len(accessPolicy.Rules)}).Info("Access policy loaded.")
+	return api.NewAuthorizer(accessPolicy)
+}
+
+// newJobQueue opens the job store of the jobs section and starts the queue running its jobs.
+func newJobQueue() *jobs.Queue {
+	dir, err := appConfig.Jobs.Directory()
+	if err != nil {
+		log.WithField("error", err).Fatal("Failed to locate the job directory.")
+	}
+	store, err := jobs.NewFileStore(dir)
+	if err != nil {
+		log.WithFields(logrus.Fields{"jobs_dir": dir, "error": err}).Fatal("Failed to open the job directory.")
+	}
+	queue, err := jobs.NewQueue(store, jobs.Options{Concurrency: appConfig.Jobs.Concurrency, MaxAttempts: appConfig.Jobs.MaxAttempts})
+	if err != nil {
+		log.WithFields(logrus.Fields{"jobs_dir": dir, "error": err}).Fatal("Failed to load jobs.")
+	}
+	log.WithFields(logrus.Fields{"jobs_dir": dir, "concurrency": appConfig.Jobs.Concurrency}).Info("Job queue started.")
+	return queue
+}
+
+// newScheduler schedules the cache refreshes of the schedules section. endpointsByProvider are
+// the endpoints of every provider with a token, as the provider API defines them.
+func newScheduler(endpointsByProvider map[string][]api.Endpoint) *schedule.Scheduler {
+	scheduler := schedule.New()
+	for _, sched := range appConfig.Schedules {
+		logCtx := log.WithFields(logrus.Fields{"schedule": sched.Name, "provider": sched.Provider, "owner": sched.Owner})
+		endpoints, ok := endpointsByProvider[sched.Provider]
+		if !ok {
+			logCtx.Warn("No token configured for the provider of the schedule; it will not run.")
+			continue
+		}
+		spec, err := schedule.Parse(sched.Cron)
+		if err != nil {
+			logCtx.WithField("error", err).Fatal("Invalid schedule.")
+		}
+		identity, err := api.CallerIdentity(sched.Caller)
+		if err != nil {
+			logCtx.WithField("error", err).Fatal("Invalid schedule.")
+		}
+		precomputer, err := api.NewPrecomputer(sched.Provider, endpoints, sched.Owner, sched.Endpoints, identity)
+		if err != nil {
+			logCtx.WithField("error", err).Fatal("Invalid schedule.")
+		}
+		scheduler.Add(sched.Name, spec, precomputer.Run)
+		logCtx.WithField("cron", sched.Cron).Info("Cache refresh scheduled.")
+	}
+	return scheduler
+}
+
+// webhookIdentities returns the callers whose caches the webhooks keep up to date: the shared
+// cache of an open API, otherwise the caller of every API key and schedule.
+func webhookIdentities() []api.Identity {
+	if !appConfig.Auth.Enabled() {
+		return []api.Identity{{}}
+	}
+	var identities []api.Identity
+	seen := map[string]bool{}
+	add := func(caller string) {
+		identity, err := api.CallerIdentity(caller)
+		if err == nil && caller != "" && !seen[caller] {
+			seen[caller] = true
+			identities = append(identities, identity)
+		}
+	}
+	for _, key := range appConfig.Auth.APIKeys {
+		add(api.AuthMethodAPIKey + ":" + key.Name)
+	}
+	for _, sched := range appConfig.Schedules {
+		add(sched.Caller)
+	}
+	return identities
+}
+
+// hasEndpoint reports whether a provider of endpointsByProvider serves the endpoint at path.
+func hasEndpoint(endpointsByProvider map[string][]api.Endpoint, path string) bool {
+	for _, endpoints := range endpointsByProvider {
+		for _, endpoint := range endpoints {
+			if endpoint.Path == path {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runServe reads the service configuration, registers the API routes and starts the HTTP server.
+func runServe(cmd *cobra.Command, args []string) {
+	log.Info("Starting API mode.")
+
+	// Service configuration comes from appConfig, which layers flags, environment, config file and defaults.
+	if cmd.Flags().Changed("addr") {
+		appConfig.Server.Address = serveAddressVar
+	}
+	serverAddress := appConfig.Server.Address
+	corsAllowedOrigin := appConfig.Server.CORSAllowedOrigin
+	redisHost := appConfig.Cache.RedisHost
+	redisPassword := appConfig.Cache.RedisPassword
+	// Tokens have no hardcoded fallbacks; a provider's routes are only registered when its token is set.
+	githubToken := appConfig.Providers.GitHub.Token
+	gitlabToken := appConfig.Providers.GitLab.Token
+	gitlabAPIHost := appConfig.Providers.GitLab.Host
+	if gitlabAPIHost == "" {
+		gitlabAPIHost = "https://gitlab.com" // Default to GitLab.com if not specified.
+	}
+	analyticsDefaults := api.AnalyticsDefaults{
+		Location:         appConfig.Analytics.Location(),
+		WorkdayStartHour: appConfig.Analytics.WorkdayStartHour,
+		WorkdayEndHour:   appConfig.Analytics.WorkdayEndHour,
+		StaleBranchDays:  appConfig.Analytics.StaleBranchDays,
+	}
+	resolver := identityResolver()
+	authenticator := newAuthenticator()
+	authorizer := newAuthorizer()
+
+	// Create a new Gorilla Mux router.
+	router := mux.NewRouter()
+
+	// Middleware for CORS and common security headers.
+	headersMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log.WithFields(logrus.Fields{"method": r.Method, "uri": r.RequestURI}).Debug("Received API request.")
+
+			// CORS Headers, only sent when an origin is configured; the embedded dashboard is same-origin.
+			if corsAllowedOrigin != "" {
+				w.Header().Set("Access-Control-Allow-Origin", corsAllowedOrigin)
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Provider-Token, X-Request-ID, If-None-Match, If-Modified-Since")
+				w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, Deprecation, Location, ETag")
+				w.Header().Add("Vary", "Origin")
+			}
+
+			// Basic Security Headers.
+			w.Header().Set("X-Content-Type-Options", "nosniff") // Prevents MIME sniffing.
+			w.Header().Set("X-Frame-Options", "DENY")           // Prevents clickjacking.
+			// Consider adding Content-Security-Policy and Strict-Transport-Security for enhanced security.
+
+			// Handle OPTIONS preflight requests for CORS.
+			if r.Method == http.MethodOptions {
+				log.Debug("Handling OPTIONS preflight request.")
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	router.Use(api.RequestID, headersMiddleware)
+
+	// Initialize Redis client.
+	redisClient, err := storage.NewRedisClient(redisHost, redisPassword)
+	if err != nil {
+		log.WithFields(logrus.Fields{"redis_host": redisHost, "error": err}).Fatal("Failed to connect to Redis.")
+	}
+	log.Info("Successfully connected to Redis.")
+
+	// How long endpoints cache their responses, and how long expired ones are served while refreshed.
+	cachePolicy := &api.CachePolicy{TTL: appConfig.Cache.TTL, Endpoints: appConfig.Cache.EndpointTTLs, StaleFor: appConfig.Cache.StaleFor}
+	// ETag and Last-Modified on the provider responses, so that clients can revalidate them.
+	conditionalResponses := &api.ConditionalResponses{Cache: redisClient}
+
+	// Background jobs for endpoints too slow to serve within a request, e.g. /loc.
+	jobsAPI := api.NewJobsApi(newJobQueue())
+
+	// Provider webhooks keeping the cache up to date, mounted for the providers configured below.
+	webhooksAPI := &api.WebhooksApi{
+		GithubSecret: appConfig.Webhooks.GitHubSecret,
+		GitlabToken:  appConfig.Webhooks.GitLabToken,
+		Cache:        cachePolicy,
+		Identities:   webhookIdentities(),
+	}
+
+	// Endpoints registered per provider, described by /api/openapi.json.
+	endpointsByProvider := map[string][]api.Endpoint{}
+	// The same endpoints without the job wrappers, refreshed in place by the schedules.
+	scheduledEndpoints := map[string][]api.Endpoint{}
+	// Rate limits of the server tokens, published as metrics and in /api/config. Clients built for
+	// forwarded caller tokens track their own limits without publishing them.
+	var rateLimits []*repository.RateLimitTracker
+
+	// Setup GitHub API service if token is provided.
+	if githubToken != "" {
+		log.Info("Initializing GitHub service.")
+		githubRateLimits := repository.NewRateLimitTracker(config.ProviderGithub)
+		githubRateLimits.Metrics = true
+		rateLimits = append(rateLimits, githubRateLimits)
+		githubETags := repository.NewETagCache(config.ProviderGithub, redisClient)          // Revalidates unchanged responses instead of fetching them again.
+		ghSdkClient := repository.ConnectGithub(githubToken, githubRateLimits, githubETags) // Creates underlying GitHub SDK client.
+		ghRepoService, err := repository.NewGithubRepo(ghSdkClient)                         // Wraps SDK client with our GitService implementation.
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to create GitHubRepo service.")
+		}
+		ghRepoService.CommitsBackend = appConfig.Providers.GitHub.CommitsBackend
+		ghRepoService.DetailWorkers = appConfig.Providers.GitHub.DetailWorkers
+		ghRepoService.StatsCache = redisClient                                                                     // Commit stats are shared by every caller of the server token.
+		githubAPIHandler := api.NewGithubApi(repository.WithIdentityAliases(ghRepoService, resolver), redisClient) // Injects GitService.
+		githubAPIHandler.Defaults = analyticsDefaults
+		githubAPIHandler.TokenService = func(token string) (interfaces.GitService, error) {
+			gitService, err := repository.NewGithubRepo(repository.ConnectGithub(token, nil, githubETags))
+			if err != nil {
+				return nil, err
+			}
+			gitService.CommitsBackend = appConfig.Providers.GitHub.CommitsBackend
+			gitService.DetailWorkers = appConfig.Providers.GitHub.DetailWorkers
+			return repository.WithIdentityAliases(gitService, resolver), nil
+		}
+
+		// Register GitHub API routes below /api/v1/github and the unversioned /api/github.
+		cachedGithubEndpoints := cachePolicy.Apply(config.ProviderGithub, githubAPIHandler.Endpoints())
+		githubEndpoints := conditionalResponses.Apply(config.ProviderGithub, jobsAPI.Async(config.ProviderGithub, cachedGithubEndpoints))
+		scheduledEndpoints[config.ProviderGithub] = cachedGithubEndpoints
+		webhooksAPI.Github = githubAPIHandler
+		api.RegisterProvider(router, config.ProviderGithub, githubEndpoints, authenticator, authorizer)
+		endpointsByProvider[config.ProviderGithub] = githubEndpoints
+		log.Info("GitHub API routes registered.")
+	} else {
+		log.Warn("No GitHub token configured. GitHub API routes will not be available.")
+	}
+
+	// Setup GitLab API service if token is provided.
+	if gitlabToken != "" {
+		log.Info("Initializing GitLab service.")
+		gitlabRateLimits := repository.NewRateLimitTracker(config.ProviderGitlab)
+		gitlabRateLimits.Metrics = true
+		rateLimits = append(rateLimits, gitlabRateLimits)
+		gitlabETags := repository.NewETagCache(config.ProviderGitlab, redisClient)                               // Revalidates unchanged responses instead of fetching them again.
+		glSdkClient, err := repository.ConnectGitlab(gitlabToken, &gitlabAPIHost, gitlabRateLimits, gitlabETags) // Creates underlying GitLab SDK client.
+		if err != nil {
+			log.WithFields(logrus.Fields{"gitlab_host": gitlabAPIHost, "error": err}).Fatal("Failed to connect to GitLab client/service.")
+		}
+		glRepoService, err := repository.NewGitlabClient(glSdkClient) // Wraps SDK client with our GitService implementation.
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to create GitLabClient service.")
+		}
+		gitlabAPIHandler := api.NewGitlabApi(repository.WithIdentityAliases(glRepoService, resolver), redisClient) // Injects GitService.
+		gitlabAPIHandler.Defaults = analyticsDefaults
+		gitlabAPIHandler.TokenService = func(token string) (interfaces.GitService, error) {
+			client, err := repository.ConnectGitlab(token, &gitlabAPIHost, nil, gitlabETags)
+			if err != nil {
+				return nil, err
+			}
+			gitService, err := repository.NewGitlabClient(client)
+			if err != nil {
+				return nil, err
+			}
+			return repository.WithIdentityAliases(gitService, resolver), nil
+		}
+
+		// Register GitLab API routes below /api/v1/gitlab and the unversioned /api/gitlab.
+		// /loc and /contributors are not implemented for GitLab yet.
+		cachedGitlabEndpoints := cachePolicy.Apply(config.ProviderGitlab, gitlabAPIHandler.Endpoints())
+		gitlabEndpoints := conditionalResponses.Apply(config.ProviderGitlab, jobsAPI.Async(config.ProviderGitlab, cachedGitlabEndpoints))
+		scheduledEndpoints[config.ProviderGitlab] = cachedGitlabEndpoints
+		webhooksAPI.Gitlab = gitlabAPIHandler
+		api.RegisterProvider(router, config.ProviderGitlab, gitlabEndpoints, authenticator, authorizer)
+		endpointsByProvider[config.ProviderGitlab] = gitlabEndpoints
+		log.Info("GitLab API routes registered.")
+	} else {
+		log.Warn("No GitLab token configured. GitLab API routes will not be available.")
+	}
+
+	for path := range appConfig.Cache.EndpointTTLs {
+		if !hasEndpoint(endpointsByProvider, path) {
+			log.WithField("path", path).Warn("cache.endpoint_ttls names an endpoint no configured provider serves.")
+		}
+	}
+
+	// Capabilities for the web dashboard and other clients (non-sensitive; tokens are never exposed).
+	// Built from the routes registered above, so it has to follow every provider registration.
+	capabilities, err := api.NewCapabilities(version, appConfig.Server.APIBaseURL, router)
+	if err != nil {
+		log.WithField("error", err).Fatal("Failed to describe registered routes.")
+	}
+	capabilities.Auth = authenticator.Capabilities()
+	capabilities.Cache = cachePolicy.Capabilities()
+	router.HandleFunc("/api/config", api.ConfigHandler(capabilities, api.RateLimitsOf(rateLimits...))).Methods(http.MethodGet, http.MethodOptions)
+	openAPIDocument := api.NewOpenAPIDocument(version, endpointsByProvider)
+	openAPIDocument.AddJobPaths()
+	router.HandleFunc("/api/openapi.json", api.OpenAPIHandler(openAPIDocument)).Methods(http.MethodGet, http.MethodOptions)
+
+	// Job routes below /api/v1/jobs. Jobs replay their request through the router, so that they
+	// pass the same authentication, policy and cache as the request would have.
+	jobsAPI.Handler = router
+	jobsAPI.Register(router, authenticator)
+	log.Info("Job routes registered.")
+
+	// Cache administration below /api/v1/admin/cache, for callers the access policy allows to purge.
+	cacheAPI := &api.CacheApi{Cache: redisClient, Authorizer: authorizer}
+	cacheAPI.Register(router, authenticator)
+	log.Info("Cache administration routes registered.")
+
+	// Webhook receivers below /api/webhooks, authenticated by their secrets instead of API keys.
+	webhooksAPI.Register(router)
+	log.WithFields(logrus.Fields{"github": webhooksAPI.Github != nil && webhooksAPI.GithubSecret != "", "gitlab": webhooksAPI.Gitlab != nil && webhooksAPI.GitlabToken != ""}).Info("Webhook routes registered.")
+
+	// Prometheus metrics endpoint.
+	router.Handle("/metrics", promhttp.Handler())
+	log.Info("Metrics endpoint /metrics registered.")
+
+	// Web dashboard, embedded in the binary. Registered last so it only serves paths no other route matches.
+	router.PathPrefix("/").Handler(http.FileServerFS(web.Assets)).Methods(http.MethodGet, http.MethodHead)
+	log.Info("Web dashboard registered at /.")
+
+	// Scheduled cache refreshes, running for as long as the server.
+	go newScheduler(scheduledEndpoints).Run(context.Background())
+
+	// Start HTTP server.
+	log.Infof("Starting server on %s", serverAddress)
+	// For production, ListenAndServeTLS with valid certificates loaded securely is recommended.
+	// Example for HTTPS: err = http.ListenAndServeTLS(serverAddress, "path/to/cert.pem", "path/to/key.pem", router)
+	if err := http.ListenAndServe(serverAddress, router); err != nil { // Using HTTP for simplicity here.
+		log.WithField("error", err).Fatalf("Failed to start server on %s.", serverAddress)
+	}
+}
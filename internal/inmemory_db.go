@@ -7,6 +7,20 @@ import (
 	"github.com/go-redis/redis"
 )
 
+// InMemoryDB is the cache abstraction used by the API handlers.
+// RedisClient is the production implementation; tests provide their own.
+type InMemoryDB interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value interface{}, duration time.Duration) error
+	Delete(key string) error
+}
+
+// KeyScanner is implemented by caches that can list their keys, which purging by pattern needs.
+type KeyScanner interface {
+	// Keys returns the keys matching the glob pattern, as Redis SCAN MATCH interprets it.
+	Keys(pattern string) ([]string, error)
+}
+
 type RedisClient struct {
 	client *redis.Client
 }
@@ -26,8 +40,9 @@ func NewRedisClient(address, password string) (*RedisClient, error) {
 	return &RedisClient{client}, nil
 }
 
+// Set stores value under key until duration has passed; zero keeps it until it is deleted.
 func (rc *RedisClient) Set(key string, value interface{}, duration time.Duration) error {
-	err := rc.client.Set(key, value, duration*time.Minute).Err()
+	err := rc.client.Set(key, value, duration).Err()
 	if err != nil {
 		return err
 	}
@@ -51,3 +66,23 @@ func (rc *RedisClient) Delete(key string) error {
 	}
 	return nil
 }
+
+// Keys scans the keyspace incrementally, so that large caches do not block Redis like KEYS would.
+func (rc *RedisClient) Keys(pattern string) ([]string, error) {
+	var keys []string
+	var cursor uint64
+	for {
+		batch, next, err := rc.client.Scan(cursor, pattern, 1000).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		if next == 0 {
+			return keys, nil
+		}
+		cursor = next
+	}
+}
+
+var _ InMemoryDB = (*RedisClient)(nil)
+var _ KeyScanner = (*RedisClient)(nil)
@@ -0,0 +1,135 @@
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+// Default working hours used when ActivityOptions leaves them unset.
+const (
+	DefaultWorkdayStartHour = 9
+	DefaultWorkdayEndHour   = 18
+)
+
+// ActivityOptions configures how commit timestamps are bucketed.
+type ActivityOptions struct {
+	Location         *time.Location // Time zone commits are converted to; nil means UTC.
+	WorkdayStartHour int            // First working hour (inclusive), 0-23.
+	WorkdayEndHour   int            // End of working hours (exclusive), 1-24.
+}
+
+// ActivityProfile is the commit activity of a repository or of a single author.
+type ActivityProfile struct {
+	Commits         int        // Number of commits with a known author date.
+	Heatmap         [7][24]int // Commit counts indexed by [weekday][hour]; weekday 0 is Sunday.
+	OffHoursCommits int        // Commits outside working hours on a weekday, or on a weekend.
+	WeekendCommits  int        // Commits on Saturday or Sunday.
+	OffHoursShare   float64    // OffHoursCommits / Commits, 0 when there are no commits.
+	WeekendShare    float64    // WeekendCommits / Commits, 0 when there are no commits.
+}
+
+// AuthorActivity is the ActivityProfile of one commit author.
+type AuthorActivity struct {
+	Name  string // Author name as recorded on the most recent commit.
+	Email string // Author email; authors are grouped by it, case-insensitively.
+	ActivityProfile
+}
+
+// ActivityReport holds the day-of-week × hour-of-day activity of a repository and its authors.
+type ActivityReport struct {
+	TimeZone         string           // Name of the time zone the heatmaps are expressed in.
+	WorkdayStartHour int              // First working hour (inclusive).
+	WorkdayEndHour   int              // End of working hours (exclusive).
+	Since            time.Time        // Start of the window the commits were taken from (inclusive); zero if unbounded.
+	Until            time.Time        // End of that window (exclusive); zero if unbounded.
+	Repository       ActivityProfile  // Activity over all commits.
+	Authors          []AuthorActivity // Per-author activity, most commits first.
+}
+
+// ComputeActivity buckets commits by the weekday and hour of their author date in opts.Location
+// and measures how many of them fall outside working hours or on weekends.
+// Commits without an author date are ignored.
+func ComputeActivity(commits []*common_types.Commit, opts ActivityOptions) *ActivityReport {
+	location := opts.Location
+	if location == nil {
+		location = time.UTC
+	}
+	startHour, endHour := opts.WorkdayStartHour, opts.WorkdayEndHour
+	if startHour == 0 && endHour == 0 {
+		startHour, endHour = DefaultWorkdayStartHour, DefaultWorkdayEndHour
+	}
+
+	report := &ActivityReport{
+		TimeZone:         location.String(),
+		WorkdayStartHour: startHour,
+		WorkdayEndHour:   endHour,
+		Authors:          []AuthorActivity{},
+	}
+	authors := map[string]*AuthorActivity{}
+	latestByAuthor := map[string]time.Time{}
+
+	for _, commit := range commits {
+		if commit == nil || commit.Author.Date.IsZero() {
+			continue
+		}
+		local := commit.Author.Date.In(location)
+		weekday, hour := local.Weekday(), local.Hour()
+		weekend := weekday == time.Saturday || weekday == time.Sunday
+		offHours := weekend || hour < startHour || hour >= endHour
+
+		key := authorKey(commit.Author)
+		author, ok := authors[key]
+		if !ok {
+			author = &AuthorActivity{Email: commit.Author.Email}
+			authors[key] = author
+		}
+		if commit.Author.Date.After(latestByAuthor[key]) || author.Name == "" {
+			latestByAuthor[key] = commit.Author.Date
+			author.Name = commit.Author.Name
+		}
+
+		for _, profile := range []*ActivityProfile{&report.Repository, &author.ActivityProfile} {
+			profile.Commits++
+			profile.Heatmap[weekday][hour]++
+			if offHours {
+				profile.OffHoursCommits++
+			}
+			if weekend {
+				profile.WeekendCommits++
+			}
+		}
+	}
+
+	report.Repository.computeShares()
+	for _, author := range authors {
+		author.computeShares()
+		report.Authors = append(report.Authors, *author)
+	}
+	sort.Slice(report.Authors, func(i, j int) bool {
+		if report.Authors[i].Commits != report.Authors[j].Commits {
+			return report.Authors[i].Commits > report.Authors[j].Commits
+		}
+		return report.Authors[i].Name < report.Authors[j].Name
+	})
+	return report
+}
+
+// computeShares derives the off-hours and weekend shares from the commit counts.
+func (profile *ActivityProfile) computeShares() {
+	if profile.Commits == 0 {
+		return
+	}
+	profile.OffHoursShare = float64(profile.OffHoursCommits) / float64(profile.Commits)
+	profile.WeekendShare = float64(profile.WeekendCommits) / float64(profile.Commits)
+}
+
+// authorKey groups commits by author email, case-insensitively, falling back to the name.
+func authorKey(author common_types.CommitAuthor) string {
+	if author.Email != "" {
+		return strings.ToLower(author.Email)
+	}
+	return author.Name
+}
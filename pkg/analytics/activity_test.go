@@ -0,0 +1,67 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func commitAt(name, email string, date time.Time) *common_types.Commit {
+	return &common_types.Commit{Author: common_types.CommitAuthor{Name: name, Email: email, Date: date}}
+}
+
+func TestComputeActivity(t *testing.T) {
+	istanbul := time.FixedZone("UTC+3", 3*60*60)
+	commits := []*common_types.Commit{
+		commitAt("Jane", "jane@example.com", time.Date(2024, 5, 6, 7, 30, 0, 0, time.UTC)),  // Monday 10:30 local.
+		commitAt("Jane", "JANE@example.com", time.Date(2024, 5, 6, 20, 0, 0, 0, time.UTC)),  // Monday 23:00 local.
+		commitAt("John", "john@example.com", time.Date(2024, 5, 11, 10, 0, 0, 0, time.UTC)), // Saturday 13:00 local.
+		commitAt("John", "john@example.com", time.Time{}),                                   // No date: ignored.
+		nil,
+	}
+
+	report := ComputeActivity(commits, ActivityOptions{Location: istanbul})
+
+	if report.TimeZone != "UTC+3" || report.WorkdayStartHour != DefaultWorkdayStartHour || report.WorkdayEndHour != DefaultWorkdayEndHour {
+		t.Errorf("unexpected report settings: %+v", report)
+	}
+	repo := report.Repository
+	if repo.Commits != 3 || repo.OffHoursCommits != 2 || repo.WeekendCommits != 1 {
+		t.Fatalf("unexpected repository totals: %+v", repo)
+	}
+	if repo.Heatmap[time.Monday][10] != 1 || repo.Heatmap[time.Monday][23] != 1 || repo.Heatmap[time.Saturday][13] != 1 {
+		t.Errorf("unexpected heatmap: %v", repo.Heatmap)
+	}
+	if repo.WeekendShare != 1.0/3 || repo.OffHoursShare != 2.0/3 {
+		t.Errorf("unexpected shares: off-hours=%v weekend=%v", repo.OffHoursShare, repo.WeekendShare)
+	}
+
+	if len(report.Authors) != 2 {
+		t.Fatalf("expected 2 authors, got %+v", report.Authors)
+	}
+	jane := report.Authors[0]
+	if jane.Name != "Jane" || jane.Commits != 2 || jane.OffHoursShare != 0.5 || jane.WeekendCommits != 0 {
+		t.Errorf("unexpected activity for Jane: %+v", jane)
+	}
+	if john := report.Authors[1]; john.Commits != 1 || john.WeekendShare != 1 {
+		t.Errorf("unexpected activity for John: %+v", john)
+	}
+}
+
+func TestComputeActivity_CustomWorkingHours(t *testing.T) {
+	commits := []*common_types.Commit{
+		commitAt("Jane", "jane@example.com", time.Date(2024, 5, 7, 6, 0, 0, 0, time.UTC)), // Tuesday 06:00.
+	}
+	report := ComputeActivity(commits, ActivityOptions{WorkdayStartHour: 6, WorkdayEndHour: 14})
+	if report.TimeZone != "UTC" || report.Repository.OffHoursCommits != 0 {
+		t.Errorf("06:00 should be within 6-14 working hours: %+v", report)
+	}
+}
+
+func TestComputeActivity_Empty(t *testing.T) {
+	report := ComputeActivity(nil, ActivityOptions{})
+	if report.Repository.Commits != 0 || report.Repository.OffHoursShare != 0 || len(report.Authors) != 0 {
+		t.Errorf("expected empty report, got %+v", report)
+	}
+}
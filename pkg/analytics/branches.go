@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+// BranchReport lists the branches that are candidates for clean-up.
+type BranchReport struct {
+	StaleAfterDays int                 // Threshold used to flag a branch as stale.
+	TotalBranches  int                 // Number of branches inspected, including the default branch.
+	Entries        []BranchReportEntry // Stale and/or merged branches, oldest head commit first.
+}
+
+// BranchReportEntry describes why a branch was included in a BranchReport.
+type BranchReportEntry struct {
+	Branch              *common_types.Branch // The branch itself.
+	DaysSinceLastCommit int                  // Whole days between the head commit and the report time.
+	Stale               bool                 // No commits for at least StaleAfterDays.
+	Merged              bool                 // Fully merged into the default branch.
+}
+
+// BuildBranchReport selects branches with no commits in staleAfterDays days, or that are already
+// merged into the default branch. The default branch itself is never reported.
+func BuildBranchReport(branches []*common_types.Branch, now time.Time, staleAfterDays int) *BranchReport {
+	report := &BranchReport{StaleAfterDays: staleAfterDays, Entries: []BranchReportEntry{}}
+	for _, branch := range branches {
+		if branch == nil {
+			continue
+		}
+		report.TotalBranches++
+		if branch.Default {
+			continue
+		}
+		entry := BranchReportEntry{Branch: branch, Merged: branch.Merged}
+		if !branch.LastCommitDate.IsZero() {
+			entry.DaysSinceLastCommit = int(now.Sub(branch.LastCommitDate) / day)
+			entry.Stale = entry.DaysSinceLastCommit >= staleAfterDays
+		}
+		if entry.Stale || entry.Merged {
+			report.Entries = append(report.Entries, entry)
+		}
+	}
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		if report.Entries[i].DaysSinceLastCommit != report.Entries[j].DaysSinceLastCommit {
+			return report.Entries[i].DaysSinceLastCommit > report.Entries[j].DaysSinceLastCommit
+		}
+		return report.Entries[i].Branch.Name < report.Entries[j].Branch.Name
+	})
+	return report
+}
@@ -0,0 +1,48 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func TestBuildBranchReport(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+
+	branches := []*common_types.Branch{
+		{Name: "main", Default: true, LastCommitDate: daysAgo(400)},
+		{Name: "fresh", LastCommitDate: daysAgo(3)},
+		{Name: "old-feature", LastCommitDate: daysAgo(120)},
+		{Name: "merged-fix", Merged: true, LastCommitDate: daysAgo(5)},
+		{Name: "ancient", Protected: true, LastCommitDate: daysAgo(365)},
+		{Name: "no-date"},
+		nil,
+	}
+
+	report := BuildBranchReport(branches, now, 90)
+
+	if report.TotalBranches != 6 {
+		t.Errorf("TotalBranches = %d, want 6", report.TotalBranches)
+	}
+	expected := []struct {
+		name   string
+		days   int
+		stale  bool
+		merged bool
+	}{
+		{"ancient", 365, true, false},
+		{"old-feature", 120, true, false},
+		{"merged-fix", 5, false, true},
+	}
+	if len(report.Entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %+v", len(expected), report.Entries)
+	}
+	for i, want := range expected {
+		got := report.Entries[i]
+		if got.Branch.Name != want.name || got.DaysSinceLastCommit != want.days || got.Stale != want.stale || got.Merged != want.merged {
+			t.Errorf("entry %d = {%s %d stale=%v merged=%v}, want %+v", i, got.Branch.Name, got.DaysSinceLastCommit, got.Stale, got.Merged, want)
+		}
+	}
+}
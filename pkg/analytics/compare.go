@@ -0,0 +1,199 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+// ChangeTotals counts commits and changed lines.
+type ChangeTotals struct {
+	Commits   int // Number of commits.
+	Additions int // Lines added.
+	Deletions int // Lines deleted.
+}
+
+// AuthorChangeTotals is the ChangeTotals of one commit author.
+type AuthorChangeTotals struct {
+	Name  string // Author name as recorded on the most recent commit.
+	Email string // Author email; authors are grouped by it, case-insensitively.
+	ChangeTotals
+}
+
+// RefComparison summarises what changed between two refs.
+type RefComparison struct {
+	BaseRef      string                     // Ref the comparison starts from (exclusive).
+	HeadRef      string                     // Ref the comparison ends at (inclusive).
+	Totals       ChangeTotals               // Commit count and line deltas over the changed files.
+	FilesChanged int                        // Number of files changed.
+	Authors      []AuthorChangeTotals       // Per-author commit and line counts, most commits first.
+	Files        []*common_types.FileChange // Files changed between the refs.
+	Commits      []*common_types.Commit     // Commits between the refs, newest first.
+}
+
+// PeriodWindow is the [Since, Until) time range of one side of a PeriodComparison.
+type PeriodWindow struct {
+	Since time.Time // Inclusive start.
+	Until time.Time // Exclusive end.
+}
+
+// ChangeDelta compares the ChangeTotals of two periods. Percentage changes are nil
+// when the previous period's value is zero.
+type ChangeDelta struct {
+	Current            ChangeTotals // Totals in the current period.
+	Previous           ChangeTotals // Totals in the previous period.
+	CommitsChangePct   *float64     // Relative change in commits, in percent.
+	AdditionsChangePct *float64     // Relative change in added lines, in percent.
+	DeletionsChangePct *float64     // Relative change in deleted lines, in percent.
+}
+
+// AuthorChangeDelta is the ChangeDelta of one commit author.
+type AuthorChangeDelta struct {
+	Name  string // Author name as recorded on the most recent commit.
+	Email string // Author email; authors are grouped by it, case-insensitively.
+	ChangeDelta
+}
+
+// PeriodComparison is the period-over-period diff of per-author commit statistics.
+type PeriodComparison struct {
+	Current  PeriodWindow        // The period being reported on.
+	Previous PeriodWindow        // The period it is compared against.
+	Totals   ChangeDelta         // Repository-wide delta.
+	Authors  []AuthorChangeDelta // Per-author deltas, most commits in the current period first.
+}
+
+// CompareRefCommits summarises a provider comparison: totals and line deltas come from the
+// changed files, per-author line counts from the commit stats.
+func CompareRefCommits(comparison *common_types.Comparison) *RefComparison {
+	result := &RefComparison{
+		BaseRef: comparison.BaseRef,
+		HeadRef: comparison.HeadRef,
+		Files:   comparison.Files,
+		Commits: comparison.Commits,
+		Authors: AggregateChangeTotals(comparison.Commits),
+	}
+	if result.Files == nil {
+		result.Files = []*common_types.FileChange{}
+	}
+	if result.Commits == nil {
+		result.Commits = []*common_types.Commit{}
+	}
+	for _, commit := range comparison.Commits {
+		if commit != nil {
+			result.Totals.Commits++
+		}
+	}
+	for _, file := range comparison.Files {
+		if file == nil {
+			continue
+		}
+		result.FilesChanged++
+		result.Totals.Additions += file.Additions
+		result.Totals.Deletions += file.Deletions
+	}
+	return result
+}
+
+// AggregateChangeTotals sums commits and changed lines per author, most commits first.
+func AggregateChangeTotals(commits []*common_types.Commit) []AuthorChangeTotals {
+	authors := map[string]*AuthorChangeTotals{}
+	latestByAuthor := map[string]time.Time{}
+	for _, commit := range commits {
+		if commit == nil {
+			continue
+		}
+		key := authorKey(commit.Author)
+		author, ok := authors[key]
+		if !ok {
+			author = &AuthorChangeTotals{Email: commit.Author.Email}
+			authors[key] = author
+		}
+		if commit.Author.Date.After(latestByAuthor[key]) || author.Name == "" {
+			latestByAuthor[key] = commit.Author.Date
+			author.Name = commit.Author.Name
+		}
+		author.Commits++
+		author.Additions += commit.Stats.Additions
+		author.Deletions += commit.Stats.Deletions
+	}
+
+	result := make([]AuthorChangeTotals, 0, len(authors))
+	for _, author := range authors {
+		result = append(result, *author)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Commits != result[j].Commits {
+			return result[i].Commits > result[j].Commits
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// ComparePeriods diffs the per-author statistics of the commits in two periods.
+// Authors present in only one of the periods are reported with zero totals for the other.
+func ComparePeriods(currentCommits, previousCommits []*common_types.Commit, current, previous PeriodWindow) *PeriodComparison {
+	result := &PeriodComparison{Current: current, Previous: previous, Authors: []AuthorChangeDelta{}}
+
+	deltas := map[string]*AuthorChangeDelta{}
+	var order []string
+	deltaFor := func(author AuthorChangeTotals) *AuthorChangeDelta {
+		key := authorKey(common_types.CommitAuthor{Name: author.Name, Email: author.Email})
+		delta, ok := deltas[key]
+		if !ok {
+			delta = &AuthorChangeDelta{Name: author.Name, Email: author.Email}
+			deltas[key] = delta
+			order = append(order, key)
+		}
+		return delta
+	}
+	for _, author := range AggregateChangeTotals(currentCommits) {
+		deltaFor(author).Current = author.ChangeTotals
+		result.Totals.Current.add(author.ChangeTotals)
+	}
+	for _, author := range AggregateChangeTotals(previousCommits) {
+		deltaFor(author).Previous = author.ChangeTotals
+		result.Totals.Previous.add(author.ChangeTotals)
+	}
+
+	result.Totals.computeChanges()
+	for _, key := range order {
+		deltas[key].computeChanges()
+		result.Authors = append(result.Authors, *deltas[key])
+	}
+	sort.SliceStable(result.Authors, func(i, j int) bool {
+		return result.Authors[i].Current.Commits > result.Authors[j].Current.Commits
+	})
+	return result
+}
+
+// PreviousWindow returns the window of the same length immediately before window.
+func PreviousWindow(window PeriodWindow) PeriodWindow {
+	length := window.Until.Sub(window.Since)
+	return PeriodWindow{Since: window.Since.Add(-length), Until: window.Since}
+}
+
+// add accumulates other into totals.
+func (totals *ChangeTotals) add(other ChangeTotals) {
+	totals.Commits += other.Commits
+	totals.Additions += other.Additions
+	totals.Deletions += other.Deletions
+}
+
+// computeChanges derives the percentage changes from Current and Previous.
+func (delta *ChangeDelta) computeChanges() {
+	delta.CommitsChangePct = percentChange(delta.Previous.Commits, delta.Current.Commits)
+	delta.AdditionsChangePct = percentChange(delta.Previous.Additions, delta.Current.Additions)
+	delta.DeletionsChangePct = percentChange(delta.Previous.Deletions, delta.Current.Deletions)
+}
+
+// percentChange returns the relative change from previous to current in percent,
+// or nil when previous is zero and the change is undefined.
+func percentChange(previous, current int) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := float64(current-previous) / float64(previous) * 100
+	return &change
+}
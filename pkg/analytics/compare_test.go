@@ -0,0 +1,75 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func commitBy(name, email string, additions, deletions int) *common_types.Commit {
+	return &common_types.Commit{
+		Author: common_types.CommitAuthor{Name: name, Email: email},
+		Stats:  common_types.CommitStats{Additions: additions, Deletions: deletions, Total: additions + deletions},
+	}
+}
+
+func TestCompareRefCommits(t *testing.T) {
+	comparison := &common_types.Comparison{
+		BaseRef: "v1.4",
+		HeadRef: "v1.5",
+		Commits: []*common_types.Commit{
+			commitBy("Jane", "jane@example.com", 10, 2),
+			commitBy("John", "john@example.com", 1, 1),
+			commitBy("Jane", "JANE@example.com", 5, 0),
+			nil,
+		},
+		Files: []*common_types.FileChange{
+			{Filename: "a.go", Status: "modified", Additions: 12, Deletions: 3},
+			{Filename: "b.go", Status: "added", Additions: 4},
+		},
+	}
+
+	result := CompareRefCommits(comparison)
+
+	if result.Totals != (ChangeTotals{Commits: 3, Additions: 16, Deletions: 3}) || result.FilesChanged != 2 {
+		t.Errorf("unexpected totals: %+v, files changed %d", result.Totals, result.FilesChanged)
+	}
+	if len(result.Authors) != 2 {
+		t.Fatalf("expected 2 authors, got %+v", result.Authors)
+	}
+	if jane := result.Authors[0]; jane.Name != "Jane" || jane.ChangeTotals != (ChangeTotals{Commits: 2, Additions: 15, Deletions: 2}) {
+		t.Errorf("unexpected totals for Jane: %+v", jane)
+	}
+}
+
+func TestComparePeriods(t *testing.T) {
+	current := PeriodWindow{Since: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Until: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)}
+	previous := PreviousWindow(current)
+	if !previous.Until.Equal(current.Since) || previous.Since != time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC) {
+		t.Fatalf("unexpected previous window: %+v", previous)
+	}
+
+	result := ComparePeriods(
+		[]*common_types.Commit{commitBy("Jane", "jane@example.com", 30, 0), commitBy("Jane", "jane@example.com", 0, 0), commitBy("New", "new@example.com", 5, 5)},
+		[]*common_types.Commit{commitBy("Jane", "jane@example.com", 10, 4), commitBy("Gone", "gone@example.com", 4, 1)},
+		current, previous,
+	)
+
+	if *result.Totals.CommitsChangePct != 50 || *result.Totals.AdditionsChangePct != 150 || *result.Totals.DeletionsChangePct != 0 {
+		t.Errorf("unexpected total changes: %+v", result.Totals)
+	}
+	if len(result.Authors) != 3 {
+		t.Fatalf("expected 3 authors, got %+v", result.Authors)
+	}
+	jane, newcomer, gone := result.Authors[0], result.Authors[1], result.Authors[2]
+	if jane.Name != "Jane" || *jane.CommitsChangePct != 100 || *jane.DeletionsChangePct != -100 {
+		t.Errorf("unexpected delta for Jane: %+v", jane)
+	}
+	if newcomer.Name != "New" || newcomer.CommitsChangePct != nil {
+		t.Errorf("a new author should have no percentage change: %+v", newcomer)
+	}
+	if gone.Name != "Gone" || gone.Current.Commits != 0 || *gone.CommitsChangePct != -100 {
+		t.Errorf("unexpected delta for an author absent from the current period: %+v", gone)
+	}
+}
@@ -0,0 +1,223 @@
+package analytics
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+// Commit categories used by the Conventional Commits breakdowns.
+const (
+	CommitCategoryFeature = "feature" // "feat" commits.
+	CommitCategoryFix     = "fix"     // "fix" commits.
+	CommitCategoryChore   = "chore"   // Any other conforming type (chore, docs, refactor, ci, ...).
+	CommitCategoryOther   = "other"   // Messages that do not follow Conventional Commits.
+)
+
+// Periods accepted by ComputeConventionalCommitStats.
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+var (
+	conventionalHeaderPattern = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()\r\n]*)\))?(!)?: +(\S.*)$`)
+	breakingFooterPattern     = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+	hashIssuePattern          = regexp.MustCompile(`(?:^|[^\w/&])(#\d+)\b`)
+	trackerIssuePattern       = regexp.MustCompile(`\b([A-Z][A-Z0-9]+-\d+)\b`)
+)
+
+// ParseConventionalCommit interprets a raw commit message according to Conventional Commits.
+// Issue keys ("#12", "PROJ-34") are collected from the whole message even when the header
+// does not conform.
+func ParseConventionalCommit(message string) *common_types.ConventionalCommit {
+	message = strings.TrimSpace(message)
+	header := message
+	if newline := strings.IndexAny(message, "\r\n"); newline >= 0 {
+		header = message[:newline]
+	}
+
+	parsed := &common_types.ConventionalCommit{Subject: strings.TrimSpace(header), IssueKeys: []string{}}
+	if match := conventionalHeaderPattern.FindStringSubmatch(header); match != nil {
+		parsed.Conforms = true
+		parsed.Type = strings.ToLower(match[1])
+		parsed.Scope = strings.TrimSpace(match[2])
+		parsed.Breaking = match[3] == "!"
+		parsed.Subject = strings.TrimSpace(match[4])
+	}
+	if parsed.Conforms && breakingFooterPattern.MatchString(message) {
+		parsed.Breaking = true
+	}
+
+	seen := map[string]bool{}
+	for _, pattern := range []*regexp.Regexp{hashIssuePattern, trackerIssuePattern} {
+		for _, match := range pattern.FindAllStringSubmatchIndex(message, -1) {
+			key := message[match[2]:match[3]]
+			if !seen[key] {
+				seen[key] = true
+				parsed.IssueKeys = append(parsed.IssueKeys, key)
+			}
+		}
+	}
+	return parsed
+}
+
+// CommitCategory maps a parsed commit to one of the CommitCategory* constants.
+func CommitCategory(parsed *common_types.ConventionalCommit) string {
+	switch {
+	case parsed == nil || !parsed.Conforms:
+		return CommitCategoryOther
+	case parsed.Type == "feat":
+		return CommitCategoryFeature
+	case parsed.Type == "fix":
+		return CommitCategoryFix
+	default:
+		return CommitCategoryChore
+	}
+}
+
+// CommitCategoryCounts is the features/fixes/chores breakdown of a set of commits.
+type CommitCategoryCounts struct {
+	Total           int            // Number of commits counted.
+	Conforming      int            // Commits whose message follows Conventional Commits.
+	ConformanceRate float64        // Conforming / Total, 0 when there are no commits.
+	Features        int            // Commits in CommitCategoryFeature.
+	Fixes           int            // Commits in CommitCategoryFix.
+	Chores          int            // Commits in CommitCategoryChore.
+	Other           int            // Commits in CommitCategoryOther.
+	Breaking        int            // Conforming commits flagged as breaking changes.
+	ByType          map[string]int // Commit counts per conforming type.
+}
+
+// AuthorCommitCategories is the breakdown for a single commit author.
+type AuthorCommitCategories struct {
+	Name  string // Author name as recorded on the most recent commit.
+	Email string // Author email; authors are grouped by it, case-insensitively.
+	CommitCategoryCounts
+}
+
+// PeriodCommitCategories is the breakdown for a single week or month.
+type PeriodCommitCategories struct {
+	PeriodStart time.Time // Monday 00:00 UTC of the week, or the 1st 00:00 UTC of the month.
+	CommitCategoryCounts
+}
+
+// ConventionalCommitStats aggregates Conventional Commits categories for a repository.
+type ConventionalCommitStats struct {
+	Period     string                   // PeriodWeek or PeriodMonth.
+	Since      time.Time                // Start of the window the commits were taken from (inclusive); zero if unbounded.
+	Until      time.Time                // End of that window (exclusive); zero if unbounded.
+	Repository CommitCategoryCounts     // Breakdown over all commits.
+	ByAuthor   []AuthorCommitCategories // Per-author breakdown, most commits first.
+	ByPeriod   []PeriodCommitCategories // Per-period breakdown, oldest first; undated commits are left out.
+}
+
+// ComputeConventionalCommitStats breaks commits down by Conventional Commits category for the
+// repository as a whole, per author and per period. Commits whose Conventional field is nil
+// are parsed from their message. An unknown period falls back to PeriodWeek.
+func ComputeConventionalCommitStats(commits []*common_types.Commit, period string) *ConventionalCommitStats {
+	if period != PeriodMonth {
+		period = PeriodWeek
+	}
+	stats := &ConventionalCommitStats{
+		Period:     period,
+		Repository: CommitCategoryCounts{ByType: map[string]int{}},
+		ByAuthor:   []AuthorCommitCategories{},
+		ByPeriod:   []PeriodCommitCategories{},
+	}
+	authors := map[string]*AuthorCommitCategories{}
+	latestByAuthor := map[string]time.Time{}
+	periods := map[time.Time]*PeriodCommitCategories{}
+
+	for _, commit := range commits {
+		if commit == nil {
+			continue
+		}
+		parsed := commit.Conventional
+		if parsed == nil {
+			parsed = ParseConventionalCommit(commit.Message)
+		}
+
+		key := authorKey(commit.Author)
+		author, ok := authors[key]
+		if !ok {
+			author = &AuthorCommitCategories{Email: commit.Author.Email, CommitCategoryCounts: CommitCategoryCounts{ByType: map[string]int{}}}
+			authors[key] = author
+		}
+		if commit.Author.Date.After(latestByAuthor[key]) || author.Name == "" {
+			latestByAuthor[key] = commit.Author.Date
+			author.Name = commit.Author.Name
+		}
+		counts := []*CommitCategoryCounts{&stats.Repository, &author.CommitCategoryCounts}
+
+		if !commit.Author.Date.IsZero() {
+			periodStart := WeekStart(commit.Author.Date)
+			if period == PeriodMonth {
+				utc := commit.Author.Date.UTC()
+				periodStart = time.Date(utc.Year(), utc.Month(), 1, 0, 0, 0, 0, time.UTC)
+			}
+			bucket, ok := periods[periodStart]
+			if !ok {
+				bucket = &PeriodCommitCategories{PeriodStart: periodStart, CommitCategoryCounts: CommitCategoryCounts{ByType: map[string]int{}}}
+				periods[periodStart] = bucket
+			}
+			counts = append(counts, &bucket.CommitCategoryCounts)
+		}
+
+		for _, count := range counts {
+			count.add(parsed)
+		}
+	}
+
+	stats.Repository.computeRate()
+	for _, author := range authors {
+		author.computeRate()
+		stats.ByAuthor = append(stats.ByAuthor, *author)
+	}
+	sort.Slice(stats.ByAuthor, func(i, j int) bool {
+		if stats.ByAuthor[i].Total != stats.ByAuthor[j].Total {
+			return stats.ByAuthor[i].Total > stats.ByAuthor[j].Total
+		}
+		return stats.ByAuthor[i].Name < stats.ByAuthor[j].Name
+	})
+	for _, bucket := range periods {
+		bucket.computeRate()
+		stats.ByPeriod = append(stats.ByPeriod, *bucket)
+	}
+	sort.Slice(stats.ByPeriod, func(i, j int) bool {
+		return stats.ByPeriod[i].PeriodStart.Before(stats.ByPeriod[j].PeriodStart)
+	})
+	return stats
+}
+
+// add counts one parsed commit.
+func (counts *CommitCategoryCounts) add(parsed *common_types.ConventionalCommit) {
+	counts.Total++
+	switch CommitCategory(parsed) {
+	case CommitCategoryFeature:
+		counts.Features++
+	case CommitCategoryFix:
+		counts.Fixes++
+	case CommitCategoryChore:
+		counts.Chores++
+	default:
+		counts.Other++
+	}
+	if parsed.Conforms {
+		counts.Conforming++
+		counts.ByType[parsed.Type]++
+		if parsed.Breaking {
+			counts.Breaking++
+		}
+	}
+}
+
+// computeRate derives the conformance rate from the commit counts.
+func (counts *CommitCategoryCounts) computeRate() {
+	if counts.Total > 0 {
+		counts.ConformanceRate = float64(counts.Conforming) / float64(counts.Total)
+	}
+}
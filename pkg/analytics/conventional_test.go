@@ -0,0 +1,95 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected common_types.ConventionalCommit
+	}{
+		{
+			name:     "type and scope",
+			message:  "feat(api): add activity endpoint",
+			expected: common_types.ConventionalCommit{Conforms: true, Type: "feat", Scope: "api", Subject: "add activity endpoint", IssueKeys: []string{}},
+		},
+		{
+			name:     "breaking marker with issue references",
+			message:  "Fix!: drop v1 routes (#42)\n\nRefs PROJ-7 and #42",
+			expected: common_types.ConventionalCommit{Conforms: true, Type: "fix", Breaking: true, Subject: "drop v1 routes (#42)", IssueKeys: []string{"#42", "PROJ-7"}},
+		},
+		{
+			name:     "breaking change footer",
+			message:  "refactor: rename config keys\n\nBREAKING CHANGE: REDIS_URL is now REDIS_ADDR",
+			expected: common_types.ConventionalCommit{Conforms: true, Type: "refactor", Breaking: true, Subject: "rename config keys", IssueKeys: []string{}},
+		},
+		{
+			name:     "non conforming",
+			message:  "Merge branch 'main' into feature\n\nBREAKING CHANGE: ignored",
+			expected: common_types.ConventionalCommit{Subject: "Merge branch 'main' into feature", IssueKeys: []string{}},
+		},
+		{
+			name:     "missing space after colon",
+			message:  "fix:typo",
+			expected: common_types.ConventionalCommit{Subject: "fix:typo", IssueKeys: []string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseConventionalCommit(tt.message); !reflect.DeepEqual(*got, tt.expected) {
+				t.Errorf("ParseConventionalCommit(%q) = %+v, want %+v", tt.message, *got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestComputeConventionalCommitStats(t *testing.T) {
+	may6 := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	june3 := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	commits := []*common_types.Commit{
+		{Message: "feat: one", Author: common_types.CommitAuthor{Name: "Jane", Email: "jane@example.com", Date: may6}},
+		{Message: "fix(ui)!: two", Author: common_types.CommitAuthor{Name: "Jane", Email: "jane@example.com", Date: may6}},
+		{Message: "docs: three", Author: common_types.CommitAuthor{Name: "John", Email: "john@example.com", Date: june3}},
+		{Message: "wip", Author: common_types.CommitAuthor{Name: "John", Email: "john@example.com"}},
+		// A pre-parsed result takes precedence over the message.
+		{Message: "ignored", Conventional: &common_types.ConventionalCommit{Conforms: true, Type: "feat"}, Author: common_types.CommitAuthor{Name: "Jane", Email: "jane@example.com", Date: june3}},
+		nil,
+	}
+
+	stats := ComputeConventionalCommitStats(commits, PeriodMonth)
+
+	repo := stats.Repository
+	if repo.Total != 5 || repo.Features != 2 || repo.Fixes != 1 || repo.Chores != 1 || repo.Other != 1 || repo.Breaking != 1 {
+		t.Errorf("unexpected repository breakdown: %+v", repo)
+	}
+	if repo.ConformanceRate != 0.8 || repo.ByType["feat"] != 2 || repo.ByType["docs"] != 1 {
+		t.Errorf("unexpected conformance or type counts: %+v", repo)
+	}
+
+	if len(stats.ByAuthor) != 2 || stats.ByAuthor[0].Name != "Jane" || stats.ByAuthor[0].Total != 3 || stats.ByAuthor[1].ConformanceRate != 0.5 {
+		t.Errorf("unexpected author breakdown: %+v", stats.ByAuthor)
+	}
+
+	if len(stats.ByPeriod) != 2 {
+		t.Fatalf("expected 2 periods, got %+v", stats.ByPeriod)
+	}
+	if !stats.ByPeriod[0].PeriodStart.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) || stats.ByPeriod[0].Total != 2 {
+		t.Errorf("unexpected first period: %+v", stats.ByPeriod[0])
+	}
+	if stats.ByPeriod[1].Features != 1 || stats.ByPeriod[1].Chores != 1 {
+		t.Errorf("unexpected second period: %+v", stats.ByPeriod[1])
+	}
+}
+
+func TestComputeConventionalCommitStats_DefaultsToWeek(t *testing.T) {
+	stats := ComputeConventionalCommitStats(nil, "fortnight")
+	if stats.Period != PeriodWeek || stats.Repository.Total != 0 || stats.Repository.ConformanceRate != 0 {
+		t.Errorf("unexpected empty stats: %+v", stats)
+	}
+}
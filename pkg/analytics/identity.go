@@ -0,0 +1,71 @@
+package analytics
+
+import (
+	"strings"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+// IdentityAlias names the canonical author for a set of emails and names one person commits under.
+type IdentityAlias struct {
+	Name    string   // Canonical author name.
+	Email   string   // Canonical author email.
+	Aliases []string // Other emails or names of the same person.
+}
+
+// IdentityResolver maps commit authors onto their canonical identity so that per-author
+// statistics count one person once.
+type IdentityResolver struct {
+	byKey map[string]common_types.CommitAuthor
+}
+
+// NewIdentityResolver indexes aliases by their canonical email and every alias, case-insensitively.
+// Earlier aliases win when two claim the same key.
+func NewIdentityResolver(aliases []IdentityAlias) *IdentityResolver {
+	resolver := &IdentityResolver{byKey: map[string]common_types.CommitAuthor{}}
+	for _, alias := range aliases {
+		canonical := common_types.CommitAuthor{Name: alias.Name, Email: alias.Email}
+		for _, key := range append([]string{alias.Email}, alias.Aliases...) {
+			key = strings.ToLower(strings.TrimSpace(key))
+			if _, claimed := resolver.byKey[key]; key != "" && !claimed {
+				resolver.byKey[key] = canonical
+			}
+		}
+	}
+	return resolver
+}
+
+// Resolve returns author with the canonical name and email of the identity its email, or
+// failing that its name, is an alias of. Unknown authors and the commit date are kept as is.
+// Empty canonical fields keep the author's own value.
+func (resolver *IdentityResolver) Resolve(author common_types.CommitAuthor) common_types.CommitAuthor {
+	if resolver == nil {
+		return author
+	}
+	canonical, ok := resolver.byKey[strings.ToLower(strings.TrimSpace(author.Email))]
+	if !ok || author.Email == "" {
+		canonical, ok = resolver.byKey[strings.ToLower(strings.TrimSpace(author.Name))]
+	}
+	if !ok {
+		return author
+	}
+	if canonical.Name != "" {
+		author.Name = canonical.Name
+	}
+	if canonical.Email != "" {
+		author.Email = canonical.Email
+	}
+	return author
+}
+
+// ResolveCommits rewrites the author of every commit to its canonical identity, in place.
+func (resolver *IdentityResolver) ResolveCommits(commits []*common_types.Commit) {
+	if resolver == nil || len(resolver.byKey) == 0 {
+		return
+	}
+	for _, commit := range commits {
+		if commit != nil {
+			commit.Author = resolver.Resolve(commit.Author)
+		}
+	}
+}
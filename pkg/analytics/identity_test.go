@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func TestIdentityResolver_Resolve(t *testing.T) {
+	resolver := NewIdentityResolver([]IdentityAlias{
+		{Name: "Jane Doe", Email: "jane@example.com", Aliases: []string{"jane@old.example.com", "jdoe"}},
+	})
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		author common_types.CommitAuthor
+		want   common_types.CommitAuthor
+	}{
+		{"alias email", common_types.CommitAuthor{Name: "J", Email: "Jane@Old.Example.com", Date: date},
+			common_types.CommitAuthor{Name: "Jane Doe", Email: "jane@example.com", Date: date}},
+		{"canonical email", common_types.CommitAuthor{Name: "jane", Email: "jane@example.com"},
+			common_types.CommitAuthor{Name: "Jane Doe", Email: "jane@example.com"}},
+		{"alias name without email", common_types.CommitAuthor{Name: "JDoe"},
+			common_types.CommitAuthor{Name: "Jane Doe", Email: "jane@example.com"}},
+		{"alias name with unknown email", common_types.CommitAuthor{Name: "jdoe", Email: "laptop@localhost"},
+			common_types.CommitAuthor{Name: "Jane Doe", Email: "jane@example.com"}},
+		{"unknown author", common_types.CommitAuthor{Name: "Bob", Email: "bob@example.com"},
+			common_types.CommitAuthor{Name: "Bob", Email: "bob@example.com"}},
+	}
+	for _, tt := range tests {
+		if got := resolver.Resolve(tt.author); got != tt.want {
+			t.Errorf("%s: Resolve() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIdentityResolver_MergesAuthorTotals(t *testing.T) {
+	commits := []*common_types.Commit{
+		{Author: common_types.CommitAuthor{Name: "Jane", Email: "jane@example.com"}, Stats: common_types.CommitStats{Additions: 1}},
+		{Author: common_types.CommitAuthor{Name: "Jane (laptop)", Email: "jane@laptop"}, Stats: common_types.CommitStats{Additions: 2}},
+	}
+	NewIdentityResolver([]IdentityAlias{{Name: "Jane", Email: "jane@example.com", Aliases: []string{"jane@laptop"}}}).ResolveCommits(commits)
+
+	totals := AggregateChangeTotals(commits)
+	if len(totals) != 1 || totals[0].Commits != 2 || totals[0].Additions != 3 {
+		t.Errorf("expected both commits under one author, got %+v", totals)
+	}
+}
+
+func TestIdentityResolver_NilIsNoOp(t *testing.T) {
+	var resolver *IdentityResolver
+	author := common_types.CommitAuthor{Name: "Bob"}
+	if got := resolver.Resolve(author); got != author {
+		t.Errorf("nil resolver changed author to %+v", got)
+	}
+	resolver.ResolveCommits([]*common_types.Commit{{Author: author}})
+}
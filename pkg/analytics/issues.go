@@ -0,0 +1,174 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+// UnlabeledIssue is the label bucket used for closed issues without any labels.
+const UnlabeledIssue = "(unlabeled)"
+
+// IssueStats is the aggregated view over a repository's issues.
+type IssueStats struct {
+	Total               int               // Number of issues analysed.
+	Open                int               // Number of issues currently open.
+	Closed              int               // Number of issues currently closed.
+	Throughput          []IssueThroughput // Opened/closed counts per week, oldest first.
+	OpenAgeDistribution []IssueAgeBucket  // Age buckets of open issues with a known creation time, youngest first.
+	TimeToCloseByLabel  []LabelCloseTimes // Time-to-close statistics per label, sorted by label name.
+}
+
+// IssueThroughput holds how many issues were opened and closed in one week.
+type IssueThroughput struct {
+	WeekStart time.Time // Monday 00:00 UTC of the week.
+	Opened    int       // Issues created during the week.
+	Closed    int       // Issues closed during the week.
+}
+
+// IssueAgeBucket counts open issues whose age falls in [MinAge, MaxAge).
+// A zero MaxAge means the bucket is unbounded.
+type IssueAgeBucket struct {
+	Label  string        // Human readable bucket name, e.g. "7-30d".
+	MinAge time.Duration // Inclusive lower bound.
+	MaxAge time.Duration // Exclusive upper bound; 0 means no upper bound.
+	Count  int           // Number of open issues in the bucket.
+}
+
+// LabelCloseTimes summarises how long issues carrying a label took to close.
+type LabelCloseTimes struct {
+	Label       string  // Label name, or UnlabeledIssue.
+	Count       int     // Number of closed issues with the label.
+	MeanHours   float64 // Mean time to close in hours.
+	MedianHours float64 // Median time to close in hours.
+}
+
+const day = 24 * time.Hour
+
+// openAgeBuckets defines the age distribution buckets for open issues.
+var openAgeBuckets = []IssueAgeBucket{
+	{Label: "<1d", MinAge: 0, MaxAge: day},
+	{Label: "1-7d", MinAge: day, MaxAge: 7 * day},
+	{Label: "7-30d", MinAge: 7 * day, MaxAge: 30 * day},
+	{Label: "30-90d", MinAge: 30 * day, MaxAge: 90 * day},
+	{Label: "90-365d", MinAge: 90 * day, MaxAge: 365 * day},
+	{Label: ">365d", MinAge: 365 * day},
+}
+
+// ComputeIssueStats aggregates issues into throughput, open-age and time-to-close statistics.
+// now is the reference time used to compute the age of open issues.
+func ComputeIssueStats(issues []*common_types.Issue, now time.Time) *IssueStats {
+	stats := &IssueStats{
+		Throughput:          []IssueThroughput{},
+		OpenAgeDistribution: make([]IssueAgeBucket, len(openAgeBuckets)),
+		TimeToCloseByLabel:  []LabelCloseTimes{},
+	}
+	copy(stats.OpenAgeDistribution, openAgeBuckets)
+
+	weeks := make(map[time.Time]*IssueThroughput)
+	weekFor := func(t time.Time) *IssueThroughput {
+		start := WeekStart(t)
+		if _, ok := weeks[start]; !ok {
+			weeks[start] = &IssueThroughput{WeekStart: start}
+		}
+		return weeks[start]
+	}
+	closeHoursByLabel := make(map[string][]float64)
+
+	for _, issue := range issues {
+		if issue == nil {
+			continue
+		}
+		stats.Total++
+		if !issue.CreatedAt.IsZero() {
+			weekFor(issue.CreatedAt).Opened++
+		}
+
+		if issue.State != "closed" {
+			stats.Open++
+			if issue.CreatedAt.IsZero() {
+				continue // Missing creation time; the age is unknown.
+			}
+			age := now.Sub(issue.CreatedAt)
+			for i := range stats.OpenAgeDistribution {
+				bucket := &stats.OpenAgeDistribution[i]
+				if age >= bucket.MinAge && (bucket.MaxAge == 0 || age < bucket.MaxAge) {
+					bucket.Count++
+					break
+				}
+			}
+			continue
+		}
+
+		stats.Closed++
+		if issue.ClosedAt.IsZero() {
+			continue // Missing close time; neither the week nor the time to close is known.
+		}
+		weekFor(issue.ClosedAt).Closed++
+		if issue.CreatedAt.IsZero() {
+			continue // Missing creation time; time to close is unknown.
+		}
+		hours := issue.ClosedAt.Sub(issue.CreatedAt).Hours()
+		if len(issue.Labels) == 0 {
+			closeHoursByLabel[UnlabeledIssue] = append(closeHoursByLabel[UnlabeledIssue], hours)
+		}
+		for _, label := range issue.Labels {
+			closeHoursByLabel[label] = append(closeHoursByLabel[label], hours)
+		}
+	}
+
+	for _, week := range weeks {
+		stats.Throughput = append(stats.Throughput, *week)
+	}
+	sort.Slice(stats.Throughput, func(i, j int) bool {
+		return stats.Throughput[i].WeekStart.Before(stats.Throughput[j].WeekStart)
+	})
+
+	for label, hours := range closeHoursByLabel {
+		stats.TimeToCloseByLabel = append(stats.TimeToCloseByLabel, LabelCloseTimes{
+			Label:       label,
+			Count:       len(hours),
+			MeanHours:   mean(hours),
+			MedianHours: median(hours),
+		})
+	}
+	sort.Slice(stats.TimeToCloseByLabel, func(i, j int) bool {
+		return stats.TimeToCloseByLabel[i].Label < stats.TimeToCloseByLabel[j].Label
+	})
+
+	return stats
+}
+
+// WeekStart returns Monday 00:00 UTC of the week containing t.
+func WeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+}
+
+// mean returns the arithmetic mean of values, or 0 for an empty slice.
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// median returns the median of values, or 0 for an empty slice. values is not modified.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
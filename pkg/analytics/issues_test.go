@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func TestWeekStart(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    time.Time
+		expected time.Time
+	}{
+		{"monday", time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"sunday", time.Date(2024, 1, 7, 23, 59, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"across month boundary", time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC), time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WeekStart(tt.input); !got.Equal(tt.expected) {
+				t.Errorf("WeekStart(%v) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestComputeIssueStats(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	monday := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+
+	issues := []*common_types.Issue{
+		{Number: 1, State: "closed", Labels: []string{"bug"}, CreatedAt: monday, ClosedAt: monday.Add(10 * time.Hour)},
+		{Number: 2, State: "closed", Labels: []string{"bug", "ui"}, CreatedAt: monday, ClosedAt: monday.Add(30 * time.Hour)},
+		{Number: 3, State: "closed", CreatedAt: monday, ClosedAt: monday.Add(7 * 24 * time.Hour)},
+		{Number: 4, State: "open", CreatedAt: now.Add(-2 * time.Hour)},
+		{Number: 5, State: "open", CreatedAt: now.Add(-40 * 24 * time.Hour)},
+		{Number: 6, State: "open"}, // No creation time: counted as open, but not in any age bucket.
+		{Number: 7, State: "closed", Labels: []string{"bug"}, ClosedAt: monday.Add(8 * 24 * time.Hour)}, // No creation time: closed, but no time to close.
+		nil,
+	}
+
+	stats := ComputeIssueStats(issues, now)
+
+	if stats.Total != 7 || stats.Open != 3 || stats.Closed != 4 {
+		t.Fatalf("unexpected totals: total=%d open=%d closed=%d", stats.Total, stats.Open, stats.Closed)
+	}
+
+	expectedThroughput := map[time.Time][2]int{
+		time.Date(2024, 4, 22, 0, 0, 0, 0, time.UTC): {1, 0}, // Issue 5 opened.
+		time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC):  {3, 2}, // Issues 1-3 opened, 1-2 closed.
+		time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC): {0, 2}, // Issues 3 and 7 closed.
+		time.Date(2024, 5, 27, 0, 0, 0, 0, time.UTC): {1, 0}, // Issue 4 opened.
+	}
+	if len(stats.Throughput) != len(expectedThroughput) {
+		t.Fatalf("expected %d throughput weeks, got %d: %+v", len(expectedThroughput), len(stats.Throughput), stats.Throughput)
+	}
+	for i, week := range stats.Throughput {
+		if i > 0 && !stats.Throughput[i-1].WeekStart.Before(week.WeekStart) {
+			t.Errorf("throughput not sorted at index %d", i)
+		}
+		want, ok := expectedThroughput[week.WeekStart]
+		if !ok || week.Opened != want[0] || week.Closed != want[1] {
+			t.Errorf("week %v: got opened=%d closed=%d, want %v", week.WeekStart, week.Opened, week.Closed, want)
+		}
+	}
+
+	bucketCounts := map[string]int{}
+	for _, bucket := range stats.OpenAgeDistribution {
+		bucketCounts[bucket.Label] = bucket.Count
+	}
+	if bucketCounts["<1d"] != 1 || bucketCounts["30-90d"] != 1 || bucketCounts[">365d"] != 0 {
+		t.Errorf("unexpected open age distribution: %+v", stats.OpenAgeDistribution)
+	}
+
+	expectedByLabel := map[string]LabelCloseTimes{
+		"bug":          {Label: "bug", Count: 2, MeanHours: 20, MedianHours: 20},
+		"ui":           {Label: "ui", Count: 1, MeanHours: 30, MedianHours: 30},
+		UnlabeledIssue: {Label: UnlabeledIssue, Count: 1, MeanHours: 168, MedianHours: 168},
+	}
+	if len(stats.TimeToCloseByLabel) != len(expectedByLabel) {
+		t.Fatalf("expected %d label entries, got %+v", len(expectedByLabel), stats.TimeToCloseByLabel)
+	}
+	for _, entry := range stats.TimeToCloseByLabel {
+		if entry != expectedByLabel[entry.Label] {
+			t.Errorf("label %q: got %+v, want %+v", entry.Label, entry, expectedByLabel[entry.Label])
+		}
+	}
+}
+
+func TestComputeIssueStats_Empty(t *testing.T) {
+	stats := ComputeIssueStats(nil, time.Now())
+	if stats.Total != 0 || len(stats.Throughput) != 0 || len(stats.TimeToCloseByLabel) != 0 {
+		t.Errorf("expected empty stats, got %+v", stats)
+	}
+	if len(stats.OpenAgeDistribution) != len(openAgeBuckets) {
+		t.Errorf("expected all age buckets to be present, got %d", len(stats.OpenAgeDistribution))
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// GetActivity handles requests for the commit activity heatmap of a GitHub repository.
+// Repository is identified by 'owner' and 'repo' query parameters.
+func (ghAPI *GithubApi) GetActivity(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, ok := githubRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "github", "/api/github/activity", "owner and repo query parameters are required.", http.StatusBadRequest)
+		return
+	}
+	serveActivity(w, r, "github", ghAPI.gitService(r), ghAPI.cache(r), ghAPI.Defaults, repoIdentifier, strings.Replace(repoIdentifier, "/", "_", 1))
+}
+
+// GetActivity handles requests for the commit activity heatmap of a GitLab repository.
+// Repository is identified by 'project' query parameter (ID or namespace/path).
+func (glAPI *GitlabApi) GetActivity(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, repoIdentifierQuery, ok := gitlabRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "gitlab", "/api/gitlab/activity", "project query parameter is required.", http.StatusBadRequest)
+		return
+	}
+	serveActivity(w, r, "gitlab", glAPI.gitService(r), glAPI.cache(r), glAPI.Defaults, repoIdentifier, repoIdentifierQuery)
+}
+
+// serveActivity is the provider-agnostic part of the activity handlers.
+// Optional query parameters: 'tz' (IANA time zone), 'workStart'/'workEnd' (working hours
+// as [workStart, workEnd)), whose defaults come from defaults, and 'since'/'until' (RFC 3339),
+// the window of the commits, by default the last DefaultAnalysisDays days.
+func serveActivity(w http.ResponseWriter, r *http.Request, provider string, gitService interfaces.GitService, cache storage.InMemoryDB, defaults AnalyticsDefaults, repoIdentifier interface{}, cacheKeyRepo string) {
+	endpointName := fmt.Sprintf("/api/%s/activity", provider)
+
+	activityOpts := analytics.ActivityOptions{
+		Location:         defaults.Location,
+		WorkdayStartHour: defaults.WorkdayStartHour,
+		WorkdayEndHour:   defaults.WorkdayEndHour,
+	}
+	if tzQuery := r.URL.Query().Get("tz"); tzQuery != "" {
+		location, loadErr := time.LoadLocation(tzQuery)
+		if loadErr != nil {
+			rejectRequest(w, r, provider, endpointName, "tz query parameter must be an IANA time zone name.", http.StatusBadRequest)
+			return
+		}
+		activityOpts.Location = location
+	}
+	if workStartQuery := r.URL.Query().Get("workStart"); workStartQuery != "" {
+		parsedHour, parseErr := strconv.Atoi(workStartQuery)
+		if parseErr != nil {
+			rejectRequest(w, r, provider, endpointName, "workStart query parameter must be an hour between 0 and 23.", http.StatusBadRequest)
+			return
+		}
+		activityOpts.WorkdayStartHour = parsedHour
+	}
+	if workEndQuery := r.URL.Query().Get("workEnd"); workEndQuery != "" {
+		parsedHour, parseErr := strconv.Atoi(workEndQuery)
+		if parseErr != nil {
+			rejectRequest(w, r, provider, endpointName, "workEnd query parameter must be an hour between 1 and 24.", http.StatusBadRequest)
+			return
+		}
+		activityOpts.WorkdayEndHour = parsedHour
+	}
+	if activityOpts.WorkdayStartHour < 0 || activityOpts.WorkdayEndHour > 24 || activityOpts.WorkdayStartHour >= activityOpts.WorkdayEndHour {
+		rejectRequest(w, r, provider, endpointName, "Working hours must satisfy 0 <= workStart < workEnd <= 24.", http.StatusBadRequest)
+		return
+	}
+
+	window, windowErr := analysisWindowFromQuery(r)
+	if windowErr != nil {
+		rejectRequest(w, r, provider, endpointName, "since and until query parameters must be RFC 3339 timestamps with since before until.", http.StatusBadRequest)
+		return
+	}
+
+	redisKey := activityCacheKey(provider, cacheKeyRepo, activityOpts, window)
+	serveCachedJSON(w, r, provider, endpointName, "commits", cache, redisKey, CacheTTL, func() (interface{}, error) {
+		// A bounded window lets the provider walk every page of it. The heatmap only needs the
+		// author dates, so no requests are spent on line stats.
+		commits, err := commitsInWindow(gitService, repoIdentifier, window, true)
+		if err != nil {
+			return nil, err
+		}
+		report := analytics.ComputeActivity(commits, activityOpts)
+		report.Since, report.Until = window.Since, window.Until
+		return report, nil
+	})
+}
+
+// activityCacheKey is the cache key of the heatmap of cacheKeyRepo with opts over window.
+func activityCacheKey(provider, cacheKeyRepo string, opts analytics.ActivityOptions, window analytics.PeriodWindow) string {
+	return responseCacheKey(provider, "activity", cacheKeyRepo, opts.Location, opts.WorkdayStartHour, opts.WorkdayEndHour, window.Since.Unix(), window.Until.Unix())
+}
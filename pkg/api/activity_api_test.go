@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+func TestGithubApi_GetActivity_Success_NoCache(t *testing.T) {
+	mockGitService := &MockGitService{
+		GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+			if repoIdentifier != "test-owner/test-repo" {
+				t.Errorf("unexpected repoIdentifier %v", repoIdentifier)
+			}
+			if !options.NoStats {
+				t.Error("expected the heatmap to skip line stats")
+			}
+			if !options.WalksAllPages() || options.Since.Format(time.RFC3339) != "2024-02-07T00:00:00Z" || options.Until.Format(time.RFC3339) != "2024-05-07T00:00:00Z" {
+				t.Errorf("expected the window until 2024-05-07 to be listed, got %s..%s", options.Since, options.Until)
+			}
+			return []*common_types.Commit{
+				{Author: common_types.CommitAuthor{Name: "Jane", Email: "jane@example.com", Date: time.Date(2024, 5, 6, 20, 0, 0, 0, time.UTC)}},
+				{Author: common_types.CommitAuthor{Name: "Jane", Email: "jane@example.com", Date: time.Date(2024, 5, 7, 0, 0, 0, 0, time.UTC)}},
+			}, nil
+		},
+	}
+	var cachedKey string
+	mockRedisClient := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) { return nil, nil }, // Cache miss
+		SetFunc: func(key string, value interface{}, expirationInSeconds time.Duration) error {
+			cachedKey = key
+			return nil
+		},
+	}
+	githubAPI := NewGithubApi(mockGitService, mockRedisClient)
+
+	req, _ := http.NewRequest("GET", "/api/github/activity?owner=test-owner&repoName=test-repo&tz=Europe/Istanbul&until=2024-05-07T00:00:00Z", nil)
+	rr := httptest.NewRecorder()
+	githubAPI.GetActivity(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("GetActivity returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var report analytics.ActivityReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("GetActivity could not unmarshal response: %v", err)
+	}
+	// 20:00 UTC is 23:00 in Istanbul, outside the default working hours.
+	if report.Repository.Heatmap[time.Monday][23] != 1 || report.Repository.OffHoursCommits != 1 || len(report.Authors) != 1 {
+		t.Errorf("GetActivity returned unexpected body: %s", rr.Body.String())
+	}
+	if report.Since.Format(time.RFC3339) != "2024-02-07T00:00:00Z" || report.Until.Format(time.RFC3339) != "2024-05-07T00:00:00Z" {
+		t.Errorf("expected the window to be echoed, got %s..%s", report.Since, report.Until)
+	}
+	if cachedKey != "github_get_activity_test-owner_test-repo:Europe/Istanbul_9_18_1707264000_1715040000" {
+		t.Errorf("GetActivity cached under unexpected key %q", cachedKey)
+	}
+}
+
+func TestGitlabApi_GetActivity_DefaultWindow(t *testing.T) {
+	var listed analytics.PeriodWindow
+	gitlabAPI := NewGitlabApi(&MockGitService{GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+		listed = analytics.PeriodWindow{Since: options.Since, Until: options.Until}
+		return nil, nil
+	}}, mapCache{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/gitlab/activity?project=42", nil)
+	rr := httptest.NewRecorder()
+	gitlabAPI.GetActivity(rr, req)
+
+	want := defaultAnalysisWindow(time.Now())
+	if rr.Code != http.StatusOK || listed != want {
+		t.Fatalf("expected the last %d days to be listed, got %d %s..%s", DefaultAnalysisDays, rr.Code, listed.Since, listed.Until)
+	}
+	if want.Until.Sub(want.Since) != DefaultAnalysisDays*24*time.Hour || !want.Until.After(time.Now()) {
+		t.Errorf("expected the default window to end at the start of tomorrow, got %s..%s", want.Since, want.Until)
+	}
+}
+
+func TestGetActivity_BadRequests(t *testing.T) {
+	githubAPI := NewGithubApi(&MockGitService{}, &MockRedisClient{})
+	gitlabAPI := NewGitlabApi(&MockGitService{}, &MockRedisClient{})
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"github missing owner", githubAPI.GetActivity, "/api/github/activity?repoName=r"},
+		{"github unknown time zone", githubAPI.GetActivity, "/api/github/activity?owner=o&repoName=r&tz=Mars/Olympus"},
+		{"gitlab inverted working hours", gitlabAPI.GetActivity, "/api/gitlab/activity?projectID=1&workStart=18&workEnd=9"},
+		{"gitlab non-numeric workEnd", gitlabAPI.GetActivity, "/api/gitlab/activity?projectID=1&workEnd=six"},
+		{"gitlab invalid since", gitlabAPI.GetActivity, "/api/gitlab/activity?projectID=1&since=yesterday"},
+		{"gitlab since after until", gitlabAPI.GetActivity, "/api/gitlab/activity?projectID=1&since=2024-06-01T00:00:00Z&until=2024-05-01T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("%s returned wrong status code: got %v want %v", tt.name, status, http.StatusBadRequest)
+			}
+		})
+	}
+}
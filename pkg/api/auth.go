@@ -0,0 +1,323 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/auth"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Authentication methods of an Identity.
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodOIDC   = "oidc"
+)
+
+// Headers callers authenticate with, besides "Authorization: Bearer <API key or token>".
+const (
+	APIKeyHeader        = "X-API-Key"
+	ProviderTokenHeader = "X-Provider-Token" // The caller's own GitHub or GitLab token.
+)
+
+// Identity is the caller of a request, as established by Authenticator.
+type Identity struct {
+	Subject       string                 // API key name or OIDC subject; empty when authentication is disabled.
+	Method        string                 // AuthMethodAPIKey or AuthMethodOIDC; empty when authentication is disabled.
+	Claims        map[string]interface{} // Verified OIDC claims; nil for API keys.
+	ProviderToken string                 // The caller's forwarded provider token, if any.
+}
+
+// identityFromContext returns the Identity Authenticator stored for the request, or the zero
+// Identity for requests it did not see.
+func identityFromContext(ctx context.Context) Identity {
+	identity, _ := ctx.Value(identityKey).(Identity)
+	return identity
+}
+
+// cachePartition returns the cache namespace of identity. Data fetched with a forwarded token is
+// cached per token, data fetched with the server token per authenticated caller, so a response
+// is never served to someone other than who it was fetched for. Unauthenticated requests to an
+// open API share the empty partition.
+func (identity Identity) cachePartition() string {
+	var owner string
+	switch {
+	case identity.ProviderToken != "":
+		owner = "token:" + identity.ProviderToken
+	case identity.Subject != "":
+		owner = identity.Method + ":" + identity.Subject
+	default:
+		return ""
+	}
+	sum := sha256.Sum256([]byte(owner))
+	return hex.EncodeToString(sum[:8])
+}
+
+// TokenVerifier verifies bearer tokens; *auth.OIDCVerifier implements it.
+type TokenVerifier interface {
+	Verify(ctx context.Context, rawToken string) (*auth.Claims, error)
+}
+
+// AuthOptions configures an Authenticator.
+type AuthOptions struct {
+	APIKeys               map[string]string // Hex SHA-256 of each API key, by key name.
+	Verifier              TokenVerifier     // Verifies bearer tokens that are JWTs; nil disables OIDC.
+	ForwardProviderTokens bool              // Whether X-Provider-Token replaces the server's provider token.
+}
+
+// apiKey is a configured API key.
+type apiKey struct {
+	name string
+	hash []byte
+}
+
+// Authenticator establishes the Identity of API callers.
+type Authenticator struct {
+	apiKeys               []apiKey
+	verifier              TokenVerifier
+	forwardProviderTokens bool
+}
+
+// NewAuthenticator returns an Authenticator for options. Callers must authenticate when an API
+// key or a verifier is configured.
+func NewAuthenticator(options AuthOptions) (*Authenticator, error) {
+	authenticator := &Authenticator{verifier: options.Verifier, forwardProviderTokens: options.ForwardProviderTokens}
+	for name, hexHash := range options.APIKeys {
+		hash, err := hex.DecodeString(hexHash)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("API key %q: expected a hex SHA-256, got %d characters", name, len(hexHash))
+		}
+		authenticator.apiKeys = append(authenticator.apiKeys, apiKey{name: name, hash: hash})
+	}
+	sort.Slice(authenticator.apiKeys, func(i, j int) bool { return authenticator.apiKeys[i].name < authenticator.apiKeys[j].name })
+	return authenticator, nil
+}
+
+// Required reports whether callers have to authenticate.
+func (a *Authenticator) Required() bool {
+	return len(a.apiKeys) > 0 || a.verifier != nil
+}
+
+// Capabilities describes the authentication settings for /api/config.
+func (a *Authenticator) Capabilities() AuthCapabilities {
+	capabilities := AuthCapabilities{Required: a.Required(), Methods: []string{}, ForwardProviderTokens: a.forwardProviderTokens}
+	if len(a.apiKeys) > 0 {
+		capabilities.Methods = append(capabilities.Methods, AuthMethodAPIKey)
+	}
+	if a.verifier != nil {
+		capabilities.Methods = append(capabilities.Methods, AuthMethodOIDC)
+	}
+	return capabilities
+}
+
+// errInvalidAPIKey is returned for a credential that matches no API key.
+var errInvalidAPIKey = errors.New("invalid API key")
+
+// Middleware returns a middleware for the routes of provider that rejects unauthenticated
+// requests and stores the caller's Identity in the request context.
+func (a *Authenticator) Middleware(provider string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var identity Identity
+			if a.Required() {
+				credential := r.Header.Get(APIKeyHeader)
+				if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+					credential = strings.TrimSpace(bearer)
+				}
+				logCtx := log.WithFields(logrus.Fields{"path": r.URL.Path, "provider": provider, "request_id": requestIDFromContext(r.Context())})
+				if credential == "" {
+					logCtx.Info("Rejected unauthenticated request.")
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					writeError(w, r, provider, APIError{Status: http.StatusUnauthorized, Code: ErrorCodeUnauthenticated, Message: "Authentication required: send an API key or a bearer token."})
+					return
+				}
+				authenticated, err := a.authenticate(r.Context(), credential)
+				switch {
+				case errors.Is(err, errInvalidAPIKey) || errors.Is(err, auth.ErrInvalidToken):
+					logCtx.WithField("error", err).Info("Rejected request with an invalid credential.")
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					writeError(w, r, provider, APIError{Status: http.StatusUnauthorized, Code: ErrorCodeUnauthenticated, Message: "The API key or bearer token is invalid or expired."})
+					return
+				case err != nil:
+					logCtx.WithField("error", err).Error("Failed to verify bearer token.")
+					writeError(w, r, provider, APIError{Status: http.StatusServiceUnavailable, Code: ErrorCodeUpstream, Message: "The identity provider is unavailable."})
+					return
+				}
+				identity = authenticated
+				logCtx.WithFields(logrus.Fields{"subject": identity.Subject, "auth_method": identity.Method}).Debug("Authenticated request.")
+			}
+			if a.forwardProviderTokens {
+				identity.ProviderToken = r.Header.Get(ProviderTokenHeader)
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey, identity)))
+		})
+	}
+}
+
+// authenticate resolves credential, a JWT or an API key, to an Identity.
+func (a *Authenticator) authenticate(ctx context.Context, credential string) (Identity, error) {
+	if a.verifier != nil && strings.Count(credential, ".") == 2 {
+		claims, err := a.verifier.Verify(ctx, credential)
+		if err != nil {
+			return Identity{}, err
+		}
+		return Identity{Subject: claims.Subject, Method: AuthMethodOIDC, Claims: claims.Raw}, nil
+	}
+
+	hash := sha256.Sum256([]byte(credential))
+	name := ""
+	for _, key := range a.apiKeys { // Compare against every key so timing does not reveal which one matched.
+		if subtle.ConstantTimeCompare(hash[:], key.hash) == 1 {
+			name = key.name
+		}
+	}
+	if name == "" {
+		return Identity{}, errInvalidAPIKey
+	}
+	return Identity{Subject: name, Method: AuthMethodAPIKey}, nil
+}
+
+// requestGitService returns the GitService to serve r with: one built by tokenService for the
+// caller's forwarded provider token, or fallback, which uses the server token.
+func requestGitService(r *http.Request, fallback interfaces.GitService, tokenService func(token string) (interfaces.GitService, error)) interfaces.GitService {
+	token := identityFromContext(r.Context()).ProviderToken
+	if token == "" || tokenService == nil {
+		return fallback
+	}
+	gitService, err := tokenService(token)
+	if err != nil {
+		return unavailableService{err: fmt.Errorf("failed to create a client for the forwarded provider token: %w", err)}
+	}
+	return gitService
+}
+
+// requestCache returns cache restricted to the cache partition of the caller of r, storing
+// responses as the CachePolicy of the endpoint says. Requests marked with cacheRefreshKey never
+// read it, only store fresh responses in it.
+func requestCache(r *http.Request, cache storage.InMemoryDB) storage.InMemoryDB {
+	settings, _ := r.Context().Value(cacheEntryKey).(*cacheSettings)
+	cache = settings.cache(cache)
+	if refresh, _ := r.Context().Value(cacheRefreshKey).(bool); refresh {
+		cache = refreshingCache{InMemoryDB: cache}
+	}
+	return identityCache(identityFromContext(r.Context()), cache)
+}
+
+// cacheKeyPrefix returns what the keys of the cache partition of identity start with.
+func (identity Identity) cacheKeyPrefix() string {
+	partition := identity.cachePartition()
+	if partition == "" {
+		return ""
+	}
+	return "identity_" + partition + "_"
+}
+
+// identityCache returns cache restricted to the cache partition of identity.
+func identityCache(identity Identity, cache storage.InMemoryDB) storage.InMemoryDB {
+	prefix := identity.cacheKeyPrefix()
+	if prefix == "" {
+		return cache
+	}
+	return partitionedCache{InMemoryDB: cache, prefix: prefix}
+}
+
+// refreshingCache misses on every read, so handlers fetch from the provider and overwrite what
+// is cached.
+type refreshingCache struct {
+	storage.InMemoryDB
+}
+
+func (refreshingCache) Get(key string) ([]byte, error) {
+	return nil, nil
+}
+
+// partitionedCache prefixes every key, giving each identity its own namespace in a shared cache.
+type partitionedCache struct {
+	storage.InMemoryDB
+	prefix string
+}
+
+func (c partitionedCache) Get(key string) ([]byte, error) {
+	return c.InMemoryDB.Get(c.prefix + key)
+}
+
+func (c partitionedCache) Set(key string, value interface{}, duration time.Duration) error {
+	return c.InMemoryDB.Set(c.prefix+key, value, duration)
+}
+
+func (c partitionedCache) Delete(key string) error {
+	return c.InMemoryDB.Delete(c.prefix + key)
+}
+
+// gitService returns the GitService serving r.
+func (ghAPI *GithubApi) gitService(r *http.Request) interfaces.GitService {
+	return requestGitService(r, ghAPI.Repo, ghAPI.TokenService)
+}
+
+// cache returns the response cache of the caller of r.
+func (ghAPI *GithubApi) cache(r *http.Request) storage.InMemoryDB {
+	return requestCache(r, ghAPI.Redis)
+}
+
+// gitService returns the GitService serving r.
+func (glAPI *GitlabApi) gitService(r *http.Request) interfaces.GitService {
+	return requestGitService(r, glAPI.Repo, glAPI.TokenService)
+}
+
+// cache returns the response cache of the caller of r.
+func (glAPI *GitlabApi) cache(r *http.Request) storage.InMemoryDB {
+	return requestCache(r, glAPI.Redis)
+}
+
+// unavailableService is a GitService whose every call fails with err.
+type unavailableService struct {
+	err error
+}
+
+func (s unavailableService) GetAllRepos(owner string) ([]*common_types.Repository, error) {
+	return nil, s.err
+}
+
+func (s unavailableService) GetRepo(identifier interface{}) (*common_types.Repository, error) {
+	return nil, s.err
+}
+
+func (s unavailableService) GetProjectCommits(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+	return nil, s.err
+}
+
+func (s unavailableService) GetRepoContributors(repoIdentifier interface{}) ([]*common_types.User, error) {
+	return nil, s.err
+}
+
+func (s unavailableService) ListIssues(repoIdentifier interface{}, options *interfaces.IssueListOptions) ([]*common_types.Issue, error) {
+	return nil, s.err
+}
+
+func (s unavailableService) ListBranches(repoIdentifier interface{}) ([]*common_types.Branch, error) {
+	return nil, s.err
+}
+
+func (s unavailableService) ListTags(repoIdentifier interface{}) ([]*common_types.Tag, error) {
+	return nil, s.err
+}
+
+func (s unavailableService) CompareRefs(repoIdentifier interface{}, baseRef, headRef string) (*common_types.Comparison, error) {
+	return nil, s.err
+}
+
+func (s unavailableService) ListLanguages(repoIdentifier interface{}) ([]*common_types.Language, error) {
+	return nil, s.err
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/policy"
+	"github.com/sirupsen/logrus"
+)
+
+// Authorizer enforces an access policy on the provider endpoints.
+type Authorizer struct {
+	policy *policy.Policy
+}
+
+// NewAuthorizer returns an Authorizer enforcing p.
+func NewAuthorizer(p *policy.Policy) *Authorizer {
+	return &Authorizer{policy: p}
+}
+
+// Authorize wraps the handler of endpoint, which belongs to provider, so that requests the policy
+// does not allow are answered with 403 before the handler runs. It must run after the
+// Authenticator middleware, which establishes the caller.
+func (a *Authorizer) Authorize(provider string, endpoint Endpoint) Endpoint {
+	action := endpoint.Action
+	if action == "" {
+		action = policy.ActionRead
+	}
+	next := endpoint.Handler
+	endpoint.Handler = func(w http.ResponseWriter, r *http.Request) {
+		if a.allow(w, r, a.policyRequest(r, provider, action)) {
+			next(w, r)
+		}
+	}
+	return endpoint
+}
+
+// authorizePurge reports whether the policy allows the caller of r to purge the cached responses
+// of the provider repositories matching repoPattern, and answers r with 403 if not. The pattern is
+// scoped like a repository path; an empty one, purging everything of provider, is not scoped.
+func (a *Authorizer) authorizePurge(w http.ResponseWriter, r *http.Request, provider, repoPattern string) bool {
+	request := policy.Request{Subject: a.subject(r), Provider: provider, Action: policy.ActionPurge}
+	request.Org, request.Repo = splitRepoPath(repoPattern)
+	return a.allow(w, r, request)
+}
+
+// allow evaluates request, made by r, and answers r with 403 unless the policy allows it.
+func (a *Authorizer) allow(w http.ResponseWriter, r *http.Request, request policy.Request) bool {
+	decision := a.policy.Evaluate(request)
+	if decision.Allowed {
+		return true
+	}
+	log.WithFields(logrus.Fields{
+		"path":       r.URL.Path,
+		"provider":   request.Provider,
+		"request_id": requestIDFromContext(r.Context()),
+		"subject":    request.Subject.ID,
+		"action":     request.Action,
+		"repo":       request.Repo,
+		"org":        request.Org,
+		"rule":       decision.Rule,
+	}).Info("Request denied by the access policy.")
+	writeError(w, r, request.Provider, APIError{Status: http.StatusForbidden, Code: ErrorCodeAccessDenied, Message: "The access policy does not allow you this request."})
+	return false
+}
+
+// subject returns the caller of r as the policy sees it; the zero Subject when unauthenticated.
+func (a *Authorizer) subject(r *http.Request) policy.Subject {
+	identity := identityFromContext(r.Context())
+	if identity.Subject == "" {
+		return policy.Subject{}
+	}
+	return policy.Subject{ID: identity.Method + ":" + identity.Subject, Groups: a.groups(identity)}
+}
+
+// policyRequest describes r, a request for action on an endpoint of provider, to the policy.
+func (a *Authorizer) policyRequest(r *http.Request, provider, action string) policy.Request {
+	request := policy.Request{Subject: a.subject(r), Provider: provider, Action: action}
+
+	if action == policy.ActionList {
+		request.Org = queryValue(r, ParamOwner)
+		return request
+	}
+	var repoPath string
+	switch provider {
+	case "github":
+		// The same precedence as the handlers: owner and repo, else the deprecated identifiers.
+		if owner, repo := queryValue(r, ParamOwner), queryValue(r, ParamRepo); owner != "" && repo != "" {
+			repoPath = owner + "/" + repo
+		} else if projectID := r.URL.Query().Get(legacyParamProjectID); projectID != "" {
+			repoPath = projectID // "owner/name" or a numeric ID.
+		} else {
+			repoPath = repoPathFromURL(r.URL.Query().Get(legacyParamRepoURL))
+		}
+	case "gitlab":
+		repoPath = queryValue(r, ParamProject) // "group/subgroup/project" or a numeric ID.
+	}
+	request.Org, request.Repo = splitRepoPath(repoPath)
+	return request
+}
+
+// splitRepoPath returns the org and the repository of repoPath, "owner/name" or
+// "group/subgroup/project". The org is empty when repoPath has none, e.g. for a numeric ID.
+func splitRepoPath(repoPath string) (org, repo string) {
+	repoPath = strings.Trim(repoPath, "/")
+	if index := strings.LastIndex(repoPath, "/"); index > 0 {
+		return repoPath[:index], repoPath
+	}
+	return "", repoPath
+}
+
+// groups returns the groups of identity: the strings in the groups claim of its OIDC token.
+func (a *Authorizer) groups(identity Identity) []string {
+	claim := a.policy.GroupsClaim
+	if claim == "" {
+		claim = policy.DefaultGroupsClaim
+	}
+	switch value := identity.Claims[claim].(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		var groups []string
+		for _, group := range value {
+			if name, ok := group.(string); ok {
+				groups = append(groups, name)
+			}
+		}
+		return groups
+	default:
+		return nil
+	}
+}
+
+// queryValue returns the query parameter name of r or its first set deprecated alias. Unlike
+// queryParam it does not mark the response as deprecated; the handler does that.
+func queryValue(r *http.Request, name string) string {
+	query := r.URL.Query()
+	if value := query.Get(name); value != "" {
+		return value
+	}
+	for _, alias := range deprecatedAliases[name] {
+		if value := query.Get(alias); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// repoPathFromURL returns "owner/name" for a GitHub clone URL such as
+// https://github.com/owner/name.git, or "" if rawURL is not one.
+func repoPathFromURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || !strings.EqualFold(parsed.Host, "github.com") {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(strings.TrimSuffix(parsed.Path, ".git"), "/"), "/")
+	if len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+		return ""
+	}
+	return segments[0] + "/" + segments[1]
+}
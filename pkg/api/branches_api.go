@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// DefaultStaleBranchDays is the built-in staleness threshold used when the 'days' query parameter is absent.
+const DefaultStaleBranchDays = 90
+
+// GetBranches handles requests to list the branches of a GitHub repository.
+// Repository is identified by 'owner' and 'repo' query parameters.
+func (ghAPI *GithubApi) GetBranches(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, ok := githubRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "github", "/api/github/branches", "owner and repo query parameters are required.", http.StatusBadRequest)
+		return
+	}
+	serveBranches(w, r, "github", ghAPI.gitService(r), ghAPI.cache(r), repoIdentifier, strings.Replace(repoIdentifier, "/", "_", 1))
+}
+
+// GetTags handles requests to list the tags of a GitHub repository.
+// Repository is identified by 'owner' and 'repo' query parameters.
+func (ghAPI *GithubApi) GetTags(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, ok := githubRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "github", "/api/github/tags", "owner and repo query parameters are required.", http.StatusBadRequest)
+		return
+	}
+	serveTags(w, r, "github", ghAPI.gitService(r), ghAPI.cache(r), repoIdentifier, strings.Replace(repoIdentifier, "/", "_", 1))
+}
+
+// GetBranchReport handles requests for the stale/merged branch report of a GitHub repository.
+// Repository is identified by 'owner' and 'repo'; 'days' sets the staleness threshold.
+func (ghAPI *GithubApi) GetBranchReport(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, ok := githubRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "github", "/api/github/branches/report", "owner and repo query parameters are required.", http.StatusBadRequest)
+		return
+	}
+	serveBranchReport(w, r, "github", ghAPI.gitService(r), ghAPI.cache(r), ghAPI.Defaults, repoIdentifier, strings.Replace(repoIdentifier, "/", "_", 1))
+}
+
+// GetBranches handles requests to list the branches of a GitLab repository.
+// Repository is identified by 'project' query parameter (ID or namespace/path).
+func (glAPI *GitlabApi) GetBranches(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, repoIdentifierQuery, ok := gitlabRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "gitlab", "/api/gitlab/branches", "project query parameter is required.", http.StatusBadRequest)
+		return
+	}
+	serveBranches(w, r, "gitlab", glAPI.gitService(r), glAPI.cache(r), repoIdentifier, repoIdentifierQuery)
+}
+
+// GetTags handles requests to list the tags of a GitLab repository.
+// Repository is identified by 'project' query parameter (ID or namespace/path).
+func (glAPI *GitlabApi) GetTags(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, repoIdentifierQuery, ok := gitlabRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "gitlab", "/api/gitlab/tags", "project query parameter is required.", http.StatusBadRequest)
+		return
+	}
+	serveTags(w, r, "gitlab", glAPI.gitService(r), glAPI.cache(r), repoIdentifier, repoIdentifierQuery)
+}
+
+// GetBranchReport handles requests for the stale/merged branch report of a GitLab repository.
+// Repository is identified by 'project'; 'days' sets the staleness threshold.
+func (glAPI *GitlabApi) GetBranchReport(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, repoIdentifierQuery, ok := gitlabRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "gitlab", "/api/gitlab/branches/report", "project query parameter is required.", http.StatusBadRequest)
+		return
+	}
+	serveBranchReport(w, r, "gitlab", glAPI.gitService(r), glAPI.cache(r), glAPI.Defaults, repoIdentifier, repoIdentifierQuery)
+}
+
+// serveBranches is the provider-agnostic part of the branch listing handlers.
+func serveBranches(w http.ResponseWriter, r *http.Request, provider string, gitService interfaces.GitService, cache storage.InMemoryDB, repoIdentifier interface{}, cacheKeyRepo string) {
+	endpointName := fmt.Sprintf("/api/%s/branches", provider)
+	redisKey := branchesCacheKey(provider, cacheKeyRepo)
+	serveCachedJSON(w, r, provider, endpointName, "branches", cache, redisKey, CacheTTL, func() (interface{}, error) {
+		return gitService.ListBranches(repoIdentifier)
+	})
+}
+
+// serveTags is the provider-agnostic part of the tag listing handlers.
+func serveTags(w http.ResponseWriter, r *http.Request, provider string, gitService interfaces.GitService, cache storage.InMemoryDB, repoIdentifier interface{}, cacheKeyRepo string) {
+	endpointName := fmt.Sprintf("/api/%s/tags", provider)
+	redisKey := tagsCacheKey(provider, cacheKeyRepo)
+	serveCachedJSON(w, r, provider, endpointName, "tags", cache, redisKey, CacheTTL, func() (interface{}, error) {
+		return gitService.ListTags(repoIdentifier)
+	})
+}
+
+// serveBranchReport is the provider-agnostic part of the branch report handlers.
+func serveBranchReport(w http.ResponseWriter, r *http.Request, provider string, gitService interfaces.GitService, cache storage.InMemoryDB, defaults AnalyticsDefaults, repoIdentifier interface{}, cacheKeyRepo string) {
+	endpointName := fmt.Sprintf("/api/%s/branches/report", provider)
+
+	staleAfterDays := defaults.StaleBranchDays
+	if daysQuery := r.URL.Query().Get("days"); daysQuery != "" {
+		parsedDays, parseErr := strconv.Atoi(daysQuery)
+		if parseErr != nil || parsedDays < 0 {
+			rejectRequest(w, r, provider, endpointName, "days query parameter must be a non-negative integer.", http.StatusBadRequest)
+			return
+		}
+		staleAfterDays = parsedDays
+	}
+
+	redisKey := branchReportCacheKey(provider, cacheKeyRepo, staleAfterDays)
+	serveCachedJSON(w, r, provider, endpointName, "branches", cache, redisKey, CacheTTL, func() (interface{}, error) {
+		branches, err := gitService.ListBranches(repoIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		return analytics.BuildBranchReport(branches, time.Now(), staleAfterDays), nil
+	})
+}
+
+// branchesCacheKey is the cache key of the branches of cacheKeyRepo.
+func branchesCacheKey(provider, cacheKeyRepo string) string {
+	return responseCacheKey(provider, "branches", cacheKeyRepo)
+}
+
+// tagsCacheKey is the cache key of the tags of cacheKeyRepo.
+func tagsCacheKey(provider, cacheKeyRepo string) string {
+	return responseCacheKey(provider, "tags", cacheKeyRepo)
+}
+
+// branchReportCacheKey is the cache key of the branch report of cacheKeyRepo for staleAfterDays.
+func branchReportCacheKey(provider, cacheKeyRepo string, staleAfterDays int) string {
+	return responseCacheKey(provider, "branch_report", cacheKeyRepo, staleAfterDays)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func TestGithubApi_GetBranchReport_Success_NoCache(t *testing.T) {
+	now := time.Now()
+	mockGitService := &MockGitService{
+		ListBranchesFunc: func(repoIdentifier interface{}) ([]*common_types.Branch, error) {
+			if repoIdentifier != "test-owner/test-repo" {
+				t.Errorf("unexpected repoIdentifier %v", repoIdentifier)
+			}
+			return []*common_types.Branch{
+				{Name: "main", Default: true, LastCommitDate: now},
+				{Name: "old", LastCommitDate: now.Add(-40 * 24 * time.Hour)},
+				{Name: "new", LastCommitDate: now.Add(-time.Hour)},
+			}, nil
+		},
+	}
+	var cachedKey string
+	mockRedisClient := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) { return nil, nil }, // Cache miss
+		SetFunc: func(key string, value interface{}, expirationInSeconds time.Duration) error {
+			cachedKey = key
+			return nil
+		},
+	}
+	githubAPI := NewGithubApi(mockGitService, mockRedisClient)
+
+	req, _ := http.NewRequest("GET", "/api/github/branches/report?owner=test-owner&repoName=test-repo&days=30", nil)
+	rr := httptest.NewRecorder()
+	githubAPI.GetBranchReport(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("GetBranchReport returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var report analytics.BranchReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("GetBranchReport could not unmarshal response: %v", err)
+	}
+	if report.StaleAfterDays != 30 || len(report.Entries) != 1 || report.Entries[0].Branch.Name != "old" {
+		t.Errorf("GetBranchReport returned unexpected body: %s", rr.Body.String())
+	}
+	if cachedKey != "github_get_branch_report_test-owner_test-repo:30" {
+		t.Errorf("GetBranchReport cached under unexpected key %q", cachedKey)
+	}
+}
+
+func TestGitlabApi_GetTags_Success_WithCache(t *testing.T) {
+	cachedBytes, _ := json.Marshal([]*common_types.Tag{{Name: "v1.0.0"}})
+	mockGitService := &MockGitService{
+		ListTagsFunc: func(repoIdentifier interface{}) ([]*common_types.Tag, error) {
+			t.Error("GitService.ListTags was called unexpectedly in cache hit scenario")
+			return nil, nil
+		},
+	}
+	mockRedisClient := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) {
+			if key != "gitlab_get_tags_group/project" {
+				t.Errorf("unexpected cache key %q", key)
+			}
+			return cachedBytes, nil
+		},
+	}
+	gitlabAPI := NewGitlabApi(mockGitService, mockRedisClient)
+
+	req, _ := http.NewRequest("GET", "/api/gitlab/tags?projectID=group/project", nil)
+	rr := httptest.NewRecorder()
+	gitlabAPI.GetTags(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("GetTags (cache hit) returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if rr.Body.String() != string(cachedBytes) {
+		t.Errorf("GetTags (cache hit) returned unexpected body: %s", rr.Body.String())
+	}
+}
+
+func TestBranchEndpoints_BadRequests(t *testing.T) {
+	githubAPI := NewGithubApi(&MockGitService{}, &MockRedisClient{})
+	gitlabAPI := NewGitlabApi(&MockGitService{}, &MockRedisClient{})
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"github branches missing owner", githubAPI.GetBranches, "/api/github/branches?repoName=r"},
+		{"github report negative days", githubAPI.GetBranchReport, "/api/github/branches/report?owner=o&repoName=r&days=-1"},
+		{"gitlab report invalid days", gitlabAPI.GetBranchReport, "/api/gitlab/branches/report?projectID=1&days=abc"},
+		{"gitlab tags missing projectID", gitlabAPI.GetTags, "/api/gitlab/tags"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("%s returned wrong status code: got %v want %v", tt.name, status, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestGitlabApi_GetBranchReport_UsesConfiguredDefault(t *testing.T) {
+	mockGitService := &MockGitService{
+		ListBranchesFunc: func(repoIdentifier interface{}) ([]*common_types.Branch, error) {
+			return []*common_types.Branch{{Name: "old", LastCommitDate: time.Now().Add(-10 * 24 * time.Hour)}}, nil
+		},
+	}
+	mockRedisClient := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) { return nil, nil },
+		SetFunc: func(key string, value interface{}, expirationInSeconds time.Duration) error { return nil },
+	}
+	gitlabAPI := NewGitlabApi(mockGitService, mockRedisClient)
+	gitlabAPI.Defaults.StaleBranchDays = 7
+
+	req, _ := http.NewRequest("GET", "/api/gitlab/branches/report?projectID=42", nil)
+	rr := httptest.NewRecorder()
+	gitlabAPI.GetBranchReport(rr, req)
+
+	var report analytics.BranchReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("GetBranchReport could not unmarshal response: %v", err)
+	}
+	if report.StaleAfterDays != 7 || len(report.Entries) != 1 {
+		t.Errorf("expected the configured 7-day default to apply, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	appMetrics "github.com/ahmetk3436/git-stats-golang/pkg/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// RefreshParam is the query parameter that makes an endpoint skip its cached response, fetch a
+// fresh one from the provider and cache that instead: ?refresh=true.
+const RefreshParam = "refresh"
+
+// revalidateTimeout bounds the background refresh of an expired response.
+const revalidateTimeout = 5 * time.Minute
+
+// freshSuffix turns the key of a cached response into the key of its freshness marker. The marker
+// expires after the TTL, while the response is kept CachePolicy.StaleFor longer. Like the
+// parameters of a key, it follows a ":", so it cannot be mistaken for part of a repository.
+const freshSuffix = ":fresh"
+
+// CachePolicy decides how long the provider endpoints cache their responses and how long expired
+// responses are still served, stale, while a background request refreshes them. The zero
+// CachePolicy keeps the TTLs of the handlers and never serves stale responses.
+type CachePolicy struct {
+	TTL       time.Duration            // How long responses are fresh; the handler's own TTL when zero.
+	Endpoints map[string]time.Duration // TTLs of single endpoints by path, e.g. "/commits", overriding TTL.
+	StaleFor  time.Duration            // How long expired responses are served while being refreshed; zero disables.
+
+	revalidating sync.Map // Cache keys being refreshed in the background.
+}
+
+// TTLFor returns the TTL of the endpoint at path, or zero when the handler's own applies.
+func (p *CachePolicy) TTLFor(path string) time.Duration {
+	if p == nil {
+		return 0
+	}
+	if ttl, ok := p.Endpoints[path]; ok {
+		return ttl
+	}
+	return p.TTL
+}
+
+// Capabilities describes the policy for /api/config.
+func (p *CachePolicy) Capabilities() CacheCapabilities {
+	ttl := p.TTLFor("")
+	if ttl == 0 {
+		ttl = CacheTTL
+	}
+	capabilities := CacheCapabilities{TTLSeconds: int(ttl / time.Second)}
+	if p != nil {
+		capabilities.StaleSeconds = int(p.StaleFor / time.Second)
+	}
+	return capabilities
+}
+
+// Apply wraps the handlers of endpoints, which belong to provider, so that they cache their
+// responses as the policy says and answer ?refresh=true with a fresh response.
+func (p *CachePolicy) Apply(provider string, endpoints []Endpoint) []Endpoint {
+	applied := make([]Endpoint, len(endpoints))
+	for i, endpoint := range endpoints {
+		applied[i] = p.apply(provider, endpoint)
+	}
+	return applied
+}
+
+func (p *CachePolicy) apply(provider string, endpoint Endpoint) Endpoint {
+	next := endpoint.Handler
+	endpointName := fmt.Sprintf("/api/%s%s", provider, endpoint.Path)
+	ttl := p.TTLFor(endpoint.Path)
+	endpoint.Handler = func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if value := r.URL.Query().Get(RefreshParam); value != "" {
+			refresh, err := strconv.ParseBool(value)
+			if err != nil {
+				rejectRequest(w, r, provider, endpointName, "refresh query parameter must be true or false.", http.StatusBadRequest)
+				return
+			}
+			if refresh {
+				ctx = context.WithValue(ctx, cacheRefreshKey, true)
+			}
+		}
+		settings := &cacheSettings{ttl: ttl, staleFor: p.StaleFor}
+		r = r.WithContext(context.WithValue(ctx, cacheEntryKey, settings))
+		if p.StaleFor > 0 {
+			settings.onStale = func(key string) {
+				p.revalidate(provider, endpointName, key, next, r)
+			}
+		}
+		next(w, r)
+	}
+	return endpoint
+}
+
+// revalidate refreshes the expired response cached under key in the background, by serving r,
+// whose response was served from it, again with handler in refresh mode. A key is refreshed by at
+// most one request at a time.
+func (p *CachePolicy) revalidate(provider, endpointName, key string, handler http.HandlerFunc, r *http.Request) {
+	appMetrics.CacheStaleServedTotal.WithLabelValues(provider, endpointName).Inc()
+	if _, running := p.revalidating.LoadOrStore(key, true); running {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithValue(context.WithoutCancel(r.Context()), cacheRefreshKey, true), revalidateTimeout)
+	revalidation := r.Clone(ctx)
+	go func() {
+		defer p.revalidating.Delete(key)
+		defer cancel()
+		response := newBufferedResponse()
+		handler(response, revalidation)
+		logCtx := log.WithFields(logrus.Fields{"endpoint": endpointName, "provider": provider, "key": key, "request_id": requestIDFromContext(ctx)})
+		if response.status >= http.StatusBadRequest {
+			logCtx.WithFields(logrus.Fields{"status": response.status, "error": errorMessage(response.body.Bytes())}).Warn("Failed to refresh an expired cached response; serving it until it is evicted.")
+			appMetrics.CacheRevalidationsTotal.WithLabelValues(provider, endpointName, "failure").Inc()
+			return
+		}
+		logCtx.Info("Refreshed an expired cached response.")
+		appMetrics.CacheRevalidationsTotal.WithLabelValues(provider, endpointName, "success").Inc()
+	}()
+}
+
+// store returns cache storing the responses of the endpoint at path as the policy says, for
+// writers that are not requests of the endpoint, such as webhooks.
+func (p *CachePolicy) store(path string, cache storage.InMemoryDB) storage.InMemoryDB {
+	if p == nil {
+		return cache
+	}
+	settings := &cacheSettings{ttl: p.TTLFor(path), staleFor: p.StaleFor}
+	return settings.cache(cache)
+}
+
+// cacheSettings is how the endpoint serving a request caches its responses.
+type cacheSettings struct {
+	ttl      time.Duration    // Replaces the TTL the handler stores responses with, unless zero.
+	staleFor time.Duration    // How long expired responses are kept and served; zero disables.
+	onStale  func(key string) // Called when an expired response is served; may be nil.
+}
+
+// cache returns cache applying the settings, or cache itself when s is nil.
+func (s *cacheSettings) cache(cache storage.InMemoryDB) storage.InMemoryDB {
+	if s == nil {
+		return cache
+	}
+	return expiringCache{InMemoryDB: cache, settings: s}
+}
+
+// expiringCache stores every response with the TTL of its settings. With stale serving enabled,
+// a response is kept staleFor longer than its TTL, and a marker stored alongside for just the TTL
+// tells fresh responses from stale ones.
+type expiringCache struct {
+	storage.InMemoryDB
+	settings *cacheSettings
+}
+
+func (c expiringCache) Get(key string) ([]byte, error) {
+	data, err := c.InMemoryDB.Get(key)
+	if err != nil || data == nil || c.settings.staleFor <= 0 {
+		return data, err
+	}
+	if fresh, err := c.InMemoryDB.Get(key + freshSuffix); err == nil && fresh == nil && c.settings.onStale != nil {
+		c.settings.onStale(key)
+	}
+	return data, nil
+}
+
+func (c expiringCache) Set(key string, value interface{}, duration time.Duration) error {
+	if c.settings.ttl > 0 {
+		duration = c.settings.ttl
+	}
+	if c.settings.staleFor <= 0 {
+		return c.InMemoryDB.Set(key, value, duration)
+	}
+	if err := c.InMemoryDB.Set(key, value, duration+c.settings.staleFor); err != nil {
+		return err
+	}
+	return c.InMemoryDB.Set(key+freshSuffix, []byte("1"), duration)
+}
+
+func (c expiringCache) Delete(key string) error {
+	if err := c.InMemoryDB.Delete(key); err != nil {
+		return err
+	}
+	if c.settings.staleFor <= 0 {
+		return nil
+	}
+	return c.InMemoryDB.Delete(key + freshSuffix)
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	appMetrics "github.com/ahmetk3436/git-stats-golang/pkg/prometheus"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// CachePath is the path of the cache administration below /api/v1.
+const CachePath = "/admin/cache"
+
+// cachedResponseKinds are the names of the cached provider responses, as in the cache keys
+// "<provider>_get_<kind>_<repository>[:<parameters>]". Endpoints caching a new kind of response
+// add it here so that purges find it.
+var cachedResponseKinds = []string{
+	"all_repos", "repo", "commits", "loc", "issue_stats", "branches", "branch_report", "tags",
+	"activity", "conventional_commits", "changelog", "compare_refs", "compare_periods",
+}
+
+// CacheApi lets administrators purge cached responses, e.g. after a history rewrite webhooks
+// did not report.
+type CacheApi struct {
+	Cache      storage.InMemoryDB // Response cache of the provider APIs; purges need a storage.KeyScanner.
+	Authorizer *Authorizer        // Decides who may purge; every caller may when nil.
+}
+
+// PurgeResult is the response to a purge.
+type PurgeResult struct {
+	Provider string `json:"provider"`       // "github" or "gitlab".
+	Repo     string `json:"repo,omitempty"` // Repository pattern purged; empty for every repository.
+	Deleted  int    `json:"deleted"`        // Cache keys deleted, across the partitions of every caller.
+}
+
+// Register mounts DELETE /api/v1/admin/cache, where errors are answered with an ErrorEnvelope,
+// guarded by authenticator unless it is nil.
+func (cacheAPI *CacheApi) Register(router *mux.Router, authenticator *Authenticator) {
+	cacheRouter := router.PathPrefix(APIV1Prefix + CachePath).Subrouter()
+	cacheRouter.Use(JSONErrors)
+	if authenticator != nil {
+		cacheRouter.Use(authenticator.Middleware(""))
+	}
+	cacheRouter.HandleFunc("", cacheAPI.Purge).Methods(http.MethodDelete, http.MethodOptions)
+}
+
+// Purge deletes the cached responses of the 'provider' repositories matching the 'repo' glob
+// pattern, e.g. "octo-org/*", or of every repository of the provider without one. Patterns match
+// like those of the access policy: case-insensitively, and "*" does not cross a "/". GitHub
+// repositories are matched as "owner/name", GitLab projects as they were requested: by path or by
+// numeric ID. The repository listings of owners matching the org part of the pattern are purged
+// too.
+func (cacheAPI *CacheApi) Purge(w http.ResponseWriter, r *http.Request) {
+	provider := r.URL.Query().Get("provider")
+	repoPattern := strings.Trim(r.URL.Query().Get("repo"), "/")
+	logCtx := log.WithFields(logrus.Fields{"path": r.URL.Path, "provider": provider, "repo": repoPattern, "request_id": requestIDFromContext(r.Context())})
+
+	if provider != "github" && provider != "gitlab" {
+		writeError(w, r, "", invalidRequest("provider query parameter must be github or gitlab."))
+		return
+	}
+	if _, err := path.Match(repoPattern, ""); err != nil {
+		writeError(w, r, provider, invalidRequest("repo query parameter must be a glob pattern such as octo-org/*."))
+		return
+	}
+	if cacheAPI.Authorizer != nil && !cacheAPI.Authorizer.authorizePurge(w, r, provider, repoPattern) {
+		return
+	}
+	scanner, ok := cacheAPI.Cache.(storage.KeyScanner)
+	if !ok {
+		writeError(w, r, provider, internalError("The cache cannot list its keys."))
+		return
+	}
+	keys, err := scanner.Keys("*" + provider + "_get_*")
+	if err != nil {
+		logCtx.WithField("error", err).Error("Failed to list cache keys.")
+		writeError(w, r, provider, internalError("Failed to list cache keys."))
+		return
+	}
+
+	result := PurgeResult{Provider: provider, Repo: repoPattern}
+	for _, key := range keys {
+		if !purgeMatches(key, provider, repoPattern) {
+			continue
+		}
+		if err := cacheAPI.Cache.Delete(key); err != nil {
+			logCtx.WithFields(logrus.Fields{"key": key, "error": err}).Error("Redis DEL error for purge.")
+			continue
+		}
+		result.Deleted++
+	}
+	appMetrics.CachePurgedKeysTotal.WithLabelValues(provider).Add(float64(result.Deleted))
+	logCtx.WithFields(logrus.Fields{"deleted": result.Deleted, "subject": identityFromContext(r.Context()).Subject}).Info("Cache purged.")
+	writeJSON(w, r, http.StatusOK, result)
+}
+
+// purgeMatches reports whether key caches a response of provider for a repository matching
+// repoPattern, or for any repository when repoPattern is empty. Keys of caller partitions and
+// freshness markers are matched like the keys of the responses themselves.
+func purgeMatches(key, provider, repoPattern string) bool {
+	kind, repo, ok := parseResponseCacheKey(key, provider)
+	if !ok {
+		return false
+	}
+	if repoPattern == "" {
+		return true
+	}
+	pattern := strings.ToLower(repoPattern)
+	if kind == "all_repos" {
+		pattern, _ = splitRepoPath(pattern)
+		if pattern == "" {
+			return false
+		}
+	}
+	matched, _ := path.Match(pattern, strings.ToLower(repo))
+	return matched
+}
+
+// parseResponseCacheKey returns the kind of response and the repository a key of responseCacheKey
+// caches, in any caller partition or as a freshness marker. GitHub repositories are returned as
+// "owner/name", GitLab projects as they were requested, and the owner for "all_repos".
+func parseResponseCacheKey(key, provider string) (kind, repo string, ok bool) {
+	if partitioned, found := strings.CutPrefix(key, "identity_"); found {
+		_, key, _ = strings.Cut(partitioned, "_")
+	}
+	rest, found := strings.CutPrefix(key, provider+"_get_")
+	if !found {
+		return "", "", false
+	}
+	for _, kind := range cachedResponseKinds {
+		repoPart, found := strings.CutPrefix(rest, kind+"_")
+		if !found {
+			continue
+		}
+		if kind == "loc" {
+			// The clone URL contains a ":" itself; loc keys have no parameters.
+			return kind, repoPathFromURL(strings.TrimSuffix(repoPart, freshSuffix)), true
+		}
+		repo, _, _ = strings.Cut(repoPart, ":")
+		if kind != "all_repos" {
+			repo = cacheKeyRepoPath(provider, repo)
+		}
+		return kind, repo, true
+	}
+	return "", "", false
+}
+
+// cacheKeyRepoPath returns the repository a cache key names as cacheKeyRepo: GitHub keys write
+// "owner/name" as "owner_name", except those of /repo; owners cannot contain a "_".
+func cacheKeyRepoPath(provider, cacheKeyRepo string) string {
+	if provider == "github" && !strings.Contains(cacheKeyRepo, "/") {
+		return strings.Replace(cacheKeyRepo, "_", "/", 1)
+	}
+	return cacheKeyRepo
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/policy"
+	"github.com/gorilla/mux"
+)
+
+// purge sends DELETE /api/v1/admin/cache?query to the cache routes of cacheAPI, as identity.
+func purge(cacheAPI *CacheApi, query string, identity Identity) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	cacheAPI.Register(router, nil)
+	req := httptest.NewRequest(http.MethodDelete, APIV1Prefix+CachePath+"?"+query, nil)
+	req = req.WithContext(context.WithValue(req.Context(), identityKey, identity))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCacheApi_PurgesByRepoPattern(t *testing.T) {
+	cache := mapCache{
+		"github_get_commits_octo-org_hello_world":                                     []byte(`[]`),
+		"github_get_commits_octo-org_hello_world:fresh":                               []byte(`1`),
+		"identity_0123456789abcdef_github_get_activity_octo-org_Hello_World:UTC_9_18": []byte(`{}`),
+		"github_get_repo_octo-org/hello_world":                                        []byte(`{}`),
+		"github_get_loc_https://github.com/octo-org/hello_world.git":                  []byte(`{}`),
+		"github_get_all_repos_octo-org":                                               []byte(`[]`),
+		"github_get_commits_octo-org_other":                                           []byte(`[]`),
+		"github_get_commits_other-org_hello_world":                                    []byte(`[]`),
+		"gitlab_get_commits_octo-org/hello_world":                                     []byte(`[]`),
+		"gitlab_get_tags_42":                                                          []byte(`[]`),
+		"github:commit-stats:octo-org/hello_world:abc":                                []byte(`{}`),
+	}
+	cacheAPI := &CacheApi{Cache: cache}
+
+	rr := purge(cacheAPI, "provider=github&repo=octo-org/hello*", Identity{})
+	var result PurgeResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil || rr.Code != http.StatusOK || result.Deleted != 6 {
+		t.Fatalf("expected 6 keys to be purged, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var remaining []string
+	for key := range cache {
+		remaining = append(remaining, key)
+	}
+	sort.Strings(remaining)
+	want := "github:commit-stats:octo-org/hello_world:abc github_get_commits_octo-org_other github_get_commits_other-org_hello_world gitlab_get_commits_octo-org/hello_world gitlab_get_tags_42"
+	if got := strings.Join(remaining, " "); got != want {
+		t.Errorf("unexpected keys left:\n got %s\nwant %s", got, want)
+	}
+
+	if rr := purge(cacheAPI, "provider=gitlab", Identity{}); !strings.Contains(rr.Body.String(), `"deleted":2`) {
+		t.Errorf("expected every GitLab response to be purged, got %s", rr.Body.String())
+	}
+	if rr := purge(cacheAPI, "provider=bitbucket", Identity{}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown provider, got %d", rr.Code)
+	}
+}
+
+func TestCacheApi_PurgeStopsAtTheEndOfTheRepository(t *testing.T) {
+	cache := mapCache{
+		"github_get_commits_octo-org_hello":                          []byte(`[]`),
+		"github_get_activity_octo-org_hello:UTC_9_18":                []byte(`{}`),
+		"github_get_activity_octo-org_hello:UTC_9_18:fresh":          []byte(`1`),
+		"github_get_commits_octo-org_hello_world":                    []byte(`[]`),
+		"github_get_commits_octo-org_hello_world:fresh":              []byte(`1`),
+		"github_get_activity_octo-org_hello_world:UTC_9_18":          []byte(`{}`),
+		"github_get_repo_octo-org/hello_world":                       []byte(`{}`),
+		"github_get_loc_https://github.com/octo-org/hello_world.git": []byte(`{}`),
+		"gitlab_get_commits_octo-org/hello_world":                    []byte(`[]`),
+	}
+	cacheAPI := &CacheApi{Cache: cache}
+
+	if rr := purge(cacheAPI, "provider=github&repo=octo-org/hello", Identity{}); !strings.Contains(rr.Body.String(), `"deleted":3`) {
+		t.Errorf("expected only the keys of octo-org/hello to be purged, got %s", rr.Body.String())
+	}
+	if rr := purge(cacheAPI, "provider=gitlab&repo=octo-org/hello", Identity{}); !strings.Contains(rr.Body.String(), `"deleted":0`) {
+		t.Errorf("expected no GitLab key to be purged, got %s", rr.Body.String())
+	}
+	if len(cache) != 6 {
+		t.Errorf("expected the keys of octo-org/hello_world to be kept, got %d keys", len(cache))
+	}
+}
+
+func TestCacheApi_RequiresPurgePermission(t *testing.T) {
+	cache := mapCache{"github_get_commits_octo-org_hello-world": []byte(`[]`)}
+	cacheAPI := &CacheApi{Cache: cache, Authorizer: NewAuthorizer(&policy.Policy{Rules: []policy.Rule{
+		{Name: "admins", Effect: policy.EffectAllow, Subjects: []string{"api_key:admin"}},
+		{Name: "readers", Effect: policy.EffectAllow, Subjects: []string{"api_key:ci"}, Actions: []string{policy.ActionRead}},
+		{Name: "secrets", Effect: policy.EffectDeny, Repos: []string{"octo-org/secret-*"}},
+	}})}
+
+	if rr := purge(cacheAPI, "provider=github&repo=octo-org/*", Identity{Subject: "ci", Method: AuthMethodAPIKey}); rr.Code != http.StatusForbidden || len(cache) != 1 {
+		t.Errorf("expected a reader to be denied, got %d", rr.Code)
+	}
+	if rr := purge(cacheAPI, "provider=github", Identity{Subject: "admin", Method: AuthMethodAPIKey}); rr.Code != http.StatusForbidden {
+		t.Errorf("expected a purge of every repository to hit the deny rule, got %d", rr.Code)
+	}
+	if rr := purge(cacheAPI, "provider=github&repo=octo-org/*", Identity{Subject: "admin", Method: AuthMethodAPIKey}); rr.Code != http.StatusOK || len(cache) != 0 {
+		t.Errorf("expected an admin to purge, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
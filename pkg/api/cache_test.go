@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// ttlCache is a storage.InMemoryDB recording the TTL of every entry, safe for the background
+// refreshes of stale responses.
+type ttlCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+	ttls    map[string]time.Duration
+}
+
+func newTTLCache() *ttlCache {
+	return &ttlCache{entries: map[string][]byte{}, ttls: map[string]time.Duration{}}
+}
+
+func (c *ttlCache) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key], nil
+}
+
+func (c *ttlCache) Set(key string, value interface{}, duration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key], c.ttls[key] = value.([]byte), duration
+	return nil
+}
+
+func (c *ttlCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	delete(c.ttls, key)
+	return nil
+}
+
+func (c *ttlCache) ttl(key string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttls[key]
+}
+
+// cachedHandler returns the handler of the GitHub endpoint at path with policy applied.
+func cachedHandler(t *testing.T, ghAPI *GithubApi, policy *CachePolicy, path string) http.HandlerFunc {
+	t.Helper()
+	for _, endpoint := range policy.Apply("github", ghAPI.Endpoints()) {
+		if endpoint.Path == path {
+			return endpoint.Handler
+		}
+	}
+	t.Fatalf("no endpoint %s", path)
+	return nil
+}
+
+func serveRequest(handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, target, nil))
+	return rr
+}
+
+func TestCachePolicy_TTLsAndRefresh(t *testing.T) {
+	fetches := 0
+	gitService := &MockGitService{
+		GetProjectCommitsFunc: func(interface{}, *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+			fetches++
+			return []*common_types.Commit{{SHA: "abc"}}, nil
+		},
+		GetRepoFunc: func(interface{}) (*common_types.Repository, error) {
+			return &common_types.Repository{Name: "hello-world"}, nil
+		},
+	}
+	cache := newTTLCache()
+	ghAPI := NewGithubApi(gitService, cache)
+	policy := &CachePolicy{TTL: 30 * time.Minute, Endpoints: map[string]time.Duration{"/commits": 5 * time.Minute}}
+	commits := cachedHandler(t, ghAPI, policy, "/commits")
+
+	serveRequest(commits, "/api/v1/github/commits?owner=octo-org&repo=hello-world")
+	serveRequest(cachedHandler(t, ghAPI, policy, "/repo"), "/api/v1/github/repo?owner=octo-org&repo=hello-world")
+	if ttl := cache.ttl("github_get_commits_octo-org_hello-world"); ttl != 5*time.Minute {
+		t.Errorf("expected the endpoint TTL for /commits, got %s", ttl)
+	}
+	if ttl := cache.ttl("github_get_repo_octo-org/hello-world"); ttl != 30*time.Minute {
+		t.Errorf("expected the policy TTL for /repo, got %s", ttl)
+	}
+
+	serveRequest(commits, "/api/v1/github/commits?owner=octo-org&repo=hello-world")
+	if fetches != 1 {
+		t.Fatalf("expected the second request to be served from the cache, got %d fetches", fetches)
+	}
+	serveRequest(commits, "/api/v1/github/commits?owner=octo-org&repo=hello-world&refresh=true")
+	if fetches != 2 {
+		t.Errorf("expected refresh=true to fetch again, got %d fetches", fetches)
+	}
+	if rr := serveRequest(commits, "/api/v1/github/commits?owner=octo-org&repo=hello-world&refresh=maybe"); rr.Code != http.StatusBadRequest || fetches != 2 {
+		t.Errorf("expected 400 for an invalid refresh value, got %d", rr.Code)
+	}
+}
+
+func TestCachePolicy_ServesStaleWhileRevalidating(t *testing.T) {
+	var mu sync.Mutex
+	name := "old"
+	refreshed := make(chan struct{}, 1)
+	gitService := &MockGitService{GetAllReposFunc: func(string) ([]*common_types.Repository, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if name == "new" {
+			refreshed <- struct{}{}
+		}
+		return []*common_types.Repository{{Name: name}}, nil
+	}}
+	cache := newTTLCache()
+	policy := &CachePolicy{TTL: time.Hour, StaleFor: 24 * time.Hour}
+	repos := cachedHandler(t, NewGithubApi(gitService, cache), policy, "/repos")
+	key := "github_get_all_repos_octo-org"
+
+	serveRequest(repos, "/api/v1/github/repos?owner=octo-org")
+	if cache.ttl(key) != 25*time.Hour || cache.ttl(key+freshSuffix) != time.Hour {
+		t.Fatalf("expected the response to be kept for TTL plus StaleFor and marked fresh for the TTL, got %s and %s", cache.ttl(key), cache.ttl(key+freshSuffix))
+	}
+
+	// The TTL passes: the response is served stale and refreshed in the background.
+	cache.Delete(key + freshSuffix)
+	mu.Lock()
+	name = "new"
+	mu.Unlock()
+	if body := serveRequest(repos, "/api/v1/github/repos?owner=octo-org").Body.String(); !containsName(body, "old") {
+		t.Errorf("expected the stale response, got %s", body)
+	}
+	select {
+	case <-refreshed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the stale response to be refreshed in the background")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !containsName(string(cachedValue(cache, key)), "new") || cachedValue(cache, key+freshSuffix) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the refreshed response to be cached and fresh, got %s", cachedValue(cache, key))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func cachedValue(cache *ttlCache, key string) []byte {
+	data, _ := cache.Get(key)
+	return data
+}
+
+func containsName(body, name string) bool {
+	return body != "" && strings.Contains(body, `"Name":"`+name+`"`)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/changelog"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// GetChangelog handles requests for the changelog of a GitHub repository between two refs.
+// Repository is identified by 'owner' and 'repo' query parameters.
+func (ghAPI *GithubApi) GetChangelog(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, ok := githubRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "github", "/api/github/changelog", "owner and repo query parameters are required.", http.StatusBadRequest)
+		return
+	}
+	serveChangelog(w, r, "github", ghAPI.gitService(r), ghAPI.cache(r), repoIdentifier, strings.Replace(repoIdentifier, "/", "_", 1))
+}
+
+// GetChangelog handles requests for the changelog of a GitLab repository between two refs.
+// Repository is identified by 'project' query parameter (ID or namespace/path).
+func (glAPI *GitlabApi) GetChangelog(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, repoIdentifierQuery, ok := gitlabRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "gitlab", "/api/gitlab/changelog", "project query parameter is required.", http.StatusBadRequest)
+		return
+	}
+	serveChangelog(w, r, "gitlab", glAPI.gitService(r), glAPI.cache(r), repoIdentifier, repoIdentifierQuery)
+}
+
+// serveChangelog is the provider-agnostic part of the changelog handlers.
+// Query parameters: 'from' (required tag or SHA, exclusive), 'to' (tag or SHA, default branch
+// when omitted) and 'format' ("json" (default), "markdown" or "keepachangelog").
+func serveChangelog(w http.ResponseWriter, r *http.Request, provider string, gitService interfaces.GitService, cache storage.InMemoryDB, repoIdentifier interface{}, cacheKeyRepo string) {
+	endpointName := fmt.Sprintf("/api/%s/changelog", provider)
+
+	fromRef := r.URL.Query().Get("from")
+	if fromRef == "" {
+		rejectRequest(w, r, provider, endpointName, "from query parameter is required.", http.StatusBadRequest)
+		return
+	}
+	toRef := r.URL.Query().Get("to")
+	format := r.URL.Query().Get("format")
+	contentType := "text/markdown; charset=utf-8"
+	switch format {
+	case "", changelog.FormatJSON:
+		format, contentType = changelog.FormatJSON, "application/json"
+	case changelog.FormatMarkdown, changelog.FormatKeepAChangelog:
+	default:
+		rejectRequest(w, r, provider, endpointName, "format query parameter must be one of: "+strings.Join(changelog.Formats, ", ")+".", http.StatusBadRequest)
+		return
+	}
+
+	redisKey := responseCacheKey(provider, "changelog", cacheKeyRepo, fromRef, toRef, format)
+	fetch := func() (interface{}, error) {
+		commits, err := gitService.GetProjectCommits(repoIdentifier, &interfaces.CommitListOptions{SHA: toRef, BaseRef: fromRef})
+		if err != nil {
+			return nil, err
+		}
+		displayToRef := toRef
+		if displayToRef == "" {
+			displayToRef = "HEAD"
+		}
+		return changelog.Build(commits, fromRef, displayToRef), nil
+	}
+	encode := func(result interface{}) ([]byte, error) {
+		var rendered bytes.Buffer
+		err := changelog.Render(&rendered, result.(*changelog.Changelog), format)
+		return rendered.Bytes(), err
+	}
+	serveCached(w, r, provider, endpointName, "commits", cache, redisKey, CacheTTL, contentType, fetch, encode)
+}
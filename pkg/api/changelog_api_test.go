@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+func TestGithubApi_GetChangelog_Markdown(t *testing.T) {
+	mockGitService := &MockGitService{
+		GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+			if options.BaseRef != "v1.4" || options.SHA != "v1.5" {
+				t.Errorf("unexpected commit range %s..%s", options.BaseRef, options.SHA)
+			}
+			return []*common_types.Commit{
+				{SHA: "abcdef123", Message: "feat: add changelog", HTMLURL: "https://github.com/test-owner/test-repo/commit/abcdef123"},
+			}, nil
+		},
+	}
+	var cachedKey string
+	mockRedisClient := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) { return nil, nil }, // Cache miss
+		SetFunc: func(key string, value interface{}, expirationInSeconds time.Duration) error {
+			cachedKey = key
+			return nil
+		},
+	}
+	githubAPI := NewGithubApi(mockGitService, mockRedisClient)
+
+	req, _ := http.NewRequest("GET", "/api/github/changelog?owner=test-owner&repoName=test-repo&from=v1.4&to=v1.5&format=markdown", nil)
+	rr := httptest.NewRecorder()
+	githubAPI.GetChangelog(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("GetChangelog returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/markdown") {
+		t.Errorf("GetChangelog returned Content-Type %q, want text/markdown", contentType)
+	}
+	if !strings.Contains(rr.Body.String(), "### Features\n\n- add changelog ([abcdef1]") {
+		t.Errorf("GetChangelog returned unexpected body:\n%s", rr.Body.String())
+	}
+	if cachedKey != "github_get_changelog_test-owner_test-repo:v1.4_v1.5_markdown" {
+		t.Errorf("GetChangelog cached under unexpected key %q", cachedKey)
+	}
+}
+
+func TestGetChangelog_BadRequests(t *testing.T) {
+	githubAPI := NewGithubApi(&MockGitService{}, &MockRedisClient{})
+	gitlabAPI := NewGitlabApi(&MockGitService{}, &MockRedisClient{})
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"github missing from", githubAPI.GetChangelog, "/api/github/changelog?owner=o&repoName=r"},
+		{"gitlab missing projectID", gitlabAPI.GetChangelog, "/api/gitlab/changelog?from=v1"},
+		{"gitlab unknown format", gitlabAPI.GetChangelog, "/api/gitlab/changelog?projectID=1&from=v1&format=html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("%s returned wrong status code: got %v want %v", tt.name, status, http.StatusBadRequest)
+			}
+		})
+	}
+}
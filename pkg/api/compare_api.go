@@ -0,0 +1,158 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// GetCompare handles requests comparing two refs or two periods of a GitHub repository.
+// Repository is identified by 'owner' and 'repo' query parameters.
+func (ghAPI *GithubApi) GetCompare(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, ok := githubRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "github", "/api/github/compare", "owner and repo query parameters are required.", http.StatusBadRequest)
+		return
+	}
+	serveCompare(w, r, "github", ghAPI.gitService(r), ghAPI.cache(r), repoIdentifier, strings.Replace(repoIdentifier, "/", "_", 1))
+}
+
+// GetCompare handles requests comparing two refs or two periods of a GitLab repository.
+// Repository is identified by 'project' query parameter (ID or namespace/path).
+func (glAPI *GitlabApi) GetCompare(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, repoIdentifierQuery, ok := gitlabRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "gitlab", "/api/gitlab/compare", "project query parameter is required.", http.StatusBadRequest)
+		return
+	}
+	serveCompare(w, r, "gitlab", glAPI.gitService(r), glAPI.cache(r), repoIdentifier, repoIdentifierQuery)
+}
+
+// serveCompare is the provider-agnostic part of the compare handlers. It runs in one of two modes:
+//   - refs: 'base' and 'head' (tags, branches or SHAs) compare commits, authors and files between refs.
+//   - periods: 'since' and 'until' (RFC 3339) select the current period, compared against
+//     'previousSince'/'previousUntil', or by default the period of the same length just before it.
+func serveCompare(w http.ResponseWriter, r *http.Request, provider string, gitService interfaces.GitService, cache storage.InMemoryDB, repoIdentifier interface{}, cacheKeyRepo string) {
+	endpointName := fmt.Sprintf("/api/%s/compare", provider)
+	query := r.URL.Query()
+
+	baseRef, headRef := query.Get("base"), query.Get("head")
+	if baseRef != "" || headRef != "" {
+		if baseRef == "" || headRef == "" {
+			rejectRequest(w, r, provider, endpointName, "base and head query parameters must be given together.", http.StatusBadRequest)
+			return
+		}
+		redisKey := responseCacheKey(provider, "compare_refs", cacheKeyRepo, baseRef, headRef)
+		serveCachedJSON(w, r, provider, endpointName, "compare", cache, redisKey, CacheTTL, func() (interface{}, error) {
+			comparison, err := gitService.CompareRefs(repoIdentifier, baseRef, headRef)
+			if err != nil {
+				return nil, err
+			}
+			return analytics.CompareRefCommits(comparison), nil
+		})
+		return
+	}
+
+	current, currentErr := windowFromQuery(query.Get("since"), query.Get("until"))
+	if currentErr != nil {
+		rejectRequest(w, r, provider, endpointName, "Either base and head, or since and until (RFC 3339, since before until) query parameters are required.", http.StatusBadRequest)
+		return
+	}
+	previous := analytics.PreviousWindow(current)
+	if query.Get("previousSince") != "" || query.Get("previousUntil") != "" {
+		var previousErr error
+		if previous, previousErr = windowFromQuery(query.Get("previousSince"), query.Get("previousUntil")); previousErr != nil {
+			rejectRequest(w, r, provider, endpointName, "previousSince and previousUntil must be RFC 3339 timestamps with previousSince before previousUntil.", http.StatusBadRequest)
+			return
+		}
+	}
+
+	redisKey := responseCacheKey(provider, "compare_periods", cacheKeyRepo,
+		current.Since.Unix(), current.Until.Unix(), previous.Since.Unix(), previous.Until.Unix())
+	serveCachedJSON(w, r, provider, endpointName, "commits", cache, redisKey, CacheTTL, func() (interface{}, error) {
+		currentCommits, err := commitsInWindow(gitService, repoIdentifier, current, false)
+		if err != nil {
+			return nil, err
+		}
+		previousCommits, err := commitsInWindow(gitService, repoIdentifier, previous, false)
+		if err != nil {
+			return nil, err
+		}
+		return analytics.ComparePeriods(currentCommits, previousCommits, current, previous), nil
+	})
+}
+
+// windowFromQuery parses an RFC 3339 [since, until) pair.
+func windowFromQuery(sinceQuery, untilQuery string) (analytics.PeriodWindow, error) {
+	since, err := time.Parse(time.RFC3339, sinceQuery)
+	if err != nil {
+		return analytics.PeriodWindow{}, err
+	}
+	until, err := time.Parse(time.RFC3339, untilQuery)
+	if err != nil {
+		return analytics.PeriodWindow{}, err
+	}
+	if !since.Before(until) {
+		return analytics.PeriodWindow{}, fmt.Errorf("since %s is not before until %s", sinceQuery, untilQuery)
+	}
+	return analytics.PeriodWindow{Since: since, Until: until}, nil
+}
+
+// DefaultAnalysisDays is how many days back the activity and Conventional Commits endpoints
+// look without 'since'.
+const DefaultAnalysisDays = 90
+
+// analysisWindowFromQuery returns the window of the optional 'since' and 'until' query
+// parameters (RFC 3339). Until defaults to the start of the next UTC day and since to
+// DefaultAnalysisDays before until, so that the default window, and the cache key derived from
+// it, only changes once a day.
+func analysisWindowFromQuery(r *http.Request) (analytics.PeriodWindow, error) {
+	window := defaultAnalysisWindow(time.Now())
+	sinceQuery, untilQuery := r.URL.Query().Get("since"), r.URL.Query().Get("until")
+	if untilQuery != "" {
+		until, err := time.Parse(time.RFC3339, untilQuery)
+		if err != nil {
+			return analytics.PeriodWindow{}, err
+		}
+		window = analytics.PeriodWindow{Since: until.AddDate(0, 0, -DefaultAnalysisDays), Until: until}
+	}
+	if sinceQuery != "" {
+		since, err := time.Parse(time.RFC3339, sinceQuery)
+		if err != nil {
+			return analytics.PeriodWindow{}, err
+		}
+		window.Since = since
+	}
+	if !window.Since.Before(window.Until) {
+		return analytics.PeriodWindow{}, fmt.Errorf("since %s is not before until %s", window.Since.Format(time.RFC3339), window.Until.Format(time.RFC3339))
+	}
+	return window, nil
+}
+
+// defaultAnalysisWindow is the window of analysisWindowFromQuery without parameters at now.
+func defaultAnalysisWindow(now time.Time) analytics.PeriodWindow {
+	until := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	return analytics.PeriodWindow{Since: until.AddDate(0, 0, -DefaultAnalysisDays), Until: until}
+}
+
+// commitsInWindow lists the commits authored in window, with line stats unless noStats is set.
+// Providers treat 'until' as inclusive, so commits exactly at window.Until are dropped here.
+func commitsInWindow(gitService interfaces.GitService, repoIdentifier interface{}, window analytics.PeriodWindow, noStats bool) ([]*common_types.Commit, error) {
+	commits, err := gitService.GetProjectCommits(repoIdentifier, &interfaces.CommitListOptions{Since: window.Since, Until: window.Until, NoStats: noStats})
+	if err != nil {
+		return nil, err
+	}
+	inWindow := make([]*common_types.Commit, 0, len(commits))
+	for _, commit := range commits {
+		if commit != nil && commit.Author.Date.Before(window.Until) {
+			inWindow = append(inWindow, commit)
+		}
+	}
+	return inWindow, nil
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+func TestGithubApi_GetCompare_Refs(t *testing.T) {
+	mockGitService := &MockGitService{
+		CompareRefsFunc: func(repoIdentifier interface{}, baseRef, headRef string) (*common_types.Comparison, error) {
+			if repoIdentifier != "test-owner/test-repo" || baseRef != "v1.4" || headRef != "v1.5" {
+				t.Errorf("unexpected comparison %v %s...%s", repoIdentifier, baseRef, headRef)
+			}
+			return &common_types.Comparison{
+				BaseRef: baseRef,
+				HeadRef: headRef,
+				Commits: []*common_types.Commit{{SHA: "a", Author: common_types.CommitAuthor{Name: "Jane"}}},
+				Files:   []*common_types.FileChange{{Filename: "main.go", Status: "modified", Additions: 3, Deletions: 1}},
+			}, nil
+		},
+	}
+	var cachedKey string
+	mockRedisClient := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) { return nil, nil }, // Cache miss
+		SetFunc: func(key string, value interface{}, expirationInSeconds time.Duration) error {
+			cachedKey = key
+			return nil
+		},
+	}
+	githubAPI := NewGithubApi(mockGitService, mockRedisClient)
+
+	req, _ := http.NewRequest("GET", "/api/github/compare?owner=test-owner&repoName=test-repo&base=v1.4&head=v1.5", nil)
+	rr := httptest.NewRecorder()
+	githubAPI.GetCompare(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("GetCompare returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var result analytics.RefComparison
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatalf("GetCompare could not unmarshal response: %v", err)
+	}
+	if result.Totals.Commits != 1 || result.Totals.Additions != 3 || result.FilesChanged != 1 || len(result.Authors) != 1 {
+		t.Errorf("GetCompare returned unexpected body: %s", rr.Body.String())
+	}
+	if cachedKey != "github_get_compare_refs_test-owner_test-repo:v1.4_v1.5" {
+		t.Errorf("GetCompare cached under unexpected key %q", cachedKey)
+	}
+}
+
+func TestGitlabApi_GetCompare_Periods(t *testing.T) {
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	mockGitService := &MockGitService{
+		GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+			if options.Since.Equal(june) {
+				return []*common_types.Commit{
+					{Author: common_types.CommitAuthor{Name: "Jane", Date: june.Add(time.Hour)}},
+					{Author: common_types.CommitAuthor{Name: "Jane", Date: june.Add(time.Hour)}},
+					// Exactly at 'until': belongs to the next period.
+					{Author: common_types.CommitAuthor{Name: "Jane", Date: options.Until}},
+				}, nil
+			}
+			if !options.Until.Equal(june) {
+				t.Errorf("expected the previous period to end at %v, got %+v", june, options)
+			}
+			return []*common_types.Commit{{Author: common_types.CommitAuthor{Name: "Jane", Date: june.Add(-time.Hour)}}}, nil
+		},
+	}
+	mockRedisClient := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) { return nil, nil },
+		SetFunc: func(key string, value interface{}, expirationInSeconds time.Duration) error { return nil },
+	}
+	gitlabAPI := NewGitlabApi(mockGitService, mockRedisClient)
+
+	req, _ := http.NewRequest("GET", "/api/gitlab/compare?projectID=5&since=2024-06-01T00:00:00Z&until=2024-07-01T00:00:00Z", nil)
+	rr := httptest.NewRecorder()
+	gitlabAPI.GetCompare(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("GetCompare returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var result analytics.PeriodComparison
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatalf("GetCompare could not unmarshal response: %v", err)
+	}
+	if result.Totals.Current.Commits != 2 || result.Totals.Previous.Commits != 1 || result.Totals.CommitsChangePct == nil || *result.Totals.CommitsChangePct != 100 {
+		t.Errorf("GetCompare returned unexpected body: %s", rr.Body.String())
+	}
+}
+
+func TestGetCompare_BadRequests(t *testing.T) {
+	githubAPI := NewGithubApi(&MockGitService{}, &MockRedisClient{})
+	gitlabAPI := NewGitlabApi(&MockGitService{}, &MockRedisClient{})
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"github missing owner", githubAPI.GetCompare, "/api/github/compare?repoName=r&base=a&head=b"},
+		{"github base without head", githubAPI.GetCompare, "/api/github/compare?owner=o&repoName=r&base=a"},
+		{"gitlab neither refs nor period", gitlabAPI.GetCompare, "/api/gitlab/compare?projectID=1"},
+		{"gitlab inverted period", gitlabAPI.GetCompare, "/api/gitlab/compare?projectID=1&since=2024-07-01T00:00:00Z&until=2024-06-01T00:00:00Z"},
+		{"gitlab bad previous period", gitlabAPI.GetCompare, "/api/gitlab/compare?projectID=1&since=2024-06-01T00:00:00Z&until=2024-07-01T00:00:00Z&previousSince=may"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("%s returned wrong status code: got %v want %v", tt.name, status, http.StatusBadRequest)
+			}
+		})
+	}
+}
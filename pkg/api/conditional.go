@@ -0,0 +1,121 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	appMetrics "github.com/ahmetk3436/git-stats-golang/pkg/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// lastModifiedTTL is how long ConditionalResponses remembers since when a response is served.
+const lastModifiedTTL = 7 * 24 * time.Hour
+
+// ConditionalResponses lets clients revalidate the responses of the provider endpoints instead of
+// downloading them again. Successful responses carry an ETag, a hash of the body, and a
+// Last-Modified, the time the same body was first served for the URL. Requests whose If-None-Match,
+// or lacking one, If-Modified-Since still holds are answered with 304 Not Modified.
+type ConditionalResponses struct {
+	Cache storage.InMemoryDB // Remembers since when responses are served; no Last-Modified is sent when nil.
+}
+
+// Apply wraps the handlers of endpoints, which belong to provider, so that they answer
+// conditional requests.
+func (c *ConditionalResponses) Apply(provider string, endpoints []Endpoint) []Endpoint {
+	applied := make([]Endpoint, len(endpoints))
+	for i, endpoint := range endpoints {
+		applied[i] = c.apply(provider, endpoint)
+	}
+	return applied
+}
+
+func (c *ConditionalResponses) apply(provider string, endpoint Endpoint) Endpoint {
+	next := endpoint.Handler
+	endpointName := fmt.Sprintf("/api/%s%s", provider, endpoint.Path)
+	endpoint.Handler = func(w http.ResponseWriter, r *http.Request) {
+		// Jobs keep the whole response as their result, whatever the request was conditioned on.
+		submitted, _ := r.Context().Value(jobSubmitKey).(bool)
+		replayed, _ := r.Context().Value(jobReplayKey).(bool)
+		if r.Method != http.MethodGet || submitted || replayed {
+			next(w, r)
+			return
+		}
+
+		response := newBufferedResponse()
+		next(response, r)
+		for name, values := range response.header {
+			w.Header()[name] = values
+		}
+		if response.status != http.StatusOK && response.status != 0 {
+			w.WriteHeader(response.status)
+			w.Write(response.body.Bytes())
+			return
+		}
+
+		sum := sha256.Sum256(response.body.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		w.Header().Set("ETag", etag)
+		// Responses depend on the caller's credentials; shared caches must not keep them.
+		w.Header().Set("Cache-Control", "private, no-cache")
+		lastModified := c.lastModified(r, etag)
+		if !lastModified.IsZero() {
+			w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+		}
+		if notModified(r, etag, lastModified) {
+			appMetrics.APINotModifiedTotal.WithLabelValues(provider, endpointName).Inc()
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(response.body.Bytes()); err != nil {
+			log.WithFields(logrus.Fields{"endpoint": endpointName, "provider": provider, "request_id": requestIDFromContext(r.Context()), "error": err}).Error("Error writing response.")
+		}
+	}
+	return endpoint
+}
+
+// lastModified returns since when the response with etag is served for the URL of r, recording
+// now if it is new. It returns the zero time when that is unknown.
+func (c *ConditionalResponses) lastModified(r *http.Request, etag string) time.Time {
+	if c.Cache == nil {
+		return time.Time{}
+	}
+	url := sha256.Sum256([]byte(r.URL.Path + "?" + r.URL.RawQuery))
+	key := "last_modified_" + hex.EncodeToString(url[:8]) + "_" + strings.Trim(etag, `"`)
+	if data, err := c.Cache.Get(key); err == nil && data != nil {
+		if since, err := time.Parse(time.RFC3339, string(data)); err == nil {
+			return since
+		}
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := c.Cache.Set(key, []byte(now.Format(time.RFC3339)), lastModifiedTTL); err != nil {
+		log.WithFields(logrus.Fields{"key": key, "error": err}).Warn("Redis SET error for Last-Modified.")
+		return time.Time{}
+	}
+	return now
+}
+
+// notModified reports whether the conditions of r hold for a response with etag and
+// lastModified: If-None-Match when it is sent, If-Modified-Since otherwise.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !lastModified.After(since)
+}
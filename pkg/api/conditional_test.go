@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func TestConditionalResponses_AnswersNotModified(t *testing.T) {
+	name := "hello-world"
+	gitService := &MockGitService{GetAllReposFunc: func(string) ([]*common_types.Repository, error) {
+		return []*common_types.Repository{{Name: name}}, nil
+	}}
+	responses := mapCache{}
+	ghAPI := NewGithubApi(gitService, responses)
+	var repos http.HandlerFunc
+	for _, endpoint := range (&ConditionalResponses{Cache: mapCache{}}).Apply("github", ghAPI.Endpoints()) {
+		if endpoint.Path == "/repos" {
+			repos = endpoint.Handler
+		}
+	}
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/github/repos?owner=octo-org", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rr := httptest.NewRecorder()
+		repos(rr, req)
+		return rr
+	}
+
+	first := get("", "")
+	etag, lastModified := first.Header().Get("ETag"), first.Header().Get("Last-Modified")
+	if first.Code != http.StatusOK || etag == "" || lastModified == "" || first.Body.Len() == 0 {
+		t.Fatalf("expected a response with ETag and Last-Modified, got %d %v", first.Code, first.Header())
+	}
+	if rr := get("If-None-Match", `"other", `+etag); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 || rr.Header().Get("ETag") != etag {
+		t.Errorf("expected 304 for a matching If-None-Match, got %d", rr.Code)
+	}
+	if rr := get("If-Modified-Since", lastModified); rr.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for an If-Modified-Since at Last-Modified, got %d", rr.Code)
+	}
+	if rr := get("If-Modified-Since", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)); rr.Code != http.StatusOK {
+		t.Errorf("expected 200 for an earlier If-Modified-Since, got %d", rr.Code)
+	}
+
+	name = "renamed"
+	delete(responses, "github_get_all_repos_octo-org")
+	if rr := get("If-None-Match", etag); rr.Code != http.StatusOK || rr.Header().Get("ETag") == etag || !containsName(rr.Body.String(), "renamed") {
+		t.Errorf("expected the changed response with a new ETag, got %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/repository"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Capabilities is the non-sensitive description of the server that /api/config returns, so that
+// the web dashboard and other clients can adapt to the providers and endpoints actually available.
+// Its JSON field names are what web/api.js reads.
+type Capabilities struct {
+	Version    string              `json:"version"`    // Server version, "dev" for unreleased builds.
+	APIBaseURL string              `json:"apiBaseUrl"` // Base URL of the API routes, e.g. "/api/v1".
+	Providers  []string            `json:"providers"`  // Providers whose routes are registered, e.g. ["github"].
+	Endpoints  map[string][]string `json:"endpoints"`  // Registered paths per provider, relative to the provider prefix, e.g. "/loc".
+	Cache      CacheCapabilities   `json:"cache"`      // How provider responses are cached.
+	Auth       AuthCapabilities    `json:"auth"`       // How callers authenticate.
+	RateLimits []RateLimitStatus   `json:"rateLimits"` // Provider quota of the server tokens, as of the last provider response.
+}
+
+// CacheCapabilities describes the response cache.
+type CacheCapabilities struct {
+	TTLSeconds   int `json:"ttlSeconds"`   // How long a cached provider response is served.
+	StaleSeconds int `json:"staleSeconds"` // How long an expired response is still served while it is refreshed.
+}
+
+// AuthCapabilities describes how callers authenticate.
+type AuthCapabilities struct {
+	Required              bool     `json:"required"`              // Whether requests without credentials are rejected.
+	Methods               []string `json:"methods"`               // Accepted methods: "api_key", "oidc".
+	ForwardProviderTokens bool     `json:"forwardProviderTokens"` // Whether X-Provider-Token is used instead of the server token.
+}
+
+// RateLimitStatus is the state of one provider rate limit of a server token.
+type RateLimitStatus struct {
+	Provider  string    `json:"provider"`  // "github" or "gitlab".
+	Resource  string    `json:"resource"`  // GitHub resource such as "core" or "search"; "default" for GitLab.
+	Limit     int       `json:"limit"`     // Requests allowed per window.
+	Remaining int       `json:"remaining"` // Requests left in the current window.
+	Reset     time.Time `json:"reset"`     // When the current window ends.
+}
+
+// RateLimitsOf returns a function listing the rate limits trackers last observed, for
+// ConfigHandler.
+func RateLimitsOf(trackers ...*repository.RateLimitTracker) func() []RateLimitStatus {
+	return func() []RateLimitStatus {
+		statuses := []RateLimitStatus{}
+		for _, tracker := range trackers {
+			for _, limit := range tracker.Limits() {
+				statuses = append(statuses, RateLimitStatus(limit))
+			}
+		}
+		return statuses
+	}
+}
+
+// NewCapabilities describes the provider routes registered on router. A route whose path template
+// is /api/v1/<provider>/<path> adds <provider> to Providers and /<path> to its Endpoints, so routes
+// must be registered before NewCapabilities is called. The unversioned routes are not listed.
+func NewCapabilities(version, apiBaseURL string, router *mux.Router) (Capabilities, error) {
+	capabilities := Capabilities{
+		Version:    version,
+		APIBaseURL: apiBaseURL,
+		Providers:  []string{},
+		Endpoints:  map[string][]string{},
+		Cache:      CacheCapabilities{TTLSeconds: int(CacheTTL / time.Second)},
+	}
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil // Routes without a path, e.g. bare subrouters, describe no endpoint.
+		}
+		rest, isAPI := strings.CutPrefix(template, APIV1Prefix+"/")
+		provider, path, ok := strings.Cut(rest, "/")
+		if !isAPI || !ok || path == "" {
+			return nil
+		}
+		if _, known := capabilities.Endpoints[provider]; !known {
+			capabilities.Providers = append(capabilities.Providers, provider)
+		}
+		capabilities.Endpoints[provider] = append(capabilities.Endpoints[provider], "/"+path)
+		return nil
+	})
+	if err != nil {
+		return Capabilities{}, err
+	}
+	sort.Strings(capabilities.Providers)
+	for _, paths := range capabilities.Endpoints {
+		sort.Strings(paths)
+	}
+	return capabilities, nil
+}
+
+// ConfigHandler returns a handler that serves capabilities as JSON. Tokens are never part of it.
+// RateLimits is filled in from rateLimits on every request unless rateLimits is nil.
+func ConfigHandler(capabilities Capabilities, rateLimits func() []RateLimitStatus) http.HandlerFunc {
+	if capabilities.Providers == nil {
+		capabilities.Providers = []string{}
+	}
+	if capabilities.Endpoints == nil {
+		capabilities.Endpoints = map[string][]string{}
+	}
+	if capabilities.Auth.Methods == nil {
+		capabilities.Auth.Methods = []string{}
+	}
+	if capabilities.RateLimits == nil {
+		capabilities.RateLimits = []RateLimitStatus{}
+	}
+	if rateLimits == nil {
+		return staticJSONHandler(capabilities)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		current := capabilities
+		current.RateLimits = rateLimits()
+		staticJSONHandler(current)(w, r)
+	}
+}
+
+// staticJSONHandler returns a handler that serves v, marshalled once up front, as JSON.
+func staticJSONHandler(v interface{}) http.HandlerFunc {
+	body, err := json.Marshal(v)
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.WithFields(logrus.Fields{"path": r.URL.Path}).Debug("Handling static JSON request.")
+		if err != nil {
+			log.WithFields(logrus.Fields{"path": r.URL.Path, "error": err}).Error("Failed to marshal response.")
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(body); err != nil {
+			log.WithFields(logrus.Fields{"path": r.URL.Path, "error": err}).Error("Error writing response.")
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func noopHandler(w http.ResponseWriter, r *http.Request) {}
+
+func TestNewCapabilities_FromRegisteredRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	ghRouter := router.PathPrefix("/api/v1/github").Subrouter()
+	ghRouter.HandleFunc("/repos", noopHandler)
+	ghRouter.HandleFunc("/loc", noopHandler)
+	glRouter := router.PathPrefix("/api/v1/gitlab").Subrouter()
+	glRouter.HandleFunc("/branches/report", noopHandler)
+	router.HandleFunc("/api/github/tags", noopHandler) // Unversioned routes are not listed.
+	router.Handle("/metrics", http.HandlerFunc(noopHandler))
+	router.PathPrefix("/").HandlerFunc(noopHandler)
+
+	capabilities, err := NewCapabilities("v1.2.3", "/api", router)
+	if err != nil {
+		t.Fatalf("NewCapabilities returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(capabilities.Providers, []string{"github", "gitlab"}) {
+		t.Errorf("unexpected providers: %v", capabilities.Providers)
+	}
+	expectedEndpoints := map[string][]string{"github": {"/loc", "/repos"}, "gitlab": {"/branches/report"}}
+	if !reflect.DeepEqual(capabilities.Endpoints, expectedEndpoints) {
+		t.Errorf("unexpected endpoints: %v", capabilities.Endpoints)
+	}
+	if capabilities.Version != "v1.2.3" || capabilities.APIBaseURL != "/api" || capabilities.Cache.TTLSeconds != int(CacheTTL/time.Second) {
+		t.Errorf("unexpected capabilities: %+v", capabilities)
+	}
+}
+
+func TestConfigHandler(t *testing.T) {
+	handler := ConfigHandler(Capabilities{
+		Version:    "dev",
+		APIBaseURL: "/api",
+		Providers:  []string{"github"},
+		Endpoints:  map[string][]string{"github": {"/repos"}},
+		Cache:      CacheCapabilities{TTLSeconds: 3600, StaleSeconds: 86400},
+	}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/config", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("unexpected Content-Type %q", contentType)
+	}
+	expected := `{"version":"dev","apiBaseUrl":"/api","providers":["github"],"endpoints":{"github":["/repos"]},"cache":{"ttlSeconds":3600,"staleSeconds":86400},"auth":{"required":false,"methods":[],"forwardProviderTokens":false},"rateLimits":[]}`
+	if rr.Body.String() != expected {
+		t.Errorf("unexpected body: got %s want %s", rr.Body.String(), expected)
+	}
+}
+
+func TestConfigHandler_NoProviders(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/api/config", nil)
+	rr := httptest.NewRecorder()
+	ConfigHandler(Capabilities{APIBaseURL: "/api"}, nil)(rr, req)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if providers, ok := body["providers"].([]interface{}); !ok || len(providers) != 0 {
+		t.Errorf("expected an empty provider list, got %v", body["providers"])
+	}
+	if endpoints, ok := body["endpoints"].(map[string]interface{}); !ok || len(endpoints) != 0 {
+		t.Errorf("expected an empty endpoint map, got %v", body["endpoints"])
+	}
+}
+
+func TestConfigHandler_RateLimits(t *testing.T) {
+	reset := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	calls := 0
+	handler := ConfigHandler(Capabilities{APIBaseURL: "/api"}, func() []RateLimitStatus {
+		calls++
+		return []RateLimitStatus{{Provider: "github", Resource: "core", Limit: 5000, Remaining: 5000 - calls, Reset: reset}}
+	})
+
+	for _, remaining := range []float64{4999, 4998} {
+		req, _ := http.NewRequest("GET", "/api/config", nil)
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+
+		var body struct {
+			RateLimits []map[string]interface{} `json:"rateLimits"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		expected := []map[string]interface{}{{"provider": "github", "resource": "core", "limit": float64(5000), "remaining": remaining, "reset": "2024-05-01T12:00:00Z"}}
+		if !reflect.DeepEqual(body.RateLimits, expected) {
+			t.Errorf("unexpected rate limits: got %v want %v", body.RateLimits, expected)
+		}
+	}
+}
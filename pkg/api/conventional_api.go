@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// GetConventionalCommitStats handles requests for the Conventional Commits breakdown of a GitHub repository.
+// Repository is identified by 'owner' and 'repo' query parameters.
+func (ghAPI *GithubApi) GetConventionalCommitStats(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, ok := githubRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "github", "/api/github/commits/conventional", "owner and repo query parameters are required.", http.StatusBadRequest)
+		return
+	}
+	serveConventionalCommitStats(w, r, "github", ghAPI.gitService(r), ghAPI.cache(r), repoIdentifier, strings.Replace(repoIdentifier, "/", "_", 1))
+}
+
+// GetConventionalCommitStats handles requests for the Conventional Commits breakdown of a GitLab repository.
+// Repository is identified by 'project' query parameter (ID or namespace/path).
+func (glAPI *GitlabApi) GetConventionalCommitStats(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, repoIdentifierQuery, ok := gitlabRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "gitlab", "/api/gitlab/commits/conventional", "project query parameter is required.", http.StatusBadRequest)
+		return
+	}
+	serveConventionalCommitStats(w, r, "gitlab", glAPI.gitService(r), glAPI.cache(r), repoIdentifier, repoIdentifierQuery)
+}
+
+// serveConventionalCommitStats is the provider-agnostic part of the Conventional Commits handlers.
+// Optional query parameters: 'period' ("week" or "month", default "week") and 'since'/'until'
+// (RFC 3339), the window of the commits, by default the last DefaultAnalysisDays days.
+func serveConventionalCommitStats(w http.ResponseWriter, r *http.Request, provider string, gitService interfaces.GitService, cache storage.InMemoryDB, repoIdentifier interface{}, cacheKeyRepo string) {
+	endpointName := fmt.Sprintf("/api/%s/commits/conventional", provider)
+
+	period := r.URL.Query().Get("period")
+	switch period {
+	case "":
+		period = analytics.PeriodWeek
+	case analytics.PeriodWeek, analytics.PeriodMonth:
+	default:
+		rejectRequest(w, r, provider, endpointName, "period query parameter must be 'week' or 'month'.", http.StatusBadRequest)
+		return
+	}
+
+	window, windowErr := analysisWindowFromQuery(r)
+	if windowErr != nil {
+		rejectRequest(w, r, provider, endpointName, "since and until query parameters must be RFC 3339 timestamps with since before until.", http.StatusBadRequest)
+		return
+	}
+
+	redisKey := conventionalCacheKey(provider, cacheKeyRepo, period, window)
+	serveCachedJSON(w, r, provider, endpointName, "commits", cache, redisKey, CacheTTL, func() (interface{}, error) {
+		// The breakdown only parses commit messages, so no requests are spent on line stats.
+		commits, err := commitsInWindow(gitService, repoIdentifier, window, true)
+		if err != nil {
+			return nil, err
+		}
+		stats := analytics.ComputeConventionalCommitStats(commits, period)
+		stats.Since, stats.Until = window.Since, window.Until
+		return stats, nil
+	})
+}
+
+// conventionalCacheKey is the cache key of the Conventional Commits stats of cacheKeyRepo by
+// period over window.
+func conventionalCacheKey(provider, cacheKeyRepo, period string, window analytics.PeriodWindow) string {
+	return responseCacheKey(provider, "conventional_commits", cacheKeyRepo, period, window.Since.Unix(), window.Until.Unix())
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+func TestGitlabApi_GetConventionalCommitStats_Success_NoCache(t *testing.T) {
+	mockGitService := &MockGitService{
+		GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+			if !options.NoStats {
+				t.Error("expected the breakdown to skip line stats")
+			}
+			if !options.WalksAllPages() || options.Since.Format(time.RFC3339) != "2024-05-01T00:00:00Z" || options.Until.Format(time.RFC3339) != "2024-06-01T00:00:00Z" {
+				t.Errorf("expected May 2024 to be listed, got %s..%s", options.Since, options.Until)
+			}
+			return []*common_types.Commit{
+				{Message: "feat: add report", Author: common_types.CommitAuthor{Name: "Jane", Date: time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)}},
+				{Message: "update readme", Author: common_types.CommitAuthor{Name: "John", Date: time.Date(2024, 5, 7, 9, 0, 0, 0, time.UTC)}},
+			}, nil
+		},
+	}
+	var cachedKey string
+	mockRedisClient := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) { return nil, nil }, // Cache miss
+		SetFunc: func(key string, value interface{}, expirationInSeconds time.Duration) error {
+			cachedKey = key
+			return nil
+		},
+	}
+	gitlabAPI := NewGitlabApi(mockGitService, mockRedisClient)
+
+	req, _ := http.NewRequest("GET", "/api/gitlab/commits/conventional?projectID=group/project&period=month&since=2024-05-01T00:00:00Z&until=2024-06-01T00:00:00Z", nil)
+	rr := httptest.NewRecorder()
+	gitlabAPI.GetConventionalCommitStats(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("GetConventionalCommitStats returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var stats analytics.ConventionalCommitStats
+	if err := json.Unmarshal(rr.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("GetConventionalCommitStats could not unmarshal response: %v", err)
+	}
+	if stats.Period != analytics.PeriodMonth || stats.Repository.Features != 1 || stats.Repository.ConformanceRate != 0.5 || len(stats.ByPeriod) != 1 {
+		t.Errorf("GetConventionalCommitStats returned unexpected body: %s", rr.Body.String())
+	}
+	if stats.Since.Format(time.RFC3339) != "2024-05-01T00:00:00Z" || stats.Until.Format(time.RFC3339) != "2024-06-01T00:00:00Z" {
+		t.Errorf("expected the window to be echoed, got %s..%s", stats.Since, stats.Until)
+	}
+	if cachedKey != "gitlab_get_conventional_commits_group/project:month_1714521600_1717200000" {
+		t.Errorf("GetConventionalCommitStats cached under unexpected key %q", cachedKey)
+	}
+}
+
+func TestGetConventionalCommitStats_BadRequests(t *testing.T) {
+	githubAPI := NewGithubApi(&MockGitService{}, &MockRedisClient{})
+	gitlabAPI := NewGitlabApi(&MockGitService{}, &MockRedisClient{})
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"github missing owner", githubAPI.GetConventionalCommitStats, "/api/github/commits/conventional?repoName=r"},
+		{"gitlab unknown period", gitlabAPI.GetConventionalCommitStats, "/api/gitlab/commits/conventional?projectID=1&period=year"},
+		{"gitlab invalid until", gitlabAPI.GetConventionalCommitStats, "/api/gitlab/commits/conventional?projectID=1&until=tomorrow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("%s returned wrong status code: got %v want %v", tt.name, status, http.StatusBadRequest)
+			}
+		})
+	}
+}
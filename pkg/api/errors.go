@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/repository"
+	"github.com/google/go-github/v56/github"
+	"github.com/sirupsen/logrus"
+	"github.com/xanzy/go-gitlab"
+)
+
+// APIV1Prefix is the path prefix of the versioned API routes.
+const APIV1Prefix = "/api/v1"
+
+// RequestIDHeader carries the ID of a request, taken from the client or generated by RequestID.
+const RequestIDHeader = "X-Request-ID"
+
+// Error codes of the JSON error envelope.
+const (
+	ErrorCodeInvalidRequest   = "invalid_request"   // A query parameter is missing or invalid.
+	ErrorCodeUnauthenticated  = "unauthenticated"   // The caller sent no valid API key or bearer token.
+	ErrorCodeUnauthorized     = "unauthorized"      // The provider rejected the configured token.
+	ErrorCodeForbidden        = "forbidden"         // The token has no access to the resource.
+	ErrorCodeAccessDenied     = "access_denied"     // The access policy does not allow the caller the request.
+	ErrorCodeNotFound         = "not_found"         // The repository, project or ref does not exist.
+	ErrorCodeRateLimited      = "rate_limited"      // The provider rate limit is exhausted.
+	ErrorCodeUpstream         = "upstream_error"    // The provider failed or answered unexpectedly.
+	ErrorCodeInternal         = "internal_error"    // The server itself failed.
+	ErrorCodeJobPending       = "job_pending"       // The job has not finished yet; poll its status.
+	ErrorCodeJobCanceled      = "job_canceled"      // The job was canceled and has no result.
+	ErrorCodeJobFailed        = "job_failed"        // The job failed without producing a result.
+	ErrorCodeJobFinished      = "job_finished"      // The job already finished and cannot be canceled.
+	ErrorCodeInvalidSignature = "invalid_signature" // The webhook delivery is not signed with the configured secret.
+)
+
+// APIError is the body of a failed /api/v1 request, wrapped in ErrorEnvelope.
+type APIError struct {
+	Status     int           `json:"-"`         // HTTP status of the response.
+	Code       string        `json:"code"`      // One of the ErrorCode constants.
+	Message    string        `json:"message"`   // Human-readable description, safe to show to users.
+	Provider   string        `json:"provider"`  // Provider the request was for, e.g. "github".
+	RequestID  string        `json:"requestId"` // ID of the request, also sent as X-Request-ID.
+	RetryAfter time.Duration `json:"-"`         // When positive, sent as the Retry-After header.
+}
+
+// ErrorEnvelope is the JSON error response of the /api/v1 routes: {"error": {...}}.
+type ErrorEnvelope struct {
+	Error APIError `json:"error"`
+}
+
+// contextKey is the type of the request context keys of this package.
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	jsonErrorsKey
+	identityKey
+	jobSubmitKey    // Set on requests POST /api/v1/jobs dispatches, which are queued whatever the endpoint.
+	jobReplayKey    // Set on requests a job replays, which are served in place.
+	cacheRefreshKey // Set on requests that bypass cached responses and store fresh ones; see requestCache.
+	cacheEntryKey   // *cacheSettings of the endpoint serving the request; see CachePolicy.Apply.
+)
+
+// RequestID is a middleware that assigns every request an ID: the client's X-Request-ID when it
+// sent a usable one, a random one otherwise. The ID is echoed in the response header, logged and
+// included in error responses.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, requestID)))
+	})
+}
+
+// validRequestID reports whether a client-supplied request ID is short and printable ASCII, so it
+// can be echoed and logged as is.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > 128 {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns 16 random bytes, hex encoded.
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(id)
+}
+
+// requestIDFromContext returns the ID RequestID assigned to the request, or "" outside of it.
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// JSONErrors is a middleware that makes the handlers answer errors with an ErrorEnvelope instead
+// of plain text. It is mounted on the /api/v1 routes; the unversioned routes keep their
+// plain-text errors for existing clients.
+func JSONErrors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), jsonErrorsKey, true)))
+	})
+}
+
+// writeError answers r with apiErr: an ErrorEnvelope on the /api/v1 routes, the message as plain
+// text elsewhere. Provider and RequestID are filled in from provider and the request context.
+func writeError(w http.ResponseWriter, r *http.Request, provider string, apiErr APIError) {
+	apiErr.Provider = provider
+	apiErr.RequestID = requestIDFromContext(r.Context())
+	if apiErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((apiErr.RetryAfter+time.Second-1)/time.Second)))
+	}
+	if jsonErrors, _ := r.Context().Value(jsonErrorsKey).(bool); !jsonErrors {
+		http.Error(w, apiErr.Message, apiErr.Status)
+		return
+	}
+
+	body, err := json.Marshal(ErrorEnvelope{Error: apiErr})
+	if err != nil {
+		log.WithFields(logrus.Fields{"provider": provider, "error": err}).Error("Failed to marshal error response.")
+		http.Error(w, apiErr.Message, apiErr.Status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(apiErr.Status)
+	if _, err := w.Write(body); err != nil {
+		log.WithFields(logrus.Fields{"provider": provider, "error": err}).Error("Error writing response.")
+	}
+}
+
+// invalidRequest is the APIError of a request that failed validation.
+func invalidRequest(message string) APIError {
+	return APIError{Status: http.StatusBadRequest, Code: ErrorCodeInvalidRequest, Message: message}
+}
+
+// internalError is the APIError of a failure inside the server; message must not contain raw
+// error text.
+func internalError(message string) APIError {
+	return APIError{Status: http.StatusInternalServerError, Code: ErrorCodeInternal, Message: message}
+}
+
+// providerError maps an error returned by a GitService to an APIError. Errors of go-github and
+// go-gitlab, also when wrapped, keep the meaning of the upstream status: 401, 403, 404 and rate
+// limits are passed on, other upstream failures become 502. Anything else is an internal error.
+// The message never contains the raw error, which may hold provider URLs or response bodies.
+func providerError(err error) APIError {
+	var trackerRateLimit *repository.RateLimitError
+	if errors.As(err, &trackerRateLimit) {
+		return rateLimited(trackerRateLimit.RetryAfter())
+	}
+	var githubRateLimit *github.RateLimitError
+	if errors.As(err, &githubRateLimit) {
+		return rateLimited(time.Until(githubRateLimit.Rate.Reset.Time))
+	}
+	var githubAbuseLimit *github.AbuseRateLimitError
+	if errors.As(err, &githubAbuseLimit) {
+		return rateLimited(githubAbuseLimit.GetRetryAfter())
+	}
+	var githubResponse *github.ErrorResponse
+	if errors.As(err, &githubResponse) && githubResponse.Response != nil {
+		return upstreamStatusError(githubResponse.Response)
+	}
+	var gitlabResponse *gitlab.ErrorResponse
+	if errors.As(err, &gitlabResponse) && gitlabResponse.Response != nil {
+		return upstreamStatusError(gitlabResponse.Response)
+	}
+	var graphQLErr *repository.GraphQLError
+	if errors.As(err, &graphQLErr) {
+		switch graphQLErr.Type {
+		case "NOT_FOUND":
+			return APIError{Status: http.StatusNotFound, Code: ErrorCodeNotFound, Message: "The repository, project or ref was not found at the provider."}
+		case "FORBIDDEN":
+			return APIError{Status: http.StatusForbidden, Code: ErrorCodeForbidden, Message: "The configured token has no access to this resource."}
+		case "RATE_LIMITED":
+			return rateLimited(0)
+		default:
+			return APIError{Status: http.StatusBadGateway, Code: ErrorCodeUpstream, Message: "The provider request failed."}
+		}
+	}
+	return internalError("Internal server error.")
+}
+
+// upstreamStatusError maps a failed provider response to an APIError.
+func upstreamStatusError(response *http.Response) APIError {
+	switch response.StatusCode {
+	case http.StatusUnauthorized:
+		return APIError{Status: http.StatusUnauthorized, Code: ErrorCodeUnauthorized, Message: "The provider rejected the configured token."}
+	case http.StatusForbidden:
+		return APIError{Status: http.StatusForbidden, Code: ErrorCodeForbidden, Message: "The configured token has no access to this resource."}
+	case http.StatusNotFound:
+		return APIError{Status: http.StatusNotFound, Code: ErrorCodeNotFound, Message: "The repository, project or ref was not found at the provider."}
+	case http.StatusUnprocessableEntity:
+		return invalidRequest("The provider rejected the request parameters.")
+	case http.StatusTooManyRequests:
+		if retryAfter, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+			return rateLimited(time.Duration(retryAfter) * time.Second)
+		}
+		reset, _ := strconv.ParseInt(response.Header.Get("RateLimit-Reset"), 10, 64) // GitLab.
+		return rateLimited(time.Until(time.Unix(reset, 0)))
+	default:
+		return APIError{Status: http.StatusBadGateway, Code: ErrorCodeUpstream, Message: "The provider request failed."}
+	}
+}
+
+// rateLimited is the APIError of an exhausted provider rate limit; retryAfter may be zero if the
+// provider did not say when it resets.
+func rateLimited(retryAfter time.Duration) APIError {
+	return APIError{Status: http.StatusTooManyRequests, Code: ErrorCodeRateLimited, Message: "The provider rate limit is exhausted; retry later.", RetryAfter: retryAfter}
+}
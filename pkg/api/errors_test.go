@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	"github.com/ahmetk3436/git-stats-golang/pkg/repository"
+	"github.com/google/go-github/v56/github"
+	"github.com/gorilla/mux"
+	"github.com/xanzy/go-gitlab"
+)
+
+func upstreamResponse(status int) *http.Response {
+	return &http.Response{StatusCode: status, Header: http.Header{}, Request: httptest.NewRequest(http.MethodGet, "https://api.example.com/repos/octo-org/hello-world", nil)}
+}
+
+func TestProviderError(t *testing.T) {
+	rateLimited429 := upstreamResponse(http.StatusTooManyRequests)
+	rateLimited429.Header.Set("Retry-After", "30")
+	gitlabReset429 := upstreamResponse(http.StatusTooManyRequests)
+	gitlabReset429.Header.Set("RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+
+	tests := []struct {
+		name       string
+		err        error
+		status     int
+		code       string
+		retryAfter bool
+	}{
+		{"github not found, wrapped", fmt.Errorf("failed to get github repository: %w", &github.ErrorResponse{Response: upstreamResponse(http.StatusNotFound), Message: "Not Found"}), http.StatusNotFound, ErrorCodeNotFound, false},
+		{"github bad credentials", &github.ErrorResponse{Response: upstreamResponse(http.StatusUnauthorized), Message: "Bad credentials"}, http.StatusUnauthorized, ErrorCodeUnauthorized, false},
+		{"github rate limit", fmt.Errorf("list: %w", &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: time.Now().Add(time.Minute)}}, Response: upstreamResponse(http.StatusForbidden)}), http.StatusTooManyRequests, ErrorCodeRateLimited, true},
+		{"github server error", &github.ErrorResponse{Response: upstreamResponse(http.StatusServiceUnavailable)}, http.StatusBadGateway, ErrorCodeUpstream, false},
+		{"gitlab forbidden", fmt.Errorf("failed to list gitlab branches: %w", &gitlab.ErrorResponse{Response: upstreamResponse(http.StatusForbidden), Message: "403 Forbidden"}), http.StatusForbidden, ErrorCodeForbidden, false},
+		{"gitlab rate limit", &gitlab.ErrorResponse{Response: rateLimited429}, http.StatusTooManyRequests, ErrorCodeRateLimited, true},
+		{"gitlab rate limit without Retry-After", &gitlab.ErrorResponse{Response: gitlabReset429}, http.StatusTooManyRequests, ErrorCodeRateLimited, true},
+		{"quota exhausted before the request", fmt.Errorf("list: %w", &repository.RateLimitError{Provider: "github", Resource: "core", Reset: time.Now().Add(time.Minute)}), http.StatusTooManyRequests, ErrorCodeRateLimited, true},
+		{"github graphql unknown ref", fmt.Errorf("list: %w", &repository.GraphQLError{Type: "NOT_FOUND", Message: "Could not resolve to a Commit"}), http.StatusNotFound, ErrorCodeNotFound, false},
+		{"github graphql failure", &repository.GraphQLError{Message: "Something went wrong"}, http.StatusBadGateway, ErrorCodeUpstream, false},
+		{"gitlab unprocessable", &gitlab.ErrorResponse{Response: upstreamResponse(http.StatusUnprocessableEntity)}, http.StatusBadRequest, ErrorCodeInvalidRequest, false},
+		{"not a provider error", errors.New("dial tcp 10.0.0.1:443: connection refused"), http.StatusInternalServerError, ErrorCodeInternal, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := providerError(tt.err)
+			if apiErr.Status != tt.status || apiErr.Code != tt.code {
+				t.Errorf("got %d %s, want %d %s", apiErr.Status, apiErr.Code, tt.status, tt.code)
+			}
+			if (apiErr.RetryAfter > 0) != tt.retryAfter {
+				t.Errorf("unexpected RetryAfter %v", apiErr.RetryAfter)
+			}
+			if apiErr.Message == "" || strings.Contains(apiErr.Message, "example.com") || strings.Contains(apiErr.Message, "10.0.0.1") {
+				t.Errorf("message %q is empty or leaks the raw error", apiErr.Message)
+			}
+		})
+	}
+}
+
+// notFoundGitService fails every commit listing like go-github does for a missing repository.
+func notFoundGitService() *MockGitService {
+	return &MockGitService{
+		GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+			return nil, fmt.Errorf("failed to list github commits: %w", &github.ErrorResponse{Response: upstreamResponse(http.StatusNotFound), Message: "Not Found"})
+		},
+	}
+}
+
+func TestRegisterProvider_ErrorEnvelope(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(RequestID)
+	cache := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) { return nil, nil },
+		SetFunc: func(key string, value interface{}, expirationInSeconds time.Duration) error { return nil },
+	}
+	RegisterProvider(router, "github", NewGithubApi(notFoundGitService(), cache).Endpoints(), nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/github/activity?owner=octo-org&repo=missing", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound || rr.Header().Get("Content-Type") != "application/json" || rr.Header().Get(RequestIDHeader) != "req-123" {
+		t.Fatalf("unexpected response: %d %v", rr.Code, rr.Header())
+	}
+	var envelope ErrorEnvelope
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("failed to decode envelope %s: %v", rr.Body.String(), err)
+	}
+	expected := APIError{Code: ErrorCodeNotFound, Message: envelope.Error.Message, Provider: "github", RequestID: "req-123"}
+	if envelope.Error != expected || envelope.Error.Message == "" {
+		t.Errorf("unexpected error body: %+v", envelope.Error)
+	}
+
+	// Validation errors use the envelope too.
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/github/activity", nil))
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil || rr.Code != http.StatusBadRequest || envelope.Error.Code != ErrorCodeInvalidRequest || envelope.Error.RequestID == "" {
+		t.Errorf("unexpected validation error: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// The unversioned routes keep plain-text errors, with the mapped status.
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/github/activity?owner=octo-org&repo=missing", nil))
+	if rr.Code != http.StatusNotFound || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("unexpected unversioned response: %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+}
+
+func TestWriteError_RetryAfter(t *testing.T) {
+	rr := httptest.NewRecorder()
+	writeError(rr, httptest.NewRequest(http.MethodGet, "/api/v1/gitlab/tags", nil), "gitlab", rateLimited(1500*time.Millisecond))
+
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "2" {
+		t.Errorf("unexpected response: %d Retry-After %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestIDFromContext(r.Context())
+	}))
+
+	for _, clientID := range []string{"", "has space", strings.Repeat("x", 200)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, clientID)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if len(seen) != 32 || seen == clientID || rr.Header().Get(RequestIDHeader) != seen {
+			t.Errorf("client ID %q: expected a generated ID, got %q (header %q)", clientID, seen, rr.Header().Get(RequestIDHeader))
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	storage "github.com/ahmetk3436/git-stats-golang/internal"
 	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
 	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	"github.com/ahmetk3436/git-stats-golang/pkg/jobs"
 	appMetrics "github.com/ahmetk3436/git-stats-golang/pkg/prometheus"
 	// "github.com/ahmetk3436/git-stats-golang/pkg/repository" // Interface is used now
 	"github.com/sirupsen/logrus"
@@ -13,6 +15,7 @@ import (
 	"os"
 	"os/exec"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -34,17 +37,22 @@ func init() {
 // GithubApi handles API requests related to GitHub.
 // It uses a GitService for interacting with the Git provider and a RedisClient for caching.
 type GithubApi struct {
-	Repo  interfaces.GitService // Service for Git operations (GitHub specific implementation).
-	Redis *storage.RedisClient  // Client for Redis caching.
+	Repo     interfaces.GitService // Service for Git operations (GitHub specific implementation).
+	Redis    storage.InMemoryDB    // Cache backend (Redis in production).
+	Defaults AnalyticsDefaults     // Used when a request leaves the matching query parameter out.
+
+	// TokenService, when set, builds the GitService for a caller's forwarded provider token.
+	TokenService func(token string) (interfaces.GitService, error)
 }
 
 // NewGithubApi creates a new instance of GithubApi.
 // It requires a GitService implementation (e.g., *repository.GitHubRepo) and a RedisClient.
-func NewGithubApi(gitService interfaces.GitService, redisClient *storage.RedisClient) *GithubApi {
+func NewGithubApi(gitService interfaces.GitService, redisClient storage.InMemoryDB) *GithubApi {
 	log.Info("Creating NewGithubApi with GitService interface.")
 	return &GithubApi{
-		Repo:  gitService,
-		Redis: redisClient,
+		Repo:     gitService,
+		Redis:    redisClient,
+		Defaults: DefaultAnalytics(),
 	}
 }
 
@@ -65,8 +73,8 @@ func (ghAPI *GithubApi) GetAllRepos(w http.ResponseWriter, r *http.Request) {
 	var err error
 	dataSource := "API" // Indicates data source for logging (API or Redis).
 
-	redisKey := "github_get_all_repos_" + ownerQueryParam // Cache key includes owner.
-	cachedData, redisErr := ghAPI.Redis.Get(redisKey)
+	redisKey := responseCacheKey("github", "all_repos", ownerQueryParam) // Cache key includes owner.
+	cachedData, redisErr := ghAPI.cache(r).Get(redisKey)
 
 	if redisErr == nil && cachedData != nil {
 		logCtx.WithField("key", redisKey).Info("Cache hit for GetAllRepos.")
@@ -74,7 +82,7 @@ func (ghAPI *GithubApi) GetAllRepos(w http.ResponseWriter, r *http.Request) {
 		if err = json.Unmarshal(cachedData, &reposFromSource); err != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": err}).Error("Error unmarshalling cached data for GetAllRepos.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-			http.Error(w, "Error processing cached data.", http.StatusInternalServerError)
+			writeError(w, r, "github", internalError("Error processing cached data."))
 			return
 		}
 		w.Write(cachedData) // Serve directly from cache if unmarshalling is not strictly needed here.
@@ -85,12 +93,12 @@ func (ghAPI *GithubApi) GetAllRepos(w http.ResponseWriter, r *http.Request) {
 			logCtx.WithField("key", redisKey).Info("Cache miss for GetAllRepos; fetching from API.")
 		}
 		dataSource = "API"
-		fetchedRepos, fetchErr := ghAPI.Repo.GetAllRepos(ownerQueryParam)
+		fetchedRepos, fetchErr := ghAPI.gitService(r).GetAllRepos(ownerQueryParam)
 		if fetchErr != nil {
 			logCtx.WithField("error", fetchErr).Error("Error fetching repos from GitHub via GitService.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
 			appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "all_repos", "failure").Inc()
-			http.Error(w, fetchErr.Error(), http.StatusInternalServerError)
+			writeError(w, r, "github", providerError(fetchErr))
 			return
 		}
 		appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "all_repos", "success").Inc()
@@ -100,11 +108,11 @@ func (ghAPI *GithubApi) GetAllRepos(w http.ResponseWriter, r *http.Request) {
 		if marshalErr != nil {
 			logCtx.WithField("error", marshalErr).Error("Error marshalling repos response.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-			http.Error(w, marshalErr.Error(), http.StatusInternalServerError)
+			writeError(w, r, "github", internalError("Error encoding response."))
 			return
 		}
-		// Cache the newly fetched data. TTL example: 1 hour (3600 seconds).
-		if setErr := ghAPI.Redis.Set(redisKey, responseBytes, 3600); setErr != nil {
+		// Cache the newly fetched data for CacheTTL.
+		if setErr := ghAPI.cache(r).Set(redisKey, responseBytes, CacheTTL); setErr != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": setErr}).Error("Redis SET error for GetAllRepos.")
 		}
 		w.Write(responseBytes)
@@ -116,20 +124,24 @@ func (ghAPI *GithubApi) GetAllRepos(w http.ResponseWriter, r *http.Request) {
 	logCtx.WithFields(logrus.Fields{"duration_seconds": duration, "source": dataSource, "items_count": len(reposFromSource)}).Info("GetAllRepos request processed successfully.")
 }
 
-// GetRepo handles requests to get a specific repository by ID or "owner/name" string.
+// GetRepo handles requests to get a specific repository identified by 'owner' and 'repo', or by
+// the deprecated 'projectID' (ID or "owner/name" string).
 // It checks cache first and falls back to the GitService.
 func (ghAPI *GithubApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	endpointName := "/api/github/repo"
-	repoIdentifierQuery := r.URL.Query().Get("projectID") // "projectID" is the legacy query param name.
+	repoIdentifierQuery, _ := githubRepoFromQuery(w, r)
+	if repoIdentifierQuery == "" {
+		repoIdentifierQuery = legacyQueryParam(w, r, legacyParamProjectID, "owner and repo")
+	}
 	logCtx := log.WithFields(logrus.Fields{"endpoint": endpointName, "method": r.Method, "identifier_query": repoIdentifierQuery})
 	logCtx.Info("GetRepo request received.")
 	w.Header().Set("Content-Type", "application/json")
 
 	if repoIdentifierQuery == "" {
-		logCtx.Error("Missing projectID query parameter.")
+		logCtx.Error("Missing owner and repo query parameters.")
 		appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-		http.Error(w, "projectID query parameter is required.", http.StatusBadRequest)
+		writeError(w, r, "github", invalidRequest("owner and repo query parameters are required."))
 		return
 	}
 
@@ -137,8 +149,8 @@ func (ghAPI *GithubApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 	var err error
 	dataSource := "API"
 
-	redisKey := "github_get_repo_" + repoIdentifierQuery
-	cachedData, redisErr := ghAPI.Redis.Get(redisKey)
+	redisKey := responseCacheKey("github", "repo", repoIdentifierQuery)
+	cachedData, redisErr := ghAPI.cache(r).Get(redisKey)
 
 	if redisErr == nil && cachedData != nil {
 		logCtx.WithField("key", redisKey).Info("Cache hit for GetRepo.")
@@ -146,7 +158,7 @@ func (ghAPI *GithubApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 		if err = json.Unmarshal(cachedData, &repoData); err != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": err}).Error("Error unmarshalling cached data for GetRepo.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-			http.Error(w, "Error processing cached data.", http.StatusInternalServerError)
+			writeError(w, r, "github", internalError("Error processing cached data."))
 			return
 		}
 		w.Write(cachedData)
@@ -163,15 +175,15 @@ func (ghAPI *GithubApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 			identifierToFetch = parsedID // Use int64 ID if parsing succeeds.
 		} else {
 			identifierToFetch = repoIdentifierQuery // Otherwise, assume it's an "owner/repo" string.
-			logCtx.WithField("input", repoIdentifierQuery).Debug("Interpreting projectID as owner/repo string due to ParseInt failure.")
+			logCtx.WithField("input", repoIdentifierQuery).Debug("Interpreting identifier as owner/repo string due to ParseInt failure.")
 		}
 
-		fetchedRepo, fetchErr := ghAPI.Repo.GetRepo(identifierToFetch)
+		fetchedRepo, fetchErr := ghAPI.gitService(r).GetRepo(identifierToFetch)
 		if fetchErr != nil {
 			logCtx.WithFields(logrus.Fields{"identifier": identifierToFetch, "error": fetchErr}).Error("Error fetching repo from GitHub via GitService.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
 			appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "single_repo", "failure").Inc()
-			http.Error(w, "Cannot get project: "+fetchErr.Error(), http.StatusBadRequest) // Provide more specific error.
+			writeError(w, r, "github", providerError(fetchErr))
 			return
 		}
 		appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "single_repo", "success").Inc()
@@ -181,10 +193,10 @@ func (ghAPI *GithubApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 		if marshalErr != nil {
 			logCtx.WithField("error", marshalErr).Error("Error marshalling repo response.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-			http.Error(w, "Error marshalling project data.", http.StatusInternalServerError)
+			writeError(w, r, "github", internalError("Error marshalling project data."))
 			return
 		}
-		if setErr := ghAPI.Redis.Set(redisKey, responseBytes, 3600); setErr != nil { // Cache for 1 hour.
+		if setErr := ghAPI.cache(r).Set(redisKey, responseBytes, CacheTTL); setErr != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": setErr}).Error("Redis SET error for GetRepo.")
 		}
 		w.Write(responseBytes)
@@ -197,21 +209,21 @@ func (ghAPI *GithubApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetAllCommits handles requests to get all commits for a specific repository.
-// Repository is identified by 'projectOwner' and 'repoName' query parameters.
+// Repository is identified by 'owner' and 'repo' query parameters.
 // It checks cache first and falls back to the GitService.
 func (ghAPI *GithubApi) GetAllCommits(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	endpointName := "/api/github/commits"
-	projectOwner := r.URL.Query().Get("projectOwner")
-	repoName := r.URL.Query().Get("repoName")
+	projectOwner := queryParam(w, r, ParamOwner)
+	repoName := queryParam(w, r, ParamRepo)
 	logCtx := log.WithFields(logrus.Fields{"endpoint": endpointName, "method": r.Method, "owner": projectOwner, "repo": repoName})
 	logCtx.Info("GetAllCommits request received.")
 	w.Header().Set("Content-Type", "application/json")
 
 	if projectOwner == "" || repoName == "" {
-		logCtx.Error("Missing projectOwner or repoName query parameters.")
+		logCtx.Error("Missing owner or repo query parameters.")
 		appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-		http.Error(w, "projectOwner and repoName query parameters are required.", http.StatusBadRequest)
+		writeError(w, r, "github", invalidRequest("owner and repo query parameters are required."))
 		return
 	}
 
@@ -219,8 +231,8 @@ func (ghAPI *GithubApi) GetAllCommits(w http.ResponseWriter, r *http.Request) {
 	var err error
 	dataSource := "API"
 
-	redisKey := fmt.Sprintf("github_get_commits_%s_%s", projectOwner, repoName)
-	cachedData, redisErr := ghAPI.Redis.Get(redisKey)
+	redisKey := commitsCacheKey("github", projectOwner+"_"+repoName)
+	cachedData, redisErr := ghAPI.cache(r).Get(redisKey)
 
 	if redisErr == nil && cachedData != nil {
 		logCtx.WithField("key", redisKey).Info("Cache hit for GetAllCommits.")
@@ -228,7 +240,7 @@ func (ghAPI *GithubApi) GetAllCommits(w http.ResponseWriter, r *http.Request) {
 		if err = json.Unmarshal(cachedData, &commitsFromSource); err != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": err}).Error("Error unmarshalling cached data for GetAllCommits.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-			http.Error(w, "Error processing cached data.", http.StatusInternalServerError)
+			writeError(w, r, "github", internalError("Error processing cached data."))
 			return
 		}
 		w.Write(cachedData)
@@ -243,12 +255,12 @@ func (ghAPI *GithubApi) GetAllCommits(w http.ResponseWriter, r *http.Request) {
 		// TODO: Populate CommitListOptions from query params if needed (e.g., branch, page).
 		commitOpts := &interfaces.CommitListOptions{PerPage: 100} // Default: 100 commits per page.
 
-		fetchedCommits, fetchErr := ghAPI.Repo.GetProjectCommits(repoIdentifier, commitOpts)
+		fetchedCommits, fetchErr := ghAPI.gitService(r).GetProjectCommits(repoIdentifier, commitOpts)
 		if fetchErr != nil {
 			logCtx.WithFields(logrus.Fields{"repo": repoIdentifier, "error": fetchErr}).Error("Error fetching commits from GitHub via GitService.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
 			appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "commits", "failure").Inc()
-			http.Error(w, fetchErr.Error(), http.StatusInternalServerError)
+			writeError(w, r, "github", providerError(fetchErr))
 			return
 		}
 		appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "commits", "success").Inc()
@@ -258,10 +270,10 @@ func (ghAPI *GithubApi) GetAllCommits(w http.ResponseWriter, r *http.Request) {
 		if marshalErr != nil {
 			logCtx.WithField("error", marshalErr).Error("Error marshalling commits response.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-			http.Error(w, marshalErr.Error(), http.StatusInternalServerError)
+			writeError(w, r, "github", internalError("Error encoding response."))
 			return
 		}
-		if setErr := ghAPI.Redis.Set(redisKey, responseBytes, 3600); setErr != nil { // Cache for 1 hour.
+		if setErr := ghAPI.cache(r).Set(redisKey, responseBytes, CacheTTL); setErr != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": setErr}).Error("Redis SET error for GetAllCommits.")
 		}
 		w.Write(responseBytes)
@@ -274,21 +286,21 @@ func (ghAPI *GithubApi) GetAllCommits(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetContributors handles requests to get contributors for a specific repository.
-// Repository is identified by 'owner' and 'repoName' query parameters.
+// Repository is identified by 'owner' and 'repo' query parameters.
 // It uses the GitService to fetch and return contributor data.
 func (ghAPI *GithubApi) GetContributors(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	endpointName := "/api/github/contributors"
-	ownerName := r.URL.Query().Get("owner")
-	repoName := r.URL.Query().Get("repoName")
+	ownerName := queryParam(w, r, ParamOwner)
+	repoName := queryParam(w, r, ParamRepo)
 	logCtx := log.WithFields(logrus.Fields{"endpoint": endpointName, "method": r.Method, "owner": ownerName, "repo": repoName})
 	logCtx.Info("GetContributors request received.")
 	w.Header().Set("Content-Type", "application/json")
 
 	if ownerName == "" || repoName == "" {
-		logCtx.Error("Owner and repoName query parameters are required for GetContributors.")
+		logCtx.Error("Owner and repo query parameters are required for GetContributors.")
 		appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-		http.Error(w, "Owner and repoName query parameters are required.", http.StatusBadRequest)
+		writeError(w, r, "github", invalidRequest("owner and repo query parameters are required."))
 		return
 	}
 
@@ -297,12 +309,12 @@ func (ghAPI *GithubApi) GetContributors(w http.ResponseWriter, r *http.Request)
 	// For simplicity in this example, direct API call via GitService is shown.
 	dataSource := "API"
 
-	contributors, fetchErr := ghAPI.Repo.GetRepoContributors(repoIdentifier)
+	contributors, fetchErr := ghAPI.gitService(r).GetRepoContributors(repoIdentifier)
 	if fetchErr != nil {
 		logCtx.WithFields(logrus.Fields{"repo": repoIdentifier, "error": fetchErr}).Error("Error fetching contributors from GitHub via GitService.")
 		appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
 		appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "contributors", "failure").Inc()
-		http.Error(w, fetchErr.Error(), http.StatusInternalServerError)
+		writeError(w, r, "github", providerError(fetchErr))
 		return
 	}
 	appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "contributors", "success").Inc()
@@ -311,7 +323,7 @@ func (ghAPI *GithubApi) GetContributors(w http.ResponseWriter, r *http.Request)
 	if marshalErr != nil {
 		logCtx.WithField("error", marshalErr).Error("Error marshalling contributors response.")
 		appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-		http.Error(w, marshalErr.Error(), http.StatusInternalServerError)
+		writeError(w, r, "github", internalError("Error encoding response."))
 		return
 	}
 	w.Write(responseBytes)
@@ -323,29 +335,47 @@ func (ghAPI *GithubApi) GetContributors(w http.ResponseWriter, r *http.Request)
 }
 
 // GetRepoTotalLinesOfCode handles requests to calculate the total lines of code for a repository.
-// The repository is identified by 'repoUrl' query parameter (URL to clone).
-// This method involves cloning the repository locally to perform line counting.
+// The repository is identified by 'owner' and 'repo' query parameters, whose clone URL is looked
+// up through the GitService, or by the deprecated 'repoUrl' (URL to clone).
+// This method involves cloning the repository locally to perform line counting, so on /api/v1 it
+// runs as a background job (see JobsApi); the clone is stopped when the request is canceled.
 func (ghAPI *GithubApi) GetRepoTotalLinesOfCode(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	endpointName := "/api/github/loc"
-	repoCloneURL := r.URL.Query().Get("repoUrl") // Expects the full clone URL.
-	logCtx := log.WithFields(logrus.Fields{"endpoint": endpointName, "method": r.Method, "repo_url": repoCloneURL})
+	repoIdentifier, byName := githubRepoFromQuery(w, r)
+	repoCloneURL := ""
+	if !byName {
+		repoCloneURL = legacyQueryParam(w, r, legacyParamRepoURL, "owner and repo") // Expects the full clone URL.
+	}
+	logCtx := log.WithFields(logrus.Fields{"endpoint": endpointName, "method": r.Method, "repo": repoIdentifier, "repo_url": repoCloneURL})
 	logCtx.Info("GetRepoTotalLinesOfCode request received.")
 	w.Header().Set("Content-Type", "application/json")
 
-	if repoCloneURL == "" {
-		logCtx.Error("Missing repoUrl query parameter.")
+	if !byName && repoCloneURL == "" {
+		logCtx.Error("Missing owner and repo query parameters.")
 		appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-		http.Error(w, "repoUrl query parameter is required.", http.StatusBadRequest)
+		writeError(w, r, "github", invalidRequest("owner and repo query parameters are required."))
 		return
 	}
+	if byName {
+		repo, fetchErr := ghAPI.gitService(r).GetRepo(repoIdentifier)
+		if fetchErr != nil {
+			logCtx.WithField("error", fetchErr).Error("Error fetching repo for LOC calculation.")
+			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
+			appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "single_repo", "failure").Inc()
+			writeError(w, r, "github", providerError(fetchErr))
+			return
+		}
+		appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "single_repo", "success").Inc()
+		repoCloneURL = repo.CloneURL
+	}
 
 	var linesOfCodeResult []byte
 	// var err error // Removed as 'err' is shadowed in blocks below.
 	dataSource := "API" // Or "Calculation" as it's not a direct Git provider API call for data.
 
-	redisKey := "github_get_loc_" + repoCloneURL // Cache key based on repo URL.
-	cachedData, redisErr := ghAPI.Redis.Get(redisKey)
+	redisKey := responseCacheKey("github", "loc", repoCloneURL) // Cache key based on repo URL.
+	cachedData, redisErr := ghAPI.cache(r).Get(redisKey)
 
 	if redisErr == nil && cachedData != nil {
 		logCtx.WithField("key", redisKey).Info("Cache hit for GetRepoTotalLinesOfCode.")
@@ -365,7 +395,7 @@ func (ghAPI *GithubApi) GetRepoTotalLinesOfCode(w http.ResponseWriter, r *http.R
 		if mkDirErr != nil {
 			logCtx.WithField("error", mkDirErr).Error("Error creating temporary directory for LOC calculation.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-			http.Error(w, fmt.Sprintf("Error creating temp dir: %v", mkDirErr), http.StatusInternalServerError)
+			writeError(w, r, "github", internalError("Error creating temporary directory."))
 			return
 		}
 		defer func() {
@@ -375,11 +405,12 @@ func (ghAPI *GithubApi) GetRepoTotalLinesOfCode(w http.ResponseWriter, r *http.R
 		}()
 		logCtx.WithField("tempDir", tempDir).Debug("Temporary directory created for cloning.")
 
-		if cloneErr := cloneRepository(repoCloneURL, tempDir); cloneErr != nil {
+		jobs.ReportProgress(r.Context(), 0, 3, "cloning repository")
+		if cloneErr := cloneRepository(r.Context(), repoCloneURL, tempDir); cloneErr != nil {
 			logCtx.WithFields(logrus.Fields{"repo_url": repoCloneURL, "tempDir": tempDir, "error": cloneErr}).Error("Error cloning repository for LOC calculation.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
 			appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "loc_clone", "failure").Inc() // Metric for clone attempt.
-			http.Error(w, fmt.Sprintf("Error cloning repo: %v", cloneErr), http.StatusInternalServerError)
+			writeError(w, r, "github", APIError{Status: http.StatusBadGateway, Code: ErrorCodeUpstream, Message: "Error cloning repository."})
 			return
 		}
 		appMetrics.RepositoryFetchesTotal.WithLabelValues("github", "loc_clone", "success").Inc()
@@ -387,11 +418,12 @@ func (ghAPI *GithubApi) GetRepoTotalLinesOfCode(w http.ResponseWriter, r *http.R
 		// Command to count lines: list files, then count lines for each, sum them up.
 		// `git ls-files` lists all tracked files. `xargs wc -l` counts lines for these files. `tail -n 1` gets the total.
 		locCommand := "git ls-files | xargs wc -l | tail -n 1"
-		output, cmdErr := runCommand(locCommand, tempDir)
+		jobs.ReportProgress(r.Context(), 1, 3, "counting lines")
+		output, cmdErr := runCommand(r.Context(), locCommand, tempDir)
 		if cmdErr != nil {
 			logCtx.WithFields(logrus.Fields{"repo_url": repoCloneURL, "command": locCommand, "error": cmdErr}).Error("Error running command for LOC calculation.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-			http.Error(w, fmt.Sprintf("Error running command: %v", cmdErr), http.StatusInternalServerError)
+			writeError(w, r, "github", internalError("Error counting lines of code."))
 			return
 		}
 
@@ -399,7 +431,7 @@ func (ghAPI *GithubApi) GetRepoTotalLinesOfCode(w http.ResponseWriter, r *http.R
 		if extractErr != nil {
 			logCtx.WithFields(logrus.Fields{"raw_output": output, "error": extractErr}).Error("Error extracting total lines from command output.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-			http.Error(w, fmt.Sprintf("Error extracting total lines: %v", extractErr), http.StatusInternalServerError)
+			writeError(w, r, "github", internalError("Error counting lines of code."))
 			return
 		}
 
@@ -408,12 +440,13 @@ func (ghAPI *GithubApi) GetRepoTotalLinesOfCode(w http.ResponseWriter, r *http.R
 		if marshalErr != nil {
 			logCtx.WithField("error", marshalErr).Error("Error marshalling LOC result.")
 			appMetrics.APICallsTotal.WithLabelValues("github", endpointName, "failure").Inc()
-			http.Error(w, fmt.Sprintf("Error encoding LOC JSON: %v", marshalErr), http.StatusInternalServerError)
+			writeError(w, r, "github", internalError("Error encoding response."))
 			return
 		}
 		linesOfCodeResult = jsonResult
-		// Cache LOC result for 24 hours (86400 seconds).
-		if setErr := ghAPI.Redis.Set(redisKey, linesOfCodeResult, 86400); setErr != nil {
+		jobs.ReportProgress(r.Context(), 2, 3, "caching result")
+		// Cache LOC result for 24 hours.
+		if setErr := ghAPI.cache(r).Set(redisKey, linesOfCodeResult, 24*time.Hour); setErr != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": setErr}).Error("Redis SET error for LOC.")
 		}
 		w.Write(linesOfCodeResult)
@@ -426,11 +459,12 @@ func (ghAPI *GithubApi) GetRepoTotalLinesOfCode(w http.ResponseWriter, r *http.R
 }
 
 // cloneRepository is a helper function to clone a Git repository into a specified directory.
-func cloneRepository(repoURL, targetDirectory string) error {
+// The clone is killed when ctx is done.
+func cloneRepository(ctx context.Context, repoURL, targetDirectory string) error {
 	log.WithFields(logrus.Fields{"repo_url": repoURL, "target_dir": targetDirectory}).Info("Cloning repository...")
 	// Basic git clone command. For production, consider more robust error handling,
 	// authentication for private repos (if needed, though tokens are server-side), and depth control.
-	cmd := exec.Command("git", "clone", "--depth", "1", repoURL, targetDirectory) // Shallow clone for LOC.
+	cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", repoURL, targetDirectory) // Shallow clone for LOC.
 	err := cmd.Run()
 	if err != nil {
 		log.WithFields(logrus.Fields{"repo_url": repoURL, "error": err}).Error("Failed to clone repository.")
@@ -441,10 +475,10 @@ func cloneRepository(repoURL, targetDirectory string) error {
 }
 
 // runCommand is a helper function to execute a shell command in a given working directory.
-// It returns the command's standard output as a string.
-func runCommand(commandString, workingDir string) (string, error) {
+// It returns the command's standard output as a string. The command is killed when ctx is done.
+func runCommand(ctx context.Context, commandString, workingDir string) (string, error) {
 	log.WithFields(logrus.Fields{"command": commandString, "cwd": workingDir}).Info("Running command...")
-	cmd := exec.Command("bash", "-c", commandString) // Use bash to interpret pipes, etc.
+	cmd := exec.CommandContext(ctx, "bash", "-c", commandString) // Use bash to interpret pipes, etc.
 	cmd.Dir = workingDir
 	outputBytes, err := cmd.Output() // Runs command and gets stdout. Use CombinedOutput for stdout & stderr.
 	if err != nil {
@@ -479,7 +513,7 @@ func extractTotalLines(wcOutput string) (int, error) {
 	var totalLines int
 	// Try to parse " <number> total"
 	if _, err := fmt.Sscanf(lastLine, "%d total", &totalLines); err == nil {
-		log.WithField("total_lines", totalLines).Debug("Total lines extracted using 'sscanf %d total'.")
+		log.WithField("total_lines", totalLines).Debug("Total lines extracted using sscanf on the 'total' line.")
 		return totalLines, nil
 	}
 	
@@ -14,73 +14,6 @@ import (
 	// "github.com/ahmetk3436/git-stats-golang/internal" // For concrete RedisClient, if not mocking InMemoryDB interface
 )
 
-// --- Mocks ---
-
-// MockGitService is a mock implementation of interfaces.GitService
-type MockGitService struct {
-	GetAllReposFunc         func(owner string) ([]*common_types.Repository, error)
-	GetRepoFunc             func(identifier interface{}) (*common_types.Repository, error)
-	GetProjectCommitsFunc   func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error)
-	GetRepoContributorsFunc func(repoIdentifier interface{}) ([]*common_types.User, error)
-}
-
-func (m *MockGitService) GetAllRepos(owner string) ([]*common_types.Repository, error) {
-	if m.GetAllReposFunc != nil {
-		return m.GetAllReposFunc(owner)
-	}
-	return nil, errors.New("GetAllReposFunc not implemented")
-}
-
-func (m *MockGitService) GetRepo(identifier interface{}) (*common_types.Repository, error) {
-	if m.GetRepoFunc != nil {
-		return m.GetRepoFunc(identifier)
-	}
-	return nil, errors.New("GetRepoFunc not implemented")
-}
-
-func (m *MockGitService) GetProjectCommits(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
-	if m.GetProjectCommitsFunc != nil {
-		return m.GetProjectCommitsFunc(repoIdentifier, options)
-	}
-	return nil, errors.New("GetProjectCommitsFunc not implemented")
-}
-
-func (m *MockGitService) GetRepoContributors(repoIdentifier interface{}) ([]*common_types.User, error) {
-	if m.GetRepoContributorsFunc != nil {
-		return m.GetRepoContributorsFunc(repoIdentifier)
-	}
-	return nil, errors.New("GetRepoContributorsFunc not implemented")
-}
-
-// MockRedisClient is a mock implementation of storage.InMemoryDB (or the relevant interface for Redis operations)
-// Assuming storage.InMemoryDB has Get and Set methods.
-type MockRedisClient struct {
-	GetFunc func(key string) ([]byte, error)
-	SetFunc func(key string, value interface{}, expirationInSeconds time.Duration) error
-	// Add DeleteFunc if needed
-}
-
-func (m *MockRedisClient) Get(key string) ([]byte, error) {
-	if m.GetFunc != nil {
-		return m.GetFunc(key)
-	}
-	return nil, errors.New("GetFunc not implemented in MockRedisClient")
-}
-
-func (m *MockRedisClient) Set(key string, value interface{}, expirationInSeconds time.Duration) error {
-	if m.SetFunc != nil {
-		// The value in the real Redis client is often marshalled before Set.
-		// Here, we might just pass it through or simulate marshalling if necessary for the test.
-		return m.SetFunc(key, value, expirationInSeconds)
-	}
-	return errors.New("SetFunc not implemented in MockRedisClient")
-}
-func (m *MockRedisClient) Delete(key string) error {
-    //TODO implement me
-    panic("implement me")
-}
-
-
 // --- Tests for GithubApi Handlers ---
 
 func TestGithubApi_GetAllRepos_Success_NoCache(t *testing.T) {
@@ -115,8 +48,7 @@ func TestGithubApi_GetAllRepos_Success_NoCache(t *testing.T) {
 		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
 	}
 
-	expectedBody := `[{"ID":1,"Name":"repo1","Owner":"owner1","HTMLURL":"","CloneURL":"","Description":"","CreatedAt":"0001-01-01T00:00:00Z","UpdatedAt":"0001-01-01T00:00:00Z","Stars":0,"Forks":0,"OpenIssues":0}]`
-	// Note: Time fields will be zero value. Marshal common_types.Repository to be sure of exact expected output.
+	// Note: Time fields will be zero value, so only the identifying fields are compared.
 	var respRepos []*common_types.Repository
 	if err := json.Unmarshal(rr.Body.Bytes(), &respRepos); err != nil {
 		t.Fatalf("Could not unmarshal response body: %v", err)
@@ -2,17 +2,14 @@ package api
 
 import (
 	"encoding/json"
-	// "fmt" // Replaced by logrus for structured logging
-	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
-	"github.com/ahmetk3436/git-stats-golang/pkg/prometheus"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
 	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
 	appMetrics "github.com/ahmetk3436/git-stats-golang/pkg/prometheus" // Alias for clarity
-	"github.com/ahmetk3436/git-stats-golang/pkg/repository"
 	"github.com/sirupsen/logrus"
 )
 
@@ -33,17 +30,22 @@ func init() {
 // GitlabApi handles API requests related to GitLab.
 // It uses a GitService for interacting with GitLab and a RedisClient for caching.
 type GitlabApi struct {
-	Repo  interfaces.GitService // Service for Git operations (GitLab specific implementation).
-	Redis *storage.RedisClient  // Client for Redis caching.
+	Repo     interfaces.GitService // Service for Git operations (GitLab specific implementation).
+	Redis    storage.InMemoryDB    // Cache backend (Redis in production).
+	Defaults AnalyticsDefaults     // Used when a request leaves the matching query parameter out.
+
+	// TokenService, when set, builds the GitService for a caller's forwarded provider token.
+	TokenService func(token string) (interfaces.GitService, error)
 }
 
 // NewGitlabApi creates a new instance of GitlabApi.
 // It requires a GitService implementation (e.g., *repository.Gitlab) and a RedisClient.
-func NewGitlabApi(gitService interfaces.GitService, redisClient *storage.RedisClient) *GitlabApi {
+func NewGitlabApi(gitService interfaces.GitService, redisClient storage.InMemoryDB) *GitlabApi {
 	log.Info("Creating NewGitlabApi with GitService interface.")
 	return &GitlabApi{
-		Repo:  gitService,
-		Redis: redisClient,
+		Repo:     gitService,
+		Redis:    redisClient,
+		Defaults: DefaultAnalytics(),
 	}
 }
 
@@ -64,8 +66,8 @@ func (glAPI *GitlabApi) GetAllRepos(w http.ResponseWriter, r *http.Request) {
 	var err error
 	dataSource := "API"
 
-	redisKey := fmt.Sprintf("gitlab_get_all_repos_%s", ownerQueryParam)
-	cachedData, redisErr := glAPI.Redis.Get(redisKey)
+	redisKey := responseCacheKey("gitlab", "all_repos", ownerQueryParam)
+	cachedData, redisErr := glAPI.cache(r).Get(redisKey)
 
 	if redisErr == nil && cachedData != nil {
 		logCtx.WithField("key", redisKey).Info("Cache hit for GetAllRepos.")
@@ -73,7 +75,7 @@ func (glAPI *GitlabApi) GetAllRepos(w http.ResponseWriter, r *http.Request) {
 		if err = json.Unmarshal(cachedData, &reposFromSource); err != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": err}).Error("Error unmarshalling cached data for GetAllRepos.")
 			appMetrics.APICallsTotal.WithLabelValues("gitlab", endpointName, "failure").Inc()
-			http.Error(w, "Error processing cached data.", http.StatusInternalServerError)
+			writeError(w, r, "gitlab", internalError("Error processing cached data."))
 			return
 		}
 		w.Write(cachedData) // Serve directly from cache.
@@ -84,12 +86,12 @@ func (glAPI *GitlabApi) GetAllRepos(w http.ResponseWriter, r *http.Request) {
 			logCtx.WithField("key", redisKey).Info("Cache miss for GetAllRepos; fetching from API.")
 		}
 		dataSource = "API"
-		fetchedRepos, fetchErr := glAPI.Repo.GetAllRepos(ownerQueryParam)
+		fetchedRepos, fetchErr := glAPI.gitService(r).GetAllRepos(ownerQueryParam)
 		if fetchErr != nil {
 			logCtx.WithField("error", fetchErr).Error("Error fetching repos from GitLab via GitService.")
 			appMetrics.APICallsTotal.WithLabelValues("gitlab", endpointName, "failure").Inc()
 			appMetrics.RepositoryFetchesTotal.WithLabelValues("gitlab", "all_repos", "failure").Inc()
-			http.Error(w, fetchErr.Error(), http.StatusInternalServerError)
+			writeError(w, r, "gitlab", providerError(fetchErr))
 			return
 		}
 		appMetrics.RepositoryFetchesTotal.WithLabelValues("gitlab", "all_repos", "success").Inc()
@@ -99,10 +101,10 @@ func (glAPI *GitlabApi) GetAllRepos(w http.ResponseWriter, r *http.Request) {
 		if marshalErr != nil {
 			logCtx.WithField("error", marshalErr).Error("Error marshalling repos response.")
 			appMetrics.APICallsTotal.WithLabelValues("gitlab", endpointName, "failure").Inc()
-			http.Error(w, marshalErr.Error(), http.StatusInternalServerError)
+			writeError(w, r, "gitlab", internalError("Error encoding response."))
 			return
 		}
-		if setErr := glAPI.Redis.Set(redisKey, responseBytes, 3600); setErr != nil { // Cache for 1 hour.
+		if setErr := glAPI.cache(r).Set(redisKey, responseBytes, CacheTTL); setErr != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": setErr}).Error("Redis SET error for GetAllRepos.")
 		}
 		w.Write(responseBytes)
@@ -119,16 +121,16 @@ func (glAPI *GitlabApi) GetAllRepos(w http.ResponseWriter, r *http.Request) {
 func (glAPI *GitlabApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	endpointName := "/api/gitlab/repo"
-	// "projectID" is the query parameter, but it can be an ID or a path for GitLab.
-	repoIdentifierQuery := r.URL.Query().Get("projectID")
+	// "project" can be an ID or a path for GitLab; "projectID" is its deprecated alias.
+	repoIdentifierQuery := queryParam(w, r, ParamProject)
 	logCtx := log.WithFields(logrus.Fields{"endpoint": endpointName, "method": r.Method, "identifier_query": repoIdentifierQuery, "provider": "gitlab"})
 	logCtx.Info("GetRepo request received.")
 	w.Header().Set("Content-Type", "application/json")
 
 	if repoIdentifierQuery == "" {
-		logCtx.Error("Missing projectID query parameter.")
+		logCtx.Error("Missing project query parameter.")
 		appMetrics.APICallsTotal.WithLabelValues("gitlab", endpointName, "failure").Inc()
-		http.Error(w, "projectID query parameter is required.", http.StatusBadRequest)
+		writeError(w, r, "gitlab", invalidRequest("project query parameter is required."))
 		return
 	}
 
@@ -136,8 +138,8 @@ func (glAPI *GitlabApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 	var err error
 	dataSource := "API"
 
-	redisKey := "gitlab_get_repo_" + repoIdentifierQuery
-	cachedData, redisErr := glAPI.Redis.Get(redisKey)
+	redisKey := responseCacheKey("gitlab", "repo", repoIdentifierQuery)
+	cachedData, redisErr := glAPI.cache(r).Get(redisKey)
 
 	if redisErr == nil && cachedData != nil {
 		logCtx.WithField("key", redisKey).Info("Cache hit for GetRepo.")
@@ -145,7 +147,7 @@ func (glAPI *GitlabApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 		if err = json.Unmarshal(cachedData, &repoData); err != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": err}).Error("Error unmarshalling cached data for GetRepo.")
 			appMetrics.APICallsTotal.WithLabelValues("gitlab", endpointName, "failure").Inc()
-			http.Error(w, "Error processing cached data.", http.StatusInternalServerError)
+			writeError(w, r, "gitlab", internalError("Error processing cached data."))
 			return
 		}
 		w.Write(cachedData)
@@ -164,15 +166,15 @@ func (glAPI *GitlabApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 			identifierToFetch = parsedID // Use int ID if parsing succeeds.
 		} else {
 			identifierToFetch = repoIdentifierQuery // Otherwise, assume it's a "namespace/path" string.
-			logCtx.WithField("input", repoIdentifierQuery).Debug("Interpreting projectID as namespace/path string due to Atoi failure.")
+			logCtx.WithField("input", repoIdentifierQuery).Debug("Interpreting project as namespace/path string due to Atoi failure.")
 		}
 
-		fetchedRepo, fetchErr := glAPI.Repo.GetRepo(identifierToFetch)
+		fetchedRepo, fetchErr := glAPI.gitService(r).GetRepo(identifierToFetch)
 		if fetchErr != nil {
 			logCtx.WithFields(logrus.Fields{"identifier": identifierToFetch, "error": fetchErr}).Error("Error fetching repo from GitLab via GitService.")
 			appMetrics.APICallsTotal.WithLabelValues("gitlab", endpointName, "failure").Inc()
 			appMetrics.RepositoryFetchesTotal.WithLabelValues("gitlab", "single_repo", "failure").Inc()
-			http.Error(w, "Cannot get project: "+fetchErr.Error(), http.StatusBadRequest)
+			writeError(w, r, "gitlab", providerError(fetchErr))
 			return
 		}
 		appMetrics.RepositoryFetchesTotal.WithLabelValues("gitlab", "single_repo", "success").Inc()
@@ -182,10 +184,10 @@ func (glAPI *GitlabApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 		if marshalErr != nil {
 			logCtx.WithField("error", marshalErr).Error("Error marshalling repo response.")
 			appMetrics.APICallsTotal.WithLabelValues("gitlab", endpointName, "failure").Inc()
-			http.Error(w, "Error marshalling project data.", http.StatusInternalServerError)
+			writeError(w, r, "gitlab", internalError("Error marshalling project data."))
 			return
 		}
-		if setErr := glAPI.Redis.Set(redisKey, responseBytes, 3600); setErr != nil { // Cache for 1 hour.
+		if setErr := glAPI.cache(r).Set(redisKey, responseBytes, CacheTTL); setErr != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": setErr}).Error("Redis SET error for GetRepo.")
 		}
 		w.Write(responseBytes)
@@ -198,20 +200,20 @@ func (glAPI *GitlabApi) GetRepo(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetAllCommits handles requests to get all commits for a specific GitLab repository.
-// Repository is identified by 'projectID' query parameter (ID or namespace/path).
+// Repository is identified by 'project' query parameter (ID or namespace/path).
 // It checks cache first and falls back to the GitService.
 func (glAPI *GitlabApi) GetAllCommits(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	endpointName := "/api/gitlab/commits"
-	repoIdentifierQuery := r.URL.Query().Get("projectID") // Can be ID or namespace/path.
+	repoIdentifierQuery := queryParam(w, r, ParamProject) // Can be ID or namespace/path.
 	logCtx := log.WithFields(logrus.Fields{"endpoint": endpointName, "method": r.Method, "identifier_query": repoIdentifierQuery, "provider": "gitlab"})
 	logCtx.Info("GetAllCommits request received.")
 	w.Header().Set("Content-Type", "application/json")
 
 	if repoIdentifierQuery == "" {
-		logCtx.Error("Missing projectID query parameter.")
+		logCtx.Error("Missing project query parameter.")
 		appMetrics.APICallsTotal.WithLabelValues("gitlab", endpointName, "failure").Inc()
-		http.Error(w, "projectID query parameter is required.", http.StatusBadRequest)
+		writeError(w, r, "gitlab", invalidRequest("project query parameter is required."))
 		return
 	}
 
@@ -219,8 +221,8 @@ func (glAPI *GitlabApi) GetAllCommits(w http.ResponseWriter, r *http.Request) {
 	var err error
 	dataSource := "API"
 
-	redisKey := "gitlab_get_commits_" + repoIdentifierQuery
-	cachedData, redisErr := glAPI.Redis.Get(redisKey)
+	redisKey := commitsCacheKey("gitlab", repoIdentifierQuery)
+	cachedData, redisErr := glAPI.cache(r).Get(redisKey)
 
 	if redisErr == nil && cachedData != nil {
 		logCtx.WithField("key", redisKey).Info("Cache hit for GetAllCommits.")
@@ -228,7 +230,7 @@ func (glAPI *GitlabApi) GetAllCommits(w http.ResponseWriter, r *http.Request) {
 		if err = json.Unmarshal(cachedData, &commitsFromSource); err != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": err}).Error("Error unmarshalling cached data for GetAllCommits.")
 			appMetrics.APICallsTotal.WithLabelValues("gitlab", endpointName, "failure").Inc()
-			http.Error(w, "Error processing cached data.", http.StatusInternalServerError)
+			writeError(w, r, "gitlab", internalError("Error processing cached data."))
 			return
 		}
 		w.Write(cachedData)
@@ -246,18 +248,18 @@ func (glAPI *GitlabApi) GetAllCommits(w http.ResponseWriter, r *http.Request) {
 			identifierToFetch = parsedID
 		} else {
 			identifierToFetch = repoIdentifierQuery
-			logCtx.WithField("input", repoIdentifierQuery).Debug("Interpreting projectID as namespace/path string due to Atoi failure.")
+			logCtx.WithField("input", repoIdentifierQuery).Debug("Interpreting project as namespace/path string due to Atoi failure.")
 		}
 
 		// TODO: Populate CommitListOptions from query params if needed (e.g., branch, page).
 		commitOpts := &interfaces.CommitListOptions{PerPage: 100} // Default: 100 commits.
 
-		fetchedCommits, fetchErr := glAPI.Repo.GetProjectCommits(identifierToFetch, commitOpts)
+		fetchedCommits, fetchErr := glAPI.gitService(r).GetProjectCommits(identifierToFetch, commitOpts)
 		if fetchErr != nil {
 			logCtx.WithFields(logrus.Fields{"identifier": identifierToFetch, "error": fetchErr}).Error("Error fetching commits from GitLab via GitService.")
 			appMetrics.APICallsTotal.WithLabelValues("gitlab", endpointName, "failure").Inc()
 			appMetrics.RepositoryFetchesTotal.WithLabelValues("gitlab", "commits", "failure").Inc()
-			http.Error(w, fetchErr.Error(), http.StatusInternalServerError)
+			writeError(w, r, "gitlab", providerError(fetchErr))
 			return
 		}
 		appMetrics.RepositoryFetchesTotal.WithLabelValues("gitlab", "commits", "success").Inc()
@@ -267,10 +269,10 @@ func (glAPI *GitlabApi) GetAllCommits(w http.ResponseWriter, r *http.Request) {
 		if marshalErr != nil {
 			logCtx.WithField("error", marshalErr).Error("Error marshalling commits response.")
 			appMetrics.APICallsTotal.WithLabelValues("gitlab", endpointName, "failure").Inc()
-			http.Error(w, marshalErr.Error(), http.StatusInternalServerError)
+			writeError(w, r, "gitlab", internalError("Error encoding response."))
 			return
 		}
-		if setErr := glAPI.Redis.Set(redisKey, responseBytes, 3600); setErr != nil { // Cache for 1 hour.
+		if setErr := glAPI.cache(r).Set(redisKey, responseBytes, CacheTTL); setErr != nil {
 			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": setErr}).Error("Redis SET error for GetAllCommits.")
 		}
 		w.Write(responseBytes)
@@ -14,73 +14,6 @@ import (
 	// storage "github.com/ahmetk3436/git-stats-golang/internal" // For concrete type if not using interface for Redis
 )
 
-// --- Mocks (can be shared from github_api_test.go if in the same package, or defined in a common test utility file) ---
-
-// MockGitService is a mock implementation of interfaces.GitService (redefined here for clarity, ideally shared)
-type MockGitService struct {
-	GetAllReposFunc         func(owner string) ([]*common_types.Repository, error)
-	GetRepoFunc             func(identifier interface{}) (*common_types.Repository, error)
-	GetProjectCommitsFunc   func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error)
-	GetRepoContributorsFunc func(repoIdentifier interface{}) ([]*common_types.User, error)
-}
-
-func (m *MockGitService) GetAllRepos(owner string) ([]*common_types.Repository, error) {
-	if m.GetAllReposFunc != nil {
-		return m.GetAllReposFunc(owner)
-	}
-	return nil, errors.New("GetAllReposFunc not implemented")
-}
-
-func (m *MockGitService) GetRepo(identifier interface{}) (*common_types.Repository, error) {
-	if m.GetRepoFunc != nil {
-		return m.GetRepoFunc(identifier)
-	}
-	return nil, errors.New("GetRepoFunc not implemented")
-}
-
-func (m *MockGitService) GetProjectCommits(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
-	if m.GetProjectCommitsFunc != nil {
-		return m.GetProjectCommitsFunc(repoIdentifier, options)
-	}
-	return nil, errors.New("GetProjectCommitsFunc not implemented")
-}
-
-func (m *MockGitService) GetRepoContributors(repoIdentifier interface{}) ([]*common_types.User, error) {
-	if m.GetRepoContributorsFunc != nil {
-		return m.GetRepoContributorsFunc(repoIdentifier)
-	}
-	return nil, errors.New("GetRepoContributorsFunc not implemented")
-}
-
-// MockRedisClient (redefined here for clarity, ideally shared)
-type MockRedisClient struct {
-	GetFunc func(key string) ([]byte, error)
-	SetFunc func(key string, value interface{}, expirationInSeconds time.Duration) error
-	DeleteFunc func(key string) error
-}
-
-func (m *MockRedisClient) Get(key string) ([]byte, error) {
-	if m.GetFunc != nil {
-		return m.GetFunc(key)
-	}
-	return nil, errors.New("GetFunc not implemented")
-}
-
-func (m *MockRedisClient) Set(key string, value interface{}, expirationInSeconds time.Duration) error {
-	if m.SetFunc != nil {
-		return m.SetFunc(key, value, expirationInSeconds)
-	}
-	return errors.New("SetFunc not implemented")
-}
-
-func (m *MockRedisClient) Delete(key string) error {
-	if m.DeleteFunc != nil {
-		return m.DeleteFunc(key)
-	}
-	return errors.New("DeleteFunc not implemented")
-}
-
-
 // --- Tests for GitlabApi Handlers ---
 
 func TestGitlabApi_GetAllRepos_Success_NoCache(t *testing.T) {
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	appMetrics "github.com/ahmetk3436/git-stats-golang/pkg/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// AnalyticsDefaults holds the values the analytics endpoints use when a request leaves the
+// matching query parameter out.
+type AnalyticsDefaults struct {
+	Location         *time.Location // Time zone of activity heatmaps ('tz').
+	WorkdayStartHour int            // First working hour, inclusive ('workStart').
+	WorkdayEndHour   int            // End of working hours, exclusive ('workEnd').
+	StaleBranchDays  int            // Staleness threshold of the branch report ('days').
+}
+
+// DefaultAnalytics returns the built-in AnalyticsDefaults: UTC, 9-18 and DefaultStaleBranchDays.
+func DefaultAnalytics() AnalyticsDefaults {
+	return AnalyticsDefaults{
+		Location:         time.UTC,
+		WorkdayStartHour: analytics.DefaultWorkdayStartHour,
+		WorkdayEndHour:   analytics.DefaultWorkdayEndHour,
+		StaleBranchDays:  DefaultStaleBranchDays,
+	}
+}
+
+// rejectRequest logs, counts and answers a request that failed validation.
+func rejectRequest(w http.ResponseWriter, r *http.Request, provider, endpointName, message string, status int) {
+	log.WithFields(logrus.Fields{"endpoint": endpointName, "provider": provider, "request_id": requestIDFromContext(r.Context())}).Error(message)
+	appMetrics.APICallsTotal.WithLabelValues(provider, endpointName, "failure").Inc()
+	apiErr := invalidRequest(message)
+	apiErr.Status = status
+	writeError(w, r, provider, apiErr)
+}
+
+// CacheTTL is how long provider responses are cached unless a CachePolicy says otherwise.
+const CacheTTL = time.Hour
+
+// responseCacheKey is the cache key of the kind response, e.g. "activity", of cacheKeyRepo:
+// "<provider>_get_<kind>_<repo>", followed by ":" and params joined by "_" when there are any.
+// Repositories cannot contain a ":", so it tells where the repository ends: the responses of
+// "octo-org/hello" are not mistaken for those of "octo-org/hello_world".
+func responseCacheKey(provider, kind, cacheKeyRepo string, params ...interface{}) string {
+	key := provider + "_get_" + kind + "_" + cacheKeyRepo
+	if len(params) == 0 {
+		return key
+	}
+	values := make([]string, len(params))
+	for i, param := range params {
+		values[i] = fmt.Sprint(param)
+	}
+	return key + ":" + strings.Join(values, "_")
+}
+
+// commitsCacheKey is the cache key of the commits of cacheKeyRepo: "owner_repo" on GitHub, the
+// 'project' parameter as given on GitLab.
+func commitsCacheKey(provider, cacheKeyRepo string) string {
+	return responseCacheKey(provider, "commits", cacheKeyRepo)
+}
+
+// serveCachedJSON implements the cache-aside flow shared by the analytics handlers:
+// serve redisKey from cache if present, otherwise call fetch, marshal its result,
+// cache it for ttl and write it out. operation labels the
+// gits_repository_fetches_total metric for the provider call.
+func serveCachedJSON(w http.ResponseWriter, r *http.Request, provider, endpointName, operation string, cache storage.InMemoryDB, redisKey string, ttl time.Duration, fetch func() (interface{}, error)) {
+	serveCached(w, r, provider, endpointName, operation, cache, redisKey, ttl, "application/json", fetch, json.Marshal)
+}
+
+// serveCached is serveCachedJSON with a caller-chosen content type and encoder.
+func serveCached(w http.ResponseWriter, r *http.Request, provider, endpointName, operation string, cache storage.InMemoryDB, redisKey string, ttl time.Duration, contentType string, fetch func() (interface{}, error), encode func(interface{}) ([]byte, error)) {
+	startTime := time.Now()
+	logCtx := log.WithFields(logrus.Fields{"endpoint": endpointName, "method": r.Method, "provider": provider, "request_id": requestIDFromContext(r.Context())})
+	logCtx.Info("Request received.")
+	w.Header().Set("Content-Type", contentType)
+
+	dataSource := "API"
+	cachedData, redisErr := cache.Get(redisKey)
+
+	if redisErr == nil && cachedData != nil {
+		logCtx.WithField("key", redisKey).Info("Cache hit.")
+		dataSource = "Redis"
+		w.Write(cachedData)
+	} else {
+		if redisErr != nil {
+			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": redisErr}).Warn("Redis GET error; proceeding to fetch from API.")
+		} else {
+			logCtx.WithField("key", redisKey).Info("Cache miss; fetching from API.")
+		}
+
+		result, fetchErr := fetch()
+		if fetchErr != nil {
+			logCtx.WithField("error", fetchErr).Error("Error fetching data via GitService.")
+			appMetrics.APICallsTotal.WithLabelValues(provider, endpointName, "failure").Inc()
+			appMetrics.RepositoryFetchesTotal.WithLabelValues(provider, operation, "failure").Inc()
+			writeError(w, r, provider, providerError(fetchErr))
+			return
+		}
+		appMetrics.RepositoryFetchesTotal.WithLabelValues(provider, operation, "success").Inc()
+
+		responseBytes, encodeErr := encode(result)
+		if encodeErr != nil {
+			logCtx.WithField("error", encodeErr).Error("Error encoding response.")
+			appMetrics.APICallsTotal.WithLabelValues(provider, endpointName, "failure").Inc()
+			writeError(w, r, provider, internalError("Error encoding response."))
+			return
+		}
+		if setErr := cache.Set(redisKey, responseBytes, ttl); setErr != nil {
+			logCtx.WithFields(logrus.Fields{"key": redisKey, "error": setErr}).Error("Redis SET error.")
+		}
+		w.Write(responseBytes)
+	}
+
+	duration := time.Since(startTime).Seconds()
+	appMetrics.APICallDuration.WithLabelValues(provider, endpointName).Observe(duration)
+	appMetrics.APICallsTotal.WithLabelValues(provider, endpointName, "success").Inc()
+	logCtx.WithFields(logrus.Fields{"duration_seconds": duration, "source": dataSource}).Info("Request processed successfully.")
+}
+
+// writeJSON answers r with status and v as JSON.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		log.WithFields(logrus.Fields{"path": r.URL.Path, "error": err}).Error("Failed to marshal response.")
+		writeError(w, r, "", internalError("Error encoding response."))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		log.WithFields(logrus.Fields{"path": r.URL.Path, "error": err}).Error("Error writing response.")
+	}
+}
+
+// bufferedResponse is an http.ResponseWriter keeping the response in memory, for requests the
+// server serves to itself: replayed jobs and scheduled cache refreshes.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: http.Header{}}
+}
+
+func (r *bufferedResponse) Header() http.Header { return r.header }
+
+func (r *bufferedResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *bufferedResponse) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(p)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// GetIssueStats handles requests for issue analytics of a GitHub repository.
+// Repository is identified by 'owner' and 'repo' query parameters.
+func (ghAPI *GithubApi) GetIssueStats(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, ok := githubRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "github", "/api/github/issues/stats", "owner and repo query parameters are required.", http.StatusBadRequest)
+		return
+	}
+	serveIssueStats(w, r, "github", ghAPI.gitService(r), ghAPI.cache(r), repoIdentifier, strings.Replace(repoIdentifier, "/", "_", 1))
+}
+
+// GetIssueStats handles requests for issue analytics of a GitLab repository.
+// Repository is identified by 'project' query parameter (ID or namespace/path).
+func (glAPI *GitlabApi) GetIssueStats(w http.ResponseWriter, r *http.Request) {
+	repoIdentifier, repoIdentifierQuery, ok := gitlabRepoFromQuery(w, r)
+	if !ok {
+		rejectRequest(w, r, "gitlab", "/api/gitlab/issues/stats", "project query parameter is required.", http.StatusBadRequest)
+		return
+	}
+	serveIssueStats(w, r, "gitlab", glAPI.gitService(r), glAPI.cache(r), repoIdentifier, repoIdentifierQuery)
+}
+
+// serveIssueStats is the provider-agnostic part of the issue statistics handlers.
+// Optional query parameters: 'labels' (comma separated) and 'since' (RFC 3339).
+// Computed statistics are cached per repository and filter combination.
+func serveIssueStats(w http.ResponseWriter, r *http.Request, provider string, gitService interfaces.GitService, cache storage.InMemoryDB, repoIdentifier interface{}, cacheKeyRepo string) {
+	endpointName := fmt.Sprintf("/api/%s/issues/stats", provider)
+
+	issueOpts := &interfaces.IssueListOptions{State: interfaces.IssueStateAll}
+	labelsQuery := r.URL.Query().Get("labels")
+	if labelsQuery != "" {
+		issueOpts.Labels = strings.Split(labelsQuery, ",")
+	}
+	sinceQuery := r.URL.Query().Get("since")
+	if sinceQuery != "" {
+		since, parseErr := time.Parse(time.RFC3339, sinceQuery)
+		if parseErr != nil {
+			rejectRequest(w, r, provider, endpointName, "since query parameter must be an RFC 3339 timestamp.", http.StatusBadRequest)
+			return
+		}
+		issueOpts.Since = since
+	}
+
+	redisKey := responseCacheKey(provider, "issue_stats", cacheKeyRepo, labelsQuery, sinceQuery)
+	serveCachedJSON(w, r, provider, endpointName, "issues", cache, redisKey, CacheTTL, func() (interface{}, error) {
+		issues, err := gitService.ListIssues(repoIdentifier, issueOpts)
+		if err != nil {
+			return nil, err
+		}
+		return analytics.ComputeIssueStats(issues, time.Now()), nil
+	})
+}
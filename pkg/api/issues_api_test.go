@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+func TestGithubApi_GetIssueStats_Success_NoCache(t *testing.T) {
+	created := time.Now().Add(-48 * time.Hour)
+	mockGitService := &MockGitService{
+		ListIssuesFunc: func(repoIdentifier interface{}, options *interfaces.IssueListOptions) ([]*common_types.Issue, error) {
+			if repoIdentifier != "test-owner/test-repo" {
+				return nil, errors.New("unexpected repoIdentifier in mock ListIssuesFunc")
+			}
+			if options.State != interfaces.IssueStateAll || len(options.Labels) != 2 {
+				t.Errorf("unexpected issue list options: %+v", options)
+			}
+			return []*common_types.Issue{
+				{Number: 1, State: "open", CreatedAt: created},
+				{Number: 2, State: "closed", Labels: []string{"bug"}, CreatedAt: created, ClosedAt: created.Add(time.Hour)},
+			}, nil
+		},
+	}
+	var cachedKey string
+	mockRedisClient := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) { return nil, nil }, // Cache miss
+		SetFunc: func(key string, value interface{}, expirationInSeconds time.Duration) error {
+			cachedKey = key
+			return nil
+		},
+	}
+	githubAPI := NewGithubApi(mockGitService, mockRedisClient)
+
+	req, _ := http.NewRequest("GET", "/api/github/issues/stats?owner=test-owner&repoName=test-repo&labels=bug,ui", nil)
+	rr := httptest.NewRecorder()
+	githubAPI.GetIssueStats(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("GetIssueStats returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	var stats analytics.IssueStats
+	if err := json.Unmarshal(rr.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("GetIssueStats could not unmarshal response: %v", err)
+	}
+	if stats.Total != 2 || stats.Open != 1 || stats.Closed != 1 || len(stats.TimeToCloseByLabel) != 1 {
+		t.Errorf("GetIssueStats returned unexpected body: %s", rr.Body.String())
+	}
+	if cachedKey != "github_get_issue_stats_test-owner_test-repo:bug,ui_" {
+		t.Errorf("GetIssueStats cached under unexpected key %q", cachedKey)
+	}
+}
+
+func TestGitlabApi_GetIssueStats_ParsesProjectID(t *testing.T) {
+	mockGitService := &MockGitService{
+		ListIssuesFunc: func(repoIdentifier interface{}, options *interfaces.IssueListOptions) ([]*common_types.Issue, error) {
+			if id, ok := repoIdentifier.(int); !ok || id != 42 {
+				t.Errorf("expected int project ID 42, got %#v", repoIdentifier)
+			}
+			return []*common_types.Issue{}, nil
+		},
+	}
+	mockRedisClient := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) { return nil, nil },
+		SetFunc: func(key string, value interface{}, expirationInSeconds time.Duration) error { return nil },
+	}
+	gitlabAPI := NewGitlabApi(mockGitService, mockRedisClient)
+
+	req, _ := http.NewRequest("GET", "/api/gitlab/issues/stats?projectID=42", nil)
+	rr := httptest.NewRecorder()
+	gitlabAPI.GetIssueStats(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("GetIssueStats returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+}
+
+func TestGetIssueStats_BadRequests(t *testing.T) {
+	githubAPI := NewGithubApi(&MockGitService{}, &MockRedisClient{})
+	gitlabAPI := NewGitlabApi(&MockGitService{}, &MockRedisClient{})
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+	}{
+		{"github missing repoName", githubAPI.GetIssueStats, "/api/github/issues/stats?owner=o"},
+		{"github invalid since", githubAPI.GetIssueStats, "/api/github/issues/stats?owner=o&repoName=r&since=yesterday"},
+		{"gitlab missing projectID", gitlabAPI.GetIssueStats, "/api/gitlab/issues/stats"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("%s returned wrong status code: got %v want %v", tt.name, status, http.StatusBadRequest)
+			}
+		})
+	}
+}
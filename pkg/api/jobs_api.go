@@ -0,0 +1,281 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/jobs"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// JobsPath is the path below /api/v1 and /api where jobs are submitted and followed.
+const JobsPath = "/jobs"
+
+// JobsApi runs provider endpoints as background jobs. A job replays the GET request it was
+// submitted with through Handler, so it passes the same authentication, access policy, forwarded
+// token and cache as the request would have; the request headers are kept in memory only and
+// never written to the job store.
+type JobsApi struct {
+	Queue   *jobs.Queue
+	Handler http.Handler // Serves replayed requests: the router the provider endpoints are registered on.
+
+	endpoints map[string]map[string]bool // Paths of the endpoints passed to Async, by provider.
+}
+
+// JobRequest is the body of POST /api/v1/jobs: the provider endpoint to run and its query
+// parameters, e.g. {"provider": "github", "endpoint": "/loc", "params": {"owner": "o", "repo": "r"}}.
+type JobRequest struct {
+	Provider string            `json:"provider"`
+	Endpoint string            `json:"endpoint"`
+	Params   map[string]string `json:"params"`
+}
+
+// JobStatus describes a job on /api/v1/jobs/{id}. The result is served separately by ResultURL.
+type JobStatus struct {
+	ID          string        `json:"id"`
+	Provider    string        `json:"provider"`
+	Endpoint    string        `json:"endpoint"`
+	Query       string        `json:"query"`
+	Status      jobs.Status   `json:"status"`
+	Progress    jobs.Progress `json:"progress"`
+	Attempts    int           `json:"attempts"`
+	MaxAttempts int           `json:"maxAttempts"`
+	Error       string        `json:"error,omitempty"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	StartedAt   *time.Time    `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time    `json:"finishedAt,omitempty"`
+	StatusURL   string        `json:"statusUrl"`
+	ResultURL   string        `json:"resultUrl,omitempty"` // Set once the job finished with a result.
+}
+
+// NewJobsApi returns a JobsApi running its jobs on queue. Handler has to be set before the first
+// job runs.
+func NewJobsApi(queue *jobs.Queue) *JobsApi {
+	return &JobsApi{Queue: queue, endpoints: map[string]map[string]bool{}}
+}
+
+// Async returns endpoints of provider with handlers that, on the /api/v1 routes, queue a job and
+// answer 202 Accepted with its JobStatus and a Location header instead of serving the request:
+// always for endpoints marked Async, for the others when submitted through POST /api/v1/jobs.
+// The unversioned routes keep serving every endpoint in place for existing clients.
+func (jobsAPI *JobsApi) Async(provider string, endpoints []Endpoint) []Endpoint {
+	if jobsAPI.endpoints[provider] == nil {
+		jobsAPI.endpoints[provider] = map[string]bool{}
+	}
+	wrapped := make([]Endpoint, len(endpoints))
+	for i, endpoint := range endpoints {
+		jobsAPI.endpoints[provider][endpoint.Path] = true
+		wrapped[i] = endpoint
+		wrapped[i].Handler = jobsAPI.asyncHandler(provider, endpoint)
+	}
+	return wrapped
+}
+
+// asyncHandler wraps the handler of endpoint as described by Async.
+func (jobsAPI *JobsApi) asyncHandler(provider string, endpoint Endpoint) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		replayed, _ := r.Context().Value(jobReplayKey).(bool)
+		submitted, _ := r.Context().Value(jobSubmitKey).(bool)
+		if replayed || r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, APIV1Prefix+"/") || !(endpoint.Async || submitted) {
+			endpoint.Handler(w, r)
+			return
+		}
+
+		job, err := jobsAPI.Queue.Submit(jobs.Job{
+			Owner:    identityFromContext(r.Context()).cachePartition(),
+			Provider: provider,
+			Endpoint: endpoint.Path,
+			Query:    r.URL.RawQuery,
+		}, jobsAPI.replay(r.URL.Path, r.URL.RawQuery, r.Header.Clone()))
+		logCtx := log.WithFields(logrus.Fields{"provider": provider, "endpoint": endpoint.Path, "request_id": requestIDFromContext(r.Context())})
+		if err != nil {
+			logCtx.WithField("error", err).Error("Failed to queue job.")
+			writeError(w, r, provider, internalError("Failed to queue the job."))
+			return
+		}
+		logCtx.WithField("job_id", job.ID).Info("Job queued.")
+		w.Header().Set("Location", APIV1Prefix+JobsPath+"/"+job.ID)
+		writeJSON(w, r, http.StatusAccepted, newJobStatus(job))
+	}
+}
+
+// replay returns the jobs.Func serving GET path?rawQuery with header through Handler. Successful
+// responses finish the job, 429 and 5xx are retried and other statuses fail it; the response is
+// kept as the result either way.
+func (jobsAPI *JobsApi) replay(path, rawQuery string, header http.Header) jobs.Func {
+	return func(ctx context.Context) (*jobs.Result, error) {
+		req, err := http.NewRequestWithContext(context.WithValue(ctx, jobReplayKey, true), http.MethodGet, path+"?"+rawQuery, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request for %s: %w", path, err)
+		}
+		req.Header = header
+		response := newBufferedResponse()
+		jobsAPI.Handler.ServeHTTP(response, req)
+		if response.status == 0 {
+			response.status = http.StatusOK
+		}
+
+		result := &jobs.Result{Status: response.status, ContentType: response.header.Get("Content-Type"), Body: response.body.Bytes()}
+		if result.Status < http.StatusBadRequest {
+			return result, nil
+		}
+		err = fmt.Errorf("%s answered %d: %s", path, result.Status, errorMessage(result.Body))
+		if result.Status == http.StatusTooManyRequests || result.Status >= http.StatusInternalServerError {
+			retryAfter, _ := strconv.Atoi(response.header.Get("Retry-After"))
+			return result, jobs.Retry(err, time.Duration(retryAfter)*time.Second)
+		}
+		return result, err
+	}
+}
+
+// errorMessage returns the message of an ErrorEnvelope body, or the body itself if it is none.
+func errorMessage(body []byte) string {
+	var envelope ErrorEnvelope
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error.Message != "" {
+		return envelope.Error.Message
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// Register mounts the job routes below /api/v1/jobs, where errors are answered with an
+// ErrorEnvelope, and /api/jobs. Both are guarded by authenticator unless it is nil.
+func (jobsAPI *JobsApi) Register(router *mux.Router, authenticator *Authenticator) {
+	v1Router := router.PathPrefix(APIV1Prefix + JobsPath).Subrouter()
+	v1Router.Use(JSONErrors)
+	legacyRouter := router.PathPrefix("/api" + JobsPath).Subrouter()
+	for _, jobsRouter := range []*mux.Router{v1Router, legacyRouter} {
+		if authenticator != nil {
+			jobsRouter.Use(authenticator.Middleware(""))
+		}
+		jobsRouter.HandleFunc("", jobsAPI.CreateJob).Methods(http.MethodPost, http.MethodOptions)
+		jobsRouter.HandleFunc("/{id}", jobsAPI.GetJob).Methods(http.MethodGet, http.MethodOptions)
+		jobsRouter.HandleFunc("/{id}", jobsAPI.CancelJob).Methods(http.MethodDelete)
+		jobsRouter.HandleFunc("/{id}/result", jobsAPI.GetJobResult).Methods(http.MethodGet, http.MethodOptions)
+	}
+}
+
+// CreateJob handles POST /api/v1/jobs: it dispatches the GET request the JobRequest describes
+// through Handler, where the endpoint queues it, so the caller gets the same 202 Accepted, or the
+// same authentication and policy errors, as when calling the endpoint directly.
+func (jobsAPI *JobsApi) CreateJob(w http.ResponseWriter, r *http.Request) {
+	var jobRequest JobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&jobRequest); err != nil {
+		writeError(w, r, "", invalidRequest("The request body must be a JSON object with provider, endpoint and params."))
+		return
+	}
+	if !jobsAPI.endpoints[jobRequest.Provider][jobRequest.Endpoint] {
+		writeError(w, r, jobRequest.Provider, APIError{Status: http.StatusNotFound, Code: ErrorCodeNotFound, Message: "Unknown provider or endpoint."})
+		return
+	}
+
+	query := url.Values{}
+	for name, value := range jobRequest.Params {
+		query.Set(name, value)
+	}
+	target := APIV1Prefix + "/" + jobRequest.Provider + jobRequest.Endpoint + "?" + query.Encode()
+	dispatched, err := http.NewRequestWithContext(context.WithValue(r.Context(), jobSubmitKey, true), http.MethodGet, target, nil)
+	if err != nil {
+		writeError(w, r, jobRequest.Provider, invalidRequest("The endpoint or params are invalid."))
+		return
+	}
+	dispatched.Header = r.Header.Clone()
+	dispatched.Header.Del("Content-Type")
+	dispatched.Header.Set(RequestIDHeader, requestIDFromContext(r.Context()))
+	jobsAPI.Handler.ServeHTTP(w, dispatched)
+}
+
+// GetJob handles GET /api/v1/jobs/{id}.
+func (jobsAPI *JobsApi) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := jobsAPI.ownJob(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, r, http.StatusOK, newJobStatus(job))
+}
+
+// GetJobResult handles GET /api/v1/jobs/{id}/result: the response the endpoint answered the job
+// with, status and content type included. Unfinished jobs answer 409 Conflict.
+func (jobsAPI *JobsApi) GetJobResult(w http.ResponseWriter, r *http.Request) {
+	job, ok := jobsAPI.ownJob(w, r)
+	if !ok {
+		return
+	}
+	switch {
+	case !job.Status.Finished():
+		writeError(w, r, job.Provider, APIError{Status: http.StatusConflict, Code: ErrorCodeJobPending, Message: "The job has not finished yet."})
+		return
+	case job.Status == jobs.StatusCanceled:
+		writeError(w, r, job.Provider, APIError{Status: http.StatusConflict, Code: ErrorCodeJobCanceled, Message: "The job was canceled."})
+		return
+	case job.Result == nil:
+		writeError(w, r, job.Provider, APIError{Status: http.StatusConflict, Code: ErrorCodeJobFailed, Message: "The job failed: " + job.Error})
+		return
+	}
+	if job.Result.ContentType != "" {
+		w.Header().Set("Content-Type", job.Result.ContentType)
+	}
+	w.WriteHeader(job.Result.Status)
+	if _, err := w.Write(job.Result.Body); err != nil {
+		log.WithFields(logrus.Fields{"job_id": job.ID, "error": err}).Error("Error writing response.")
+	}
+}
+
+// CancelJob handles DELETE /api/v1/jobs/{id}.
+func (jobsAPI *JobsApi) CancelJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := jobsAPI.ownJob(w, r)
+	if !ok {
+		return
+	}
+	job, err := jobsAPI.Queue.Cancel(job.ID)
+	switch {
+	case errors.Is(err, jobs.ErrFinished):
+		writeError(w, r, job.Provider, APIError{Status: http.StatusConflict, Code: ErrorCodeJobFinished, Message: "The job already finished."})
+		return
+	case err != nil:
+		log.WithFields(logrus.Fields{"job_id": job.ID, "error": err}).Error("Failed to cancel job.")
+	}
+	log.WithFields(logrus.Fields{"job_id": job.ID, "request_id": requestIDFromContext(r.Context())}).Info("Job canceled.")
+	writeJSON(w, r, http.StatusOK, newJobStatus(job))
+}
+
+// ownJob returns the job of the {id} path variable. Jobs of other cache partitions answer 404
+// like unknown ones, so that job IDs reveal nothing to other callers.
+func (jobsAPI *JobsApi) ownJob(w http.ResponseWriter, r *http.Request) (jobs.Job, bool) {
+	job, err := jobsAPI.Queue.Get(mux.Vars(r)["id"])
+	if err != nil || job.Owner != identityFromContext(r.Context()).cachePartition() {
+		writeError(w, r, "", APIError{Status: http.StatusNotFound, Code: ErrorCodeNotFound, Message: "Job not found."})
+		return jobs.Job{}, false
+	}
+	return job, true
+}
+
+// newJobStatus describes job.
+func newJobStatus(job jobs.Job) JobStatus {
+	status := JobStatus{
+		ID:          job.ID,
+		Provider:    job.Provider,
+		Endpoint:    job.Endpoint,
+		Query:       job.Query,
+		Status:      job.Status,
+		Progress:    job.Progress,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		Error:       job.Error,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+		StatusURL:   APIV1Prefix + JobsPath + "/" + job.ID,
+	}
+	if job.Status.Finished() && job.Result != nil {
+		status.ResultURL = status.StatusURL + "/result"
+	}
+	return status
+}
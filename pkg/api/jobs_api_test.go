@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/jobs"
+	"github.com/gorilla/mux"
+)
+
+// jobsTestRouter mounts endpoints of a "github" provider with jobs enabled, guarded by API keys
+// "alice-key" and "bob-key".
+func jobsTestRouter(t *testing.T, endpoints []Endpoint) http.Handler {
+	t.Helper()
+	queue, err := jobs.NewQueue(jobs.NewMemoryStore(), jobs.Options{RetryDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewQueue returned error: %v", err)
+	}
+	authenticator, err := NewAuthenticator(AuthOptions{APIKeys: map[string]string{"alice": hashKey("alice-key"), "bob": hashKey("bob-key")}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator returned error: %v", err)
+	}
+	jobsAPI := NewJobsApi(queue)
+	router := mux.NewRouter()
+	router.Use(RequestID)
+	RegisterProvider(router, "github", jobsAPI.Async("github", endpoints), authenticator, nil)
+	jobsAPI.Handler = router
+	jobsAPI.Register(router, authenticator)
+	return router
+}
+
+// serveJobs serves method path with body as the caller holding key.
+func serveJobs(router http.Handler, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(APIKeyHeader, key)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// waitForJob polls the job at location as the caller holding key until it finished.
+func waitForJob(t *testing.T, router http.Handler, location, key string) JobStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rr := serveJobs(router, http.MethodGet, location, key, "")
+		var status JobStatus
+		if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("GET %s answered %d: %s", location, rr.Code, rr.Body.String())
+		}
+		if status.Status.Finished() {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not finish: %+v", location, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJobsApi_AsyncEndpointReturnsJob(t *testing.T) {
+	var calls int32
+	router := jobsTestRouter(t, []Endpoint{{Path: "/loc", Async: true, Handler: func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		jobs.ReportProgress(r.Context(), 1, 1, "counted")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalLines":42,"repo":"` + r.URL.Query().Get("repo") + `"}`))
+	}}})
+
+	rr := serveJobs(router, http.MethodGet, "/api/v1/github/loc?owner=o&repo=r", "alice-key", "")
+	location := rr.Header().Get("Location")
+	if rr.Code != http.StatusAccepted || !strings.HasPrefix(location, "/api/v1/jobs/") {
+		t.Fatalf("expected 202 with a job Location, got %d %q: %s", rr.Code, location, rr.Body.String())
+	}
+	status := waitForJob(t, router, location, "alice-key")
+	if status.Status != jobs.StatusSucceeded || status.Progress.Message != "counted" || status.ResultURL != location+"/result" || status.Query != "owner=o&repo=r" {
+		t.Errorf("unexpected job status %+v", status)
+	}
+
+	result := serveJobs(router, http.MethodGet, status.ResultURL, "alice-key", "")
+	if result.Code != http.StatusOK || result.Header().Get("Content-Type") != "application/json" || result.Body.String() != `{"totalLines":42,"repo":"r"}` {
+		t.Errorf("unexpected result %d %q: %s", result.Code, result.Header().Get("Content-Type"), result.Body.String())
+	}
+
+	// The unversioned route keeps serving in place.
+	if legacy := serveJobs(router, http.MethodGet, "/api/github/loc?owner=o&repo=r", "alice-key", ""); legacy.Code != http.StatusOK || calls != 2 {
+		t.Errorf("expected the legacy route to serve in place, got %d after %d calls", legacy.Code, calls)
+	}
+}
+
+func TestJobsApi_CreateJob(t *testing.T) {
+	router := jobsTestRouter(t, []Endpoint{{Path: "/repos", Handler: func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`["` + r.URL.Query().Get("owner") + `"]`))
+	}}})
+
+	rr := serveJobs(router, http.MethodPost, "/api/v1/jobs", "alice-key", `{"provider":"github","endpoint":"/repos","params":{"owner":"octo-org"}}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	status := waitForJob(t, router, rr.Header().Get("Location"), "alice-key")
+	if result := serveJobs(router, http.MethodGet, status.ResultURL, "alice-key", ""); result.Body.String() != `["octo-org"]` {
+		t.Errorf("unexpected result %s", result.Body.String())
+	}
+	if legacy := serveJobs(router, http.MethodPost, "/api/jobs", "alice-key", `{"provider":"github","endpoint":"/repos"}`); legacy.Code != http.StatusAccepted {
+		t.Errorf("expected /api/jobs to queue the job too, got %d", legacy.Code)
+	}
+
+	for name, tt := range map[string]struct {
+		key, body string
+		status    int
+	}{
+		"unknown endpoint":   {"alice-key", `{"provider":"github","endpoint":"/nope"}`, http.StatusNotFound},
+		"unknown provider":   {"alice-key", `{"provider":"bitbucket","endpoint":"/repos"}`, http.StatusNotFound},
+		"malformed body":     {"alice-key", `{"provider":`, http.StatusBadRequest},
+		"unauthenticated":    {"", `{"provider":"github","endpoint":"/repos"}`, http.StatusUnauthorized},
+		"invalid credential": {"guess", `{"provider":"github","endpoint":"/repos"}`, http.StatusUnauthorized},
+	} {
+		if rr := serveJobs(router, http.MethodPost, "/api/v1/jobs", tt.key, tt.body); rr.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", name, tt.status, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestJobsApi_JobsAreVisibleToTheirOwnerOnly(t *testing.T) {
+	router := jobsTestRouter(t, []Endpoint{{Path: "/loc", Async: true, Handler: func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}}})
+	location := serveJobs(router, http.MethodGet, "/api/v1/github/loc", "alice-key", "").Header().Get("Location")
+	waitForJob(t, router, location, "alice-key")
+
+	for _, path := range []string{location, location + "/result"} {
+		rr := serveJobs(router, http.MethodGet, path, "bob-key", "")
+		var envelope ErrorEnvelope
+		if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil || rr.Code != http.StatusNotFound || envelope.Error.Code != ErrorCodeNotFound {
+			t.Errorf("GET %s as another caller: expected a 404 envelope, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := serveJobs(router, http.MethodDelete, location, "bob-key", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("DELETE as another caller: expected 404, got %d", rr.Code)
+	}
+}
+
+func TestJobsApi_RetriesUpstreamFailures(t *testing.T) {
+	var calls int32
+	router := jobsTestRouter(t, []Endpoint{
+		{Path: "/flaky", Async: true, Handler: func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				writeError(w, r, "github", APIError{Status: http.StatusBadGateway, Code: ErrorCodeUpstream, Message: "The provider request failed."})
+				return
+			}
+			w.Write([]byte(`{"ok":true}`))
+		}},
+		{Path: "/missing", Async: true, Handler: func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, r, "github", APIError{Status: http.StatusNotFound, Code: ErrorCodeNotFound, Message: "Not found."})
+		}},
+	})
+
+	flaky := waitForJob(t, router, serveJobs(router, http.MethodGet, "/api/v1/github/flaky", "alice-key", "").Header().Get("Location"), "alice-key")
+	if flaky.Status != jobs.StatusSucceeded || flaky.Attempts != 2 {
+		t.Errorf("expected success on the second attempt, got %+v", flaky)
+	}
+
+	missing := waitForJob(t, router, serveJobs(router, http.MethodGet, "/api/v1/github/missing", "alice-key", "").Header().Get("Location"), "alice-key")
+	if missing.Status != jobs.StatusFailed || missing.Attempts != 1 || !strings.Contains(missing.Error, "Not found.") {
+		t.Errorf("expected a single failed attempt, got %+v", missing)
+	}
+	if rr := serveJobs(router, http.MethodGet, missing.ResultURL, "alice-key", ""); rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), ErrorCodeNotFound) {
+		t.Errorf("expected the stored 404 as result, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestJobsApi_CancelJob(t *testing.T) {
+	started := make(chan struct{})
+	router := jobsTestRouter(t, []Endpoint{{Path: "/loc", Async: true, Handler: func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}}})
+	location := serveJobs(router, http.MethodGet, "/api/v1/github/loc", "alice-key", "").Header().Get("Location")
+
+	rr := serveJobs(router, http.MethodGet, location+"/result", "alice-key", "")
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), ErrorCodeJobPending) {
+		t.Errorf("expected 409 %s before the job finished, got %d: %s", ErrorCodeJobPending, rr.Code, rr.Body.String())
+	}
+
+	<-started
+	rr = serveJobs(router, http.MethodDelete, location, "alice-key", "")
+	var status JobStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil || rr.Code != http.StatusOK || status.Status != jobs.StatusCanceled {
+		t.Fatalf("expected the canceled job, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := serveJobs(router, http.MethodGet, location+"/result", "alice-key", ""); rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), ErrorCodeJobCanceled) {
+		t.Errorf("expected 409 %s, got %d: %s", ErrorCodeJobCanceled, rr.Code, rr.Body.String())
+	}
+	if rr := serveJobs(router, http.MethodDelete, location, "alice-key", ""); rr.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a second cancel, got %d", rr.Code)
+	}
+}
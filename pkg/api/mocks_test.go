@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// --- Mocks shared by the GitHub and GitLab handler tests ---
+
+// MockGitService is a mock implementation of interfaces.GitService
+type MockGitService struct {
+	GetAllReposFunc         func(owner string) ([]*common_types.Repository, error)
+	GetRepoFunc             func(identifier interface{}) (*common_types.Repository, error)
+	GetProjectCommitsFunc   func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error)
+	GetRepoContributorsFunc func(repoIdentifier interface{}) ([]*common_types.User, error)
+	ListIssuesFunc          func(repoIdentifier interface{}, options *interfaces.IssueListOptions) ([]*common_types.Issue, error)
+	ListBranchesFunc        func(repoIdentifier interface{}) ([]*common_types.Branch, error)
+	ListTagsFunc            func(repoIdentifier interface{}) ([]*common_types.Tag, error)
+	CompareRefsFunc         func(repoIdentifier interface{}, baseRef, headRef string) (*common_types.Comparison, error)
+	ListLanguagesFunc       func(repoIdentifier interface{}) ([]*common_types.Language, error)
+}
+
+func (m *MockGitService) GetAllRepos(owner string) ([]*common_types.Repository, error) {
+	if m.GetAllReposFunc != nil {
+		return m.GetAllReposFunc(owner)
+	}
+	return nil, errors.New("GetAllReposFunc not implemented")
+}
+
+func (m *MockGitService) GetRepo(identifier interface{}) (*common_types.Repository, error) {
+	if m.GetRepoFunc != nil {
+		return m.GetRepoFunc(identifier)
+	}
+	return nil, errors.New("GetRepoFunc not implemented")
+}
+
+func (m *MockGitService) GetProjectCommits(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+	if m.GetProjectCommitsFunc != nil {
+		return m.GetProjectCommitsFunc(repoIdentifier, options)
+	}
+	return nil, errors.New("GetProjectCommitsFunc not implemented")
+}
+
+func (m *MockGitService) GetRepoContributors(repoIdentifier interface{}) ([]*common_types.User, error) {
+	if m.GetRepoContributorsFunc != nil {
+		return m.GetRepoContributorsFunc(repoIdentifier)
+	}
+	return nil, errors.New("GetRepoContributorsFunc not implemented")
+}
+
+func (m *MockGitService) ListIssues(repoIdentifier interface{}, options *interfaces.IssueListOptions) ([]*common_types.Issue, error) {
+	if m.ListIssuesFunc != nil {
+		return m.ListIssuesFunc(repoIdentifier, options)
+	}
+	return nil, errors.New("ListIssuesFunc not implemented")
+}
+
+func (m *MockGitService) ListBranches(repoIdentifier interface{}) ([]*common_types.Branch, error) {
+	if m.ListBranchesFunc != nil {
+		return m.ListBranchesFunc(repoIdentifier)
+	}
+	return nil, errors.New("ListBranchesFunc not implemented")
+}
+
+func (m *MockGitService) ListTags(repoIdentifier interface{}) ([]*common_types.Tag, error) {
+	if m.ListTagsFunc != nil {
+		return m.ListTagsFunc(repoIdentifier)
+	}
+	return nil, errors.New("ListTagsFunc not implemented")
+}
+
+func (m *MockGitService) CompareRefs(repoIdentifier interface{}, baseRef, headRef string) (*common_types.Comparison, error) {
+	if m.CompareRefsFunc != nil {
+		return m.CompareRefsFunc(repoIdentifier, baseRef, headRef)
+	}
+	return nil, errors.New("CompareRefsFunc not implemented")
+}
+
+func (m *MockGitService) ListLanguages(repoIdentifier interface{}) ([]*common_types.Language, error) {
+	if m.ListLanguagesFunc != nil {
+		return m.ListLanguagesFunc(repoIdentifier)
+	}
+	return nil, errors.New("ListLanguagesFunc not implemented")
+}
+
+// MockRedisClient is a mock implementation of storage.InMemoryDB.
+type MockRedisClient struct {
+	GetFunc    func(key string) ([]byte, error)
+	SetFunc    func(key string, value interface{}, expirationInSeconds time.Duration) error
+	DeleteFunc func(key string) error
+}
+
+func (m *MockRedisClient) Get(key string) ([]byte, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(key)
+	}
+	return nil, errors.New("GetFunc not implemented in MockRedisClient")
+}
+
+func (m *MockRedisClient) Set(key string, value interface{}, expirationInSeconds time.Duration) error {
+	if m.SetFunc != nil {
+		return m.SetFunc(key, value, expirationInSeconds)
+	}
+	return errors.New("SetFunc not implemented in MockRedisClient")
+}
+
+func (m *MockRedisClient) Delete(key string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(key)
+	}
+	return errors.New("DeleteFunc not implemented in MockRedisClient")
+}
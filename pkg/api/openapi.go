@@ -0,0 +1,245 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification the generated document follows.
+const OpenAPIVersion = "3.0.3"
+
+// OpenAPIDocument is an OpenAPI 3 description of the API, reduced to the parts this server uses.
+type OpenAPIDocument struct {
+	OpenAPI string                     `json:"openapi"`
+	Info    OpenAPIInfo                `json:"info"`
+	Tags    []OpenAPITag               `json:"tags,omitempty"`
+	Paths   map[string]OpenAPIPathItem `json:"paths"`
+}
+
+// OpenAPIInfo is the document's metadata.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPITag groups the operations of one provider.
+type OpenAPITag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIPathItem holds the operations of one path. The provider routes are all GETs; only the
+// job routes take POST and DELETE.
+type OpenAPIPathItem struct {
+	Get    *OpenAPIOperation `json:"get,omitempty"`
+	Post   *OpenAPIOperation `json:"post,omitempty"`
+	Delete *OpenAPIOperation `json:"delete,omitempty"`
+}
+
+// OpenAPIOperation describes one endpoint.
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIRequestBody describes the body of a POST operation.
+type OpenAPIRequestBody struct {
+	Description string                      `json:"description,omitempty"`
+	Required    bool                        `json:"required,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIParameter describes one query or path parameter.
+type OpenAPIParameter struct {
+	Name        string        `json:"name"`
+	In          string        `json:"in"`
+	Description string        `json:"description,omitempty"`
+	Required    bool          `json:"required,omitempty"`
+	Deprecated  bool          `json:"deprecated,omitempty"`
+	Schema      OpenAPISchema `json:"schema"`
+	Example     interface{}   `json:"example,omitempty"`
+}
+
+// OpenAPISchema is the schema of a parameter value.
+type OpenAPISchema struct {
+	Type   string   `json:"type"`
+	Format string   `json:"format,omitempty"`
+	Enum   []string `json:"enum,omitempty"`
+}
+
+// OpenAPIResponse describes one response status.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType is an empty media type object; response bodies are not described in detail.
+type OpenAPIMediaType struct{}
+
+// NewOpenAPIDocument describes the endpoints registered per provider, each mounted below
+// /api/v1/<provider>, along with /api/config and the document itself.
+func NewOpenAPIDocument(version string, endpointsByProvider map[string][]Endpoint) OpenAPIDocument {
+	doc := OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info: OpenAPIInfo{
+			Title:       "Git Stats API",
+			Description: "Statistics of GitHub and GitLab repositories. Errors are returned as {\"error\": {code, message, provider, requestId}}. Parameters marked deprecated are pre-v1 names, still accepted; the unversioned /api/<provider> routes are deprecated too and answer errors in plain text.",
+			Version:     version,
+		},
+		Paths: map[string]OpenAPIPathItem{
+			"/api/config": {Get: &OpenAPIOperation{
+				OperationID: "getConfig",
+				Summary:     "Describe the server: version, providers, endpoints and cache TTL.",
+				Responses:   map[string]OpenAPIResponse{"200": jsonResponse("Server capabilities.")},
+			}},
+			"/api/openapi.json": {Get: &OpenAPIOperation{
+				OperationID: "getOpenAPI",
+				Summary:     "This document.",
+				Responses:   map[string]OpenAPIResponse{"200": jsonResponse("OpenAPI document.")},
+			}},
+		},
+	}
+
+	providers := make([]string, 0, len(endpointsByProvider))
+	for provider := range endpointsByProvider {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	for _, provider := range providers {
+		doc.Tags = append(doc.Tags, OpenAPITag{Name: provider})
+		for _, endpoint := range endpointsByProvider[provider] {
+			doc.Paths[APIV1Prefix+"/"+provider+endpoint.Path] = OpenAPIPathItem{Get: newOpenAPIOperation(provider, endpoint)}
+		}
+	}
+	return doc
+}
+
+// newOpenAPIOperation describes endpoint of provider.
+func newOpenAPIOperation(provider string, endpoint Endpoint) *OpenAPIOperation {
+	contentTypes := endpoint.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = []string{"application/json"}
+	}
+	success := OpenAPIResponse{Description: "Success.", Content: map[string]OpenAPIMediaType{}}
+	for _, contentType := range contentTypes {
+		success.Content[contentType] = OpenAPIMediaType{}
+	}
+
+	operation := &OpenAPIOperation{
+		OperationID: provider + "." + endpoint.OperationID,
+		Summary:     endpoint.Summary,
+		Tags:        []string{provider},
+		Responses: map[string]OpenAPIResponse{
+			"200": success,
+			"304": {Description: "Unchanged since the If-None-Match or If-Modified-Since of the request; see ETag and Last-Modified."},
+			"400": jsonResponse("A query parameter is missing or invalid (" + ErrorCodeInvalidRequest + ")."),
+			"401": jsonResponse("The caller did not authenticate (" + ErrorCodeUnauthenticated + ") or the provider rejected the configured token (" + ErrorCodeUnauthorized + ")."),
+			"403": jsonResponse("The access policy denies the request (" + ErrorCodeAccessDenied + ") or the configured token has no access to the resource (" + ErrorCodeForbidden + ")."),
+			"404": jsonResponse("The repository, project or ref was not found (" + ErrorCodeNotFound + ")."),
+			"429": jsonResponse("The provider rate limit is exhausted (" + ErrorCodeRateLimited + "); see Retry-After."),
+			"500": jsonResponse("The server failed (" + ErrorCodeInternal + ")."),
+			"502": jsonResponse("The provider request failed (" + ErrorCodeUpstream + ")."),
+		},
+	}
+	if endpoint.Async {
+		success.Description = "Success; answered by the job result of the 202 response."
+		operation.Responses["200"] = success
+		operation.Responses["202"] = jsonResponse("Queued as a background job; follow the Location header to " + APIV1Prefix + JobsPath + "/{id}.")
+	}
+	for _, param := range endpoint.Parameters {
+		operation.Parameters = append(operation.Parameters, OpenAPIParameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Deprecated:  param.Deprecated,
+			Schema:      OpenAPISchema{Type: param.Type, Format: param.Format, Enum: param.Enum},
+			Example:     exampleValue(param),
+		})
+	}
+	return operation
+}
+
+// AddJobPaths describes the routes of JobsApi below /api/v1/jobs.
+func (doc *OpenAPIDocument) AddJobPaths() {
+	jobID := OpenAPIParameter{Name: "id", In: "path", Description: "ID of the job.", Required: true, Schema: OpenAPISchema{Type: "string"}}
+	notFound := jsonResponse("The job does not exist or belongs to another caller (" + ErrorCodeNotFound + ").")
+	doc.Tags = append(doc.Tags, OpenAPITag{Name: "jobs", Description: "Background jobs running provider endpoints."})
+	doc.Paths[APIV1Prefix+JobsPath] = OpenAPIPathItem{Post: &OpenAPIOperation{
+		OperationID: "jobs.createJob",
+		Summary:     "Run a provider endpoint as a background job.",
+		Tags:        []string{"jobs"},
+		RequestBody: &OpenAPIRequestBody{
+			Description: "{\"provider\": \"github\", \"endpoint\": \"/loc\", \"params\": {\"owner\": \"...\", \"repo\": \"...\"}}",
+			Required:    true,
+			Content:     map[string]OpenAPIMediaType{"application/json": {}},
+		},
+		Responses: map[string]OpenAPIResponse{
+			"202": jsonResponse("Queued; the Location header points to the job."),
+			"400": jsonResponse("The body is not a valid job request (" + ErrorCodeInvalidRequest + ")."),
+			"401": jsonResponse("The caller did not authenticate (" + ErrorCodeUnauthenticated + ")."),
+			"403": jsonResponse("The access policy denies the endpoint (" + ErrorCodeAccessDenied + ")."),
+			"404": jsonResponse("The provider or endpoint does not exist (" + ErrorCodeNotFound + ")."),
+		},
+	}}
+	doc.Paths[APIV1Prefix+JobsPath+"/{id}"] = OpenAPIPathItem{
+		Get: &OpenAPIOperation{
+			OperationID: "jobs.getJob",
+			Summary:     "Get the status and progress of a job.",
+			Tags:        []string{"jobs"},
+			Parameters:  []OpenAPIParameter{jobID},
+			Responses:   map[string]OpenAPIResponse{"200": jsonResponse("Job status."), "404": notFound},
+		},
+		Delete: &OpenAPIOperation{
+			OperationID: "jobs.cancelJob",
+			Summary:     "Cancel a queued or running job.",
+			Tags:        []string{"jobs"},
+			Parameters:  []OpenAPIParameter{jobID},
+			Responses: map[string]OpenAPIResponse{
+				"200": jsonResponse("Job status after canceling."),
+				"404": notFound,
+				"409": jsonResponse("The job already finished (" + ErrorCodeJobFinished + ")."),
+			},
+		},
+	}
+	doc.Paths[APIV1Prefix+JobsPath+"/{id}/result"] = OpenAPIPathItem{Get: &OpenAPIOperation{
+		OperationID: "jobs.getJobResult",
+		Summary:     "Get the response the endpoint answered a finished job with.",
+		Tags:        []string{"jobs"},
+		Parameters:  []OpenAPIParameter{jobID},
+		Responses: map[string]OpenAPIResponse{
+			"200": jsonResponse("The endpoint response; its status and content type are those of the endpoint."),
+			"404": notFound,
+			"409": jsonResponse("The job is not finished (" + ErrorCodeJobPending + "), was canceled (" + ErrorCodeJobCanceled + ") or failed without a result (" + ErrorCodeJobFailed + ")."),
+		},
+	}}
+}
+
+// exampleValue returns the example of param typed as its schema: a number for integers.
+func exampleValue(param Parameter) interface{} {
+	if param.Example == "" {
+		return nil
+	}
+	if param.Type == "integer" {
+		if value, err := strconv.Atoi(param.Example); err == nil {
+			return value
+		}
+	}
+	return param.Example
+}
+
+// jsonResponse is a JSON response with the given description.
+func jsonResponse(description string) OpenAPIResponse {
+	return OpenAPIResponse{Description: description, Content: map[string]OpenAPIMediaType{"application/json": {}}}
+}
+
+// OpenAPIHandler returns a handler that serves doc as JSON.
+func OpenAPIHandler(doc OpenAPIDocument) http.HandlerFunc {
+	return staticJSONHandler(doc)
+}
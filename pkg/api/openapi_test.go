@@ -0,0 +1,246 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// permissiveGitService answers every GitService call with an empty, successful result.
+func permissiveGitService(t *testing.T) *MockGitService {
+	return &MockGitService{
+		GetAllReposFunc: func(owner string) ([]*common_types.Repository, error) { return nil, nil },
+		GetRepoFunc: func(identifier interface{}) (*common_types.Repository, error) {
+			// A clone URL that does not exist makes /loc fail fast instead of reaching the network.
+			return &common_types.Repository{Name: "hello-world", CloneURL: filepath.Join(t.TempDir(), "missing")}, nil
+		},
+		GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+			return nil, nil
+		},
+		GetRepoContributorsFunc: func(repoIdentifier interface{}) ([]*common_types.User, error) { return nil, nil },
+		ListIssuesFunc: func(repoIdentifier interface{}, options *interfaces.IssueListOptions) ([]*common_types.Issue, error) {
+			return nil, nil
+		},
+		ListBranchesFunc: func(repoIdentifier interface{}) ([]*common_types.Branch, error) { return nil, nil },
+		ListTagsFunc:     func(repoIdentifier interface{}) ([]*common_types.Tag, error) { return nil, nil },
+		CompareRefsFunc: func(repoIdentifier interface{}, baseRef, headRef string) (*common_types.Comparison, error) {
+			return &common_types.Comparison{BaseRef: baseRef, HeadRef: headRef}, nil
+		},
+		ListLanguagesFunc: func(repoIdentifier interface{}) ([]*common_types.Language, error) { return nil, nil },
+	}
+}
+
+// testEndpoints returns the endpoints of both providers, backed by permissive mocks.
+func testEndpoints(t *testing.T) map[string][]Endpoint {
+	cache := &MockRedisClient{
+		GetFunc: func(key string) ([]byte, error) { return nil, nil },
+		SetFunc: func(key string, value interface{}, expirationInSeconds time.Duration) error { return nil },
+	}
+	return map[string][]Endpoint{
+		"github": NewGithubApi(permissiveGitService(t), cache).Endpoints(),
+		"gitlab": NewGitlabApi(permissiveGitService(t), cache).Endpoints(),
+	}
+}
+
+// callEndpoint serves a GET request for endpoint with query and returns the recorded response.
+func callEndpoint(endpoint Endpoint, query url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, endpoint.Path+"?"+query.Encode(), nil)
+	rr := httptest.NewRecorder()
+	endpoint.Handler(rr, req)
+	return rr
+}
+
+// exampleQuery sets every documented, non-deprecated parameter of endpoint to its example.
+func exampleQuery(endpoint Endpoint) url.Values {
+	query := url.Values{}
+	for _, param := range endpoint.Parameters {
+		if !param.Deprecated {
+			query.Set(param.Name, param.Example)
+		}
+	}
+	return query
+}
+
+func TestOpenAPIDocument_IsValid(t *testing.T) {
+	doc := NewOpenAPIDocument("test", testEndpoints(t))
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") || doc.Info.Title == "" || doc.Info.Version != "test" {
+		t.Errorf("unexpected document header: %+v %+v", doc.OpenAPI, doc.Info)
+	}
+	operationIDs := map[string]bool{}
+	for path, item := range doc.Paths {
+		if !strings.HasPrefix(path, "/api/") {
+			t.Errorf("path %s is not below /api/", path)
+		}
+		operation := item.Get
+		if operation == nil {
+			t.Errorf("path %s has no GET operation", path)
+			continue
+		}
+		if operation.OperationID == "" || operationIDs[operation.OperationID] {
+			t.Errorf("path %s: operationId %q is empty or not unique", path, operation.OperationID)
+		}
+		operationIDs[operation.OperationID] = true
+		if operation.Summary == "" {
+			t.Errorf("path %s has no summary", path)
+		}
+		if success, ok := operation.Responses["200"]; !ok || success.Description == "" || len(success.Content) == 0 {
+			t.Errorf("path %s: missing or empty 200 response", path)
+		}
+
+		names := map[string]bool{}
+		for _, param := range operation.Parameters {
+			if names[param.Name] {
+				t.Errorf("path %s: parameter %s listed twice", path, param.Name)
+			}
+			names[param.Name] = true
+			if param.In != "query" || (param.Schema.Type != "string" && param.Schema.Type != "integer") {
+				t.Errorf("path %s: parameter %s has location %q and type %q", path, param.Name, param.In, param.Schema.Type)
+			}
+			if param.Required && param.Deprecated {
+				t.Errorf("path %s: deprecated parameter %s must not be required", path, param.Name)
+			}
+			if param.Example == nil {
+				t.Errorf("path %s: parameter %s has no example", path, param.Name)
+			}
+			if example, ok := param.Example.(string); ok {
+				if len(param.Schema.Enum) > 0 && !slices.Contains(param.Schema.Enum, example) {
+					t.Errorf("path %s: example %q of %s is not one of %v", path, example, param.Name, param.Schema.Enum)
+				}
+				if _, err := time.Parse(time.RFC3339, example); param.Schema.Format == "date-time" && err != nil {
+					t.Errorf("path %s: example %q of %s is not RFC 3339", path, example, param.Name)
+				}
+			}
+		}
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("failed to marshal document: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil || decoded["openapi"] != OpenAPIVersion {
+		t.Errorf("document does not round-trip through JSON: %v", err)
+	}
+}
+
+func TestOpenAPIDocument_CoversRegisteredRoutes(t *testing.T) {
+	endpointsByProvider := testEndpoints(t)
+	router := mux.NewRouter()
+	for provider, endpoints := range endpointsByProvider {
+		RegisterProvider(router, provider, endpoints, nil, nil)
+	}
+	capabilities, err := NewCapabilities("test", "/api", router)
+	if err != nil {
+		t.Fatalf("NewCapabilities returned error: %v", err)
+	}
+	doc := NewOpenAPIDocument("test", endpointsByProvider)
+
+	var registered, documented []string
+	for provider, paths := range capabilities.Endpoints {
+		for _, path := range paths {
+			registered = append(registered, APIV1Prefix+"/"+provider+path)
+		}
+	}
+	for path := range doc.Paths {
+		if strings.Count(path, "/") > 2 { // Skip /api/config and /api/openapi.json.
+			documented = append(documented, path)
+		}
+	}
+	sort.Strings(registered)
+	sort.Strings(documented)
+	if !slices.Equal(registered, documented) {
+		t.Errorf("registered routes and documented paths differ:\nregistered: %v\ndocumented: %v", registered, documented)
+	}
+}
+
+// TestOpenAPIDocument_MatchesHandlers checks the documented parameters against the handlers:
+// the examples are accepted, leaving out a required parameter is rejected and deprecated aliases
+// still work but mark the response as deprecated.
+func TestOpenAPIDocument_MatchesHandlers(t *testing.T) {
+	for provider, endpoints := range testEndpoints(t) {
+		for _, endpoint := range endpoints {
+			endpoint := endpoint
+			t.Run(provider+endpoint.Path, func(t *testing.T) {
+				if rr := callEndpoint(endpoint, exampleQuery(endpoint)); rr.Code == http.StatusBadRequest {
+					t.Errorf("documented examples rejected: %s", rr.Body.String())
+				} else if rr.Header().Get("Deprecation") != "" {
+					t.Errorf("v1 parameters must not be marked deprecated")
+				}
+
+				documented := map[string]bool{}
+				for _, param := range endpoint.Parameters {
+					documented[param.Name] = true
+				}
+				for _, param := range endpoint.Parameters {
+					if param.Required {
+						query := exampleQuery(endpoint)
+						query.Del(param.Name)
+						if rr := callEndpoint(endpoint, query); rr.Code != http.StatusBadRequest {
+							t.Errorf("expected 400 without required %s, got %d", param.Name, rr.Code)
+						}
+					}
+					for _, alias := range deprecatedAliases[param.Name] {
+						if !documented[alias] {
+							if param.Required {
+								t.Errorf("deprecated alias %s of %s is not documented", alias, param.Name)
+							}
+							continue
+						}
+						query := exampleQuery(endpoint)
+						query.Set(alias, query.Get(param.Name))
+						query.Del(param.Name)
+						rr := callEndpoint(endpoint, query)
+						if rr.Code == http.StatusBadRequest || rr.Header().Get("Deprecation") != "true" {
+							t.Errorf("alias %s: got status %d and Deprecation %q", alias, rr.Code, rr.Header().Get("Deprecation"))
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	rr := httptest.NewRecorder()
+	OpenAPIHandler(NewOpenAPIDocument("test", testEndpoints(t)))(rr, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response: %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rr.Body.String(), `"/api/v1/github/commits"`) || !strings.Contains(rr.Body.String(), `"deprecated":true`) {
+		t.Errorf("unexpected document: %s", rr.Body.String())
+	}
+}
+
+func TestOpenAPIDocument_AddJobPaths(t *testing.T) {
+	doc := NewOpenAPIDocument("test", testEndpoints(t))
+	doc.AddJobPaths()
+
+	if _, ok := doc.Paths["/api/v1/github/loc"].Get.Responses["202"]; !ok {
+		t.Error("expected the async /loc endpoint to document 202")
+	}
+	if _, ok := doc.Paths["/api/v1/github/commits"].Get.Responses["202"]; ok {
+		t.Error("expected only async endpoints to document 202")
+	}
+	jobs := doc.Paths["/api/v1/jobs"]
+	job := doc.Paths["/api/v1/jobs/{id}"]
+	result := doc.Paths["/api/v1/jobs/{id}/result"]
+	if jobs.Post == nil || jobs.Post.RequestBody == nil || job.Get == nil || job.Delete == nil || result.Get == nil {
+		t.Fatalf("job paths missing: %+v %+v %+v", jobs, job, result)
+	}
+	if len(job.Get.Parameters) != 1 || job.Get.Parameters[0].In != "path" || !job.Get.Parameters[0].Required {
+		t.Errorf("expected a required id path parameter, got %+v", job.Get.Parameters)
+	}
+}
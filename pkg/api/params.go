@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Query parameter names of the v1 scheme. GitHub endpoints identify a repository with 'owner'
+// and 'repo', GitLab endpoints with 'project' (numeric ID or namespace/path).
+const (
+	ParamOwner   = "owner"
+	ParamRepo    = "repo"
+	ParamProject = "project"
+)
+
+// Query parameter names used before v1, still accepted as deprecated aliases.
+const (
+	legacyParamProjectOwner = "projectOwner" // Alias of 'owner' on /commits.
+	legacyParamRepoName     = "repoName"     // Alias of 'repo'.
+	legacyParamProjectID    = "projectID"    // Alias of 'project'; on GitHub /repo an ID or "owner/name".
+	legacyParamRepoURL      = "repoUrl"      // Clone URL; replaced by 'owner' and 'repo' on GitHub /loc.
+)
+
+// deprecatedAliases lists, for each v1 parameter, the legacy names that queryParam falls back to.
+var deprecatedAliases = map[string][]string{
+	ParamOwner:   {legacyParamProjectOwner},
+	ParamRepo:    {legacyParamRepoName},
+	ParamProject: {legacyParamProjectID},
+}
+
+// queryParam returns the query parameter name, falling back to its deprecated aliases.
+func queryParam(w http.ResponseWriter, r *http.Request, name string) string {
+	if value := r.URL.Query().Get(name); value != "" {
+		return value
+	}
+	for _, alias := range deprecatedAliases[name] {
+		if value := legacyQueryParam(w, r, alias, name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// legacyQueryParam returns the deprecated query parameter alias. When it is set, the response
+// is marked with a Deprecation header and the use is logged along with the replacement.
+func legacyQueryParam(w http.ResponseWriter, r *http.Request, alias, replacement string) string {
+	value := r.URL.Query().Get(alias)
+	if value != "" {
+		w.Header().Set("Deprecation", "true")
+		log.WithFields(logrus.Fields{"path": r.URL.Path, "parameter": alias, "replacement": replacement}).Debug("Deprecated query parameter used.")
+	}
+	return value
+}
+
+// githubRepoFromQuery reads the 'owner' and 'repo' query parameters used by the GitHub
+// endpoints and returns the "owner/repo" identifier understood by GitHubRepo.
+func githubRepoFromQuery(w http.ResponseWriter, r *http.Request) (string, bool) {
+	ownerName := queryParam(w, r, ParamOwner)
+	repoName := queryParam(w, r, ParamRepo)
+	if ownerName == "" || repoName == "" {
+		return "", false
+	}
+	return ownerName + "/" + repoName, true
+}
+
+// gitlabRepoFromQuery reads the 'project' query parameter (ID or namespace/path) and returns
+// the identifier understood by the Gitlab service along with the raw value for cache keys.
+func gitlabRepoFromQuery(w http.ResponseWriter, r *http.Request) (interface{}, string, bool) {
+	repoIdentifierQuery := queryParam(w, r, ParamProject)
+	if repoIdentifierQuery == "" {
+		return nil, "", false
+	}
+	if parsedID, parseErr := strconv.Atoi(repoIdentifierQuery); parseErr == nil {
+		return parsedID, repoIdentifierQuery, true
+	}
+	return repoIdentifierQuery, repoIdentifierQuery, true
+}
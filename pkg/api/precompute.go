@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultPrecomputeEndpoints are the per-repository endpoints a Precomputer refreshes when none
+// are configured: the repository itself, its commits and the analytics derived from them.
+var DefaultPrecomputeEndpoints = []string{"/repo", "/commits", "/activity", "/commits/conventional", "/branches"}
+
+// Precomputer refreshes the cached responses of a provider for the repositories of an owner, so
+// that requests are served from a warm cache. It serves requests to the endpoint handlers the
+// way a caller would, with default parameters, except that they skip the cache and overwrite
+// what it holds.
+type Precomputer struct {
+	provider  string
+	owner     string
+	identity  Identity
+	list      Endpoint   // /repos, which lists the repositories to refresh.
+	endpoints []Endpoint // Per-repository endpoints to refresh.
+}
+
+// NewPrecomputer returns a Precomputer refreshing paths, DefaultPrecomputeEndpoints when empty,
+// for every repository of owner on provider. endpoints are the endpoints of provider, without
+// the job or access policy wrappers. The cache is filled for identity, whose partition is the
+// one its requests read; the zero Identity fills the cache of an open API.
+func NewPrecomputer(provider string, endpoints []Endpoint, owner string, paths []string, identity Identity) (*Precomputer, error) {
+	byPath := map[string]Endpoint{}
+	for _, endpoint := range endpoints {
+		byPath[endpoint.Path] = endpoint
+	}
+	list, ok := byPath["/repos"]
+	if !ok {
+		return nil, fmt.Errorf("provider %s has no /repos endpoint to list the repositories of %s", provider, owner)
+	}
+
+	precomputer := &Precomputer{provider: provider, owner: owner, identity: identity, list: list}
+	if len(paths) == 0 {
+		for _, path := range DefaultPrecomputeEndpoints {
+			if endpoint, ok := byPath[path]; ok {
+				precomputer.endpoints = append(precomputer.endpoints, endpoint)
+			}
+		}
+		return precomputer, nil
+	}
+	for _, path := range paths {
+		endpoint, ok := byPath[path]
+		if !ok || path == "/repos" {
+			return nil, fmt.Errorf("provider %s has no per-repository endpoint %s", provider, path)
+		}
+		precomputer.endpoints = append(precomputer.endpoints, endpoint)
+	}
+	return precomputer, nil
+}
+
+// CallerIdentity returns the Identity of caller, "api_key:<name>" or "oidc:<subject>" as in the
+// schedules of the config, or the zero Identity when caller is empty.
+func CallerIdentity(caller string) (Identity, error) {
+	if caller == "" {
+		return Identity{}, nil
+	}
+	method, subject, _ := strings.Cut(caller, ":")
+	if (method != AuthMethodAPIKey && method != AuthMethodOIDC) || subject == "" {
+		return Identity{}, fmt.Errorf("caller must be %q or %q, got %q", AuthMethodAPIKey+":<name>", AuthMethodOIDC+":<subject>", caller)
+	}
+	return Identity{Subject: subject, Method: method}, nil
+}
+
+// Run refreshes the repository list of the owner, then every endpoint for every repository in
+// it. It keeps going when an endpoint fails and returns an error summarizing the failures, but
+// stops early when ctx is canceled or the provider rate limits the refresh.
+func (p *Precomputer) Run(ctx context.Context) error {
+	body, err := p.refresh(ctx, p.list, url.Values{ParamOwner: {p.owner}})
+	if err != nil {
+		return err
+	}
+	var repos []common_types.Repository
+	if err := json.Unmarshal(body, &repos); err != nil {
+		return fmt.Errorf("failed to parse the repositories of %s: %w", p.owner, err)
+	}
+
+	logCtx := log.WithFields(logrus.Fields{"provider": p.provider, "owner": p.owner})
+	var failures []string
+	refreshes := 0
+	for _, repo := range repos {
+		for _, query := range p.repoQueries(repo) {
+			refreshes++
+			for _, endpoint := range p.endpoints {
+				if ctx.Err() != nil {
+					return fmt.Errorf("refresh of %s stopped: %w", p.owner, ctx.Err())
+				}
+				if _, err := p.refresh(ctx, endpoint, query); err != nil {
+					var refreshErr refreshError
+					if errors.As(err, &refreshErr) && refreshErr.status == http.StatusTooManyRequests {
+						return fmt.Errorf("refresh of %s stopped: %w", p.owner, err)
+					}
+					logCtx.WithFields(logrus.Fields{"repo": repo.Name, "endpoint": endpoint.Path, "error": err}).Warn("Failed to refresh cached response.")
+					failures = append(failures, err.Error())
+				}
+			}
+		}
+	}
+	logCtx.WithFields(logrus.Fields{"repos": len(repos), "failures": len(failures)}).Info("Refreshed cached responses.")
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d refreshes of %s failed, first: %s", len(failures), refreshes*len(p.endpoints), p.owner, failures[0])
+	}
+	return nil
+}
+
+// repoQueries returns the query parameters selecting repo on the provider. GitLab projects are
+// cached under the 'project' parameter as requested, so they are selected by numeric ID and by
+// path, the forms dashboards and webhooks use.
+func (p *Precomputer) repoQueries(repo common_types.Repository) []url.Values {
+	if p.provider == "gitlab" {
+		queries := []url.Values{{ParamProject: {strconv.FormatInt(repo.ID, 10)}}}
+		if repo.FullName != "" {
+			queries = append(queries, url.Values{ParamProject: {repo.FullName}})
+		}
+		return queries
+	}
+	owner := repo.Owner
+	if owner == "" {
+		owner = p.owner
+	}
+	return []url.Values{{ParamOwner: {owner}, ParamRepo: {repo.Name}}}
+}
+
+// refreshError is a refresh the endpoint answered with an error status.
+type refreshError struct {
+	path    string
+	status  int
+	message string
+}
+
+func (e refreshError) Error() string {
+	return fmt.Sprintf("%s answered %d: %s", e.path, e.status, e.message)
+}
+
+// refresh serves GET endpoint?query as the identity of p, bypassing the cache, and returns the
+// response body.
+func (p *Precomputer) refresh(ctx context.Context, endpoint Endpoint, query url.Values) ([]byte, error) {
+	ctx = context.WithValue(ctx, identityKey, p.identity)
+	ctx = context.WithValue(ctx, cacheRefreshKey, true)
+	path := APIV1Prefix + "/" + p.provider + endpoint.Path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request for %s: %w", path, err)
+	}
+	response := newBufferedResponse()
+	endpoint.Handler(response, req)
+	if response.status >= http.StatusBadRequest {
+		return nil, refreshError{path: path + "?" + query.Encode(), status: response.status, message: errorMessage(response.body.Bytes())}
+	}
+	return response.body.Bytes(), nil
+}
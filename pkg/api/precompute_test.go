@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	"github.com/gorilla/mux"
+)
+
+func TestPrecomputer_RefreshesCacheOfCaller(t *testing.T) {
+	var commitCalls []string
+	gitService := &MockGitService{
+		GetAllReposFunc: func(owner string) ([]*common_types.Repository, error) {
+			return []*common_types.Repository{{Name: "api", Owner: owner}, {Name: "web", Owner: owner}}, nil
+		},
+		GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+			commitCalls = append(commitCalls, repoIdentifier.(string))
+			return []*common_types.Commit{{SHA: "fresh"}}, nil
+		},
+	}
+	cache := mapCache{}
+	ghAPI := NewGithubApi(gitService, cache)
+	identity, err := CallerIdentity("api_key:dashboard")
+	if err != nil {
+		t.Fatalf("CallerIdentity returned error: %v", err)
+	}
+	precomputer, err := NewPrecomputer("github", ghAPI.Endpoints(), "octo-org", []string{"/commits"}, identity)
+	if err != nil {
+		t.Fatalf("NewPrecomputer returned error: %v", err)
+	}
+
+	// A stale entry is overwritten rather than served.
+	partition := "identity_" + identity.cachePartition() + "_"
+	cache[partition+"github_get_commits_octo-org_api"] = []byte(`[{"SHA":"stale"}]`)
+	if err := precomputer.Run(context.Background()); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if strings.Join(commitCalls, ",") != "octo-org/api,octo-org/web" {
+		t.Errorf("expected the commits of both repositories to be fetched, got %v", commitCalls)
+	}
+	if cached := string(cache[partition+"github_get_commits_octo-org_api"]); !strings.Contains(cached, "fresh") {
+		t.Errorf("expected the stale entry to be refreshed, got %s", cached)
+	}
+
+	// The caller the cache was filled for is served from it.
+	authenticator, err := NewAuthenticator(AuthOptions{APIKeys: map[string]string{"dashboard": hashKey("dashboard-key")}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator returned error: %v", err)
+	}
+	router := mux.NewRouter()
+	RegisterProvider(router, "github", ghAPI.Endpoints(), authenticator, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/github/commits?owner=octo-org&repo=web", nil)
+	req.Header.Set(APIKeyHeader, "dashboard-key")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || len(commitCalls) != 2 {
+		t.Errorf("expected a cache hit, got %d after %d provider calls: %s", rr.Code, len(commitCalls), rr.Body.String())
+	}
+}
+
+func TestPrecomputer_RefreshesGitlabProjectsByIDAndPath(t *testing.T) {
+	var commitCalls []string
+	gitService := &MockGitService{
+		GetAllReposFunc: func(owner string) ([]*common_types.Repository, error) {
+			return []*common_types.Repository{{ID: 42, Name: "API", Owner: owner, FullName: "group/api"}}, nil
+		},
+		GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+			commitCalls = append(commitCalls, fmt.Sprint(repoIdentifier))
+			return []*common_types.Commit{{SHA: "fresh"}}, nil
+		},
+	}
+	cache := mapCache{}
+	precomputer, err := NewPrecomputer("gitlab", NewGitlabApi(gitService, cache).Endpoints(), "group", []string{"/commits"}, Identity{})
+	if err != nil {
+		t.Fatalf("NewPrecomputer returned error: %v", err)
+	}
+	if err := precomputer.Run(context.Background()); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if strings.Join(commitCalls, ",") != "42,group/api" {
+		t.Errorf("expected the commits to be fetched by ID and by path, got %v", commitCalls)
+	}
+	for _, key := range []string{"gitlab_get_commits_42", "gitlab_get_commits_group/api"} {
+		if !strings.Contains(string(cache[key]), "fresh") {
+			t.Errorf("expected %s to be refreshed", key)
+		}
+	}
+}
+
+func TestPrecomputer_ReportsFailures(t *testing.T) {
+	gitService := &MockGitService{
+		GetAllReposFunc: func(owner string) ([]*common_types.Repository, error) {
+			return []*common_types.Repository{{Name: "api", Owner: owner}, {Name: "web", Owner: owner}}, nil
+		},
+		GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+			if repoIdentifier == "octo-org/web" {
+				return nil, errors.New("boom")
+			}
+			return nil, nil
+		},
+	}
+	precomputer, err := NewPrecomputer("github", NewGithubApi(gitService, mapCache{}).Endpoints(), "octo-org", []string{"/commits"}, Identity{})
+	if err != nil {
+		t.Fatalf("NewPrecomputer returned error: %v", err)
+	}
+	if err := precomputer.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "1 of 2 refreshes") {
+		t.Errorf("expected one failed refresh to be reported, got %v", err)
+	}
+}
+
+func TestNewPrecomputer_RejectsUnknownEndpoints(t *testing.T) {
+	endpoints := NewGitlabApi(&MockGitService{}, mapCache{}).Endpoints()
+	for _, paths := range [][]string{{"/nope"}, {"/repos"}, {"/loc"}} {
+		if _, err := NewPrecomputer("gitlab", endpoints, "group", paths, Identity{}); err == nil {
+			t.Errorf("NewPrecomputer(%v) returned no error", paths)
+		}
+	}
+	if _, err := CallerIdentity("root"); err == nil {
+		t.Error("CallerIdentity accepted a caller without a method")
+	}
+}
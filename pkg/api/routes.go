@@ -0,0 +1,165 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/changelog"
+	"github.com/ahmetk3436/git-stats-golang/pkg/policy"
+	"github.com/gorilla/mux"
+)
+
+// Endpoint describes one provider route: where it is mounted, what it returns and which query
+// parameters its handler reads. The same list registers the routes and generates the OpenAPI
+// document, so the two cannot drift apart.
+type Endpoint struct {
+	Path         string           // Path below the provider prefix, e.g. "/commits".
+	OperationID  string           // Unique name of the operation within its provider, e.g. "getCommits".
+	Summary      string           // One-line description.
+	Parameters   []Parameter      // Query parameters by their v1 names; deprecated aliases are listed too.
+	ContentTypes []string         // Content types of a successful response; JSON when empty.
+	Action       string           // Access policy action, e.g. policy.ActionClone; policy.ActionRead when empty.
+	Async        bool             // Whether /api/v1 runs the endpoint as a background job; see JobsApi.Async.
+	Handler      http.HandlerFunc // Handler serving GET requests.
+}
+
+// Parameter describes one query parameter of an Endpoint.
+type Parameter struct {
+	Name        string   // Query parameter name.
+	Description string   // What the parameter selects.
+	Required    bool     // Whether the handler rejects requests without it.
+	Deprecated  bool     // Whether the name is a pre-v1 alias.
+	Type        string   // JSON schema type: "string" or "integer".
+	Format      string   // JSON schema format, e.g. "date-time"; empty if none.
+	Enum        []string // Accepted values; empty if any value of Type is accepted.
+	Example     string   // Example value, also used by the handler tests.
+}
+
+// Shared query parameters of the GitHub and GitLab endpoints.
+var (
+	ownerParam = Parameter{Name: ParamOwner, Description: "Repository owner (user or organization).", Required: true, Type: "string", Example: "octo-org"}
+	repoParam  = Parameter{Name: ParamRepo, Description: "Repository name.", Required: true, Type: "string", Example: "hello-world"}
+
+	projectParam = Parameter{Name: ParamProject, Description: "Project ID or URL-encoded namespace/path.", Required: true, Type: "string", Example: "42"}
+
+	issuesSinceParam   = Parameter{Name: "since", Description: "Only issues updated at or after this time (RFC 3339).", Type: "string", Format: "date-time", Example: "2024-01-01T00:00:00Z"}
+	labelsParam        = Parameter{Name: "labels", Description: "Comma separated labels the issues must carry.", Type: "string", Example: "bug"}
+	tzParam            = Parameter{Name: "tz", Description: "IANA time zone the heatmap is bucketed in.", Type: "string", Example: "Europe/Istanbul"}
+	workStartParam     = Parameter{Name: "workStart", Description: "First working hour, inclusive (0-23).", Type: "integer", Example: "9"}
+	workEndParam       = Parameter{Name: "workEnd", Description: "End of working hours, exclusive (1-24).", Type: "integer", Example: "18"}
+	windowSinceParam   = Parameter{Name: "since", Description: "Only commits authored at or after this time (RFC 3339); 90 days before until by default.", Type: "string", Format: "date-time", Example: "2024-01-01T00:00:00Z"}
+	windowUntilParam   = Parameter{Name: "until", Description: "Only commits authored before this time (RFC 3339); the start of the next UTC day by default.", Type: "string", Format: "date-time", Example: "2024-07-01T00:00:00Z"}
+	periodParam        = Parameter{Name: "period", Description: "Bucket size of the breakdown.", Type: "string", Enum: []string{analytics.PeriodWeek, analytics.PeriodMonth}, Example: analytics.PeriodWeek}
+	fromParam          = Parameter{Name: "from", Description: "Tag or SHA the changelog starts after.", Required: true, Type: "string", Example: "v1.4.0"}
+	toParam            = Parameter{Name: "to", Description: "Tag or SHA the changelog ends at; the default branch when omitted.", Type: "string", Example: "v1.5.0"}
+	formatParam        = Parameter{Name: "format", Description: "Changelog format.", Type: "string", Enum: changelog.Formats, Example: changelog.FormatJSON}
+	daysParam          = Parameter{Name: "days", Description: "Days without commits after which a branch is stale.", Type: "integer", Example: "90"}
+	baseParam          = Parameter{Name: "base", Description: "Ref the comparison starts from; requires head.", Type: "string", Example: "v1.4.0"}
+	headParam          = Parameter{Name: "head", Description: "Ref the comparison ends at; requires base.", Type: "string", Example: "v1.5.0"}
+	compareSinceParam  = Parameter{Name: "since", Description: "Start of the current period (RFC 3339); used with until instead of base and head.", Type: "string", Format: "date-time", Example: "2024-06-01T00:00:00Z"}
+	compareUntilParam  = Parameter{Name: "until", Description: "End of the current period (RFC 3339), exclusive.", Type: "string", Format: "date-time", Example: "2024-07-01T00:00:00Z"}
+	previousSinceParam = Parameter{Name: "previousSince", Description: "Start of the period compared against; the period just before since by default.", Type: "string", Format: "date-time", Example: "2024-05-01T00:00:00Z"}
+	previousUntilParam = Parameter{Name: "previousUntil", Description: "End of the period compared against, exclusive.", Type: "string", Format: "date-time", Example: "2024-06-01T00:00:00Z"}
+	reposOwnerParam    = Parameter{Name: ParamOwner, Description: "Only list repositories of this user or organization.", Type: "string", Example: "octo-org"}
+	legacyIDParam      = Parameter{Name: legacyParamProjectID, Description: "Repository ID or \"owner/name\"; use owner and repo instead.", Deprecated: true, Type: "string", Example: "octo-org/hello-world"}
+	legacyRepoURLParam = Parameter{Name: legacyParamRepoURL, Description: "Clone URL of the repository; use owner and repo instead.", Deprecated: true, Type: "string", Example: "https://github.com/octo-org/hello-world.git"}
+)
+
+// withAliases appends the deprecated aliases of params, so that the document lists every name the
+// handlers accept.
+func withAliases(params ...Parameter) []Parameter {
+	result := append([]Parameter{}, params...)
+	for _, param := range params {
+		for _, alias := range deprecatedAliases[param.Name] {
+			result = append(result, Parameter{
+				Name:        alias,
+				Description: "Deprecated alias of " + param.Name + ".",
+				Deprecated:  true,
+				Type:        param.Type,
+				Example:     param.Example,
+			})
+		}
+	}
+	return result
+}
+
+// Endpoints lists the GitHub routes, mounted below /api/github.
+func (ghAPI *GithubApi) Endpoints() []Endpoint {
+	repo := []Parameter{ownerParam, repoParam}
+	return []Endpoint{
+		{Path: "/commits", OperationID: "getCommits", Summary: "List the commits of a repository.", Parameters: withAliases(repo...), Handler: ghAPI.GetAllCommits},
+		{Path: "/repo", OperationID: "getRepo", Summary: "Get a repository.", Parameters: append(withAliases(optional(repo)...), legacyIDParam), Handler: ghAPI.GetRepo},
+		{Path: "/repos", OperationID: "listRepos", Summary: "List repositories.", Parameters: []Parameter{reposOwnerParam}, Action: policy.ActionList, Handler: ghAPI.GetAllRepos},
+		{Path: "/loc", OperationID: "getLinesOfCode", Summary: "Count the lines of code of a repository by cloning it.", Parameters: append(withAliases(optional(repo)...), legacyRepoURLParam), Action: policy.ActionClone, Async: true, Handler: ghAPI.GetRepoTotalLinesOfCode},
+		{Path: "/contributors", OperationID: "listContributors", Summary: "List the contributors of a repository.", Parameters: withAliases(repo...), Handler: ghAPI.GetContributors},
+		{Path: "/issues/stats", OperationID: "getIssueStats", Summary: "Issue throughput, open-issue age and time-to-close by label.", Parameters: withAliases(ownerParam, repoParam, labelsParam, issuesSinceParam), Handler: ghAPI.GetIssueStats},
+		{Path: "/branches", OperationID: "listBranches", Summary: "List branches with last commit, ahead/behind and protection.", Parameters: withAliases(repo...), Handler: ghAPI.GetBranches},
+		{Path: "/branches/report", OperationID: "getBranchReport", Summary: "Report stale and merged branches.", Parameters: withAliases(ownerParam, repoParam, daysParam), Handler: ghAPI.GetBranchReport},
+		{Path: "/tags", OperationID: "listTags", Summary: "List tags with commit SHA, author and date.", Parameters: withAliases(repo...), Handler: ghAPI.GetTags},
+		{Path: "/activity", OperationID: "getActivity", Summary: "Commit heatmap (weekday x hour) per repository and author.", Parameters: withAliases(ownerParam, repoParam, tzParam, workStartParam, workEndParam, windowSinceParam, windowUntilParam), Handler: ghAPI.GetActivity},
+		{Path: "/commits/conventional", OperationID: "getConventionalCommitStats", Summary: "Features/fixes/chores breakdown and Conventional Commits conformance.", Parameters: withAliases(ownerParam, repoParam, periodParam, windowSinceParam, windowUntilParam), Handler: ghAPI.GetConventionalCommitStats},
+		{Path: "/changelog", OperationID: "getChangelog", Summary: "Grouped changelog between two refs.", Parameters: withAliases(ownerParam, repoParam, fromParam, toParam, formatParam), ContentTypes: changelogContentTypes, Handler: ghAPI.GetChangelog},
+		{Path: "/compare", OperationID: "compare", Summary: "Compare two refs, or the per-author changes of two periods.", Parameters: withAliases(ownerParam, repoParam, baseParam, headParam, compareSinceParam, compareUntilParam, previousSinceParam, previousUntilParam), Handler: ghAPI.GetCompare},
+	}
+}
+
+// Endpoints lists the GitLab routes, mounted below /api/gitlab.
+func (glAPI *GitlabApi) Endpoints() []Endpoint {
+	return []Endpoint{
+		{Path: "/commits", OperationID: "getCommits", Summary: "List the commits of a project.", Parameters: withAliases(projectParam), Handler: glAPI.GetAllCommits},
+		{Path: "/repo", OperationID: "getRepo", Summary: "Get a project.", Parameters: withAliases(projectParam), Handler: glAPI.GetRepo},
+		{Path: "/repos", OperationID: "listRepos", Summary: "List projects.", Parameters: []Parameter{reposOwnerParam}, Action: policy.ActionList, Handler: glAPI.GetAllRepos},
+		{Path: "/issues/stats", OperationID: "getIssueStats", Summary: "Issue throughput, open-issue age and time-to-close by label.", Parameters: withAliases(projectParam, labelsParam, issuesSinceParam), Handler: glAPI.GetIssueStats},
+		{Path: "/branches", OperationID: "listBranches", Summary: "List branches with last commit, ahead/behind and protection.", Parameters: withAliases(projectParam), Handler: glAPI.GetBranches},
+		{Path: "/branches/report", OperationID: "getBranchReport", Summary: "Report stale and merged branches.", Parameters: withAliases(projectParam, daysParam), Handler: glAPI.GetBranchReport},
+		{Path: "/tags", OperationID: "listTags", Summary: "List tags with commit SHA, author and date.", Parameters: withAliases(projectParam), Handler: glAPI.GetTags},
+		{Path: "/activity", OperationID: "getActivity", Summary: "Commit heatmap (weekday x hour) per project and author.", Parameters: withAliases(projectParam, tzParam, workStartParam, workEndParam, windowSinceParam, windowUntilParam), Handler: glAPI.GetActivity},
+		{Path: "/commits/conventional", OperationID: "getConventionalCommitStats", Summary: "Features/fixes/chores breakdown and Conventional Commits conformance.", Parameters: withAliases(projectParam, periodParam, windowSinceParam, windowUntilParam), Handler: glAPI.GetConventionalCommitStats},
+		{Path: "/changelog", OperationID: "getChangelog", Summary: "Grouped changelog between two refs.", Parameters: withAliases(projectParam, fromParam, toParam, formatParam), ContentTypes: changelogContentTypes, Handler: glAPI.GetChangelog},
+		{Path: "/compare", OperationID: "compare", Summary: "Compare two refs, or the per-author changes of two periods.", Parameters: withAliases(projectParam, baseParam, headParam, compareSinceParam, compareUntilParam, previousSinceParam, previousUntilParam), Handler: glAPI.GetCompare},
+	}
+}
+
+// changelogContentTypes are the responses of the changelog endpoints: JSON by default, Markdown
+// for the markdown and keepachangelog formats.
+var changelogContentTypes = []string{"application/json", "text/markdown"}
+
+// optional returns copies of params that are not required. It describes endpoints that accept
+// either the v1 parameters or a deprecated replacement for all of them.
+func optional(params []Parameter) []Parameter {
+	result := make([]Parameter, len(params))
+	for i, param := range params {
+		param.Required = false
+		result[i] = param
+	}
+	return result
+}
+
+// RegisterProvider mounts the endpoints of provider below /api/v1/<provider>, where errors are
+// answered with an ErrorEnvelope, and below the unversioned /api/<provider> kept for existing
+// clients. Both are guarded by authenticator and authorizer unless they are nil.
+func RegisterProvider(router *mux.Router, provider string, endpoints []Endpoint, authenticator *Authenticator, authorizer *Authorizer) {
+	v1Router := router.PathPrefix(APIV1Prefix + "/" + provider).Subrouter()
+	v1Router.Use(JSONErrors)
+	legacyRouter := router.PathPrefix("/api/" + provider).Subrouter()
+	if authenticator != nil {
+		v1Router.Use(authenticator.Middleware(provider))
+		legacyRouter.Use(authenticator.Middleware(provider))
+	}
+	if authorizer != nil {
+		authorized := make([]Endpoint, len(endpoints))
+		for i, endpoint := range endpoints {
+			authorized[i] = authorizer.Authorize(provider, endpoint)
+		}
+		endpoints = authorized
+	}
+	RegisterEndpoints(v1Router, endpoints)
+	RegisterEndpoints(legacyRouter, endpoints)
+}
+
+// RegisterEndpoints mounts endpoints on router for GET requests and CORS preflights.
+func RegisterEndpoints(router *mux.Router, endpoints []Endpoint) {
+	for _, endpoint := range endpoints {
+		router.HandleFunc(endpoint.Path, endpoint.Handler).Methods(http.MethodGet, http.MethodOptions)
+	}
+}
@@ -0,0 +1,451 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	appMetrics "github.com/ahmetk3436/git-stats-golang/pkg/prometheus"
+	"github.com/google/go-github/v56/github"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/xanzy/go-gitlab"
+)
+
+// WebhooksPath is the path below /api where provider webhooks are delivered.
+const WebhooksPath = "/webhooks"
+
+// GitLabTokenHeader is the header GitLab sends the secret token of a webhook in.
+const GitLabTokenHeader = "X-Gitlab-Token"
+
+// commitsPageSize is the number of commits the commit endpoints fetch and cache.
+const commitsPageSize = 100
+
+// maxWebhookPayloadBytes bounds the webhook payloads read; GitHub caps them at 25 MB.
+const maxWebhookPayloadBytes = 25 << 20
+
+// WebhooksApi receives provider webhooks and keeps the cached responses of the pushed repository
+// up to date instead of waiting for them to expire: the commits of a push to the default branch
+// are fetched and merged into the cached commit lists, and cached responses derived from the
+// commits, branches or tags that changed are deleted.
+type WebhooksApi struct {
+	Github       *GithubApi   // Nil when GitHub is not configured.
+	Gitlab       *GitlabApi   // Nil when GitLab is not configured.
+	GithubSecret string       // Secret GitHub deliveries are signed with; the receiver is off when empty.
+	GitlabToken  string       // Secret token GitLab deliveries carry; the receiver is off when empty.
+	Cache        *CachePolicy // TTLs merged commit lists are stored with; the handlers' own when nil.
+
+	// Identities whose caches are updated when the cache is not a storage.KeyScanner; the zero
+	// Identity is the cache of an open API. A scanner lists the keys of every partition, those of
+	// forwarded provider tokens included, so pushed commits are merged into all of them.
+	Identities []Identity
+}
+
+// WebhookResult is the response to a webhook delivery.
+type WebhookResult struct {
+	Event       string `json:"event"`                // Provider event, e.g. "push" or "Push Hook".
+	Repository  string `json:"repository,omitempty"` // "owner/repo" or the GitLab project path.
+	Ignored     bool   `json:"ignored,omitempty"`    // The event does not affect cached responses.
+	Ingested    int    `json:"ingested"`             // New commits merged into the cached commit lists.
+	Invalidated int    `json:"invalidated"`          // Cached responses deleted; see invalidate.
+}
+
+// webhookEvent is a provider event reduced to what it changes in the cache.
+type webhookEvent struct {
+	name            string
+	repository      string
+	repoIdentifier  interface{} // GitService identifier of the repository.
+	cacheKeyRepos   []string    // Forms the endpoints cache the repository under.
+	commitsChanged  bool        // The default branch got new commits.
+	before, after   string      // Range of a push to the default branch to ingest; empty if it cannot be.
+	branchesChanged bool
+	tagsChanged     bool
+}
+
+// Register mounts the receivers with a secret below /api/webhooks, answering errors with an
+// ErrorEnvelope. They authenticate deliveries by their secret, not by API key or bearer token.
+func (webhooksAPI *WebhooksApi) Register(router *mux.Router) {
+	webhookRouter := router.PathPrefix("/api" + WebhooksPath).Subrouter()
+	webhookRouter.Use(JSONErrors)
+	if webhooksAPI.Github != nil && webhooksAPI.GithubSecret != "" {
+		webhookRouter.HandleFunc("/github", webhooksAPI.ReceiveGithub).Methods(http.MethodPost)
+	}
+	if webhooksAPI.Gitlab != nil && webhooksAPI.GitlabToken != "" {
+		webhookRouter.HandleFunc("/gitlab", webhooksAPI.ReceiveGitlab).Methods(http.MethodPost)
+	}
+}
+
+// ReceiveGithub handles POST /api/webhooks/github: ping, push, pull_request and release events
+// signed with GithubSecret.
+func (webhooksAPI *WebhooksApi) ReceiveGithub(w http.ResponseWriter, r *http.Request) {
+	eventType := github.WebHookType(r)
+	logCtx := log.WithFields(logrus.Fields{"provider": "github", "event": eventType, "delivery": github.DeliveryID(r), "request_id": requestIDFromContext(r.Context())})
+	// The signature covers the whole payload, so it is read before it can be checked.
+	r.Body = http.MaxBytesReader(w, r.Body, maxWebhookPayloadBytes)
+	payload, err := github.ValidatePayload(r, []byte(webhooksAPI.GithubSecret))
+	if err != nil {
+		logCtx.WithField("error", err).Warn("Rejected webhook delivery with an invalid signature.")
+		appMetrics.WebhookEventsTotal.WithLabelValues("github", eventType, "rejected").Inc()
+		writeError(w, r, "github", APIError{Status: http.StatusUnauthorized, Code: ErrorCodeInvalidSignature, Message: "The delivery is not signed with the webhook secret."})
+		return
+	}
+
+	event, err := parseGithubEvent(eventType, payload)
+	if err != nil {
+		logCtx.WithField("error", err).Warn("Failed to parse webhook delivery.")
+		appMetrics.WebhookEventsTotal.WithLabelValues("github", eventType, "rejected").Inc()
+		writeError(w, r, "github", invalidRequest("The delivery payload is not a valid "+eventType+" event."))
+		return
+	}
+	webhooksAPI.apply(w, r, "github", webhooksAPI.Github.Repo, webhooksAPI.Github.Redis, webhooksAPI.Github.Defaults, event)
+}
+
+// ReceiveGitlab handles POST /api/webhooks/gitlab: push, tag push, merge request and release
+// events carrying GitlabToken.
+func (webhooksAPI *WebhooksApi) ReceiveGitlab(w http.ResponseWriter, r *http.Request) {
+	eventType := string(gitlab.HookEventType(r))
+	logCtx := log.WithFields(logrus.Fields{"provider": "gitlab", "event": eventType, "request_id": requestIDFromContext(r.Context())})
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(GitLabTokenHeader)), []byte(webhooksAPI.GitlabToken)) != 1 {
+		logCtx.Warn("Rejected webhook delivery with an invalid token.")
+		appMetrics.WebhookEventsTotal.WithLabelValues("gitlab", eventType, "rejected").Inc()
+		writeError(w, r, "gitlab", APIError{Status: http.StatusUnauthorized, Code: ErrorCodeInvalidSignature, Message: "The delivery does not carry the webhook secret token."})
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayloadBytes))
+	if err == nil {
+		var event webhookEvent
+		if event, err = parseGitlabEvent(gitlab.EventType(eventType), payload); err == nil {
+			webhooksAPI.apply(w, r, "gitlab", webhooksAPI.Gitlab.Repo, webhooksAPI.Gitlab.Redis, webhooksAPI.Gitlab.Defaults, event)
+			return
+		}
+	}
+	logCtx.WithField("error", err).Warn("Failed to parse webhook delivery.")
+	appMetrics.WebhookEventsTotal.WithLabelValues("gitlab", eventType, "rejected").Inc()
+	writeError(w, r, "gitlab", invalidRequest("The delivery payload is not a valid "+eventType+" event."))
+}
+
+// parseGithubEvent reduces a GitHub delivery of eventType to a webhookEvent. Events other than
+// push, merged pull requests and releases change nothing.
+func parseGithubEvent(eventType string, payload []byte) (webhookEvent, error) {
+	switch eventType {
+	case "push", "pull_request", "release":
+	default:
+		return webhookEvent{name: eventType}, nil
+	}
+	parsed, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		return webhookEvent{}, err
+	}
+
+	var event webhookEvent
+	switch e := parsed.(type) {
+	case *github.PushEvent:
+		event = githubRepoEvent(e.GetRepo().GetFullName())
+		ref := e.GetRef()
+		event.branchesChanged = strings.HasPrefix(ref, "refs/heads/")
+		event.tagsChanged = strings.HasPrefix(ref, "refs/tags/")
+		if ref == "refs/heads/"+e.GetRepo().GetDefaultBranch() {
+			event.commitsChanged = true
+			if !e.GetForced() && !e.GetCreated() && !e.GetDeleted() {
+				event.before, event.after = e.GetBefore(), e.GetAfter()
+			}
+		}
+	case *github.PullRequestEvent:
+		event = githubRepoEvent(e.GetRepo().GetFullName())
+		if e.GetAction() == "closed" && e.GetPullRequest().GetMerged() {
+			event.branchesChanged = true
+			event.commitsChanged = e.GetPullRequest().GetBase().GetRef() == e.GetRepo().GetDefaultBranch()
+		}
+	case *github.ReleaseEvent:
+		event = githubRepoEvent(e.GetRepo().GetFullName())
+		event.tagsChanged = true
+	}
+	event.name = eventType
+	if event.repository == "" {
+		return webhookEvent{}, errors.New("the payload names no repository")
+	}
+	return event, nil
+}
+
+// githubRepoEvent returns a webhookEvent of the GitHub repository fullName ("owner/repo").
+func githubRepoEvent(fullName string) webhookEvent {
+	if !strings.Contains(fullName, "/") {
+		return webhookEvent{}
+	}
+	return webhookEvent{repository: fullName, repoIdentifier: fullName, cacheKeyRepos: []string{strings.Replace(fullName, "/", "_", 1)}}
+}
+
+// parseGitlabEvent reduces a GitLab delivery of eventType to a webhookEvent. Events other than
+// pushes, tag pushes, merged merge requests and releases change nothing. GitLab does not flag
+// force pushes; their commits are merged like any other.
+func parseGitlabEvent(eventType gitlab.EventType, payload []byte) (webhookEvent, error) {
+	switch eventType {
+	case gitlab.EventTypePush, gitlab.EventTypeTagPush, gitlab.EventTypeMergeRequest, gitlab.EventTypeRelease:
+	default:
+		return webhookEvent{name: string(eventType)}, nil
+	}
+	parsed, err := gitlab.ParseWebhook(eventType, payload)
+	if err != nil {
+		return webhookEvent{}, err
+	}
+
+	var event webhookEvent
+	switch e := parsed.(type) {
+	case *gitlab.PushEvent:
+		event = gitlabProjectEvent(e.ProjectID, e.Project.PathWithNamespace)
+		event.branchesChanged = true
+		if e.Ref == "refs/heads/"+e.Project.DefaultBranch {
+			event.commitsChanged = true
+			if !isZeroSHA(e.Before) && !isZeroSHA(e.After) {
+				event.before, event.after = e.Before, e.After
+			}
+		}
+	case *gitlab.TagEvent:
+		event = gitlabProjectEvent(e.ProjectID, e.Project.PathWithNamespace)
+		event.tagsChanged = true
+	case *gitlab.MergeEvent:
+		event = gitlabProjectEvent(e.Project.ID, e.Project.PathWithNamespace)
+		if e.ObjectAttributes.Action == "merge" {
+			event.branchesChanged = true
+			event.commitsChanged = e.ObjectAttributes.TargetBranch == e.Project.DefaultBranch
+		}
+	case *gitlab.ReleaseEvent:
+		event = gitlabProjectEvent(e.Project.ID, e.Project.PathWithNamespace)
+		event.tagsChanged = true
+	}
+	event.name = string(eventType)
+	if event.repository == "" {
+		return webhookEvent{}, errors.New("the payload names no project")
+	}
+	return event, nil
+}
+
+// gitlabProjectEvent returns a webhookEvent of a GitLab project, cached under its ID and path.
+func gitlabProjectEvent(projectID int, path string) webhookEvent {
+	if projectID == 0 || path == "" {
+		return webhookEvent{}
+	}
+	return webhookEvent{repository: path, repoIdentifier: projectID, cacheKeyRepos: []string{strconv.Itoa(projectID), path}}
+}
+
+// isZeroSHA reports whether sha is the all-zero SHA providers send for created and deleted refs.
+func isZeroSHA(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
+
+// apply updates the cache of every identity for event and answers r with the WebhookResult.
+func (webhooksAPI *WebhooksApi) apply(w http.ResponseWriter, r *http.Request, provider string, gitService interfaces.GitService, cache storage.InMemoryDB, defaults AnalyticsDefaults, event webhookEvent) {
+	logCtx := log.WithFields(logrus.Fields{"provider": provider, "event": event.name, "repository": event.repository, "request_id": requestIDFromContext(r.Context())})
+	result := WebhookResult{Event: event.name, Repository: event.repository}
+	if !event.commitsChanged && !event.branchesChanged && !event.tagsChanged {
+		result.Ignored = true
+		appMetrics.WebhookEventsTotal.WithLabelValues(provider, event.name, "ignored").Inc()
+		writeJSON(w, r, http.StatusOK, result)
+		return
+	}
+
+	var newCommits []*common_types.Commit
+	ingest := event.before != ""
+	if ingest {
+		var err error
+		newCommits, err = gitService.GetProjectCommits(event.repoIdentifier, &interfaces.CommitListOptions{SHA: event.after, BaseRef: event.before})
+		if err != nil {
+			logCtx.WithField("error", err).Warn("Failed to fetch pushed commits; invalidating the cached commits instead.")
+			ingest = false
+		}
+	}
+
+	staleKinds := map[string]bool{}
+	if event.commitsChanged {
+		staleKinds["commits"] = !ingest
+		staleKinds["activity"], staleKinds["conventional_commits"] = true, true
+		staleKinds["changelog"], staleKinds["compare_refs"], staleKinds["compare_periods"] = true, true, true
+	}
+	if event.commitsChanged || event.branchesChanged {
+		staleKinds["branches"], staleKinds["branch_report"] = true, true
+	}
+	if event.tagsChanged {
+		staleKinds["tags"] = true
+	}
+	result.Invalidated = webhooksAPI.invalidate(logCtx, provider, cache, defaults, event, staleKinds)
+
+	if ingest {
+		commitsCache := webhooksAPI.Cache.store("/commits", cache)
+		for _, key := range webhooksAPI.commitsKeys(logCtx, provider, cache, event) {
+			ingested, err := mergeCachedCommits(commitsCache, key, newCommits)
+			if err != nil {
+				logCtx.WithFields(logrus.Fields{"key": key, "error": err}).Error("Failed to merge pushed commits into the cache.")
+				if err := commitsCache.Delete(key); err == nil {
+					result.Invalidated++
+				}
+				continue
+			}
+			result.Ingested += ingested
+		}
+	}
+
+	logCtx.WithFields(logrus.Fields{"ingested": result.Ingested, "invalidated": result.Invalidated}).Info("Webhook applied.")
+	appMetrics.WebhookEventsTotal.WithLabelValues(provider, event.name, "applied").Inc()
+	writeJSON(w, r, http.StatusOK, result)
+}
+
+// invalidate deletes the responses of the kinds in staleKinds cached for the repository of event
+// and returns how many it deleted. When cache lists its keys, every variant is deleted, whatever
+// its parameters or caller partition. Otherwise only the responses with the default parameters of
+// defaults are, in the partitions of the configured identities.
+func (webhooksAPI *WebhooksApi) invalidate(logCtx *logrus.Entry, provider string, cache storage.InMemoryDB, defaults AnalyticsDefaults, event webhookEvent, staleKinds map[string]bool) int {
+	invalidated := 0
+	if scanner, ok := cache.(storage.KeyScanner); ok {
+		keys, err := scanner.Keys("*" + provider + "_get_*")
+		if err == nil {
+			for _, key := range keys {
+				kind, repo, ok := parseResponseCacheKey(key, provider)
+				if !ok || !staleKinds[kind] || !event.cachedAs(provider, repo) {
+					continue
+				}
+				if err := cache.Delete(key); err != nil {
+					logCtx.WithFields(logrus.Fields{"key": key, "error": err}).Error("Redis DEL error for webhook.")
+					continue
+				}
+				if !strings.HasSuffix(key, freshSuffix) {
+					invalidated++
+				}
+			}
+			return invalidated
+		}
+		logCtx.WithField("error", err).Warn("Failed to list cache keys; invalidating the default responses only.")
+	}
+
+	var stale []string
+	window := defaultAnalysisWindow(time.Now())
+	for _, cacheKeyRepo := range event.cacheKeyRepos {
+		if staleKinds["commits"] {
+			stale = append(stale, commitsCacheKey(provider, cacheKeyRepo))
+		}
+		if staleKinds["activity"] {
+			stale = append(stale,
+				activityCacheKey(provider, cacheKeyRepo, analytics.ActivityOptions{Location: defaults.Location, WorkdayStartHour: defaults.WorkdayStartHour, WorkdayEndHour: defaults.WorkdayEndHour}, window),
+				conventionalCacheKey(provider, cacheKeyRepo, analytics.PeriodWeek, window),
+				conventionalCacheKey(provider, cacheKeyRepo, analytics.PeriodMonth, window),
+			)
+		}
+		if staleKinds["branches"] {
+			stale = append(stale, branchesCacheKey(provider, cacheKeyRepo), branchReportCacheKey(provider, cacheKeyRepo, defaults.StaleBranchDays))
+		}
+		if staleKinds["tags"] {
+			stale = append(stale, tagsCacheKey(provider, cacheKeyRepo))
+		}
+	}
+	for _, identity := range webhooksAPI.identities() {
+		identityCache := identityCache(identity, webhooksAPI.Cache.store("/commits", cache))
+		for _, key := range stale {
+			if err := identityCache.Delete(key); err != nil {
+				logCtx.WithFields(logrus.Fields{"key": key, "error": err}).Error("Redis DEL error for webhook.")
+				continue
+			}
+			invalidated++
+		}
+	}
+	return invalidated
+}
+
+// commitsKeys returns the keys the commit lists of the repository of event are cached under: in
+// every caller partition when cache lists its keys, otherwise in those of the configured identities.
+func (webhooksAPI *WebhooksApi) commitsKeys(logCtx *logrus.Entry, provider string, cache storage.InMemoryDB, event webhookEvent) []string {
+	if scanner, ok := cache.(storage.KeyScanner); ok {
+		keys, err := scanner.Keys("*" + provider + "_get_commits_*")
+		if err == nil {
+			var matched []string
+			for _, key := range keys {
+				kind, repo, ok := parseResponseCacheKey(key, provider)
+				if ok && kind == "commits" && !strings.HasSuffix(key, freshSuffix) && event.cachedAs(provider, repo) {
+					matched = append(matched, key)
+				}
+			}
+			return matched
+		}
+		logCtx.WithField("error", err).Warn("Failed to list cache keys; merging into the commits of the configured identities only.")
+	}
+
+	var keys []string
+	for _, identity := range webhooksAPI.identities() {
+		for _, cacheKeyRepo := range event.cacheKeyRepos {
+			keys = append(keys, identity.cacheKeyPrefix()+commitsCacheKey(provider, cacheKeyRepo))
+		}
+	}
+	return keys
+}
+
+// cachedAs reports whether repo, as returned by parseResponseCacheKey, is one of the forms the
+// endpoints cache the repository of e under.
+func (e webhookEvent) cachedAs(provider, repo string) bool {
+	for _, cacheKeyRepo := range e.cacheKeyRepos {
+		if strings.EqualFold(cacheKeyRepoPath(provider, cacheKeyRepo), repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// identities returns Identities, or the shared cache of an open API when none are set.
+func (webhooksAPI *WebhooksApi) identities() []Identity {
+	if len(webhooksAPI.Identities) == 0 {
+		return []Identity{{}}
+	}
+	return webhooksAPI.Identities
+}
+
+// mergeCachedCommits adds the commits of newCommits missing from the commit list cached under key,
+// newest first, and returns how many it added. Nothing is cached when key is not: the list is
+// fetched whole on the next request.
+func mergeCachedCommits(cache storage.InMemoryDB, key string, newCommits []*common_types.Commit) (int, error) {
+	cachedData, err := cache.Get(key)
+	if err != nil || cachedData == nil {
+		return 0, err
+	}
+	var cached []*common_types.Commit
+	if err := json.Unmarshal(cachedData, &cached); err != nil {
+		return 0, fmt.Errorf("failed to parse the cached commits: %w", err)
+	}
+
+	known := make(map[string]bool, len(cached))
+	for _, commit := range cached {
+		known[commit.SHA] = true
+	}
+	var added []*common_types.Commit
+	for _, commit := range newCommits {
+		if !known[commit.SHA] {
+			known[commit.SHA] = true
+			added = append(added, commit)
+		}
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+	sort.SliceStable(added, func(i, j int) bool { return added[i].Author.Date.After(added[j].Author.Date) })
+
+	merged := append(added, cached...)
+	if limit := max(len(cached), commitsPageSize); len(merged) > limit {
+		merged = merged[:limit]
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode the merged commits: %w", err)
+	}
+	if err := cache.Set(key, data, CacheTTL); err != nil {
+		return 0, err
+	}
+	return len(added), nil
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// webhookTestRouter mounts the receivers of both providers on cache, fetching commits with
+// gitService.
+func webhookTestRouter(gitService *MockGitService, cache mapCache, identities ...Identity) http.Handler {
+	webhooksAPI := &WebhooksApi{
+		Github:       NewGithubApi(gitService, cache),
+		Gitlab:       NewGitlabApi(gitService, cache),
+		GithubSecret: "hook-secret",
+		GitlabToken:  "hook-token",
+		Identities:   identities,
+	}
+	router := mux.NewRouter()
+	webhooksAPI.Register(router)
+	return router
+}
+
+// deliverGithub posts payload as a GitHub event signed with secret.
+func deliverGithub(router http.Handler, event, secret, payload string) *httptest.ResponseRecorder {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/github", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// deliverGitlab posts payload as a GitLab event carrying token.
+func deliverGitlab(router http.Handler, event, token, payload string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/gitlab", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gitlab-Event", event)
+	req.Header.Set(GitLabTokenHeader, token)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func decodeWebhookResult(t *testing.T, rr *httptest.ResponseRecorder) WebhookResult {
+	t.Helper()
+	var result WebhookResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("expected a webhook result, got %d: %s", rr.Code, rr.Body.String())
+	}
+	return result
+}
+
+func cachedCommitSHAs(t *testing.T, cache mapCache, key string) []string {
+	t.Helper()
+	var commits []*common_types.Commit
+	if err := json.Unmarshal(cache[key], &commits); err != nil {
+		t.Fatalf("cache entry %s is not a commit list: %v", key, err)
+	}
+	var shas []string
+	for _, commit := range commits {
+		shas = append(shas, commit.SHA)
+	}
+	return shas
+}
+
+const githubPushPayload = `{"ref":"refs/heads/main","before":"aaa","after":"ccc","forced":%t,
+	"repository":{"name":"repo","full_name":"octo-org/repo","default_branch":"main","owner":{"login":"octo-org"}}}`
+
+func TestWebhooksApi_GithubPushIngestsCommits(t *testing.T) {
+	var ranges []string
+	now := time.Now()
+	gitService := &MockGitService{GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+		ranges = append(ranges, fmt.Sprintf("%v %s..%s", repoIdentifier, options.BaseRef, options.SHA))
+		return []*common_types.Commit{
+			{SHA: "bbb", Author: common_types.CommitAuthor{Date: now.Add(-time.Minute)}},
+			{SHA: "ccc", Author: common_types.CommitAuthor{Date: now}},
+		}, nil
+	}}
+	dashboard := Identity{Subject: "dashboard", Method: AuthMethodAPIKey}
+	partition := "identity_" + dashboard.cachePartition() + "_"
+	cache := mapCache{
+		"github_get_commits_octo-org_repo":                   []byte(`[{"SHA":"aaa"}]`),
+		partition + "github_get_commits_octo-org_repo":       []byte(`[{"SHA":"aaa"}]`),
+		"github_get_activity_octo-org_repo:UTC_9_18":         []byte(`{}`),
+		"github_get_conventional_commits_octo-org_repo:week": []byte(`{}`),
+		"github_get_branches_octo-org_repo":                  []byte(`[]`),
+		"github_get_tags_octo-org_repo":                      []byte(`[]`),
+		// Variants with other parameters, of forwarded tokens and of a repository named alike.
+		"github_get_activity_octo-org_repo:Europe/Istanbul_8_17":                       []byte(`{}`),
+		"github_get_activity_octo-org_repo:Europe/Istanbul_8_17:fresh":                 []byte(`1`),
+		"identity_0123456789abcdef_github_get_branch_report_octo-org_Repo:60":          []byte(`{}`),
+		"identity_0123456789abcdef_github_get_conventional_commits_octo-org_repo:week": []byte(`{}`),
+		"github_get_activity_octo-org_repo_v2:UTC_9_18":                                []byte(`{}`),
+		"identity_0123456789abcdef_github_get_commits_octo-org_Repo":                   []byte(`[{"SHA":"aaa"}]`),
+		"github_get_changelog_octo-org_repo:v1.0_v1.1_markdown":                        []byte(`{}`),
+		"identity_0123456789abcdef_github_get_compare_refs_octo-org_repo:main_dev":     []byte(`{}`),
+		"github_get_compare_periods_octo-org_repo:2024-01-01_2024-01-31":               []byte(`{}`),
+	}
+	router := webhookTestRouter(gitService, cache, Identity{}, dashboard)
+
+	result := decodeWebhookResult(t, deliverGithub(router, "push", "hook-secret", fmt.Sprintf(githubPushPayload, false)))
+	if result.Event != "push" || result.Repository != "octo-org/repo" || result.Ingested != 6 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(ranges) != 1 || ranges[0] != "octo-org/repo aaa..ccc" {
+		t.Errorf("expected the pushed range to be fetched once, got %v", ranges)
+	}
+	for _, key := range []string{"github_get_commits_octo-org_repo", partition + "github_get_commits_octo-org_repo", "identity_0123456789abcdef_github_get_commits_octo-org_Repo"} {
+		if shas := strings.Join(cachedCommitSHAs(t, cache, key), ","); shas != "ccc,bbb,aaa" {
+			t.Errorf("%s: expected the pushed commits ahead of the cached ones, got %s", key, shas)
+		}
+	}
+	if result.Invalidated != 9 {
+		t.Errorf("expected 9 cached responses to be invalidated, got %d", result.Invalidated)
+	}
+	for _, key := range []string{
+		"github_get_activity_octo-org_repo:UTC_9_18", "github_get_conventional_commits_octo-org_repo:week", "github_get_branches_octo-org_repo",
+		"github_get_activity_octo-org_repo:Europe/Istanbul_8_17", "github_get_activity_octo-org_repo:Europe/Istanbul_8_17:fresh",
+		"identity_0123456789abcdef_github_get_branch_report_octo-org_Repo:60", "identity_0123456789abcdef_github_get_conventional_commits_octo-org_repo:week",
+		"github_get_changelog_octo-org_repo:v1.0_v1.1_markdown", "identity_0123456789abcdef_github_get_compare_refs_octo-org_repo:main_dev",
+		"github_get_compare_periods_octo-org_repo:2024-01-01_2024-01-31",
+	} {
+		if _, ok := cache[key]; ok {
+			t.Errorf("expected %s to be invalidated", key)
+		}
+	}
+	if _, ok := cache["github_get_activity_octo-org_repo_v2:UTC_9_18"]; !ok {
+		t.Error("a push must not invalidate the responses of another repository")
+	}
+	if _, ok := cache["github_get_tags_octo-org_repo"]; !ok {
+		t.Error("a branch push must not invalidate the tags")
+	}
+
+	// A force push rewrote history: the cached commits are dropped instead.
+	result = decodeWebhookResult(t, deliverGithub(router, "push", "hook-secret", fmt.Sprintf(githubPushPayload, true)))
+	if _, ok := cache["github_get_commits_octo-org_repo"]; ok || result.Ingested != 0 || len(ranges) != 1 {
+		t.Errorf("expected a force push to invalidate the commits without fetching, got %+v", result)
+	}
+}
+
+func TestWebhooksApi_GithubRejectsInvalidSignatures(t *testing.T) {
+	cache := mapCache{"github_get_commits_octo-org_repo": []byte(`[]`)}
+	router := webhookTestRouter(&MockGitService{}, cache)
+
+	rr := deliverGithub(router, "push", "guess", fmt.Sprintf(githubPushPayload, true))
+	var envelope ErrorEnvelope
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil || rr.Code != http.StatusUnauthorized || envelope.Error.Code != ErrorCodeInvalidSignature {
+		t.Errorf("expected 401 %s, got %d: %s", ErrorCodeInvalidSignature, rr.Code, rr.Body.String())
+	}
+	if _, ok := cache["github_get_commits_octo-org_repo"]; !ok {
+		t.Error("an unsigned delivery must not touch the cache")
+	}
+
+	if result := decodeWebhookResult(t, deliverGithub(router, "issues", "hook-secret", `{}`)); !result.Ignored {
+		t.Errorf("expected an issues event to be ignored, got %+v", result)
+	}
+}
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	io.Reader
+	read int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.read += int64(n)
+	return n, err
+}
+
+func TestWebhooksApi_GithubStopsReadingOversizedDeliveries(t *testing.T) {
+	router := webhookTestRouter(&MockGitService{}, mapCache{})
+	body := &countingReader{Reader: io.LimitReader(zeroReader{}, 4*maxWebhookPayloadBytes)}
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/github", body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", "push")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected an oversized unsigned delivery to be rejected, got %d", rr.Code)
+	}
+	if body.read > maxWebhookPayloadBytes+64<<10 {
+		t.Errorf("expected reading to stop at %d bytes, read %d", maxWebhookPayloadBytes, body.read)
+	}
+}
+
+// zeroReader reads zeros forever.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestWebhooksApi_GitlabEvents(t *testing.T) {
+	gitService := &MockGitService{GetProjectCommitsFunc: func(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+		if repoIdentifier != 42 || options.BaseRef != "aaa" || options.SHA != "bbb" {
+			t.Errorf("unexpected range %v %s..%s", repoIdentifier, options.BaseRef, options.SHA)
+		}
+		return []*common_types.Commit{{SHA: "bbb"}}, nil
+	}}
+	cache := mapCache{
+		"gitlab_get_commits_42":         []byte(`[{"SHA":"aaa"}]`),
+		"gitlab_get_commits_group/proj": []byte(`[{"SHA":"aaa"}]`),
+		"gitlab_get_tags_42":            []byte(`[]`),
+	}
+	router := webhookTestRouter(gitService, cache)
+
+	if rr := deliverGitlab(router, "Push Hook", "guess", `{}`); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a wrong token, got %d", rr.Code)
+	}
+
+	push := `{"object_kind":"push","ref":"refs/heads/main","before":"aaa","after":"bbb","project_id":42,
+		"project":{"id":42,"path_with_namespace":"group/proj","default_branch":"main"}}`
+	if result := decodeWebhookResult(t, deliverGitlab(router, "Push Hook", "hook-token", push)); result.Ingested != 2 || result.Repository != "group/proj" {
+		t.Errorf("unexpected push result %+v", result)
+	}
+	if shas := strings.Join(cachedCommitSHAs(t, cache, "gitlab_get_commits_group/proj"), ","); shas != "bbb,aaa" {
+		t.Errorf("expected the pushed commit to be merged, got %s", shas)
+	}
+
+	merge := `{"object_kind":"merge_request","project":{"id":42,"path_with_namespace":"group/proj","default_branch":"main"},
+		"object_attributes":{"action":"merge","target_branch":"main"}}`
+	decodeWebhookResult(t, deliverGitlab(router, "Merge Request Hook", "hook-token", merge))
+	if _, ok := cache["gitlab_get_commits_42"]; ok {
+		t.Error("expected a merged merge request to invalidate the cached commits")
+	}
+
+	release := `{"object_kind":"release","action":"create","project":{"id":42,"path_with_namespace":"group/proj"}}`
+	decodeWebhookResult(t, deliverGitlab(router, "Release Hook", "hook-token", release))
+	if _, ok := cache["gitlab_get_tags_42"]; ok {
+		t.Error("expected a release to invalidate the cached tags")
+	}
+}
+
+func TestMergeCachedCommits_KeepsPageSize(t *testing.T) {
+	cache := mapCache{}
+	var cached []*common_types.Commit
+	for i := 0; i < commitsPageSize; i++ {
+		cached = append(cached, &common_types.Commit{SHA: fmt.Sprintf("old-%d", i)})
+	}
+	data, _ := json.Marshal(cached)
+	cache["key"] = data
+
+	added, err := mergeCachedCommits(cache, "key", []*common_types.Commit{{SHA: "new"}, {SHA: "old-0"}})
+	shas := cachedCommitSHAs(t, cache, "key")
+	if err != nil || added != 1 || len(shas) != commitsPageSize || shas[0] != "new" || shas[len(shas)-1] != fmt.Sprintf("old-%d", commitsPageSize-2) {
+		t.Errorf("expected one new commit in a full page, got %d (%v), first %s of %d", added, err, shas[0], len(shas))
+	}
+	if added, err := mergeCachedCommits(cache, "missing", []*common_types.Commit{{SHA: "new"}}); added != 0 || err != nil {
+		t.Errorf("expected nothing to be cached for a missing entry, got %d (%v)", added, err)
+	}
+	if _, ok := cache["missing"]; ok {
+		t.Error("a missing entry must stay missing")
+	}
+}
@@ -0,0 +1,311 @@
+// Package auth verifies the OIDC bearer tokens API callers present.
+//
+// Only what the API needs is implemented: signed JWTs (RS256 and ES256) checked against the
+// issuer's published JSON Web Key Set, with the issuer, audience and validity period enforced.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken is wrapped by every error Verify returns for a token that must be rejected.
+var ErrInvalidToken = errors.New("invalid token")
+
+// clockSkew is how far exp and nbf may be off to allow for unsynchronized clocks.
+const clockSkew = time.Minute
+
+// minRefreshInterval limits how often an unknown key ID triggers a JWKS refetch.
+const minRefreshInterval = time.Minute
+
+// Claims are the verified claims of a token.
+type Claims struct {
+	Subject   string                 // "sub": who the token was issued to.
+	Issuer    string                 // "iss": the issuer URL.
+	Audience  []string               // "aud": who the token is meant for.
+	ExpiresAt time.Time              // "exp".
+	Raw       map[string]interface{} // All claims, e.g. for group or role lookups.
+}
+
+// OIDCVerifier verifies tokens of one issuer for one audience. It is safe for concurrent use.
+type OIDCVerifier struct {
+	issuer   string
+	audience string
+	jwksURL  string // Discovered from the issuer when empty.
+	client   *http.Client
+	now      func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey // By key ID.
+	fetchedAt time.Time
+}
+
+// NewOIDCVerifier returns a verifier for tokens issued by issuer to audience. jwksURL may be
+// empty to discover it from the issuer's /.well-known/openid-configuration. Keys are fetched on
+// first use; a nil client means http.DefaultClient.
+func NewOIDCVerifier(issuer, audience, jwksURL string, client *http.Client) *OIDCVerifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OIDCVerifier{
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		audience: audience,
+		jwksURL:  jwksURL,
+		client:   client,
+		now:      time.Now,
+	}
+}
+
+// jwtHeader is the JOSE header of a token.
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Verify checks the signature and claims of rawToken and returns its claims.
+func (v *OIDCVerifier) Verify(ctx context.Context, rawToken string) (*Claims, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a signed JWT", ErrInvalidToken)
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature: %v", ErrInvalidToken, err)
+	}
+
+	key, err := v.key(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(header.Algorithm, key, digest[:], signature); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims: %v", ErrInvalidToken, err)
+	}
+	return v.checkClaims(raw)
+}
+
+// verifySignature checks signature over digest, a SHA-256 hash, for the RS256 and ES256
+// algorithms. Any other algorithm, including "none" and the HMAC family, is rejected.
+func verifySignature(algorithm string, key crypto.PublicKey, digest, signature []byte) error {
+	switch algorithm {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: RS256 token signed with a non-RSA key", ErrInvalidToken)
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest, signature); err != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return fmt.Errorf("%w: ES256 token signed with a non-P-256 key", ErrInvalidToken)
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, algorithm)
+	}
+	return nil
+}
+
+// checkClaims enforces the issuer, audience and validity period of raw.
+func (v *OIDCVerifier) checkClaims(raw map[string]interface{}) (*Claims, error) {
+	claims := &Claims{Raw: raw}
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Issuer, _ = raw["iss"].(string)
+	switch audience := raw["aud"].(type) {
+	case string:
+		claims.Audience = []string{audience}
+	case []interface{}:
+		for _, entry := range audience {
+			if value, ok := entry.(string); ok {
+				claims.Audience = append(claims.Audience, value)
+			}
+		}
+	}
+
+	if strings.TrimSuffix(claims.Issuer, "/") != v.issuer {
+		return nil, fmt.Errorf("%w: issuer %q is not trusted", ErrInvalidToken, claims.Issuer)
+	}
+	audienceMatches := false
+	for _, audience := range claims.Audience {
+		audienceMatches = audienceMatches || audience == v.audience
+	}
+	if !audienceMatches {
+		return nil, fmt.Errorf("%w: token is not meant for audience %q", ErrInvalidToken, v.audience)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	now := v.now()
+	expiresAt, ok := raw["exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing expiry", ErrInvalidToken)
+	}
+	claims.ExpiresAt = time.Unix(int64(expiresAt), 0)
+	if now.After(claims.ExpiresAt.Add(clockSkew)) {
+		return nil, fmt.Errorf("%w: token expired at %s", ErrInvalidToken, claims.ExpiresAt.Format(time.RFC3339))
+	}
+	if notBefore, ok := raw["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(notBefore), 0)) {
+		return nil, fmt.Errorf("%w: token is not valid yet", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// key returns the public key with keyID, fetching the key set when it has not been fetched yet or
+// does not contain keyID, which happens after the issuer rotates its keys.
+func (v *OIDCVerifier) key(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[keyID]; ok {
+		return key, nil
+	}
+	if v.keys != nil && v.now().Sub(v.fetchedAt) < minRefreshInterval {
+		return nil, fmt.Errorf("%w: unknown key ID %q", ErrInvalidToken, keyID)
+	}
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.keys, v.fetchedAt = keys, v.now()
+	if key, ok := keys[keyID]; ok {
+		return key, nil
+	}
+	if keyID == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown key ID %q", ErrInvalidToken, keyID)
+}
+
+// jsonWebKey is one entry of a JWKS, restricted to RSA and EC keys.
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// fetchKeys downloads the signing keys of the issuer. Keys of unsupported types are skipped.
+func (v *OIDCVerifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	jwksURL := v.jwksURL
+	if jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(ctx, v.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, fmt.Errorf("failed to discover the OIDC configuration of %s: %w", v.issuer, err)
+		}
+		if discovery.JWKSURI == "" {
+			return nil, fmt.Errorf("OIDC configuration of %s has no jwks_uri", v.issuer)
+		}
+		jwksURL = discovery.JWKSURI
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, jwksURL, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch the JWKS of %s: %w", v.issuer, err)
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.KeyID] = key
+		}
+	}
+	return keys, nil
+}
+
+// publicKey decodes k into an *rsa.PublicKey or a P-256 *ecdsa.PublicKey.
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) > 4 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("invalid EC coordinates")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("EC point is not on the curve")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+// getJSON decodes the JSON document at url into target.
+func (v *OIDCVerifier) getJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a JWT into v.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testIssuer serves an OIDC discovery document and a JWKS holding an RSA and an EC key.
+type testIssuer struct {
+	server    *httptest.Server
+	rsaKey    *rsa.PrivateKey
+	ecKey     *ecdsa.PrivateKey
+	jwksCalls int
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	issuer := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.server.URL, "jwks_uri": issuer.server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.jwksCalls++
+		encode := base64.RawURLEncoding.EncodeToString
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encode(ecKey.X.FillBytes(make([]byte, 32))), "y": encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+		}})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// sign returns a JWT with claims, signed with the issuer's key for algorithm.
+func (i *testIssuer) sign(t *testing.T, algorithm, keyID string, claims map[string]interface{}) string {
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("failed to marshal: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signingInput := encode(map[string]string{"alg": algorithm, "kid": keyID, "typ": "JWT"}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch algorithm {
+	case "RS256":
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, i.rsaKey, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, i.ecKey, digest[:])
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (i *testIssuer) claims(overrides map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss": i.server.URL,
+		"aud": []string{"gitstats", "other"},
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for key, value := range overrides {
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+	}
+	return claims
+}
+
+func TestOIDCVerifier_Verify(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := NewOIDCVerifier(issuer.server.URL+"/", "gitstats", "", issuer.server.Client())
+
+	for _, algorithm := range []string{"RS256", "ES256"} {
+		keyID := map[string]string{"RS256": "rsa-1", "ES256": "ec-1"}[algorithm]
+		claims, err := verifier.Verify(context.Background(), issuer.sign(t, algorithm, keyID, issuer.claims(map[string]interface{}{"groups": []string{"admins"}})))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", algorithm, err)
+		}
+		if claims.Subject != "alice" || claims.Raw["groups"] == nil {
+			t.Errorf("%s: unexpected claims: %+v", algorithm, claims)
+		}
+	}
+	if issuer.jwksCalls != 1 {
+		t.Errorf("expected the JWKS to be fetched once, got %d", issuer.jwksCalls)
+	}
+}
+
+func TestOIDCVerifier_Rejects(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := NewOIDCVerifier(issuer.server.URL, "gitstats", issuer.server.URL+"/jwks", issuer.server.Client())
+	valid := issuer.sign(t, "RS256", "rsa-1", issuer.claims(nil))
+
+	tests := map[string]string{
+		"expired":        issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"not yet valid":  issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()})),
+		"wrong audience": issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{"aud": "someone-else"})),
+		"wrong issuer":   issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{"iss": "https://evil.example.com"})),
+		"no subject":     issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{"sub": nil})),
+		"no expiry":      issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{"exp": nil})),
+		"unknown key":    issuer.sign(t, "RS256", "rsa-2", issuer.claims(nil)),
+		"key mismatch":   issuer.sign(t, "RS256", "ec-1", issuer.claims(nil)),
+		"alg none":       valid[:len(valid)-len(valid[lastDot(valid):])] + ".",
+		"tampered":       valid[:lastDot(valid)-2] + "xx" + valid[lastDot(valid):],
+		"not a JWT":      "api-key-value",
+	}
+	for name, token := range tests {
+		if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func lastDot(token string) int {
+	for i := len(token) - 1; i >= 0; i-- {
+		if token[i] == '.' {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestOIDCVerifier_UnreachableIssuer(t *testing.T) {
+	issuer := newTestIssuer(t)
+	token := issuer.sign(t, "RS256", "rsa-1", issuer.claims(nil))
+	issuer.server.Close()
+
+	verifier := NewOIDCVerifier(issuer.server.URL, "gitstats", "", issuer.server.Client())
+	if _, err := verifier.Verify(context.Background(), token); err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a non-token error for an unreachable issuer, got %v", err)
+	}
+}
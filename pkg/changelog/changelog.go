@@ -0,0 +1,180 @@
+// Package changelog builds release notes from the commits between two refs.
+package changelog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+// Section titles, in the order they are rendered.
+const (
+	SectionBreaking    = "Breaking Changes"
+	SectionFeatures    = "Features"
+	SectionFixes       = "Bug Fixes"
+	SectionPerformance = "Performance Improvements"
+	SectionReverts     = "Reverts"
+	SectionOther       = "Other Changes"
+)
+
+var sectionOrder = []string{SectionBreaking, SectionFeatures, SectionFixes, SectionPerformance, SectionReverts, SectionOther}
+
+// maintenanceTypes are conforming commit types left out of the changelog entirely.
+var maintenanceTypes = map[string]bool{"chore": true, "ci": true, "build": true, "test": true, "style": true, "docs": true}
+
+var (
+	// "Subject (#123)" as written by GitHub squash merges.
+	githubPullRequestPattern = regexp.MustCompile(`\(#(\d+)\)\s*$`)
+	// "See merge request group/project!123" as written by GitLab merge commits and squashes.
+	gitlabMergeRequestPattern = regexp.MustCompile(`(?m)merge request [\w./-]*!(\d+)`)
+)
+
+// Entry is a single changelog line.
+type Entry struct {
+	SHA            string    // Full commit SHA.
+	ShortSHA       string    // First 7 characters of the SHA.
+	Type           string    // Conventional Commits type; empty for non-conforming messages.
+	Scope          string    // Conventional Commits scope, if any.
+	Subject        string    // Commit description.
+	Breaking       bool      // Whether the commit is a breaking change.
+	Author         string    // Commit author name.
+	Date           time.Time // Commit author date.
+	CommitURL      string    // Link to the commit, if known.
+	PullRequest    int       // Number of the pull/merge request the commit came from; 0 if unknown.
+	PullRequestURL string    // Link to the pull/merge request, if known.
+	IssueKeys      []string  // Issue keys referenced by the commit message.
+}
+
+// Section groups entries under a heading such as "Features".
+type Section struct {
+	Title   string  // One of the Section* constants.
+	Entries []Entry // Entries in the section, newest first.
+}
+
+// Changelog lists the changes between two refs.
+type Changelog struct {
+	FromRef    string    // Ref the changelog starts after (exclusive).
+	ToRef      string    // Ref the changelog ends at (inclusive).
+	Date       time.Time // Author date of the newest commit; zero when there are no commits.
+	CompareURL string    // Web link to the diff between the refs, if it can be derived from the commit URLs.
+	Sections   []Section // Non-empty sections in rendering order.
+	Skipped    int       // Merge and maintenance commits left out of the changelog.
+}
+
+// Build groups commits (as returned by GetProjectCommits, newest first) into a Changelog.
+// Merge commits and maintenance types (chore, ci, build, test, style, docs) are skipped.
+func Build(commits []*common_types.Commit, fromRef, toRef string) *Changelog {
+	changelog := &Changelog{FromRef: fromRef, ToRef: toRef, Sections: []Section{}}
+	bySection := map[string][]Entry{}
+
+	for _, commit := range commits {
+		if commit == nil {
+			continue
+		}
+		if strings.HasPrefix(commit.Message, "Merge ") {
+			changelog.Skipped++
+			continue
+		}
+		parsed := commit.Conventional
+		if parsed == nil {
+			parsed = analytics.ParseConventionalCommit(commit.Message)
+		}
+		if parsed.Conforms && !parsed.Breaking && maintenanceTypes[parsed.Type] {
+			changelog.Skipped++
+			continue
+		}
+
+		if changelog.CompareURL == "" {
+			if repoURL, isGitlab := repositoryURL(commit.HTMLURL); repoURL != "" {
+				comparePath := "/compare/"
+				if isGitlab {
+					comparePath = "/-/compare/"
+				}
+				changelog.CompareURL = repoURL + comparePath + fromRef + "..." + toRef
+			}
+		}
+		entry := newEntry(commit, parsed)
+		if entry.Date.After(changelog.Date) {
+			changelog.Date = entry.Date
+		}
+		section := sectionFor(parsed)
+		bySection[section] = append(bySection[section], entry)
+	}
+
+	for _, title := range sectionOrder {
+		if entries := bySection[title]; len(entries) > 0 {
+			changelog.Sections = append(changelog.Sections, Section{Title: title, Entries: entries})
+		}
+	}
+	return changelog
+}
+
+// sectionFor picks the section a parsed commit belongs to.
+func sectionFor(parsed *common_types.ConventionalCommit) string {
+	switch {
+	case parsed.Breaking:
+		return SectionBreaking
+	case parsed.Type == "feat":
+		return SectionFeatures
+	case parsed.Type == "fix":
+		return SectionFixes
+	case parsed.Type == "perf":
+		return SectionPerformance
+	case parsed.Type == "revert":
+		return SectionReverts
+	default:
+		return SectionOther
+	}
+}
+
+// newEntry builds the changelog entry for one commit, linking its pull/merge request when
+// the message names one and the repository URL can be derived from the commit URL.
+func newEntry(commit *common_types.Commit, parsed *common_types.ConventionalCommit) Entry {
+	entry := Entry{
+		SHA:       commit.SHA,
+		ShortSHA:  commit.SHA,
+		Type:      parsed.Type,
+		Scope:     parsed.Scope,
+		Subject:   parsed.Subject,
+		Breaking:  parsed.Breaking,
+		Author:    commit.Author.Name,
+		Date:      commit.Author.Date,
+		CommitURL: commit.HTMLURL,
+		IssueKeys: parsed.IssueKeys,
+	}
+	if len(entry.ShortSHA) > 7 {
+		entry.ShortSHA = entry.ShortSHA[:7]
+	}
+
+	repoURL, isGitlab := repositoryURL(commit.HTMLURL)
+	var match []string
+	linkPath := "/pull/"
+	if isGitlab {
+		match, linkPath = gitlabMergeRequestPattern.FindStringSubmatch(commit.Message), "/-/merge_requests/"
+	} else if match = githubPullRequestPattern.FindStringSubmatch(entry.Subject); match != nil {
+		entry.Subject = strings.TrimSpace(githubPullRequestPattern.ReplaceAllString(entry.Subject, ""))
+	}
+	if match != nil {
+		entry.PullRequest, _ = strconv.Atoi(match[1])
+		if repoURL != "" {
+			entry.PullRequestURL = repoURL + linkPath + match[1]
+		}
+	}
+	return entry
+}
+
+// repositoryURL derives the repository web URL from a commit URL. GitHub commit URLs end in
+// "/commit/<sha>", GitLab ones in "/-/commit/<sha>"; isGitlab reports which one matched.
+func repositoryURL(commitURL string) (repoURL string, isGitlab bool) {
+	if index := strings.Index(commitURL, "/-/commit/"); index >= 0 {
+		return commitURL[:index], true
+	}
+	if index := strings.Index(commitURL, "/commit/"); index >= 0 {
+		return commitURL[:index], false
+	}
+	return "", false
+}
@@ -0,0 +1,123 @@
+package changelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func githubCommit(sha, message string, date time.Time) *common_types.Commit {
+	return &common_types.Commit{
+		SHA:     sha,
+		Message: message,
+		HTMLURL: "https://github.com/o/r/commit/" + sha,
+		Author:  common_types.CommitAuthor{Name: "Jane", Date: date},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	newest := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	commits := []*common_types.Commit{
+		githubCommit("aaaaaaaaaa", "feat(api): add compare endpoint (#12)\n\n* squashed details", newest),
+		githubCommit("bbbbbbbbbb", "fix: handle empty repos", newest.Add(-time.Hour)),
+		githubCommit("cccccccccc", "chore: bump deps", newest.Add(-2*time.Hour)),
+		githubCommit("dddddddddd", "Merge pull request #11 from o/feature", newest.Add(-3*time.Hour)),
+		githubCommit("eeeeeeeeee", "refactor!: drop legacy CLI", newest.Add(-4*time.Hour)),
+		githubCommit("ffffffffff", "Tidy up README", newest.Add(-5*time.Hour)),
+		nil,
+	}
+
+	changelog := Build(commits, "v1.4", "v1.5")
+
+	if changelog.Skipped != 2 || !changelog.Date.Equal(newest) {
+		t.Errorf("unexpected skipped count or date: %+v", changelog)
+	}
+	if changelog.CompareURL != "https://github.com/o/r/compare/v1.4...v1.5" {
+		t.Errorf("CompareURL = %q", changelog.CompareURL)
+	}
+	var titles []string
+	for _, section := range changelog.Sections {
+		titles = append(titles, section.Title)
+	}
+	if got := strings.Join(titles, ","); got != "Breaking Changes,Features,Bug Fixes,Other Changes" {
+		t.Fatalf("unexpected sections: %s", got)
+	}
+	feature := changelog.Sections[1].Entries[0]
+	if feature.Subject != "add compare endpoint" || feature.Scope != "api" || feature.ShortSHA != "aaaaaaa" ||
+		feature.PullRequest != 12 || feature.PullRequestURL != "https://github.com/o/r/pull/12" {
+		t.Errorf("unexpected feature entry: %+v", feature)
+	}
+}
+
+func TestBuild_GitlabMergeRequestLinks(t *testing.T) {
+	commits := []*common_types.Commit{{
+		SHA:     "123456789",
+		Message: "fix: retry uploads\n\nSee merge request group/project!34",
+		HTMLURL: "https://gitlab.com/group/project/-/commit/123456789",
+	}}
+
+	changelog := Build(commits, "v1", "v2")
+
+	entry := changelog.Sections[0].Entries[0]
+	if entry.PullRequest != 34 || entry.PullRequestURL != "https://gitlab.com/group/project/-/merge_requests/34" {
+		t.Errorf("unexpected merge request link: %+v", entry)
+	}
+	if changelog.CompareURL != "https://gitlab.com/group/project/-/compare/v1...v2" {
+		t.Errorf("CompareURL = %q", changelog.CompareURL)
+	}
+}
+
+func TestRender(t *testing.T) {
+	date := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	changelog := Build([]*common_types.Commit{
+		githubCommit("aaaaaaaaaa", "feat: add compare endpoint (#12)", date),
+		githubCommit("bbbbbbbbbb", "perf!: stream responses", date),
+	}, "v1.4", "v1.5")
+
+	tests := []struct {
+		format   string
+		contains []string
+	}{
+		{FormatMarkdown, []string{
+			"## [v1.4...v1.5](https://github.com/o/r/compare/v1.4...v1.5) (2024-05-10)",
+			"### Features\n\n- add compare endpoint ([aaaaaaa](https://github.com/o/r/commit/aaaaaaaaaa)) ([#12](https://github.com/o/r/pull/12))",
+			"### Breaking Changes\n\n- stream responses",
+		}},
+		{FormatKeepAChangelog, []string{
+			"## [v1.5] - 2024-05-10",
+			"### Added\n\n- add compare endpoint",
+			"### Changed\n\n- **BREAKING:** stream responses",
+			"[v1.5]: https://github.com/o/r/compare/v1.4...v1.5",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := Render(&out, changelog, tt.format); err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Render(%s) output missing %q:\n%s", tt.format, want, out.String())
+				}
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	if err := Render(&out, changelog, FormatJSON); err != nil {
+		t.Fatalf("Render(json) returned error: %v", err)
+	}
+	var decoded Changelog
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || len(decoded.Sections) != 2 {
+		t.Errorf("Render(json) produced unexpected output (%v):\n%s", err, out.String())
+	}
+
+	if err := Render(&out, changelog, "html"); err == nil {
+		t.Error("Render() expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,128 @@
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Output formats accepted by Render.
+const (
+	FormatMarkdown       = "markdown"
+	FormatJSON           = "json"
+	FormatKeepAChangelog = "keepachangelog"
+)
+
+// Formats lists the output formats accepted by Render.
+var Formats = []string{FormatMarkdown, FormatJSON, FormatKeepAChangelog}
+
+// keepAChangelogSections maps changelog sections onto the Keep a Changelog headings,
+// in the order that convention prescribes.
+var keepAChangelogSections = []struct {
+	Heading  string
+	Sections []string
+}{
+	{"Added", []string{SectionFeatures}},
+	{"Changed", []string{SectionBreaking, SectionPerformance, SectionReverts, SectionOther}},
+	{"Fixed", []string{SectionFixes}},
+}
+
+// Render writes changelog to out in the given format.
+func Render(out io.Writer, changelog *Changelog, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return renderMarkdown(out, changelog)
+	case FormatJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(changelog)
+	case FormatKeepAChangelog:
+		return renderKeepAChangelog(out, changelog)
+	default:
+		return fmt.Errorf("unsupported changelog format %q (expected one of %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// renderMarkdown writes the conventional-changelog style layout: one heading per section.
+func renderMarkdown(out io.Writer, changelog *Changelog) error {
+	var builder strings.Builder
+	title := changelog.FromRef + "..." + changelog.ToRef
+	if changelog.CompareURL != "" {
+		title = "[" + title + "](" + changelog.CompareURL + ")"
+	}
+	builder.WriteString("## " + title)
+	if !changelog.Date.IsZero() {
+		builder.WriteString(" (" + changelog.Date.Format("2006-01-02") + ")")
+	}
+	builder.WriteString("\n")
+	if len(changelog.Sections) == 0 {
+		builder.WriteString("\nNo notable changes.\n")
+	}
+	for _, section := range changelog.Sections {
+		builder.WriteString("\n### " + section.Title + "\n\n")
+		for _, entry := range section.Entries {
+			builder.WriteString(entryLine(entry, false))
+		}
+	}
+	_, err := io.WriteString(out, builder.String())
+	return err
+}
+
+// renderKeepAChangelog writes the https://keepachangelog.com layout for a single release.
+func renderKeepAChangelog(out io.Writer, changelog *Changelog) error {
+	bySection := map[string][]Entry{}
+	for _, section := range changelog.Sections {
+		bySection[section.Title] = section.Entries
+	}
+
+	var builder strings.Builder
+	builder.WriteString("## [" + changelog.ToRef + "]")
+	if !changelog.Date.IsZero() {
+		builder.WriteString(" - " + changelog.Date.Format("2006-01-02"))
+	}
+	builder.WriteString("\n")
+	for _, heading := range keepAChangelogSections {
+		var lines []string
+		for _, title := range heading.Sections {
+			for _, entry := range bySection[title] {
+				lines = append(lines, entryLine(entry, true))
+			}
+		}
+		if len(lines) > 0 {
+			builder.WriteString("\n### " + heading.Heading + "\n\n" + strings.Join(lines, ""))
+		}
+	}
+	if changelog.CompareURL != "" {
+		builder.WriteString("\n[" + changelog.ToRef + "]: " + changelog.CompareURL + "\n")
+	}
+	_, err := io.WriteString(out, builder.String())
+	return err
+}
+
+// entryLine formats one entry as a Markdown list item.
+func entryLine(entry Entry, markBreaking bool) string {
+	var builder strings.Builder
+	builder.WriteString("- ")
+	if markBreaking && entry.Breaking {
+		builder.WriteString("**BREAKING:** ")
+	}
+	if entry.Scope != "" {
+		builder.WriteString("**" + entry.Scope + ":** ")
+	}
+	builder.WriteString(entry.Subject)
+	if entry.CommitURL != "" {
+		builder.WriteString(" ([" + entry.ShortSHA + "](" + entry.CommitURL + "))")
+	} else if entry.ShortSHA != "" {
+		builder.WriteString(" (" + entry.ShortSHA + ")")
+	}
+	if entry.PullRequestURL != "" {
+		reference := "#" // GitHub pull requests are "#12", GitLab merge requests "!12".
+		if strings.Contains(entry.PullRequestURL, "/-/merge_requests/") {
+			reference = "!"
+		}
+		builder.WriteString(fmt.Sprintf(" ([%s%d](%s))", reference, entry.PullRequest, entry.PullRequestURL))
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
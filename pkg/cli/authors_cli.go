@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// PrintAuthors fetches the commits of repoIdentifier authored in [since, until) through gitService
+// and writes per-author commit and line counts to out, most commits first.
+func PrintAuthors(gitService interfaces.GitService, repoIdentifier interface{}, since, until time.Time, out io.Writer, opts OutputOptions) error {
+	commits, err := listCommits(gitService, repoIdentifier, since, until)
+	if err != nil {
+		return err
+	}
+	return authorTable(analytics.AggregateChangeTotals(commits)).write(out, opts)
+}
+
+// PrintAllAuthors fetches the commits authored in [since, until) in every repository of owner
+// (every accessible repository when owner is empty) and writes per-author totals across all of
+// them to out. Repositories whose commits cannot be fetched, such as empty ones, are skipped
+// with a warning on errOut.
+func PrintAllAuthors(gitService interfaces.GitService, owner string, since, until time.Time, out, errOut io.Writer, opts OutputOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	repos, err := gitService.GetAllRepos(owner)
+	if err != nil {
+		return fmt.Errorf("failed to list repositories for owner '%s': %w", owner, err)
+	}
+
+	var commits []*common_types.Commit
+	for _, repo := range repos {
+		if repo == nil {
+			continue
+		}
+		// The numeric ID identifies a repository on every provider.
+		repoCommits, err := listCommits(gitService, repo.ID, since, until)
+		if err != nil {
+			fmt.Fprintf(errOut, "warning: skipping %s/%s: %v\n", repo.Owner, repo.Name, err)
+			continue
+		}
+		commits = append(commits, repoCommits...)
+	}
+	return authorTable(analytics.AggregateChangeTotals(commits)).write(out, opts)
+}
+
+// authorTable lays out per-author commit and line counts.
+func authorTable(authors []analytics.AuthorChangeTotals) *table {
+	result := &table{
+		columns: []Column{
+			{"AUTHOR", "author"}, {"EMAIL", "email"}, {"COMMITS", "commits"}, {"ADDITIONS", "additions"}, {"DELETIONS", "deletions"},
+		},
+		sortKeys: map[string]string{SortCommits: "commits", SortAdditions: "additions", SortName: "author"},
+	}
+	for _, author := range authors {
+		result.rows = append(result.rows, []interface{}{author.Name, author.Email, author.Commits, author.Additions, author.Deletions})
+	}
+	return result
+}
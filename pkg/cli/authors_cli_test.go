@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func TestPrintAuthors(t *testing.T) {
+	service := &stubGitService{commits: sampleCommits()}
+
+	var out bytes.Buffer
+	if err := PrintAuthors(service, "o/r", time.Time{}, time.Time{}, &out, OutputOptions{}); err != nil {
+		t.Fatalf("PrintAuthors(, OutputOptions{}) returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 authors, got:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[1]); fields[0] != "Jane" || fields[2] != "2" || fields[3] != "31" || fields[4] != "6" {
+		t.Errorf("unexpected first author row %q", lines[1])
+	}
+}
+
+func TestPrintAllAuthors_AggregatesAcrossReposAndSkipsFailures(t *testing.T) {
+	commits := sampleCommits()
+	service := &stubGitService{
+		repos: []*common_types.Repository{
+			{ID: 1, Owner: "octo", Name: "alpha"},
+			{ID: 2, Owner: "octo", Name: "empty"},
+			{ID: 3, Owner: "octo", Name: "beta"},
+		},
+		commitsByRepo: map[interface{}][]*common_types.Commit{
+			int64(1): commits[:2],
+			int64(3): commits,
+		},
+		errByRepo: map[interface{}]error{int64(2): errors.New("repository is empty")},
+	}
+
+	var out, errOut bytes.Buffer
+	if err := PrintAllAuthors(service, "octo", time.Time{}, time.Time{}, &out, &errOut, OutputOptions{}); err != nil {
+		t.Fatalf("PrintAllAuthors(, OutputOptions{}) returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 authors, got:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[1]); fields[0] != "Jane" || fields[2] != "4" || fields[3] != "62" {
+		t.Errorf("unexpected first author row %q", lines[1])
+	}
+	if !strings.Contains(errOut.String(), "octo/empty") {
+		t.Errorf("expected a warning about the skipped repository, got %q", errOut.String())
+	}
+}
+
+func TestPrintAllAuthors_PropagatesListError(t *testing.T) {
+	service := &stubGitService{err: errors.New("boom")}
+	if err := PrintAllAuthors(service, "", time.Time{}, time.Time{}, &bytes.Buffer{}, &bytes.Buffer{}, OutputOptions{}); err == nil {
+		t.Error("PrintAllAuthors(, OutputOptions{}) expected an error, got nil")
+	}
+}
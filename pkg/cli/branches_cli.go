@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// PrintBranchReport fetches the branches of repoIdentifier through gitService and writes
+// the branches with no commits in staleAfterDays days, or already merged, to out.
+func PrintBranchReport(gitService interfaces.GitService, repoIdentifier interface{}, staleAfterDays int, out io.Writer, opts OutputOptions) error {
+	branches, err := gitService.ListBranches(repoIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to list branches for %v: %w", repoIdentifier, err)
+	}
+	report := analytics.BuildBranchReport(branches, time.Now(), staleAfterDays)
+
+	result := &table{
+		summary: fmt.Sprintf("%d of %d branches are stale (>= %d days) or merged.", len(report.Entries), report.TotalBranches, report.StaleAfterDays),
+		columns: []Column{
+			{"BRANCH", "branch"}, {"LAST COMMIT", "last_commit"}, {"DAYS", "days_since_last_commit"}, {"AUTHOR", "last_commit_author"},
+			{"AHEAD", "ahead"}, {"BEHIND", "behind"}, {"STALE", "stale"}, {"MERGED", "merged"}, {"PROTECTED", "protected"},
+		},
+		sortKeys: map[string]string{SortName: "branch"},
+	}
+	for _, entry := range report.Entries {
+		branch := entry.Branch
+		result.rows = append(result.rows, []interface{}{
+			branch.Name, branch.LastCommitDate, entry.DaysSinceLastCommit, branch.LastCommitAuthor,
+			branch.Ahead, branch.Behind, entry.Stale, entry.Merged, branch.Protected,
+		})
+	}
+	return result.write(out, opts)
+}
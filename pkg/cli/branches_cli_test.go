@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// stubGitService implements interfaces.GitService with canned repositories, branches, commits and languages.
+type stubGitService struct {
+	interfaces.GitService
+	repo        *common_types.Repository
+	repos       []*common_types.Repository
+	reposOwner  string
+	languages   []*common_types.Language
+	branches    []*common_types.Branch
+	commits     []*common_types.Commit
+	commitsOpts *interfaces.CommitListOptions
+	err         error
+
+	// Per-repository commits and errors, keyed by repository identifier; used instead of
+	// commits and err when set.
+	commitsByRepo map[interface{}][]*common_types.Commit
+	errByRepo     map[interface{}]error
+}
+
+func (s *stubGitService) GetAllRepos(owner string) ([]*common_types.Repository, error) {
+	s.reposOwner = owner
+	return s.repos, s.err
+}
+
+func (s *stubGitService) GetRepo(identifier interface{}) (*common_types.Repository, error) {
+	return s.repo, s.err
+}
+
+func (s *stubGitService) ListLanguages(repoIdentifier interface{}) ([]*common_types.Language, error) {
+	return s.languages, s.err
+}
+
+func (s *stubGitService) ListBranches(repoIdentifier interface{}) ([]*common_types.Branch, error) {
+	return s.branches, s.err
+}
+
+func (s *stubGitService) GetProjectCommits(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+	s.commitsOpts = options
+	if s.commitsByRepo != nil {
+		return s.commitsByRepo[repoIdentifier], s.errByRepo[repoIdentifier]
+	}
+	return s.commits, s.err
+}
+
+func TestPrintBranchReport(t *testing.T) {
+	service := &stubGitService{branches: []*common_types.Branch{
+		{Name: "main", Default: true, LastCommitDate: time.Now()},
+		{Name: "stale-feature", LastCommitDate: time.Now().Add(-200 * 24 * time.Hour), LastCommitAuthor: "Jane"},
+		{Name: "active", LastCommitDate: time.Now()},
+	}}
+
+	var out bytes.Buffer
+	if err := PrintBranchReport(service, "o/r", 90, &out, OutputOptions{}); err != nil {
+		t.Fatalf("PrintBranchReport(, OutputOptions{}) returned error: %v", err)
+	}
+	output := out.String()
+	if !strings.Contains(output, "1 of 3 branches") || !strings.Contains(output, "stale-feature") || strings.Contains(output, "active") {
+		t.Errorf("unexpected report output:\n%s", output)
+	}
+}
+
+func TestPrintBranchReport_PropagatesError(t *testing.T) {
+	service := &stubGitService{err: errors.New("boom")}
+	if err := PrintBranchReport(service, "o/r", 90, &bytes.Buffer{}, OutputOptions{}); err == nil {
+		t.Error("PrintBranchReport(, OutputOptions{}) expected an error, got nil")
+	}
+}
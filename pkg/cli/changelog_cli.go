@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/changelog"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// PrintChangelog fetches the commits of repoIdentifier reachable from toRef but not from fromRef
+// through gitService and writes them to out as a changelog in the given format.
+// An empty toRef means the default branch.
+func PrintChangelog(gitService interfaces.GitService, repoIdentifier interface{}, fromRef, toRef, format string, out io.Writer) error {
+	commits, err := gitService.GetProjectCommits(repoIdentifier, &interfaces.CommitListOptions{SHA: toRef, BaseRef: fromRef})
+	if err != nil {
+		return fmt.Errorf("failed to list commits between %s and %s for %v: %w", fromRef, toRef, repoIdentifier, err)
+	}
+	if toRef == "" {
+		toRef = "HEAD"
+	}
+	return changelog.Render(out, changelog.Build(commits, fromRef, toRef), format)
+}
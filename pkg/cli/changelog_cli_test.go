@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func TestPrintChangelog(t *testing.T) {
+	service := &stubGitService{commits: []*common_types.Commit{
+		{SHA: "abcdef123", Message: "fix(cli): exit non-zero on errors"},
+	}}
+
+	var out bytes.Buffer
+	if err := PrintChangelog(service, "o/r", "v1.0", "", "keepachangelog", &out); err != nil {
+		t.Fatalf("PrintChangelog() returned error: %v", err)
+	}
+	if service.commitsOpts.BaseRef != "v1.0" || service.commitsOpts.SHA != "" {
+		t.Errorf("unexpected commit list options: %+v", service.commitsOpts)
+	}
+	if !strings.Contains(out.String(), "## [HEAD]") || !strings.Contains(out.String(), "### Fixed\n\n- **cli:** exit non-zero on errors (abcdef1)") {
+		t.Errorf("unexpected changelog output:\n%s", out.String())
+	}
+}
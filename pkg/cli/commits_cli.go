@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// PrintCommits fetches the commits of repoIdentifier authored in [since, until) through gitService
+// and writes them to out, newest first. Zero times leave that end of the range open.
+func PrintCommits(gitService interfaces.GitService, repoIdentifier interface{}, since, until time.Time, out io.Writer, opts OutputOptions) error {
+	commits, err := listCommits(gitService, repoIdentifier, since, until)
+	if err != nil {
+		return err
+	}
+
+	result := &table{
+		columns: []Column{
+			{"SHA", "sha"}, {"DATE", "date"}, {"AUTHOR", "author"}, {"ADDITIONS", "additions"}, {"DELETIONS", "deletions"}, {"SUBJECT", "subject"},
+		},
+		sortKeys: map[string]string{SortAdditions: "additions", SortName: "author"},
+	}
+	for _, commit := range commits {
+		if commit == nil {
+			continue
+		}
+		result.rows = append(result.rows, []interface{}{
+			shortSHA(commit.SHA), commit.Author.Date, commit.Author.Name,
+			commit.Stats.Additions, commit.Stats.Deletions, subjectLine(commit.Message),
+		})
+	}
+	return result.write(out, opts)
+}
+
+// PrintLinesOfCode fetches the commits of repoIdentifier authored in [since, until) through gitService
+// and writes the lines added, deleted and the net change over them to out.
+func PrintLinesOfCode(gitService interfaces.GitService, repoIdentifier interface{}, since, until time.Time, out io.Writer, opts OutputOptions) error {
+	commits, err := listCommits(gitService, repoIdentifier, since, until)
+	if err != nil {
+		return err
+	}
+
+	var totals analytics.ChangeTotals
+	for _, commit := range commits {
+		if commit == nil {
+			continue
+		}
+		totals.Commits++
+		totals.Additions += commit.Stats.Additions
+		totals.Deletions += commit.Stats.Deletions
+	}
+
+	result := &table{
+		columns:  []Column{{"COMMITS", "commits"}, {"ADDITIONS", "additions"}, {"DELETIONS", "deletions"}, {"NET", "net"}},
+		rows:     [][]interface{}{{totals.Commits, totals.Additions, totals.Deletions, totals.Additions - totals.Deletions}},
+		sortKeys: map[string]string{SortCommits: "commits", SortAdditions: "additions"},
+	}
+	return result.write(out, opts)
+}
+
+// listCommits fetches every commit of repoIdentifier authored in [since, until). Providers return
+// a single page of an open range, so an open end is closed at the current time.
+func listCommits(gitService interfaces.GitService, repoIdentifier interface{}, since, until time.Time) ([]*common_types.Commit, error) {
+	if until.IsZero() {
+		until = time.Now()
+	}
+	commits, err := gitService.GetProjectCommits(repoIdentifier, &interfaces.CommitListOptions{Since: since, Until: until})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits for %v: %w", repoIdentifier, err)
+	}
+	return commits, nil
+}
+
+// shortSHA abbreviates a commit SHA to the seven characters git shows by default.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// subjectLine returns the first line of a commit message.
+func subjectLine(message string) string {
+	subject, _, _ := strings.Cut(message, "\n")
+	return strings.TrimSpace(subject)
+}
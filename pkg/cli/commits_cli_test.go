@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func sampleCommits() []*common_types.Commit {
+	date := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	return []*common_types.Commit{
+		{SHA: "aaaaaaaaaaaa", Message: "feat: add login\n\nLong body.", Author: common_types.CommitAuthor{Name: "Jane", Email: "jane@example.com", Date: date},
+			Stats: common_types.CommitStats{Additions: 30, Deletions: 5}},
+		{SHA: "bbbbbbbbbbbb", Message: "fix: typo", Author: common_types.CommitAuthor{Name: "Jane", Email: "JANE@example.com", Date: date},
+			Stats: common_types.CommitStats{Additions: 1, Deletions: 1}},
+		{SHA: "cccccccccccc", Message: "docs: readme", Author: common_types.CommitAuthor{Name: "Bob", Email: "bob@example.com", Date: date},
+			Stats: common_types.CommitStats{Additions: 4, Deletions: 10}},
+	}
+}
+
+func TestPrintCommits(t *testing.T) {
+	service := &stubGitService{commits: sampleCommits()}
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+	if err := PrintCommits(service, "o/r", since, until, &out, OutputOptions{}); err != nil {
+		t.Fatalf("PrintCommits(, OutputOptions{}) returned error: %v", err)
+	}
+	if !service.commitsOpts.Since.Equal(since) || !service.commitsOpts.Until.Equal(until) {
+		t.Errorf("GetProjectCommits() called with since=%v until=%v, want %v and %v",
+			service.commitsOpts.Since, service.commitsOpts.Until, since, until)
+	}
+	output := out.String()
+	if !strings.Contains(output, "aaaaaaa ") || strings.Contains(output, "aaaaaaaa") {
+		t.Errorf("expected SHAs abbreviated to 7 characters:\n%s", output)
+	}
+	if !strings.Contains(output, "feat: add login") || strings.Contains(output, "Long body") {
+		t.Errorf("expected only the subject line of each message:\n%s", output)
+	}
+}
+
+func TestPrintLinesOfCode(t *testing.T) {
+	service := &stubGitService{commits: sampleCommits()}
+
+	var out bytes.Buffer
+	if err := PrintLinesOfCode(service, "o/r", time.Time{}, time.Time{}, &out, OutputOptions{}); err != nil {
+		t.Fatalf("PrintLinesOfCode(, OutputOptions{}) returned error: %v", err)
+	}
+	if !service.commitsOpts.WalksAllPages() || !service.commitsOpts.Since.IsZero() || time.Since(service.commitsOpts.Until) > time.Minute {
+		t.Errorf("GetProjectCommits() called with since=%v until=%v, want the whole history up to now",
+			service.commitsOpts.Since, service.commitsOpts.Until)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if got, want := strings.Fields(lines[len(lines)-1]), []string{"3", "35", "16", "19"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("totals row = %v, want %v", got, want)
+	}
+}
+
+func TestPrintCommits_PropagatesError(t *testing.T) {
+	service := &stubGitService{err: errors.New("boom")}
+	if err := PrintCommits(service, "o/r", time.Time{}, time.Time{}, &bytes.Buffer{}, OutputOptions{}); err == nil {
+		t.Error("PrintCommits(, OutputOptions{}) expected an error, got nil")
+	}
+}
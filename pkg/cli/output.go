@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by OutputOptions.Format.
+const (
+	OutputTable    = "table"
+	OutputJSON     = "json"
+	OutputCSV      = "csv"
+	OutputYAML     = "yaml"
+	OutputMarkdown = "markdown"
+)
+
+// OutputFormats lists the supported output formats, in the order shown in help texts.
+var OutputFormats = []string{OutputTable, OutputJSON, OutputCSV, OutputYAML, OutputMarkdown}
+
+// Sort orders accepted by OutputOptions.Sort. Counts sort descending, names ascending.
+const (
+	SortCommits   = "commits"
+	SortAdditions = "additions"
+	SortName      = "name"
+)
+
+// SortOrders lists the supported sort orders, in the order shown in help texts.
+var SortOrders = []string{SortCommits, SortAdditions, SortName}
+
+// OutputOptions controls how a command writes its result.
+type OutputOptions struct {
+	Format string // One of OutputFormats; empty means OutputTable.
+	Sort   string // One of SortOrders; empty keeps the command's default order.
+	Top    int    // Keep only the first Top rows after sorting; 0 keeps all.
+}
+
+// Validate reports an unknown format or sort order, or a negative Top.
+func (opts OutputOptions) Validate() error {
+	if opts.Format != "" && !slices.Contains(OutputFormats, opts.Format) {
+		return fmt.Errorf("unknown output format %q: expected one of %s", opts.Format, strings.Join(OutputFormats, ", "))
+	}
+	if opts.Sort != "" && !slices.Contains(SortOrders, opts.Sort) {
+		return fmt.Errorf("unknown sort order %q: expected one of %s", opts.Sort, strings.Join(SortOrders, ", "))
+	}
+	if opts.Top < 0 {
+		return fmt.Errorf("top must not be negative, got %d", opts.Top)
+	}
+	return nil
+}
+
+// Column is one column of a table.
+type Column struct {
+	Header string // Heading in table and Markdown output.
+	Key    string // Field name in JSON, YAML and CSV output.
+}
+
+// table is the result of a command before it is rendered in the requested format.
+// Cells hold strings, ints, bools or time.Time values.
+type table struct {
+	summary  string            // Line written before table and Markdown output; omitted otherwise.
+	columns  []Column          // Columns in display order.
+	rows     [][]interface{}   // One cell per column.
+	sortKeys map[string]string // Sort order -> column key; orders not listed are rejected.
+}
+
+// write sorts and truncates t as opts asks and renders it to out.
+func (t *table) write(out io.Writer, opts OutputOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.Sort != "" {
+		if err := t.sortBy(opts.Sort); err != nil {
+			return err
+		}
+	}
+	if opts.Top > 0 && len(t.rows) > opts.Top {
+		t.rows = t.rows[:opts.Top]
+	}
+
+	switch opts.Format {
+	case OutputJSON:
+		return t.writeJSON(out)
+	case OutputCSV:
+		return t.writeCSV(out)
+	case OutputYAML:
+		return t.writeYAML(out)
+	case OutputMarkdown:
+		return t.writeMarkdown(out)
+	default:
+		return t.writeText(out)
+	}
+}
+
+// sortBy stably sorts the rows by the column mapped to order: counts descending, text ascending.
+func (t *table) sortBy(order string) error {
+	key, ok := t.sortKeys[order]
+	if !ok {
+		return fmt.Errorf("--sort %s is not supported here", order)
+	}
+	index := -1
+	for i, column := range t.columns {
+		if column.Key == key {
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("sort column %q not found", key)
+	}
+	sort.SliceStable(t.rows, func(i, j int) bool {
+		left, right := t.rows[i][index], t.rows[j][index]
+		if leftInt, ok := left.(int); ok {
+			return leftInt > right.(int)
+		}
+		return strings.ToLower(formatCell(left)) < strings.ToLower(formatCell(right))
+	})
+	return nil
+}
+
+func (t *table) writeText(out io.Writer) error {
+	if t.summary != "" {
+		fmt.Fprintln(out, t.summary)
+	}
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		headers[i] = column.Header
+	}
+	fmt.Fprintln(writer, strings.Join(headers, "\t"))
+	for _, row := range t.rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = formatCell(cell)
+		}
+		fmt.Fprintln(writer, strings.Join(cells, "\t"))
+	}
+	return writer.Flush()
+}
+
+func (t *table) writeMarkdown(out io.Writer) error {
+	if t.summary != "" {
+		fmt.Fprintf(out, "%s\n\n", t.summary)
+	}
+	headers := make([]string, len(t.columns))
+	separators := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		headers[i] = escapeMarkdownCell(column.Header)
+		separators[i] = "---"
+	}
+	fmt.Fprintf(out, "| %s |\n| %s |\n", strings.Join(headers, " | "), strings.Join(separators, " | "))
+	for _, row := range t.rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = escapeMarkdownCell(formatCell(cell))
+		}
+		fmt.Fprintf(out, "| %s |\n", strings.Join(cells, " | "))
+	}
+	return nil
+}
+
+func (t *table) writeCSV(out io.Writer) error {
+	writer := csv.NewWriter(out)
+	keys := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		keys[i] = column.Key
+	}
+	if err := writer.Write(keys); err != nil {
+		return err
+	}
+	for _, row := range t.rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = formatCell(cell)
+		}
+		if err := writer.Write(cells); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeJSON writes the rows as an array of objects whose fields follow the column order.
+func (t *table) writeJSON(out io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for r, row := range t.rows {
+		if r > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  {")
+		for i, cell := range row {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			key, _ := json.Marshal(t.columns[i].Key)
+			value, err := json.Marshal(cell)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s: %w", t.columns[i].Key, err)
+			}
+			buf.Write(key)
+			buf.WriteString(": ")
+			buf.Write(value)
+		}
+		buf.WriteString("}")
+	}
+	if len(t.rows) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+	_, err := out.Write(buf.Bytes())
+	return err
+}
+
+// writeYAML writes the rows as a sequence of mappings whose keys follow the column order.
+func (t *table) writeYAML(out io.Writer) error {
+	document := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, row := range t.rows {
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+		for i, cell := range row {
+			value := &yaml.Node{}
+			if err := value.Encode(cell); err != nil {
+				return fmt.Errorf("failed to encode %s: %w", t.columns[i].Key, err)
+			}
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: t.columns[i].Key}, value)
+		}
+		document.Content = append(document.Content, mapping)
+	}
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// formatCell renders a cell for table, Markdown and CSV output.
+func formatCell(cell interface{}) string {
+	if date, ok := cell.(time.Time); ok {
+		if date.IsZero() {
+			return ""
+		}
+		return date.Format("2006-01-02")
+	}
+	return fmt.Sprint(cell)
+}
+
+// escapeMarkdownCell keeps pipes and newlines in a value from breaking a Markdown table row.
+func escapeMarkdownCell(value string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(value)
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func sampleTable() *table {
+	return &table{
+		summary: "3 authors.",
+		columns: []Column{{"AUTHOR", "author"}, {"COMMITS", "commits"}, {"ADDITIONS", "additions"}, {"LAST", "last"}},
+		rows: [][]interface{}{
+			{"bob", 2, 50, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+			{"Alice", 5, 10, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+			{"carol|x", 2, 70, time.Time{}},
+		},
+		sortKeys: map[string]string{SortCommits: "commits", SortAdditions: "additions", SortName: "author"},
+	}
+}
+
+func firstColumn(t *testing.T, tbl *table) []string {
+	t.Helper()
+	var names []string
+	for _, row := range tbl.rows {
+		names = append(names, row[0].(string))
+	}
+	return names
+}
+
+func TestTableSort(t *testing.T) {
+	tests := []struct {
+		order string
+		want  string
+	}{
+		{SortCommits, "Alice,bob,carol|x"}, // Ties keep their original order.
+		{SortAdditions, "carol|x,bob,Alice"},
+		{SortName, "Alice,bob,carol|x"}, // Case-insensitive.
+	}
+	for _, tt := range tests {
+		tbl := sampleTable()
+		if err := tbl.write(&bytes.Buffer{}, OutputOptions{Sort: tt.order}); err != nil {
+			t.Fatalf("write(sort=%s) returned error: %v", tt.order, err)
+		}
+		if got := strings.Join(firstColumn(t, tbl), ","); got != tt.want {
+			t.Errorf("sort=%s order = %s, want %s", tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestTableSort_RejectsUnsupportedOrder(t *testing.T) {
+	tbl := sampleTable()
+	delete(tbl.sortKeys, SortAdditions)
+	if err := tbl.write(&bytes.Buffer{}, OutputOptions{Sort: SortAdditions}); err == nil {
+		t.Error("write() expected an error for an unsupported sort order, got nil")
+	}
+}
+
+func TestTableTop(t *testing.T) {
+	tbl := sampleTable()
+	if err := tbl.write(&bytes.Buffer{}, OutputOptions{Sort: SortCommits, Top: 1}); err != nil {
+		t.Fatalf("write() returned error: %v", err)
+	}
+	if got := firstColumn(t, tbl); len(got) != 1 || got[0] != "Alice" {
+		t.Errorf("top 1 by commits = %v, want [Alice]", got)
+	}
+}
+
+func TestTableWrite_JSONKeepsColumnOrderAndTypes(t *testing.T) {
+	var out bytes.Buffer
+	if err := sampleTable().write(&out, OutputOptions{Format: OutputJSON}); err != nil {
+		t.Fatalf("write() returned error: %v", err)
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if len(rows) != 3 || rows[1]["commits"] != float64(5) || rows[0]["last"] != "2024-03-01T00:00:00Z" {
+		t.Errorf("unexpected rows %v", rows)
+	}
+	if first := strings.Split(out.String(), "\n")[1]; strings.Index(first, `"author"`) > strings.Index(first, `"commits"`) {
+		t.Errorf("expected fields in column order, got %s", first)
+	}
+	if strings.Contains(out.String(), "3 authors.") {
+		t.Error("summary line must not be part of JSON output")
+	}
+}
+
+func TestTableWrite_EmptyJSONIsAnArray(t *testing.T) {
+	var out bytes.Buffer
+	if err := (&table{columns: []Column{{"A", "a"}}}).write(&out, OutputOptions{Format: OutputJSON}); err != nil {
+		t.Fatalf("write() returned error: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("empty table JSON = %q, want []", out.String())
+	}
+}
+
+func TestTableWrite_YAML(t *testing.T) {
+	var out bytes.Buffer
+	if err := sampleTable().write(&out, OutputOptions{Format: OutputYAML}); err != nil {
+		t.Fatalf("write() returned error: %v", err)
+	}
+	var rows []map[string]interface{}
+	if err := yaml.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatalf("output is not valid YAML: %v\n%s", err, out.String())
+	}
+	if len(rows) != 3 || rows[1]["author"] != "Alice" || rows[1]["commits"] != 5 {
+		t.Errorf("unexpected rows %v", rows)
+	}
+}
+
+func TestTableWrite_CSV(t *testing.T) {
+	var out bytes.Buffer
+	if err := sampleTable().write(&out, OutputOptions{Format: OutputCSV}); err != nil {
+		t.Fatalf("write() returned error: %v", err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if strings.Join(records[0], ",") != "author,commits,additions,last" || strings.Join(records[1], ",") != "bob,2,50,2024-03-01" {
+		t.Errorf("unexpected records %v", records)
+	}
+}
+
+func TestTableWrite_Markdown(t *testing.T) {
+	var out bytes.Buffer
+	if err := sampleTable().write(&out, OutputOptions{Format: OutputMarkdown}); err != nil {
+		t.Fatalf("write() returned error: %v", err)
+	}
+	want := "3 authors.\n\n" +
+		"| AUTHOR | COMMITS | ADDITIONS | LAST |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| bob | 2 | 50 | 2024-03-01 |\n" +
+		"| Alice | 5 | 10 | 2024-03-02 |\n" +
+		"| carol\\|x | 2 | 70 |  |\n"
+	if out.String() != want {
+		t.Errorf("markdown output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestOutputOptionsValidate(t *testing.T) {
+	for _, opts := range []OutputOptions{{Format: "xml"}, {Sort: "date"}, {Top: -1}} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Validate(%+v) expected an error, got nil", opts)
+		}
+	}
+	if err := (OutputOptions{Format: OutputYAML, Sort: SortName, Top: 3}).Validate(); err != nil {
+		t.Errorf("Validate() returned error for valid options: %v", err)
+	}
+}
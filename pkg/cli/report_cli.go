@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	"github.com/ahmetk3436/git-stats-golang/pkg/report"
+)
+
+// WriteReport fetches the repository, its commits between since and until and its languages
+// through gitService and writes them to out as a self-contained report in the given format
+// (one of report.Formats). Zero times leave the range open; every commit in it is read.
+func WriteReport(gitService interfaces.GitService, repoIdentifier interface{}, since, until time.Time, format string, out io.Writer) error {
+	repo, err := gitService.GetRepo(repoIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to get repository %v: %w", repoIdentifier, err)
+	}
+	commits, err := listCommits(gitService, repoIdentifier, since, until)
+	if err != nil {
+		return err
+	}
+	languages, err := gitService.ListLanguages(repoIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to list languages for %v: %w", repoIdentifier, err)
+	}
+	return report.Render(out, report.Build(repo, commits, languages, since, until, time.Now()), format)
+}
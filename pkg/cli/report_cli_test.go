@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/report"
+)
+
+func TestWriteReport(t *testing.T) {
+	service := &stubGitService{
+		repo: &common_types.Repository{Owner: "octo", Name: "hello"},
+		commits: []*common_types.Commit{{
+			Author: common_types.CommitAuthor{Name: "Jane", Email: "jane@example.com", Date: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)},
+			Stats:  common_types.CommitStats{Additions: 3, Deletions: 1},
+		}},
+		languages: []*common_types.Language{{Name: "Go", Share: 1}},
+	}
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+	if err := WriteReport(service, "octo/hello", since, time.Time{}, report.FormatMarkdown, &out); err != nil {
+		t.Fatalf("WriteReport returned error: %v", err)
+	}
+	if !service.commitsOpts.Since.Equal(since) || !service.commitsOpts.WalksAllPages() || time.Since(service.commitsOpts.Until) > time.Minute {
+		t.Errorf("expected every commit since the start of the range to be listed, got %+v", service.commitsOpts)
+	}
+	output := out.String()
+	for _, want := range []string{"# octo/hello", "Commits from 2024-01-01", "| Jane | 1 | +3 | -1 |", "| Go | 100.0% |"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestWriteReport_Error(t *testing.T) {
+	service := &stubGitService{err: errors.New("not found")}
+	if err := WriteReport(service, "octo/missing", time.Time{}, time.Time{}, report.FormatHTML, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error when the repository cannot be fetched")
+	}
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// PrintRepos lists the repositories of owner through gitService and writes them to out.
+// An empty owner lists the repositories the authenticated user has access to.
+func PrintRepos(gitService interfaces.GitService, owner string, out io.Writer, opts OutputOptions) error {
+	repos, err := gitService.GetAllRepos(owner)
+	if err != nil {
+		return fmt.Errorf("failed to list repositories for owner '%s': %w", owner, err)
+	}
+
+	result := &table{
+		columns: []Column{
+			{"ID", "id"}, {"OWNER", "owner"}, {"NAME", "name"}, {"DEFAULT BRANCH", "default_branch"},
+			{"STARS", "stars"}, {"FORKS", "forks"}, {"OPEN ISSUES", "open_issues"}, {"UPDATED", "updated_at"},
+		},
+		sortKeys: map[string]string{SortName: "name"},
+	}
+	for _, repo := range repos {
+		if repo == nil {
+			continue
+		}
+		result.rows = append(result.rows, []interface{}{
+			repo.ID, repo.Owner, repo.Name, repo.DefaultBranch, repo.Stars, repo.Forks, repo.OpenIssues, repo.UpdatedAt,
+		})
+	}
+	return result.write(out, opts)
+}
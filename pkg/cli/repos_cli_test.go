@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func TestPrintRepos(t *testing.T) {
+	service := &stubGitService{repos: []*common_types.Repository{
+		{ID: 1, Owner: "octo", Name: "alpha", DefaultBranch: "main", Stars: 5},
+		{ID: 2, Owner: "octo", Name: "beta", DefaultBranch: "develop"},
+	}}
+
+	var out bytes.Buffer
+	if err := PrintRepos(service, "octo", &out, OutputOptions{}); err != nil {
+		t.Fatalf("PrintRepos(, OutputOptions{}) returned error: %v", err)
+	}
+	if service.reposOwner != "octo" {
+		t.Errorf("GetAllRepos() called with owner %q, want %q", service.reposOwner, "octo")
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "alpha") || !strings.Contains(lines[2], "develop") {
+		t.Errorf("unexpected repos output:\n%s", out.String())
+	}
+}
+
+func TestPrintRepos_PropagatesError(t *testing.T) {
+	service := &stubGitService{err: errors.New("boom")}
+	if err := PrintRepos(service, "", &bytes.Buffer{}, OutputOptions{}); err == nil {
+		t.Error("PrintRepos(, OutputOptions{}) expected an error, got nil")
+	}
+}
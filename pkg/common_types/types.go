@@ -5,17 +5,19 @@ import "time"
 // Repository holds common, provider-agnostic repository information.
 // This struct is used to standardize repository data from different Git providers.
 type Repository struct {
-	ID          int64     // Unique identifier for the repository.
-	Name        string    // Name of the repository.
-	Owner       string    // Login name of the repository owner (user or organization).
-	HTMLURL     string    // URL to the repository's main page.
-	CloneURL    string    // URL used for cloning the repository (typically HTTPS).
-	Description string    // Short description of the repository.
-	CreatedAt   time.Time // Timestamp when the repository was created.
-	UpdatedAt   time.Time // Timestamp when the repository was last updated.
-	Stars       int       // Number of stars or likes.
-	Forks       int       // Number of forks.
-	OpenIssues  int       // Number of open issues.
+	ID            int64     // Unique identifier for the repository.
+	Name          string    // Name of the repository.
+	Owner         string    // Login name of the repository owner (user or organization).
+	FullName      string    // "owner/name" on GitHub, the path with namespace on GitLab.
+	HTMLURL       string    // URL to the repository's main page.
+	CloneURL      string    // URL used for cloning the repository (typically HTTPS).
+	Description   string    // Short description of the repository.
+	CreatedAt     time.Time // Timestamp when the repository was created.
+	UpdatedAt     time.Time // Timestamp when the repository was last updated.
+	Stars         int       // Number of stars or likes.
+	Forks         int       // Number of forks.
+	OpenIssues    int       // Number of open issues.
+	DefaultBranch string    // Name of the default branch (e.g., "main").
 }
 
 // CommitAuthor holds common, provider-agnostic commit author information.
@@ -29,11 +31,40 @@ type CommitAuthor struct {
 // Commit holds common, provider-agnostic commit information.
 // This struct standardizes commit data from different Git providers.
 type Commit struct {
-	SHA     string       // SHA hash of the commit.
-	Author  CommitAuthor // Information about the commit author.
-	Message string       // Commit message.
-	HTMLURL string       // URL to the commit's page.
-	Stats   CommitStats  // Statistics related to the commit (additions, deletions).
+	SHA          string              // SHA hash of the commit.
+	Author       CommitAuthor        // Information about the commit author.
+	Message      string              // Commit message.
+	HTMLURL      string              // URL to the commit's page.
+	Stats        CommitStats         // Statistics related to the commit (additions, deletions).
+	Conventional *ConventionalCommit // Message parsed against Conventional Commits; nil if not parsed.
+}
+
+// ConventionalCommit holds the Conventional Commits (https://www.conventionalcommits.org)
+// interpretation of a commit message.
+type ConventionalCommit struct {
+	Conforms  bool     // Whether the header follows "type(scope)!: description".
+	Type      string   // Lower-cased commit type, e.g. "feat" or "fix"; empty if not conforming.
+	Scope     string   // Optional scope given in parentheses.
+	Breaking  bool     // Header has "!" or a footer starts with "BREAKING CHANGE:".
+	Subject   string   // Description following the colon; the first line when not conforming.
+	IssueKeys []string // Referenced issue keys such as "#12" or "PROJ-34", in order of appearance.
+}
+
+// FileChange holds common, provider-agnostic information about a file changed between two refs.
+type FileChange struct {
+	Filename         string // Path of the file after the change.
+	PreviousFilename string // Path before the change, for renamed files; empty otherwise.
+	Status           string // One of "added", "removed", "renamed" or "modified".
+	Additions        int    // Number of lines added.
+	Deletions        int    // Number of lines deleted.
+}
+
+// Comparison holds what changed between two refs of a repository.
+type Comparison struct {
+	BaseRef string        // Ref the comparison starts from (exclusive).
+	HeadRef string        // Ref the comparison ends at (inclusive).
+	Commits []*Commit     // Commits reachable from HeadRef but not from BaseRef, newest first.
+	Files   []*FileChange // Files changed between BaseRef and HeadRef.
 }
 
 // CommitStats holds common, provider-agnostic commit statistics.
@@ -53,3 +84,46 @@ type User struct {
 	HTMLURL   string // URL to the user's profile page.
 	Name      string // Real name of the user, if available (might be empty).
 }
+
+// Issue holds common, provider-agnostic issue information.
+// Pull requests / merge requests are not represented as issues.
+type Issue struct {
+	Number    int       // Project-scoped issue number (GitHub number, GitLab IID).
+	Title     string    // Title of the issue.
+	State     string    // Normalised state: "open" or "closed".
+	Author    string    // Login of the user who opened the issue.
+	Labels    []string  // Names of the labels attached to the issue.
+	Assignees []string  // Logins of the users assigned to the issue.
+	HTMLURL   string    // URL to the issue's page.
+	CreatedAt time.Time // Timestamp when the issue was opened.
+	ClosedAt  time.Time // Timestamp when the issue was closed; zero while it is open.
+}
+
+// Branch holds common, provider-agnostic branch information.
+// Ahead and Behind are counted against the repository's default branch.
+type Branch struct {
+	Name             string    // Name of the branch.
+	LastCommitSHA    string    // SHA of the branch head.
+	LastCommitDate   time.Time // Timestamp when the branch head was authored.
+	LastCommitAuthor string    // Name of the author of the branch head.
+	Ahead            int       // Commits on the branch that are not on the default branch.
+	Behind           int       // Commits on the default branch that are not on the branch.
+	Protected        bool      // Whether the branch is protected.
+	Default          bool      // Whether this is the repository's default branch.
+	Merged           bool      // Whether the branch is fully merged into the default branch.
+}
+
+// Tag holds common, provider-agnostic tag information.
+type Tag struct {
+	Name         string    // Name of the tag.
+	CommitSHA    string    // SHA of the tagged commit.
+	CommitDate   time.Time // Timestamp when the tagged commit was authored.
+	CommitAuthor string    // Name of the author of the tagged commit.
+}
+
+// Language holds how much of a repository's code is written in one language, as measured by the provider.
+type Language struct {
+	Name  string  // Name of the language, e.g. "Go".
+	Bytes int64   // Bytes of code in the language; 0 when the provider only reports shares (GitLab).
+	Share float64 // Fraction of the repository's code in the language, 0-1.
+}
@@ -0,0 +1,491 @@
+// Package config loads gitstats settings from a YAML file and the environment.
+//
+// Settings are layered: built-in defaults, then the config file, then environment
+// variables. Command-line flags are applied on top by the caller.
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/schedule"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported values of Providers.Default.
+const (
+	ProviderGithub = "github"
+	ProviderGitlab = "gitlab"
+)
+
+// Config is the complete gitstats configuration.
+type Config struct {
+	Providers  Providers  `yaml:"providers"`
+	Cache      Cache      `yaml:"cache"`
+	Server     Server     `yaml:"server"`
+	Analytics  Analytics  `yaml:"analytics"`
+	Auth       Auth       `yaml:"auth"`
+	Jobs       Jobs       `yaml:"jobs"`
+	Schedules  []Schedule `yaml:"schedules"` // Periodic cache refreshes of the API.
+	Webhooks   Webhooks   `yaml:"webhooks"`
+	Identities []Identity `yaml:"identities"` // People who commit under more than one name or email.
+}
+
+// Providers holds the Git provider credentials.
+type Providers struct {
+	Default string `yaml:"default"` // Provider the CLI queries: "github", "gitlab" or empty to pick the one with a token.
+	GitHub  GitHub `yaml:"github"`
+	GitLab  GitLab `yaml:"gitlab"`
+}
+
+// GitHub holds the GitHub credentials.
+type GitHub struct {
+	Token          string `yaml:"token"`           // Personal access token; GitHub is disabled when empty.
+	CommitsBackend string `yaml:"commits_backend"` // API commits are listed with: "rest" or "graphql".
+	DetailWorkers  int    `yaml:"detail_workers"`  // Commits whose stats are fetched at once, one request each (REST only).
+}
+
+// GitLab holds the GitLab credentials.
+type GitLab struct {
+	Token string `yaml:"token"` // Personal access token; GitLab is disabled when empty.
+	Host  string `yaml:"host"`  // Base URL of a self-managed instance; empty means GitLab.com.
+}
+
+// Cache configures the Redis response cache used by the API.
+type Cache struct {
+	RedisHost     string                   `yaml:"redis_host"`     // host:port of the Redis server.
+	RedisPassword string                   `yaml:"redis_password"` // Redis AUTH password.
+	TTL           time.Duration            `yaml:"ttl"`            // How long provider responses are served from the cache, e.g. "1h".
+	EndpointTTLs  map[string]time.Duration `yaml:"endpoint_ttls"`  // TTLs of single endpoints by path, e.g. "/commits": 15m.
+	StaleFor      time.Duration            `yaml:"stale_for"`      // How long expired responses are served while refreshed in the background; 0 disables.
+}
+
+// Server configures the HTTP API.
+type Server struct {
+	Address           string `yaml:"address"`             // Listen address, e.g. ":1323".
+	CORSAllowedOrigin string `yaml:"cors_allowed_origin"` // Value of Access-Control-Allow-Origin.
+	APIBaseURL        string `yaml:"api_base_url"`        // Base URL the web dashboard sends API requests to.
+}
+
+// Auth configures how API callers authenticate. Authentication is required as soon as an API key
+// or an OIDC issuer is configured; without either, the API is open to anyone who can reach it.
+type Auth struct {
+	APIKeys               []APIKey `yaml:"api_keys"`                // Static keys, e.g. for CI jobs.
+	OIDC                  OIDC     `yaml:"oidc"`                    // Bearer tokens of an OpenID Connect provider.
+	ForwardProviderTokens bool     `yaml:"forward_provider_tokens"` // Use the caller's X-Provider-Token instead of the server token.
+	PolicyFile            string   `yaml:"policy_file"`             // Access policy restricting what callers may query; none when empty.
+}
+
+// APIKey is an API key, stored as the hex SHA-256 of the key so the config file holds no secret.
+type APIKey struct {
+	Name   string `yaml:"name"`   // Identity of callers using the key, e.g. "ci".
+	SHA256 string `yaml:"sha256"` // Hex SHA-256 of the key: echo -n "$KEY" | sha256sum.
+}
+
+// OIDC configures the verification of OpenID Connect ID or access tokens.
+type OIDC struct {
+	Issuer   string `yaml:"issuer"`   // Issuer URL; OIDC is disabled when empty.
+	Audience string `yaml:"audience"` // Audience ("aud") tokens must be issued for.
+	JWKSURL  string `yaml:"jwks_url"` // Key set URL; discovered from the issuer when empty.
+}
+
+// Enabled reports whether callers have to authenticate.
+func (a Auth) Enabled() bool {
+	return len(a.APIKeys) > 0 || a.OIDC.Issuer != ""
+}
+
+// Analytics holds the defaults used when a request or command does not set them.
+type Analytics struct {
+	TimeZone         string `yaml:"time_zone"`          // IANA time zone of activity heatmaps.
+	WorkdayStartHour int    `yaml:"workday_start_hour"` // First working hour (inclusive), 0-23.
+	WorkdayEndHour   int    `yaml:"workday_end_hour"`   // End of working hours (exclusive), 1-24.
+	StaleBranchDays  int    `yaml:"stale_branch_days"`  // Branches without commits for this many days are stale.
+}
+
+// Jobs configures the background jobs of the API.
+type Jobs struct {
+	Dir         string `yaml:"dir"`          // Directory job records are kept in; see Directory.
+	Concurrency int    `yaml:"concurrency"`  // Jobs running at once.
+	MaxAttempts int    `yaml:"max_attempts"` // Attempts per job when the provider fails or rate limits it.
+}
+
+// Schedule refreshes the cached API responses of the repositories of an owner periodically, so
+// dashboards read warm data instead of waiting for the provider once the cache expired.
+type Schedule struct {
+	Name      string   `yaml:"name"`      // Unique name, used as label of the schedule metrics.
+	Cron      string   `yaml:"cron"`      // When to run, in UTC: a cron expression, "@daily" or "@every 30m".
+	Provider  string   `yaml:"provider"`  // "github" or "gitlab".
+	Owner     string   `yaml:"owner"`     // Organization, user or group whose repositories are refreshed.
+	Endpoints []string `yaml:"endpoints"` // Per-repository endpoints to refresh, e.g. "/commits"; a default set when empty.
+	Caller    string   `yaml:"caller"`    // Whose cache to fill when auth is enabled: "api_key:<name>" or "oidc:<subject>".
+}
+
+// Webhooks configures the receivers of provider push, merge and release events, which keep the
+// cache of the API up to date. A receiver is only mounted when its secret is set.
+type Webhooks struct {
+	GitHubSecret string `yaml:"github_secret"` // Secret GitHub signs deliveries with (X-Hub-Signature-256).
+	GitLabToken  string `yaml:"gitlab_token"`  // Secret token GitLab sends in X-Gitlab-Token.
+}
+
+// Identity maps the names and emails one person commits under to a single author.
+type Identity struct {
+	Name    string   `yaml:"name"`    // Canonical author name.
+	Email   string   `yaml:"email"`   // Canonical author email.
+	Aliases []string `yaml:"aliases"` // Other emails or names of the same person, matched case-insensitively.
+}
+
+// Default returns the built-in configuration.
+func Default() *Config {
+	return &Config{
+		Providers: Providers{
+			GitHub: GitHub{CommitsBackend: "rest", DetailWorkers: 8},
+		},
+		Cache: Cache{
+			RedisHost:     "redis:6379",
+			RedisPassword: "toor", // TODO: Ensure 'toor' is a dev-only default.
+			TTL:           time.Hour,
+			EndpointTTLs:  map[string]time.Duration{"/loc": 24 * time.Hour},
+			StaleFor:      24 * time.Hour,
+		},
+		Server: Server{
+			Address:           ":1323",
+			CORSAllowedOrigin: "",
+			APIBaseURL:        "/api/v1",
+		},
+		Analytics: Analytics{
+			TimeZone:         "UTC",
+			WorkdayStartHour: 9,
+			WorkdayEndHour:   18,
+			StaleBranchDays:  90,
+		},
+		Jobs: Jobs{
+			Concurrency: 2,
+			MaxAttempts: 3,
+		},
+	}
+}
+
+// DefaultPath returns the config file read when no path is given:
+// $XDG_CONFIG_HOME/gitstats/config.yaml, or the platform equivalent.
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user config directory: %w", err)
+	}
+	return filepath.Join(configDir, "gitstats", "config.yaml"), nil
+}
+
+// Load builds the configuration from the defaults, the file at path and the environment.
+// An empty path reads DefaultPath if that file exists; an explicit path must exist.
+// The result is not validated; call Validate once flags have been applied.
+func Load(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+
+	explicit := path != ""
+	if !explicit {
+		defaultPath, err := DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+		path = defaultPath
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := cfg.decode(path, bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// No config file is fine; defaults and the environment apply.
+	default:
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := cfg.applyEnv(lookupEnv); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// decode merges the YAML document read from r into cfg. Unknown keys are rejected so that
+// typos do not silently fall back to defaults.
+func (cfg *Config) decode(path string, r io.Reader) error {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides cfg with the environment variables that are set and non-empty.
+func (cfg *Config) applyEnv(lookupEnv func(string) (string, bool)) error {
+	stringVars := map[string]*string{
+		"GITSTATS_PROVIDER":               &cfg.Providers.Default,
+		"GITHUB_TOKEN":                    &cfg.Providers.GitHub.Token,
+		"GITLAB_TOKEN":                    &cfg.Providers.GitLab.Token,
+		"GITLAB_HOST":                     &cfg.Providers.GitLab.Host,
+		"REDIS_HOST":                      &cfg.Cache.RedisHost,
+		"REDIS_PASSWORD":                  &cfg.Cache.RedisPassword,
+		"SERVER_ADDRESS":                  &cfg.Server.Address,
+		"CORS_ALLOWED_ORIGIN":             &cfg.Server.CORSAllowedOrigin,
+		"API_BASE_URL":                    &cfg.Server.APIBaseURL,
+		"GITSTATS_TIME_ZONE":              &cfg.Analytics.TimeZone,
+		"GITSTATS_OIDC_ISSUER":            &cfg.Auth.OIDC.Issuer,
+		"GITSTATS_OIDC_AUDIENCE":          &cfg.Auth.OIDC.Audience,
+		"GITSTATS_OIDC_JWKS_URL":          &cfg.Auth.OIDC.JWKSURL,
+		"GITSTATS_POLICY_FILE":            &cfg.Auth.PolicyFile,
+		"GITSTATS_GITHUB_COMMITS_BACKEND": &cfg.Providers.GitHub.CommitsBackend,
+		"GITSTATS_JOBS_DIR":               &cfg.Jobs.Dir,
+		"GITSTATS_GITHUB_WEBHOOK_SECRET":  &cfg.Webhooks.GitHubSecret,
+		"GITSTATS_GITLAB_WEBHOOK_TOKEN":   &cfg.Webhooks.GitLabToken,
+	}
+	for key, target := range stringVars {
+		if value, ok := lookupEnv(key); ok && value != "" {
+			*target = value
+		}
+	}
+
+	intVars := map[string]*int{
+		"GITSTATS_WORKDAY_START_HOUR":    &cfg.Analytics.WorkdayStartHour,
+		"GITSTATS_WORKDAY_END_HOUR":      &cfg.Analytics.WorkdayEndHour,
+		"GITSTATS_STALE_BRANCH_DAYS":     &cfg.Analytics.StaleBranchDays,
+		"GITSTATS_GITHUB_DETAIL_WORKERS": &cfg.Providers.GitHub.DetailWorkers,
+		"GITSTATS_JOBS_CONCURRENCY":      &cfg.Jobs.Concurrency,
+		"GITSTATS_JOBS_MAX_ATTEMPTS":     &cfg.Jobs.MaxAttempts,
+	}
+	for key, target := range intVars {
+		value, ok := lookupEnv(key)
+		if !ok || value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("environment variable %s must be an integer, got %q", key, value)
+		}
+		*target = parsed
+	}
+
+	durationVars := map[string]*time.Duration{
+		"GITSTATS_CACHE_TTL":       &cfg.Cache.TTL,
+		"GITSTATS_CACHE_STALE_FOR": &cfg.Cache.StaleFor,
+	}
+	for key, target := range durationVars {
+		value, ok := lookupEnv(key)
+		if !ok || value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("environment variable %s must be a duration such as 30m, got %q", key, value)
+		}
+		*target = parsed
+	}
+
+	if value, ok := lookupEnv("GITSTATS_FORWARD_PROVIDER_TOKENS"); ok && value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("environment variable GITSTATS_FORWARD_PROVIDER_TOKENS must be a boolean, got %q", value)
+		}
+		cfg.Auth.ForwardProviderTokens = parsed
+	}
+	// GITSTATS_API_KEYS replaces auth.api_keys: comma separated name:sha256 pairs.
+	if value, ok := lookupEnv("GITSTATS_API_KEYS"); ok && value != "" {
+		cfg.Auth.APIKeys = nil
+		for _, entry := range strings.Split(value, ",") {
+			name, hash, found := strings.Cut(strings.TrimSpace(entry), ":")
+			if !found {
+				return fmt.Errorf("environment variable GITSTATS_API_KEYS must hold name:sha256 pairs, got %q", entry)
+			}
+			cfg.Auth.APIKeys = append(cfg.Auth.APIKeys, APIKey{Name: name, SHA256: hash})
+		}
+	}
+	// GITSTATS_CACHE_ENDPOINT_TTLS is added to cache.endpoint_ttls: comma separated path=duration pairs.
+	if value, ok := lookupEnv("GITSTATS_CACHE_ENDPOINT_TTLS"); ok && value != "" {
+		if cfg.Cache.EndpointTTLs == nil {
+			cfg.Cache.EndpointTTLs = map[string]time.Duration{}
+		}
+		for _, entry := range strings.Split(value, ",") {
+			endpoint, ttl, found := strings.Cut(strings.TrimSpace(entry), "=")
+			parsed, err := time.ParseDuration(ttl)
+			if !found || err != nil {
+				return fmt.Errorf("environment variable GITSTATS_CACHE_ENDPOINT_TTLS must hold path=duration pairs, got %q", entry)
+			}
+			cfg.Cache.EndpointTTLs[endpoint] = parsed
+		}
+	}
+	return nil
+}
+
+// Validate checks cfg and reports every problem found, one per line.
+func (cfg *Config) Validate() error {
+	var problems []error
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	switch cfg.Providers.Default {
+	case "", ProviderGithub, ProviderGitlab:
+	default:
+		addProblem("providers.default must be %q or %q, got %q", ProviderGithub, ProviderGitlab, cfg.Providers.Default)
+	}
+	if host := cfg.Providers.GitLab.Host; host != "" {
+		if parsed, err := url.Parse(host); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			addProblem("providers.gitlab.host must be an absolute URL such as https://gitlab.example.com, got %q", host)
+		}
+	}
+	switch cfg.Providers.GitHub.CommitsBackend {
+	case "rest", "graphql":
+	default:
+		addProblem("providers.github.commits_backend must be \"rest\" or \"graphql\", got %q", cfg.Providers.GitHub.CommitsBackend)
+	}
+	if workers := cfg.Providers.GitHub.DetailWorkers; workers < 1 || workers > 64 {
+		addProblem("providers.github.detail_workers must be between 1 and 64, got %d", workers)
+	}
+	if cfg.Cache.RedisHost == "" {
+		addProblem("cache.redis_host must not be empty")
+	}
+	if cfg.Cache.TTL < time.Second {
+		addProblem("cache.ttl must be at least 1s, got %s", cfg.Cache.TTL)
+	}
+	for endpoint, ttl := range cfg.Cache.EndpointTTLs {
+		if !strings.HasPrefix(endpoint, "/") {
+			addProblem("cache.endpoint_ttls must be keyed by paths such as \"/commits\", got %q", endpoint)
+		}
+		if ttl < time.Second {
+			addProblem("cache.endpoint_ttls[%s] must be at least 1s, got %s", endpoint, ttl)
+		}
+	}
+	if cfg.Cache.StaleFor < 0 {
+		addProblem("cache.stale_for must not be negative, got %s", cfg.Cache.StaleFor)
+	}
+	if cfg.Server.Address == "" {
+		addProblem("server.address must not be empty")
+	}
+	if cfg.Server.APIBaseURL == "" {
+		addProblem("server.api_base_url must not be empty")
+	}
+	if _, err := time.LoadLocation(cfg.Analytics.TimeZone); err != nil {
+		addProblem("analytics.time_zone must be an IANA time zone name, got %q", cfg.Analytics.TimeZone)
+	}
+	if start, end := cfg.Analytics.WorkdayStartHour, cfg.Analytics.WorkdayEndHour; start < 0 || end > 24 || start >= end {
+		addProblem("analytics working hours must satisfy 0 <= workday_start_hour < workday_end_hour <= 24, got %d-%d", start, end)
+	}
+	if cfg.Analytics.StaleBranchDays < 0 {
+		addProblem("analytics.stale_branch_days must not be negative, got %d", cfg.Analytics.StaleBranchDays)
+	}
+	if concurrency := cfg.Jobs.Concurrency; concurrency < 1 || concurrency > 64 {
+		addProblem("jobs.concurrency must be between 1 and 64, got %d", concurrency)
+	}
+	if attempts := cfg.Jobs.MaxAttempts; attempts < 1 || attempts > 10 {
+		addProblem("jobs.max_attempts must be between 1 and 10, got %d", attempts)
+	}
+
+	keyNames := map[string]bool{}
+	for i, key := range cfg.Auth.APIKeys {
+		if key.Name == "" || keyNames[key.Name] {
+			addProblem("auth.api_keys[%d] needs a unique name", i)
+		}
+		keyNames[key.Name] = true
+		if decoded, err := hex.DecodeString(key.SHA256); err != nil || len(decoded) != sha256.Size {
+			addProblem("auth.api_keys[%d].sha256 must be the hex SHA-256 of the key, not the key itself", i)
+		}
+	}
+	if issuer := cfg.Auth.OIDC.Issuer; issuer != "" {
+		if parsed, err := url.Parse(issuer); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			addProblem("auth.oidc.issuer must be an absolute URL, got %q", issuer)
+		}
+		if cfg.Auth.OIDC.Audience == "" {
+			addProblem("auth.oidc.audience must be set when auth.oidc.issuer is")
+		}
+	}
+
+	scheduleNames := map[string]bool{}
+	for i, sched := range cfg.Schedules {
+		if sched.Name == "" || scheduleNames[sched.Name] {
+			addProblem("schedules[%d] needs a unique name", i)
+		}
+		scheduleNames[sched.Name] = true
+		if _, err := schedule.Parse(sched.Cron); err != nil {
+			addProblem("schedules[%d].cron is invalid: %v", i, err)
+		}
+		if sched.Provider != ProviderGithub && sched.Provider != ProviderGitlab {
+			addProblem("schedules[%d].provider must be %q or %q, got %q", i, ProviderGithub, ProviderGitlab, sched.Provider)
+		}
+		if sched.Owner == "" {
+			addProblem("schedules[%d].owner must not be empty", i)
+		}
+		for _, endpoint := range sched.Endpoints {
+			if !strings.HasPrefix(endpoint, "/") {
+				addProblem("schedules[%d].endpoints must be paths such as \"/commits\", got %q", i, endpoint)
+			}
+		}
+		method, subject, _ := strings.Cut(sched.Caller, ":")
+		switch {
+		case sched.Caller == "":
+			if cfg.Auth.Enabled() {
+				addProblem("schedules[%d].caller must be set when auth is enabled, since every caller has its own cache", i)
+			}
+		case method == "api_key" && keyNames[subject]:
+		case method == "oidc" && subject != "" && cfg.Auth.OIDC.Issuer != "":
+		default:
+			addProblem("schedules[%d].caller must be \"api_key:<name>\" of a configured key or \"oidc:<subject>\", got %q", i, sched.Caller)
+		}
+	}
+
+	claimedBy := map[string]int{}
+	for i, identity := range cfg.Identities {
+		if identity.Name == "" && identity.Email == "" {
+			addProblem("identities[%d] needs a name or an email", i)
+			continue
+		}
+		for _, alias := range append([]string{identity.Email}, identity.Aliases...) {
+			key := strings.ToLower(strings.TrimSpace(alias))
+			if key == "" {
+				continue
+			}
+			if other, ok := claimedBy[key]; ok && other != i {
+				addProblem("identities[%d] and identities[%d] both claim %q", other, i, alias)
+				continue
+			}
+			claimedBy[key] = i
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(problems...))
+	}
+	return nil
+}
+
+// Location returns the time zone of Analytics.TimeZone, or UTC if it cannot be loaded.
+func (a Analytics) Location() *time.Location {
+	location, err := time.LoadLocation(a.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// Directory returns Jobs.Dir, or $XDG_CACHE_HOME/gitstats/jobs, or the platform equivalent, when
+// it is empty.
+func (j Jobs) Directory() (string, error) {
+	if j.Dir != "" {
+		return j.Dir, nil
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "gitstats", "jobs"), nil
+}
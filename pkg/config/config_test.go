@@ -0,0 +1,242 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envFrom(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoad_LayersFileOverDefaultsAndEnvOverFile(t *testing.T) {
+	path := writeConfig(t, `
+providers:
+  default: gitlab
+  gitlab:
+    token: file-token
+    host: https://gitlab.example.com
+server:
+  address: ":8080"
+analytics:
+  time_zone: Europe/Istanbul
+  stale_branch_days: 30
+identities:
+  - name: Jane Doe
+    email: jane@example.com
+    aliases: [jane@old.example.com]
+`)
+	cfg, err := Load(path, envFrom(map[string]string{"GITLAB_TOKEN": "env-token", "GITSTATS_STALE_BRANCH_DAYS": "45", "GITSTATS_GITHUB_DETAIL_WORKERS": "4", "GITSTATS_GITHUB_COMMITS_BACKEND": "graphql", "GITSTATS_JOBS_CONCURRENCY": "4", "GITSTATS_JOBS_DIR": "/var/lib/gitstats/jobs", "GITSTATS_GITHUB_WEBHOOK_SECRET": "hook-secret", "REDIS_HOST": ""}))
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if cfg.Providers.GitLab.Token != "env-token" {
+		t.Errorf("env should override the file token, got %q", cfg.Providers.GitLab.Token)
+	}
+	if cfg.Providers.GitLab.Host != "https://gitlab.example.com" || cfg.Server.Address != ":8080" || cfg.Analytics.TimeZone != "Europe/Istanbul" {
+		t.Errorf("file values not applied: %+v", cfg)
+	}
+	if cfg.Analytics.StaleBranchDays != 45 {
+		t.Errorf("env should override stale_branch_days, got %d", cfg.Analytics.StaleBranchDays)
+	}
+	if cfg.Providers.GitHub.DetailWorkers != 4 || cfg.Providers.GitHub.CommitsBackend != "graphql" {
+		t.Errorf("env should override detail_workers and commits_backend, got %+v", cfg.Providers.GitHub)
+	}
+	if cfg.Jobs.Concurrency != 4 || cfg.Jobs.Dir != "/var/lib/gitstats/jobs" || cfg.Jobs.MaxAttempts != 3 {
+		t.Errorf("env should override jobs.concurrency and jobs.dir, got %+v", cfg.Jobs)
+	}
+	if cfg.Webhooks.GitHubSecret != "hook-secret" || cfg.Webhooks.GitLabToken != "" {
+		t.Errorf("env should set webhooks.github_secret only, got %+v", cfg.Webhooks)
+	}
+	if cfg.Cache.RedisHost != "redis:6379" || cfg.Analytics.WorkdayStartHour != 9 || cfg.Server.APIBaseURL != "/api/v1" {
+		t.Errorf("defaults should fill unset values (empty env vars are ignored), got %+v", cfg)
+	}
+	if len(cfg.Identities) != 1 || cfg.Identities[0].Aliases[0] != "jane@old.example.com" {
+		t.Errorf("identities not loaded: %+v", cfg.Identities)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+}
+
+func TestLoad_MissingFiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if _, err := Load("", envFrom(nil)); err != nil {
+		t.Errorf("a missing default config file should not be an error, got %v", err)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), envFrom(nil)); err == nil {
+		t.Error("a missing explicit config file should be an error")
+	}
+}
+
+func TestLoad_RejectsUnknownKeysAndBadEnv(t *testing.T) {
+	path := writeConfig(t, "server:\n  adress: \":8080\"\n")
+	if _, err := Load(path, envFrom(nil)); err == nil || !strings.Contains(err.Error(), "adress") {
+		t.Errorf("expected an error naming the unknown key, got %v", err)
+	}
+	if _, err := Load(writeConfig(t, ""), envFrom(map[string]string{"GITSTATS_WORKDAY_END_HOUR": "six"})); err == nil {
+		t.Error("expected an error for a non-integer environment variable")
+	}
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	cfg := Default()
+	cfg.Providers.Default = "bitbucket"
+	cfg.Providers.GitLab.Host = "gitlab.example.com"
+	cfg.Analytics.TimeZone = "Mars/Olympus"
+	cfg.Analytics.WorkdayStartHour, cfg.Analytics.WorkdayEndHour = 18, 9
+	cfg.Analytics.StaleBranchDays = -1
+	cfg.Providers.GitHub.DetailWorkers = 0
+	cfg.Providers.GitHub.CommitsBackend = "soap"
+	cfg.Jobs.Concurrency = 0
+	cfg.Jobs.MaxAttempts = 11
+	cfg.Identities = []Identity{
+		{Name: "A", Email: "a@example.com", Aliases: []string{"shared"}},
+		{Name: "B", Aliases: []string{"SHARED"}},
+		{},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected an error, got nil")
+	}
+	for _, want := range []string{"providers.default", "providers.gitlab.host", "providers.github.detail_workers", "providers.github.commits_backend", "jobs.concurrency", "jobs.max_attempts", "analytics.time_zone", "working hours", "stale_branch_days", "both claim", "identities[2]"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error does not mention %q:\n%v", want, err)
+		}
+	}
+	if err := Default().Validate(); err != nil {
+		t.Errorf("Default() should be valid, got %v", err)
+	}
+}
+
+func TestLoad_Auth(t *testing.T) {
+	keyHash := strings.Repeat("ab", 32)
+	path := writeConfig(t, `
+auth:
+  api_keys:
+    - name: ci
+      sha256: `+keyHash+`
+  oidc:
+    issuer: https://login.example.com
+    audience: gitstats
+`)
+	cfg, err := Load(path, envFrom(map[string]string{"GITSTATS_FORWARD_PROVIDER_TOKENS": "true", "GITSTATS_POLICY_FILE": "/etc/gitstats/policy.yaml"}))
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if !cfg.Auth.Enabled() || !cfg.Auth.ForwardProviderTokens || cfg.Auth.APIKeys[0].Name != "ci" || cfg.Auth.OIDC.Audience != "gitstats" || cfg.Auth.PolicyFile != "/etc/gitstats/policy.yaml" {
+		t.Errorf("auth not loaded: %+v", cfg.Auth)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+
+	cfg, err = Load(path, envFrom(map[string]string{"GITSTATS_API_KEYS": "dashboard:" + keyHash + ", cron:" + keyHash}))
+	if err != nil || len(cfg.Auth.APIKeys) != 2 || cfg.Auth.APIKeys[1].Name != "cron" {
+		t.Errorf("GITSTATS_API_KEYS should replace the file keys, got %+v (%v)", cfg.Auth.APIKeys, err)
+	}
+	if Default().Auth.Enabled() {
+		t.Error("authentication should be disabled by default")
+	}
+
+	cfg = Default()
+	cfg.Auth.APIKeys = []APIKey{{Name: "ci", SHA256: "plain-secret"}, {Name: "ci", SHA256: keyHash}}
+	cfg.Auth.OIDC.Issuer = "https://login.example.com"
+	err = cfg.Validate()
+	for _, want := range []string{"auth.api_keys[0].sha256", "auth.api_keys[1] needs a unique name", "auth.oidc.audience"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoad_Schedules(t *testing.T) {
+	keyHash := strings.Repeat("ab", 32)
+	path := writeConfig(t, `
+auth:
+  api_keys:
+    - name: dashboard
+      sha256: `+keyHash+`
+schedules:
+  - name: octo-nightly
+    cron: "30 2 * * *"
+    provider: github
+    owner: octo-org
+    endpoints: [/commits, /activity]
+    caller: api_key:dashboard
+`)
+	cfg, err := Load(path, envFrom(nil))
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if len(cfg.Schedules) != 1 || cfg.Schedules[0].Owner != "octo-org" || len(cfg.Schedules[0].Endpoints) != 2 {
+		t.Fatalf("schedules not loaded: %+v", cfg.Schedules)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+
+	cfg.Schedules = append(cfg.Schedules,
+		Schedule{Name: "octo-nightly", Cron: "every night", Provider: "bitbucket", Endpoints: []string{"commits"}, Caller: "api_key:unknown"},
+		Schedule{Name: "group", Cron: "@hourly", Provider: ProviderGitlab, Owner: "group"},
+	)
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected an error, got nil")
+	}
+	for _, want := range []string{"schedules[1] needs a unique name", "schedules[1].cron", "schedules[1].provider", "schedules[1].owner", "schedules[1].endpoints", "schedules[1].caller", "schedules[2].caller must be set"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoad_CacheTTLs(t *testing.T) {
+	path := writeConfig(t, `
+cache:
+  ttl: 30m
+  endpoint_ttls:
+    /commits: 5m
+`)
+	cfg, err := Load(path, envFrom(map[string]string{"GITSTATS_CACHE_STALE_FOR": "0s", "GITSTATS_CACHE_ENDPOINT_TTLS": "/tags=2h"}))
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	want := map[string]time.Duration{"/loc": 24 * time.Hour, "/commits": 5 * time.Minute, "/tags": 2 * time.Hour}
+	if cfg.Cache.TTL != 30*time.Minute || cfg.Cache.StaleFor != 0 || !reflect.DeepEqual(cfg.Cache.EndpointTTLs, want) {
+		t.Errorf("unexpected cache settings: %+v", cfg.Cache)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+
+	if _, err := Load(writeConfig(t, ""), envFrom(map[string]string{"GITSTATS_CACHE_TTL": "3600"})); err == nil {
+		t.Error("expected a TTL without unit to be rejected")
+	}
+	cfg.Cache.TTL = 0
+	cfg.Cache.EndpointTTLs["commits"] = time.Millisecond
+	err = cfg.Validate()
+	for _, want := range []string{"cache.ttl", "cache.endpoint_ttls must be keyed by paths", "cache.endpoint_ttls[commits]"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error does not mention %q:\n%v", want, err)
+		}
+	}
+}
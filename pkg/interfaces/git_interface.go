@@ -1,6 +1,8 @@
 package interfaces
 
 import (
+	"time"
+
 	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
 )
 
@@ -32,6 +34,32 @@ type GitService interface {
 	// Note: The level of detail in common_types.User for contributors may vary
 	// depending on what the provider's API returns for contributors.
 	GetRepoContributors(repoIdentifier interface{}) ([]*common_types.User, error)
+
+	// ListIssues retrieves issues for a specific repository.
+	// The 'repoIdentifier' is similar to GetRepo's 'identifier'.
+	// Pull requests / merge requests are excluded from the result.
+	// If 'options' is nil or options.Page is 0, implementations walk every page.
+	ListIssues(repoIdentifier interface{}, options *IssueListOptions) ([]*common_types.Issue, error)
+
+	// ListBranches retrieves every branch of a specific repository, including the head commit,
+	// protection status and ahead/behind counts against the default branch.
+	// The 'repoIdentifier' is similar to GetRepo's 'identifier'.
+	// Note: ahead/behind counts cost extra provider calls per branch.
+	ListBranches(repoIdentifier interface{}) ([]*common_types.Branch, error)
+
+	// ListTags retrieves every tag of a specific repository together with the tagged commit.
+	// The 'repoIdentifier' is similar to GetRepo's 'identifier'.
+	ListTags(repoIdentifier interface{}) ([]*common_types.Tag, error)
+
+	// CompareRefs retrieves the commits reachable from headRef but not from baseRef (newest first)
+	// together with the files changed between the two refs.
+	// The 'repoIdentifier' is similar to GetRepo's 'identifier'.
+	CompareRefs(repoIdentifier interface{}, baseRef, headRef string) (*common_types.Comparison, error)
+
+	// ListLanguages retrieves the languages of a specific repository with their share of its code,
+	// largest share first.
+	// The 'repoIdentifier' is similar to GetRepo's 'identifier'.
+	ListLanguages(repoIdentifier interface{}) ([]*common_types.Language, error)
 }
 
 // CommitListOptions provides optional parameters for listing commits.
@@ -42,4 +70,33 @@ type CommitListOptions struct {
 	Author  string // Commit author (e.g., email or username) to filter by. Empty if not filtering by author.
 	Page    int    // Page number for pagination. Typically 1-based. 0 or 1 means first page.
 	PerPage int    // Number of items per page for pagination. 0 means provider's default.
+	BaseRef string // If set, only commits reachable from SHA but not from BaseRef are listed, across all pages.
+
+	Since time.Time // If set, only commits authored at or after Since are listed, across all pages.
+	Until time.Time // If set, only commits authored before or at Until are listed, across all pages.
+
+	NoStats bool // If set, commits may come without line stats, sparing the requests providers make to fetch them.
+}
+
+// WalksAllPages reports whether the options select a bounded commit range that implementations
+// list in full instead of returning a single page.
+func (options *CommitListOptions) WalksAllPages() bool {
+	return options != nil && (options.BaseRef != "" || !options.Since.IsZero() || !options.Until.IsZero())
+}
+
+// Issue states accepted by IssueListOptions.State.
+const (
+	IssueStateOpen   = "open"
+	IssueStateClosed = "closed"
+	IssueStateAll    = "all"
+)
+
+// IssueListOptions provides optional parameters for listing issues.
+// Zero-values for fields usually mean the provider's default will be used.
+type IssueListOptions struct {
+	State   string    // One of IssueStateOpen, IssueStateClosed or IssueStateAll. Empty means IssueStateAll.
+	Labels  []string  // Only return issues carrying all of these labels. Empty if not filtering by label.
+	Since   time.Time // Only return issues updated at or after this time. Zero if not filtering.
+	Page    int       // Page number for pagination. 0 walks every page.
+	PerPage int       // Number of items per page for pagination. 0 means provider's default.
 }
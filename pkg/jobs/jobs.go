@@ -0,0 +1,391 @@
+// Package jobs runs long analyses in the background: an in-process queue with a concurrency limit,
+// retries and cancellation, whose job records outlive the process in a Store.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	appMetrics "github.com/ahmetk3436/git-stats-golang/pkg/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// Status is the state of a Job.
+type Status string
+
+// Job states. Queued and running jobs are unfinished; the others are final.
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+// Finished reports whether s is final.
+func (s Status) Finished() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCanceled
+}
+
+// Defaults of Options.
+const (
+	DefaultConcurrency = 2
+	DefaultMaxAttempts = 3
+	DefaultRetryDelay  = 5 * time.Second
+	DefaultRetention   = 7 * 24 * time.Hour
+)
+
+// Errors of Queue.
+var (
+	ErrNotFound = errors.New("job not found")
+	ErrFinished = errors.New("job already finished")
+)
+
+// Job is the record of one background job.
+type Job struct {
+	ID          string     `json:"id"`
+	Owner       string     `json:"owner,omitempty"` // Who may see the job; opaque to the queue.
+	Provider    string     `json:"provider"`        // e.g. "github".
+	Endpoint    string     `json:"endpoint"`        // Provider endpoint the job runs, e.g. "/loc".
+	Query       string     `json:"query"`           // Encoded query parameters of the endpoint.
+	Status      Status     `json:"status"`
+	Progress    Progress   `json:"progress"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"maxAttempts"`
+	Error       string     `json:"error,omitempty"`  // Why the last attempt failed.
+	Result      *Result    `json:"result,omitempty"` // Output of the last attempt.
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Progress is how far a running job got, as reported through ReportProgress.
+type Progress struct {
+	Done    int    `json:"done"`
+	Total   int    `json:"total"` // Zero when unknown.
+	Message string `json:"message,omitempty"`
+}
+
+// Result is the output of a job: the response the endpoint answered with.
+type Result struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// Func runs one attempt of a job. Its context is canceled when the job is. An error wrapped in
+// Retry makes the queue try again; any other error fails the job, keeping result if it is set.
+type Func func(ctx context.Context) (*Result, error)
+
+// RetryError is an attempt failure that a later attempt may not hit, such as a rate limit.
+type RetryError struct {
+	Err   error
+	After time.Duration // How long to wait before the next attempt; the queue's RetryDelay when zero.
+}
+
+func (e *RetryError) Error() string { return e.Err.Error() }
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// Retry marks err as worth another attempt after waiting after.
+func Retry(err error, after time.Duration) error {
+	return &RetryError{Err: err, After: after}
+}
+
+// progressKey is the context key of the progress reporter of a running job.
+type progressKey struct{}
+
+// ReportProgress records the progress of the job running with ctx. It does nothing for contexts
+// of other requests, so handlers can report unconditionally.
+func ReportProgress(ctx context.Context, done, total int, message string) {
+	if report, ok := ctx.Value(progressKey{}).(func(Progress)); ok {
+		report(Progress{Done: done, Total: total, Message: message})
+	}
+}
+
+// Options configures a Queue.
+type Options struct {
+	Concurrency int           // Jobs running at once; DefaultConcurrency when zero.
+	MaxAttempts int           // Attempts per job, the first included; DefaultMaxAttempts when zero.
+	RetryDelay  time.Duration // Wait before a retry, multiplied by the attempt; DefaultRetryDelay when zero.
+	Retention   time.Duration // Finished jobs older than this are deleted on start and on Submit; DefaultRetention when zero.
+}
+
+// Queue runs jobs in the background. It is safe for concurrent use.
+type Queue struct {
+	store   Store
+	options Options
+	slots   chan struct{} // One token per running job.
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	cancels map[string]context.CancelFunc // Of unfinished jobs.
+
+	now func() time.Time // Replaced by tests.
+}
+
+// NewQueue returns a queue keeping its records in store. Jobs store holds from an earlier process
+// that did not finish are failed, as the callers they ran for are gone, and finished jobs older
+// than the retention are deleted.
+func NewQueue(store Store, options Options) (*Queue, error) {
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultConcurrency
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultMaxAttempts
+	}
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = DefaultRetryDelay
+	}
+	if options.Retention <= 0 {
+		options.Retention = DefaultRetention
+	}
+	q := &Queue{
+		store:   store,
+		options: options,
+		slots:   make(chan struct{}, options.Concurrency),
+		jobs:    map[string]*Job{},
+		cancels: map[string]context.CancelFunc{},
+		now:     time.Now,
+	}
+
+	stored, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jobs: %w", err)
+	}
+	now := q.now()
+	for _, job := range stored {
+		if !job.Status.Finished() {
+			job.Status = StatusFailed
+			job.Error = "interrupted by a server restart; submit the job again"
+			job.FinishedAt = &now
+			if err := store.Save(job); err != nil {
+				return nil, fmt.Errorf("failed to save job %s: %w", job.ID, err)
+			}
+		}
+		if q.expired(job, now) {
+			if err := store.Delete(job.ID); err != nil {
+				return nil, fmt.Errorf("failed to delete job %s: %w", job.ID, err)
+			}
+			continue
+		}
+		q.jobs[job.ID] = job
+	}
+	return q, nil
+}
+
+// Submit queues job, of which Owner, Provider, Endpoint and Query are used, to be run by run and
+// returns its record. Finished jobs older than the retention are deleted on the way.
+func (q *Queue) Submit(job Job, run Func) (Job, error) {
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+	record := &Job{
+		ID:          id,
+		Owner:       job.Owner,
+		Provider:    job.Provider,
+		Endpoint:    job.Endpoint,
+		Query:       job.Query,
+		Status:      StatusQueued,
+		MaxAttempts: q.options.MaxAttempts,
+		CreatedAt:   q.now(),
+	}
+	if err := q.store.Save(record); err != nil {
+		return Job{}, fmt.Errorf("failed to save job: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.mu.Lock()
+	q.pruneLocked()
+	q.jobs[id] = record
+	q.cancels[id] = cancel
+	snapshot := *record
+	q.mu.Unlock()
+	appMetrics.JobsActive.WithLabelValues(string(StatusQueued)).Inc()
+
+	go q.run(ctx, id, run)
+	return snapshot, nil
+}
+
+// Get returns the record of job id.
+func (q *Queue) Get(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return *job, nil
+}
+
+// Cancel stops job id, or fails with ErrFinished if it already finished.
+func (q *Queue) Cancel(id string) (Job, error) {
+	q.mu.Lock()
+	job, ok := q.jobs[id]
+	if !ok {
+		q.mu.Unlock()
+		return Job{}, ErrNotFound
+	}
+	if job.Status.Finished() {
+		snapshot := *job
+		q.mu.Unlock()
+		return snapshot, ErrFinished
+	}
+	previous := job.Status
+	q.finishLocked(job, StatusCanceled, "canceled on request")
+	err := q.store.Save(job)
+	snapshot := *job
+	cancel := q.cancels[id]
+	delete(q.cancels, id)
+	q.mu.Unlock()
+
+	cancel()
+	appMetrics.JobsActive.WithLabelValues(string(previous)).Dec()
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to save job %s: %w", id, err)
+	}
+	return snapshot, nil
+}
+
+// run waits for a slot and runs job id until it succeeds, fails for good or is canceled.
+func (q *Queue) run(ctx context.Context, id string, run Func) {
+	defer q.release(id)
+	for {
+		select {
+		case q.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		retry, after := q.attempt(ctx, id, run)
+		<-q.slots
+		if !retry {
+			return
+		}
+		select {
+		case <-time.After(after):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// attempt runs one attempt of job id and reports whether to retry, and after how long.
+func (q *Queue) attempt(ctx context.Context, id string, run Func) (bool, time.Duration) {
+	job, ok := q.update(id, func(job *Job) {
+		now := q.now()
+		job.Status = StatusRunning
+		job.Attempts++
+		job.StartedAt = &now
+		job.Error = ""
+	})
+	if !ok {
+		return false, 0 // Canceled while waiting.
+	}
+	appMetrics.JobsActive.WithLabelValues(string(StatusQueued)).Dec()
+	appMetrics.JobsActive.WithLabelValues(string(StatusRunning)).Inc()
+
+	result, err := run(context.WithValue(ctx, progressKey{}, func(progress Progress) {
+		q.update(id, func(job *Job) { job.Progress = progress })
+	}))
+
+	var retryErr *RetryError
+	retry := errors.As(err, &retryErr) && job.Attempts < job.MaxAttempts
+	after := q.options.RetryDelay * time.Duration(job.Attempts)
+	if retry && retryErr.After > 0 {
+		after = retryErr.After
+	}
+	finished, ok := q.update(id, func(job *Job) {
+		job.Result = result
+		switch {
+		case err == nil:
+			q.finishLocked(job, StatusSucceeded, "")
+		case retry:
+			job.Status = StatusQueued
+			job.Error = err.Error()
+		default:
+			q.finishLocked(job, StatusFailed, err.Error())
+		}
+	})
+	if !ok {
+		return false, 0 // Canceled while running; Cancel updated the gauges.
+	}
+	appMetrics.JobsActive.WithLabelValues(string(StatusRunning)).Dec()
+	if retry {
+		appMetrics.JobsActive.WithLabelValues(string(StatusQueued)).Inc()
+	} else {
+		appMetrics.JobsFinishedTotal.WithLabelValues(finished.Provider, finished.Endpoint, string(finished.Status)).Inc()
+	}
+	return retry, after
+}
+
+// update applies change to job id and saves it, unless the job was canceled meanwhile. Saving
+// under q.mu keeps the stored records in the order of the changes.
+func (q *Queue) update(id string, change func(job *Job)) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok || job.Status == StatusCanceled {
+		return Job{}, false
+	}
+	change(job)
+	if err := q.store.Save(job); err != nil {
+		logrus.WithField("job_id", id).WithError(err).Error("Failed to save job.")
+	}
+	return *job, true
+}
+
+// finishLocked moves job to the final status with message as its error. q.mu must be held.
+func (q *Queue) finishLocked(job *Job, status Status, message string) {
+	now := q.now()
+	job.Status = status
+	job.Error = message
+	job.FinishedAt = &now
+	if status == StatusCanceled {
+		appMetrics.JobsFinishedTotal.WithLabelValues(job.Provider, job.Endpoint, string(status)).Inc()
+	}
+}
+
+// expired reports whether job finished longer than the retention before now.
+func (q *Queue) expired(job *Job, now time.Time) bool {
+	return job.FinishedAt != nil && now.Sub(*job.FinishedAt) > q.options.Retention
+}
+
+// pruneLocked deletes the expired jobs from memory and from the store. q.mu must be held.
+func (q *Queue) pruneLocked() {
+	now := q.now()
+	for id, job := range q.jobs {
+		if !q.expired(job, now) {
+			continue
+		}
+		if err := q.store.Delete(id); err != nil {
+			logrus.WithField("job_id", id).WithError(err).Error("Failed to delete expired job.")
+			continue
+		}
+		delete(q.jobs, id)
+	}
+}
+
+// release forgets the cancel function of job id once its goroutine ends.
+func (q *Queue) release(id string) {
+	q.mu.Lock()
+	cancel, ok := q.cancels[id]
+	delete(q.cancels, id)
+	q.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// newID returns a random job ID.
+func newID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
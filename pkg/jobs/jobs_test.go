@@ -0,0 +1,213 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls job id until it reaches status or the test times out.
+func waitFor(t *testing.T, q *Queue, id string, status Status) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := q.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s) returned error: %v", id, err)
+		}
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is %s, want %s", id, job.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newTestQueue(t *testing.T, store Store, options Options) *Queue {
+	t.Helper()
+	q, err := NewQueue(store, options)
+	if err != nil {
+		t.Fatalf("NewQueue() returned error: %v", err)
+	}
+	return q
+}
+
+func TestQueue_RunsJobAndReportsProgress(t *testing.T) {
+	q := newTestQueue(t, NewMemoryStore(), Options{})
+	job, err := q.Submit(Job{Provider: "github", Endpoint: "/loc", Query: "repo=o%2Fr"}, func(ctx context.Context) (*Result, error) {
+		ReportProgress(ctx, 1, 2, "cloning")
+		return &Result{Status: 200, ContentType: "application/json", Body: []byte(`{"total":3}`)}, nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() returned error: %v", err)
+	}
+	if job.Status != StatusQueued || job.ID == "" {
+		t.Errorf("expected a queued job with an ID, got %+v", job)
+	}
+
+	done := waitFor(t, q, job.ID, StatusSucceeded)
+	if done.Attempts != 1 || done.Progress.Message != "cloning" || string(done.Result.Body) != `{"total":3}` || done.FinishedAt == nil {
+		t.Errorf("unexpected finished job %+v", done)
+	}
+}
+
+func TestQueue_LimitsConcurrency(t *testing.T) {
+	q := newTestQueue(t, NewMemoryStore(), Options{Concurrency: 2})
+	var running, maxRunning int32
+	release := make(chan struct{})
+	var ids []string
+	for i := 0; i < 5; i++ {
+		job, _ := q.Submit(Job{}, func(ctx context.Context) (*Result, error) {
+			current := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				seen := atomic.LoadInt32(&maxRunning)
+				if current <= seen || atomic.CompareAndSwapInt32(&maxRunning, seen, current) {
+					break
+				}
+			}
+			<-release
+			return &Result{Status: 200}, nil
+		})
+		ids = append(ids, job.ID)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for _, id := range ids {
+		waitFor(t, q, id, StatusSucceeded)
+	}
+	if maxRunning != 2 {
+		t.Errorf("expected at most 2 jobs running at once, got %d", maxRunning)
+	}
+}
+
+func TestQueue_RetriesRetryableErrors(t *testing.T) {
+	q := newTestQueue(t, NewMemoryStore(), Options{MaxAttempts: 3, RetryDelay: time.Millisecond})
+	var attempts int32
+	job, _ := q.Submit(Job{}, func(ctx context.Context) (*Result, error) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			return nil, Retry(errors.New("upstream returned 502"), 0)
+		}
+		return &Result{Status: 200}, nil
+	})
+	if done := waitFor(t, q, job.ID, StatusSucceeded); done.Attempts != 3 || done.Error != "" {
+		t.Errorf("expected success on the third attempt, got %+v", done)
+	}
+
+	job, _ = q.Submit(Job{}, func(ctx context.Context) (*Result, error) {
+		return nil, Retry(errors.New("upstream returned 502"), 0)
+	})
+	if failed := waitFor(t, q, job.ID, StatusFailed); failed.Attempts != 3 || failed.Error != "upstream returned 502" {
+		t.Errorf("expected failure after 3 attempts, got %+v", failed)
+	}
+
+	job, _ = q.Submit(Job{}, func(ctx context.Context) (*Result, error) {
+		return &Result{Status: 404}, errors.New("not found")
+	})
+	if failed := waitFor(t, q, job.ID, StatusFailed); failed.Attempts != 1 || failed.Result.Status != 404 {
+		t.Errorf("expected a single attempt keeping its result, got %+v", failed)
+	}
+}
+
+func TestQueue_Cancel(t *testing.T) {
+	q := newTestQueue(t, NewMemoryStore(), Options{})
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	job, _ := q.Submit(Job{}, func(ctx context.Context) (*Result, error) {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+		return nil, ctx.Err()
+	})
+	<-started
+
+	canceled, err := q.Cancel(job.ID)
+	if err != nil || canceled.Status != StatusCanceled {
+		t.Fatalf("Cancel() = %+v, %v", canceled, err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the job context to be canceled")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if after, _ := q.Get(job.ID); after.Status != StatusCanceled {
+		t.Errorf("expected the job to stay canceled, got %s", after.Status)
+	}
+	if _, err := q.Cancel(job.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("expected ErrFinished for a second cancel, got %v", err)
+	}
+	if _, err := q.Cancel("unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNewQueue_RecoversStoredJobs(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() returned error: %v", err)
+	}
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	for _, job := range []*Job{
+		{ID: "running", Status: StatusRunning, CreatedAt: recent},
+		{ID: "done", Status: StatusSucceeded, FinishedAt: &recent, Result: &Result{Status: 200, Body: []byte("ok")}},
+		{ID: "expired", Status: StatusFailed, FinishedAt: &old},
+	} {
+		if err := store.Save(job); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+
+	q := newTestQueue(t, store, Options{})
+	if job, _ := q.Get("running"); job.Status != StatusFailed || job.Error == "" {
+		t.Errorf("expected the interrupted job to be failed, got %+v", job)
+	}
+	if job, _ := q.Get("done"); job.Status != StatusSucceeded || string(job.Result.Body) != "ok" {
+		t.Errorf("expected the finished job to be kept, got %+v", job)
+	}
+	if _, err := q.Get("expired"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the expired job to be pruned, got %v", err)
+	}
+
+	stored, _ := store.Load()
+	if len(stored) != 2 {
+		t.Errorf("expected 2 job files after pruning, got %d", len(stored))
+	}
+}
+
+func TestQueue_SubmitPrunesExpiredJobs(t *testing.T) {
+	store := NewMemoryStore()
+	q := newTestQueue(t, store, Options{Retention: time.Hour})
+
+	first, err := q.Submit(Job{Provider: "github", Endpoint: "/loc"}, func(ctx context.Context) (*Result, error) {
+		return &Result{Status: 200}, nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() returned error: %v", err)
+	}
+	waitFor(t, q, first.ID, StatusSucceeded)
+
+	q.mu.Lock()
+	q.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	q.mu.Unlock()
+	second, err := q.Submit(Job{Provider: "github", Endpoint: "/loc"}, func(ctx context.Context) (*Result, error) {
+		return &Result{Status: 200}, nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() returned error: %v", err)
+	}
+	waitFor(t, q, second.ID, StatusSucceeded)
+
+	if _, err := q.Get(first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the expired job to be pruned, got %v", err)
+	}
+	stored, _ := store.Load()
+	if len(stored) != 1 || stored[0].ID != second.ID {
+		t.Errorf("expected only the new job to be stored, got %+v", stored)
+	}
+}
@@ -0,0 +1,245 @@
+// Package policy decides which API callers may query which providers, organizations and
+// repositories, and which of them may trigger expensive operations.
+//
+// A Policy is a list of allow and deny rules read from a YAML file. A request is denied when a
+// deny rule matches it, allowed when an allow rule matches it and otherwise gets the default
+// effect, which is deny unless the file says otherwise.
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Actions a request performs.
+const (
+	ActionRead  = "read"  // Read the statistics of one repository.
+	ActionList  = "list"  // List the repositories of a user, an organization or the whole token.
+	ActionClone = "clone" // Clone a repository, e.g. to count its lines of code.
+	ActionPurge = "purge" // Delete the cached responses of repositories.
+)
+
+// Effects of a Rule and of Policy.Default.
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// DefaultGroupsClaim is the OIDC claim groups are read from when Policy.GroupsClaim is empty.
+const DefaultGroupsClaim = "groups"
+
+// Policy is the access policy of the API.
+type Policy struct {
+	Default     string `yaml:"default"`      // Effect when no rule matches: "deny" (when empty) or "allow".
+	GroupsClaim string `yaml:"groups_claim"` // OIDC claim holding the caller's groups; "groups" when empty.
+	Rules       []Rule `yaml:"rules"`
+}
+
+// Rule allows or denies the requests it matches. A request matches when it matches every
+// non-empty list of the rule; a rule with only an effect matches every request.
+//
+// Orgs and Repos are path.Match patterns, so "*" does not cross a "/"; like GitHub and GitLab
+// paths, they are compared case-insensitively. An org pattern also matches the subgroups of a
+// matching GitLab group. Listing the repositories of an org needs an org pattern. Subjects and
+// Groups are compared exactly; "*" matches any authenticated caller.
+type Rule struct {
+	Name      string   `yaml:"name"`      // Shown in logs when the rule decides a request.
+	Effect    string   `yaml:"effect"`    // "allow" or "deny".
+	Subjects  []string `yaml:"subjects"`  // Callers as "<method>:<name>", e.g. "api_key:ci" or "oidc:alice".
+	Groups    []string `yaml:"groups"`    // Groups of OIDC callers, read from GroupsClaim.
+	Providers []string `yaml:"providers"` // "github" or "gitlab".
+	Orgs      []string `yaml:"orgs"`      // Owners, organizations or GitLab groups, e.g. "octo-org".
+	Repos     []string `yaml:"repos"`     // Repositories as "owner/name" or GitLab "group/project", e.g. "octo-org/*".
+	Actions   []string `yaml:"actions"`   // ActionRead, ActionList, ActionClone or ActionPurge.
+}
+
+// Subject is the caller of a request. The zero Subject is an unauthenticated caller.
+type Subject struct {
+	ID     string   // "<method>:<name>", e.g. "oidc:alice"; empty when unauthenticated.
+	Groups []string // Groups of the caller.
+}
+
+// Request is a request to authorize.
+type Request struct {
+	Subject  Subject
+	Provider string // "github" or "gitlab".
+	Action   string // ActionRead, ActionList, ActionClone or ActionPurge.
+	Org      string // Owner or group the request reads; empty if unknown.
+	Repo     string // Repository path, "owner/name" or "group/subgroup/project"; empty for listings.
+}
+
+// scoped reports whether the request names the org it reads. Requests that identify a
+// repository by a numeric ID or list every repository of the token are not scoped.
+func (req Request) scoped() bool {
+	return req.Org != ""
+}
+
+// Decision is the outcome of Policy.Evaluate.
+type Decision struct {
+	Allowed bool
+	Rule    string // Name of the deciding rule; empty when the default applied.
+}
+
+// Load reads and validates the policy file at path.
+func Load(filePath string) (*Policy, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	policy := &Policy{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid policy file %s: %w", filePath, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", filePath, err)
+	}
+	return policy, nil
+}
+
+// Validate checks p and reports every problem found, one per line.
+func (p *Policy) Validate() error {
+	var problems []error
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	switch p.Default {
+	case "", EffectAllow, EffectDeny:
+	default:
+		addProblem("default must be %q or %q, got %q", EffectAllow, EffectDeny, p.Default)
+	}
+	for i, rule := range p.Rules {
+		if rule.Effect != EffectAllow && rule.Effect != EffectDeny {
+			addProblem("rules[%d].effect must be %q or %q, got %q", i, EffectAllow, EffectDeny, rule.Effect)
+		}
+		for _, action := range rule.Actions {
+			if action != ActionRead && action != ActionList && action != ActionClone && action != ActionPurge {
+				addProblem("rules[%d].actions: unknown action %q", i, action)
+			}
+		}
+		for _, pattern := range append(append([]string{}, rule.Orgs...), rule.Repos...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				addProblem("rules[%d]: invalid pattern %q", i, pattern)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.Join(problems...)
+	}
+	return nil
+}
+
+// Evaluate decides req: denied by any matching deny rule, else allowed by any matching allow
+// rule, else decided by p.Default.
+func (p *Policy) Evaluate(req Request) Decision {
+	var allowedBy *Rule
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if !rule.matches(req) {
+			continue
+		}
+		if rule.Effect == EffectDeny {
+			return Decision{Allowed: false, Rule: rule.Name}
+		}
+		if allowedBy == nil {
+			allowedBy = rule
+		}
+	}
+	if allowedBy != nil {
+		return Decision{Allowed: true, Rule: allowedBy.Name}
+	}
+	return Decision{Allowed: p.Default == EffectAllow}
+}
+
+// matches reports whether the rule applies to req.
+func (rule *Rule) matches(req Request) bool {
+	if len(rule.Subjects) > 0 || len(rule.Groups) > 0 {
+		if req.Subject.ID == "" {
+			return false
+		}
+		if !containsSubject(rule.Subjects, req.Subject.ID) && !containsAnyGroup(rule.Groups, req.Subject.Groups) {
+			return false
+		}
+	}
+	if len(rule.Providers) > 0 && !contains(rule.Providers, req.Provider) {
+		return false
+	}
+	if len(rule.Actions) > 0 && !contains(rule.Actions, req.Action) {
+		return false
+	}
+	return rule.matchesResource(req)
+}
+
+// matchesResource reports whether req reads one of the orgs or repositories of the rule. A
+// request that is not scoped to an org could read anything, so it matches every deny rule but
+// only the allow rules that are not restricted to orgs or repositories.
+func (rule *Rule) matchesResource(req Request) bool {
+	if len(rule.Orgs) == 0 && len(rule.Repos) == 0 {
+		return true
+	}
+	if !req.scoped() {
+		return rule.Effect == EffectDeny
+	}
+	org, repo := strings.ToLower(req.Org), strings.ToLower(req.Repo)
+	for _, pattern := range rule.Orgs {
+		pattern = strings.ToLower(pattern)
+		for namespace := org; namespace != "." && namespace != "/" && namespace != ""; namespace = path.Dir(namespace) {
+			if matched, _ := path.Match(pattern, namespace); matched {
+				return true
+			}
+		}
+	}
+	if repo != "" {
+		for _, pattern := range rule.Repos {
+			if matched, _ := path.Match(strings.ToLower(pattern), repo); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// contains reports whether values holds value, ignoring case.
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsSubject reports whether subjects holds id or "*".
+func containsSubject(subjects []string, id string) bool {
+	for _, subject := range subjects {
+		if subject == "*" || subject == id {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAnyGroup reports whether groups holds one of callerGroups or "*".
+func containsAnyGroup(groups, callerGroups []string) bool {
+	for _, group := range groups {
+		if group == "*" {
+			return true
+		}
+		for _, callerGroup := range callerGroups {
+			if group == callerGroup {
+				return true
+			}
+		}
+	}
+	return false
+}
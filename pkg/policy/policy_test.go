@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testPolicy() *Policy {
+	return &Policy{
+		Rules: []Rule{
+			{Name: "admins", Effect: EffectAllow, Groups: []string{"gitstats-admins"}},
+			{Name: "platform-read", Effect: EffectAllow, Groups: []string{"platform"}, Orgs: []string{"octo-org", "platform-group"}, Actions: []string{ActionRead, ActionList}},
+			{Name: "ci-hello-world", Effect: EffectAllow, Subjects: []string{"api_key:ci"}, Providers: []string{"github"}, Repos: []string{"octo-org/hello-*"}},
+			{Name: "no-secret-repos", Effect: EffectDeny, Repos: []string{"octo-org/secret-*"}},
+			{Name: "no-clones-for-ci", Effect: EffectDeny, Subjects: []string{"api_key:ci"}, Actions: []string{ActionClone}},
+		},
+	}
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	admin := Subject{ID: "oidc:root", Groups: []string{"gitstats-admins"}}
+	platform := Subject{ID: "oidc:alice", Groups: []string{"platform", "developers"}}
+	ci := Subject{ID: "api_key:ci"}
+	stranger := Subject{ID: "oidc:mallory", Groups: []string{"developers"}}
+
+	tests := []struct {
+		name    string
+		request Request
+		allowed bool
+		rule    string
+	}{
+		{"admin reads anything", Request{Subject: admin, Provider: "gitlab", Action: ActionClone, Org: "any", Repo: "any/thing"}, true, "admins"},
+		{"admin lists an org", Request{Subject: admin, Provider: "github", Action: ActionList, Org: "octo-org"}, true, "admins"},
+		{"unscoped listing may include denied repos", Request{Subject: admin, Provider: "github", Action: ActionList}, false, "no-secret-repos"},
+		{"deny wins over allow", Request{Subject: admin, Provider: "github", Action: ActionRead, Org: "octo-org", Repo: "octo-org/secret-plans"}, false, "no-secret-repos"},
+		{"deny patterns ignore case", Request{Subject: admin, Provider: "github", Action: ActionRead, Org: "Octo-Org", Repo: "Octo-Org/Secret-Plans"}, false, "no-secret-repos"},
+		{"group reads its org", Request{Subject: platform, Provider: "github", Action: ActionRead, Org: "octo-org", Repo: "octo-org/hello-world"}, true, "platform-read"},
+		{"group lists its org", Request{Subject: platform, Provider: "github", Action: ActionList, Org: "octo-org"}, true, "platform-read"},
+		{"org pattern covers subgroups", Request{Subject: platform, Provider: "gitlab", Action: ActionRead, Org: "platform-group/infra", Repo: "platform-group/infra/terraform"}, true, "platform-read"},
+		{"org pattern is not a prefix", Request{Subject: platform, Provider: "github", Action: ActionRead, Org: "octo-org-archive", Repo: "octo-org-archive/old"}, false, ""},
+		{"group may not clone", Request{Subject: platform, Provider: "github", Action: ActionClone, Org: "octo-org", Repo: "octo-org/hello-world"}, false, ""},
+		{"group may not list every repository", Request{Subject: platform, Provider: "github", Action: ActionList}, false, "no-secret-repos"},
+		{"numeric ID is not scoped to an org", Request{Subject: platform, Provider: "gitlab", Action: ActionRead, Repo: "42"}, false, "no-secret-repos"},
+		{"API key reads its repository", Request{Subject: ci, Provider: "github", Action: ActionRead, Org: "octo-org", Repo: "octo-org/hello-world"}, true, "ci-hello-world"},
+		{"API key is limited to its provider", Request{Subject: ci, Provider: "gitlab", Action: ActionRead, Org: "octo-org", Repo: "octo-org/hello-world"}, false, ""},
+		{"API key may not list the org", Request{Subject: ci, Provider: "github", Action: ActionList, Org: "octo-org"}, false, ""},
+		{"API key may not clone", Request{Subject: ci, Provider: "github", Action: ActionClone, Org: "octo-org", Repo: "octo-org/hello-world"}, false, "no-clones-for-ci"},
+		{"repo pattern does not cross a slash", Request{Subject: ci, Provider: "github", Action: ActionRead, Org: "octo-org", Repo: "octo-org/hello-world/extra"}, false, ""},
+		{"other callers get the default", Request{Subject: stranger, Provider: "github", Action: ActionRead, Org: "octo-org", Repo: "octo-org/hello-world"}, false, ""},
+		{"unauthenticated callers match no subject rule", Request{Provider: "github", Action: ActionRead, Org: "octo-org", Repo: "octo-org/hello-world"}, false, ""},
+	}
+	policy := testPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Evaluate(tt.request)
+			if decision.Allowed != tt.allowed || decision.Rule != tt.rule {
+				t.Errorf("got %+v, want allowed=%v by %q", decision, tt.allowed, tt.rule)
+			}
+		})
+	}
+}
+
+func TestPolicy_EvaluateDefaultAllow(t *testing.T) {
+	policy := &Policy{
+		Default: EffectAllow,
+		Rules:   []Rule{{Name: "clones-for-maintainers", Effect: EffectDeny, Actions: []string{ActionClone}, Subjects: []string{"*"}}},
+	}
+
+	tests := []struct {
+		name    string
+		request Request
+		allowed bool
+	}{
+		{"anonymous read", Request{Provider: "github", Action: ActionRead, Org: "octo-org", Repo: "octo-org/hello-world"}, true},
+		{"anonymous clone", Request{Provider: "github", Action: ActionClone, Org: "octo-org", Repo: "octo-org/hello-world"}, true},
+		{"authenticated clone", Request{Subject: Subject{ID: "api_key:ci"}, Provider: "github", Action: ActionClone}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if decision := policy.Evaluate(tt.request); decision.Allowed != tt.allowed {
+				t.Errorf("got %+v, want allowed=%v", decision, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errs    []string
+	}{
+		{"valid", `
+default: deny
+groups_claim: roles
+rules:
+  - name: platform
+    effect: allow
+    groups: [platform]
+    orgs: [octo-org]
+    actions: [read, list]
+`, nil},
+		{"empty file", "", nil},
+		{"invalid values", `
+default: maybe
+rules:
+  - effect: permit
+    actions: [write]
+    repos: ["octo-org/["]
+`, []string{`default must be "allow" or "deny"`, `rules[0].effect`, `unknown action "write"`, `invalid pattern "octo-org/["`}},
+		{"unknown key", "rules:\n  - effect: allow\n    repositories: [a/b]\n", []string{"repositories"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to write policy: %v", err)
+			}
+			policy, err := Load(path)
+			if len(tt.errs) == 0 {
+				if err != nil || policy == nil {
+					t.Fatalf("Load() returned error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.errs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
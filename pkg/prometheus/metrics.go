@@ -19,7 +19,7 @@ var (
 			Name: "gits_repository_fetches_total",
 			Help: "Total number of repository fetch attempts.",
 		},
-		[]string{"provider", "status"}, // provider (e.g., github, gitlab), status (e.g., success, failure)
+		[]string{"provider", "operation", "status"}, // provider (e.g., github, gitlab), operation (e.g., commits, all_repos), status (e.g., success, failure)
 	)
 
 	APICallDuration = promauto.NewHistogramVec(
@@ -30,6 +30,135 @@ var (
 		},
 		[]string{"api_type", "endpoint"},
 	)
+
+	ProviderRateLimitLimit = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gits_provider_rate_limit_limit",
+			Help: "Requests allowed per window by the provider rate limit, as last reported by the provider.",
+		},
+		[]string{"provider", "resource"}, // provider (e.g., github, gitlab), resource (e.g., core, search; default for GitLab)
+	)
+
+	ProviderRateLimitRemaining = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gits_provider_rate_limit_remaining",
+			Help: "Requests left in the current provider rate limit window.",
+		},
+		[]string{"provider", "resource"},
+	)
+
+	ProviderRateLimitReset = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gits_provider_rate_limit_reset_timestamp_seconds",
+			Help: "Unix time at which the provider rate limit window resets.",
+		},
+		[]string{"provider", "resource"},
+	)
+
+	ProviderRateLimitedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gits_provider_rate_limited_total",
+			Help: "Total number of provider requests that hit a rate limit.",
+		},
+		[]string{"provider", "outcome"}, // outcome: waited (retried after backing off) or rejected (returned as exhausted)
+	)
+
+	JobsActive = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gits_jobs_active",
+			Help: "Background jobs that are queued or running.",
+		},
+		[]string{"status"}, // status: queued or running
+	)
+
+	JobsFinishedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gits_jobs_finished_total",
+			Help: "Total number of background jobs that finished.",
+		},
+		[]string{"provider", "endpoint", "status"}, // status: succeeded, failed or canceled
+	)
+
+	ScheduledRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gits_scheduled_runs_total",
+			Help: "Total number of scheduled cache refresh runs.",
+		},
+		[]string{"schedule", "status"}, // schedule: name from the config; status: success or failure
+	)
+
+	ScheduledRunDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gits_scheduled_run_duration_seconds",
+			Help:    "Duration of scheduled cache refresh runs.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12), // 1s, 2s, 4s, ..., ~34m
+		},
+		[]string{"schedule"},
+	)
+
+	ScheduledRunLast = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gits_scheduled_run_last_timestamp_seconds",
+			Help: "Unix time at which the last scheduled run with the given outcome started.",
+		},
+		[]string{"schedule", "status"},
+	)
+
+	ScheduledRunNext = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gits_scheduled_run_next_timestamp_seconds",
+			Help: "Unix time of the next scheduled run.",
+		},
+		[]string{"schedule"},
+	)
+
+	WebhookEventsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gits_webhook_events_total",
+			Help: "Total number of provider webhook deliveries received.",
+		},
+		[]string{"provider", "event", "outcome"}, // outcome: applied, ignored or rejected
+	)
+
+	CacheStaleServedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gits_cache_stale_served_total",
+			Help: "Total number of expired cached responses served while they were refreshed in the background.",
+		},
+		[]string{"provider", "endpoint"},
+	)
+
+	CacheRevalidationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gits_cache_revalidations_total",
+			Help: "Total number of background refreshes of expired cached responses.",
+		},
+		[]string{"provider", "endpoint", "status"}, // status: success or failure
+	)
+
+	CachePurgedKeysTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gits_cache_purged_keys_total",
+			Help: "Total number of cache keys deleted through the purge endpoint.",
+		},
+		[]string{"provider"},
+	)
+
+	ProviderConditionalRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gits_provider_conditional_requests_total",
+			Help: "Total number of provider requests sent with a cached ETag or Last-Modified.",
+		},
+		[]string{"provider", "outcome"}, // outcome: not_modified or modified
+	)
+
+	APINotModifiedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gits_api_not_modified_total",
+			Help: "Total number of API requests answered with 304 Not Modified.",
+		},
+		[]string{"provider", "endpoint"},
+	)
 )
 
 // InitMetrics can be called to ensure metrics are registered.
@@ -0,0 +1,81 @@
+package report
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	texttemplate "text/template"
+	"time"
+)
+
+// Supported output formats for Render.
+const (
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+)
+
+// Formats lists every value accepted by Render, in the order shown in help texts.
+var Formats = []string{FormatHTML, FormatMarkdown}
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// funcs are the helpers shared by both templates.
+var funcs = map[string]any{
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	},
+	"percent": formatPercent,
+}
+
+// htmlFuncs adds the inline SVG charts, which only the HTML template draws.
+var htmlFuncs = map[string]any{
+	"commitsChart":   commitsChart,
+	"churnChart":     churnChart,
+	"authorsChart":   authorsChart,
+	"languagesChart": languagesChart,
+}
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("report.html.tmpl").
+			Funcs(funcs).Funcs(htmlFuncs).ParseFS(templateFiles, "templates/report.html.tmpl"))
+	markdownTemplate = texttemplate.Must(texttemplate.New("report.md.tmpl").
+				Funcs(funcs).Funcs(texttemplate.FuncMap{"md": escapeMarkdown}).ParseFS(templateFiles, "templates/report.md.tmpl"))
+)
+
+// Render writes report to out in the given format.
+func Render(out io.Writer, report *Report, format string) error {
+	var err error
+	switch format {
+	case FormatHTML:
+		err = htmlTemplate.Execute(out, report)
+	case FormatMarkdown:
+		err = markdownTemplate.Execute(out, report)
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render %s report: %w", format, err)
+	}
+	return nil
+}
+
+// escapeMarkdown keeps s from breaking a Markdown table cell.
+func escapeMarkdown(s string) string {
+	var out []rune
+	for _, r := range s {
+		switch r {
+		case '|', '\\', '*', '_', '`', '[', ']', '<', '>':
+			out = append(out, '\\', r)
+		case '\n', '\r':
+			out = append(out, ' ')
+		default:
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
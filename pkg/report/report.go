@@ -0,0 +1,108 @@
+// Package report builds a self-contained repository report from commits and language statistics
+// and renders it as a single HTML page with inline SVG charts, or as Markdown.
+package report
+
+import (
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+// DefaultTopAuthors is the number of authors listed under "Top authors".
+const DefaultTopAuthors = 10
+
+// PeriodStats is the commit activity and churn of one calendar month.
+type PeriodStats struct {
+	Period    string // Month in YYYY-MM form.
+	Commits   int    // Commits authored in the month.
+	Additions int    // Lines added by those commits.
+	Deletions int    // Lines deleted by those commits.
+}
+
+// Churn is the number of lines touched in the period.
+func (p PeriodStats) Churn() int {
+	return p.Additions + p.Deletions
+}
+
+// Report is everything a rendered report shows.
+type Report struct {
+	Repository   *common_types.Repository       // Repository the report is about.
+	GeneratedAt  time.Time                      // When the report was built.
+	Since        time.Time                      // Start of the commit range; zero when open.
+	Until        time.Time                      // End of the commit range; zero when open.
+	Totals       analytics.ChangeTotals         // Commits and changed lines over the range.
+	Months       []PeriodStats                  // Activity per month, oldest first, without gaps.
+	TopAuthors   []analytics.AuthorChangeTotals // The most active authors, most commits first.
+	Contributors []analytics.AuthorChangeTotals // Every author with a commit in the range, most commits first.
+	Languages    []*common_types.Language       // Languages by share of the code, largest first.
+}
+
+// Build aggregates commits and languages of repo into a Report.
+// Commits without an author date count towards the totals and authors but not towards Months.
+func Build(repo *common_types.Repository, commits []*common_types.Commit, languages []*common_types.Language, since, until, generatedAt time.Time) *Report {
+	report := &Report{
+		Repository:   repo,
+		GeneratedAt:  generatedAt,
+		Since:        since,
+		Until:        until,
+		Contributors: analytics.AggregateChangeTotals(commits),
+		Languages:    languages,
+	}
+	if report.Repository == nil {
+		report.Repository = &common_types.Repository{}
+	}
+
+	byMonth := map[string]*PeriodStats{}
+	var first, last time.Time
+	for _, commit := range commits {
+		if commit == nil {
+			continue
+		}
+		report.Totals.Commits++
+		report.Totals.Additions += commit.Stats.Additions
+		report.Totals.Deletions += commit.Stats.Deletions
+
+		if commit.Author.Date.IsZero() {
+			continue
+		}
+		month := monthStart(commit.Author.Date)
+		if first.IsZero() || month.Before(first) {
+			first = month
+		}
+		if month.After(last) {
+			last = month
+		}
+		key := month.Format("2006-01")
+		stats, ok := byMonth[key]
+		if !ok {
+			stats = &PeriodStats{Period: key}
+			byMonth[key] = stats
+		}
+		stats.Commits++
+		stats.Additions += commit.Stats.Additions
+		stats.Deletions += commit.Stats.Deletions
+	}
+	if !first.IsZero() {
+		for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+			key := month.Format("2006-01")
+			if stats, ok := byMonth[key]; ok {
+				report.Months = append(report.Months, *stats)
+			} else {
+				report.Months = append(report.Months, PeriodStats{Period: key})
+			}
+		}
+	}
+
+	report.TopAuthors = report.Contributors
+	if len(report.TopAuthors) > DefaultTopAuthors {
+		report.TopAuthors = report.TopAuthors[:DefaultTopAuthors]
+	}
+	return report
+}
+
+// monthStart returns midnight UTC on the first day of t's month.
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,123 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+func commitAt(name, email string, date time.Time, additions, deletions int) *common_types.Commit {
+	return &common_types.Commit{
+		Author: common_types.CommitAuthor{Name: name, Email: email, Date: date},
+		Stats:  common_types.CommitStats{Additions: additions, Deletions: deletions, Total: additions + deletions},
+	}
+}
+
+func testReport() *Report {
+	repo := &common_types.Repository{ID: 1, Owner: "octo", Name: "hello", HTMLURL: "https://github.com/octo/hello"}
+	commits := []*common_types.Commit{
+		commitAt("Jane", "jane@example.com", time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC), 10, 2),
+		commitAt("<John>", "john@example.com", time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC), 1, 1),
+		commitAt("Jane", "jane@example.com", time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC), 5, 0),
+		nil,
+	}
+	languages := []*common_types.Language{{Name: "Go", Bytes: 900, Share: 0.9}, {Name: "Shell", Bytes: 100, Share: 0.1}}
+	return Build(repo, commits, languages, time.Time{}, time.Time{}, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestBuild(t *testing.T) {
+	report := testReport()
+
+	if report.Totals.Commits != 3 || report.Totals.Additions != 16 || report.Totals.Deletions != 3 {
+		t.Errorf("unexpected totals: %+v", report.Totals)
+	}
+	expectedMonths := []PeriodStats{
+		{Period: "2024-01", Commits: 1, Additions: 10, Deletions: 2},
+		{Period: "2024-02"},
+		{Period: "2024-03", Commits: 2, Additions: 6, Deletions: 1},
+	}
+	if len(report.Months) != len(expectedMonths) {
+		t.Fatalf("expected %d months, got %+v", len(expectedMonths), report.Months)
+	}
+	for i, month := range expectedMonths {
+		if report.Months[i] != month {
+			t.Errorf("month %d: expected %+v, got %+v", i, month, report.Months[i])
+		}
+	}
+	if len(report.Contributors) != 2 || report.Contributors[0].Name != "Jane" || report.Contributors[0].Commits != 2 {
+		t.Errorf("unexpected contributors: %+v", report.Contributors)
+	}
+}
+
+func TestBuild_LimitsTopAuthors(t *testing.T) {
+	var commits []*common_types.Commit
+	for i := 0; i < DefaultTopAuthors+5; i++ {
+		commits = append(commits, commitAt("Author", string(rune('a'+i))+"@example.com", time.Now(), 1, 0))
+	}
+
+	report := Build(nil, commits, nil, time.Time{}, time.Time{}, time.Now())
+
+	if len(report.TopAuthors) != DefaultTopAuthors || len(report.Contributors) != DefaultTopAuthors+5 {
+		t.Errorf("expected %d top authors of %d contributors, got %d of %d",
+			DefaultTopAuthors, DefaultTopAuthors+5, len(report.TopAuthors), len(report.Contributors))
+	}
+	if report.Repository == nil {
+		t.Error("expected an empty repository instead of nil")
+	}
+}
+
+func TestRender_HTML(t *testing.T) {
+	var out bytes.Buffer
+	if err := Render(&out, testReport(), FormatHTML); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+
+	html := out.String()
+	for _, want := range []string{
+		`<a href="https://github.com/octo/hello">octo/hello</a>`,
+		`<svg xmlns="http://www.w3.org/2000/svg"`,
+		`Commits per month`,
+		`Share of code by language`,
+		`90.0%`,
+		`&lt;John&gt;`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected HTML report to contain %q", want)
+		}
+	}
+	if strings.Contains(html, "<John>") {
+		t.Error("expected author names to be escaped")
+	}
+	if strings.Contains(html, "<script") || strings.Contains(html, "<link") {
+		t.Error("expected a self-contained report without external resources")
+	}
+}
+
+func TestRender_Markdown(t *testing.T) {
+	var out bytes.Buffer
+	if err := Render(&out, testReport(), FormatMarkdown); err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+
+	markdown := out.String()
+	for _, want := range []string{
+		"# octo/hello",
+		"| 2024-02 | 0 | +0 | -0 |",
+		"| Jane | 2 | +15 | -2 |",
+		`| \<John\> | 1 | +1 | -1 |`,
+		"| Go | 90.0% |",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("expected Markdown report to contain %q, got:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestRender_UnsupportedFormat(t *testing.T) {
+	if err := Render(&bytes.Buffer{}, testReport(), "pdf"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
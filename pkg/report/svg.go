@@ -0,0 +1,182 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+)
+
+// Chart geometry, in SVG user units.
+const (
+	chartWidth    = 720
+	chartHeight   = 220
+	chartPadding  = 32
+	barRowHeight  = 22
+	barLabelWidth = 200
+)
+
+// Chart colours; additions and deletions follow the usual diff colours.
+const (
+	colorPrimary   = "#4c78a8"
+	colorAdditions = "#2ca02c"
+	colorDeletions = "#d62728"
+)
+
+// commitsChart draws the commits of each month as a column chart.
+func commitsChart(months []PeriodStats) template.HTML {
+	labels := make([]string, len(months))
+	series := make([]int, len(months))
+	for i, month := range months {
+		labels[i] = month.Period
+		series[i] = month.Commits
+	}
+	return columnChart("Commits per month", labels, []columnSeries{{Name: "Commits", Color: colorPrimary, Values: series}})
+}
+
+// churnChart draws the additions and deletions of each month as stacked columns.
+func churnChart(months []PeriodStats) template.HTML {
+	labels := make([]string, len(months))
+	additions := make([]int, len(months))
+	deletions := make([]int, len(months))
+	for i, month := range months {
+		labels[i] = month.Period
+		additions[i] = month.Additions
+		deletions[i] = month.Deletions
+	}
+	return columnChart("Lines changed per month", labels, []columnSeries{
+		{Name: "Additions", Color: colorAdditions, Values: additions},
+		{Name: "Deletions", Color: colorDeletions, Values: deletions},
+	})
+}
+
+// authorsChart draws the commits of each top author as horizontal bars.
+func authorsChart(report *Report) template.HTML {
+	labels := make([]string, len(report.TopAuthors))
+	values := make([]float64, len(report.TopAuthors))
+	for i, author := range report.TopAuthors {
+		labels[i] = author.Name
+		values[i] = float64(author.Commits)
+		if labels[i] == "" {
+			labels[i] = author.Email
+		}
+	}
+	return barChart("Commits by author", labels, values, func(v float64) string { return fmt.Sprintf("%d", int(v)) })
+}
+
+// languagesChart draws the share of each language as horizontal bars.
+func languagesChart(languages []*common_types.Language) template.HTML {
+	labels := make([]string, len(languages))
+	values := make([]float64, len(languages))
+	for i, language := range languages {
+		labels[i] = language.Name
+		values[i] = language.Share
+	}
+	return barChart("Share of code by language", labels, values, formatPercent)
+}
+
+// columnSeries is one stacked layer of a column chart.
+type columnSeries struct {
+	Name   string
+	Color  string
+	Values []int
+}
+
+// columnChart renders one column per label, stacking the series bottom to top.
+// Only every n-th label is printed so that long ranges stay readable.
+func columnChart(title string, labels []string, series []columnSeries) template.HTML {
+	maxTotal := 0
+	for i := range labels {
+		total := 0
+		for _, s := range series {
+			total += s.Values[i]
+		}
+		maxTotal = max(maxTotal, total)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" role="img" aria-label="%s">`, chartWidth, chartHeight, escape(title))
+	fmt.Fprintf(&b, `<title>%s</title>`, escape(title))
+	if len(labels) == 0 || maxTotal == 0 {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">No data</text></svg>`, chartWidth/2, chartHeight/2)
+		return template.HTML(b.String())
+	}
+
+	plotHeight := float64(chartHeight - 2*chartPadding)
+	slot := float64(chartWidth-2*chartPadding) / float64(len(labels))
+	barWidth := slot * 0.8
+	labelEvery := (len(labels) + 11) / 12
+	for i, label := range labels {
+		x := float64(chartPadding) + float64(i)*slot + (slot-barWidth)/2
+		y := float64(chartHeight - chartPadding)
+		for _, s := range series {
+			if s.Values[i] == 0 {
+				continue
+			}
+			height := plotHeight * float64(s.Values[i]) / float64(maxTotal)
+			y -= height
+			fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s %s: %d</title></rect>`,
+				x, y, barWidth, height, s.Color, escape(label), escape(s.Name), s.Values[i])
+		}
+		if i%labelEvery == 0 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" font-size="10" text-anchor="middle">%s</text>`,
+				x+barWidth/2, chartHeight-chartPadding+14, escape(label))
+		}
+	}
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="10">%d</text>`, chartPadding, chartPadding-6, maxTotal)
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#999"/>`,
+		chartPadding, chartHeight-chartPadding, chartWidth-chartPadding, chartHeight-chartPadding)
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// barChart renders one horizontal bar per label, scaled to the largest value.
+func barChart(title string, labels []string, values []float64, format func(float64) string) template.HTML {
+	maxValue := 0.0
+	for _, v := range values {
+		maxValue = max(maxValue, v)
+	}
+	height := max(len(labels), 1)*barRowHeight + barRowHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" role="img" aria-label="%s">`, chartWidth, height, escape(title))
+	fmt.Fprintf(&b, `<title>%s</title>`, escape(title))
+	if len(labels) == 0 || maxValue == 0 {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">No data</text></svg>`, chartWidth/2, barRowHeight)
+		return template.HTML(b.String())
+	}
+
+	barSpace := float64(chartWidth - barLabelWidth - 2*chartPadding - 40)
+	for i, label := range labels {
+		y := barRowHeight/2 + i*barRowHeight
+		width := barSpace * values[i] / maxValue
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="12" text-anchor="end">%s</text>`,
+			chartPadding+barLabelWidth-8, y+barRowHeight/2+1, escape(truncate(label, 30)))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"><title>%s: %s</title></rect>`,
+			chartPadding+barLabelWidth, y+3, width, barRowHeight-6, colorPrimary, escape(label), escape(format(values[i])))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" font-size="11">%s</text>`,
+			float64(chartPadding+barLabelWidth)+width+4, y+barRowHeight/2+1, escape(format(values[i])))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// escape makes s safe to embed as SVG text or attribute value.
+func escape(s string) string {
+	return template.HTMLEscapeString(s)
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// formatPercent renders a 0-1 fraction as a percentage with one decimal.
+func formatPercent(share float64) string {
+	return fmt.Sprintf("%.1f%%", share*100)
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/google/go-github/v56/github"
+)
+
+// DefaultCommitDetailWorkers is how many commit or branch details GitHubRepo and Gitlab fetch at
+// once when DetailWorkers is not set.
+const DefaultCommitDetailWorkers = 8
+
+// commitStatsCacheTTL is how long fetched commit stats are cached. The stats of a commit never
+// change, so they outlive the API response cache by far.
+const commitStatsCacheTTL = 7 * 24 * time.Hour
+
+// commitStatsCacheKey is the cache key of the stats of commit sha in ownerLogin/repoName.
+func commitStatsCacheKey(ownerLogin, repoName, sha string) string {
+	return "github:commit-stats:" + strings.ToLower(ownerLogin+"/"+repoName) + ":" + sha
+}
+
+// taggedCommitCacheKey is the cache key of the author and date of commit sha in
+// ownerLogin/repoName, as listed with its tags.
+func taggedCommitCacheKey(ownerLogin, repoName, sha string) string {
+	return "github:tagged-commit:" + strings.ToLower(ownerLogin+"/"+repoName) + ":" + sha
+}
+
+// taggedCommit is the part of a tagged commit ListTags reports, as cached in StatsCache.
+type taggedCommit struct {
+	Author string    `json:"author"`
+	Date   time.Time `json:"date"`
+}
+
+// toCommonCommits converts githubCommits in order, fetching the stats of each commit with up to
+// DetailWorkers concurrent GetCommit calls unless noStats is set. Stats found in StatsCache are
+// not fetched again, and fetched stats are added to it. lastResp is the response of the listing,
+// whose remaining quota has to cover every fetch. Nil commits are skipped.
+func (ghRepo *GitHubRepo) toCommonCommits(ctx context.Context, githubCommits []*github.RepositoryCommit, lastResp *github.Response, ownerLogin, repositoryName string, noStats bool) ([]*common_types.Commit, error) {
+	stats := make([]*github.CommitStats, len(githubCommits))
+	var pending []int
+	for i, githubCommit := range githubCommits {
+		if githubCommit == nil || noStats {
+			continue
+		}
+		if stats[i] = ghRepo.cachedCommitStats(ownerLogin, repositoryName, githubCommit.GetSHA()); stats[i] == nil {
+			pending = append(pending, i)
+		}
+	}
+
+	// Every commit not cached yet costs one GetCommit call for its stats.
+	if err := checkRateBudget(lastResp, len(pending)); err != nil {
+		return nil, fmt.Errorf("not enough rate limit left to fetch stats of %d commits for %s/%s: %w", len(pending), ownerLogin, repositoryName, err)
+	}
+	if err := ghRepo.fetchCommitStatsConcurrently(ctx, githubCommits, pending, stats, ownerLogin, repositoryName); err != nil {
+		return nil, err
+	}
+
+	commonCommits := make([]*common_types.Commit, 0, len(githubCommits))
+	for i, githubCommit := range githubCommits {
+		if githubCommit != nil {
+			commonCommits = append(commonCommits, commitWithStats(githubCommit, stats[i]))
+		}
+	}
+	return commonCommits, nil
+}
+
+// fetchCommitStatsConcurrently fills stats[i] for every index in pending. A commit whose details
+// cannot be fetched keeps nil stats, except on a rate limit error, which stops all workers and is
+// returned: zero stats for the rest of the listing would be silently wrong.
+func (ghRepo *GitHubRepo) fetchCommitStatsConcurrently(ctx context.Context, githubCommits []*github.RepositoryCommit, pending []int, stats []*github.CommitStats, ownerLogin, repositoryName string) error {
+	return ghRepo.fetchDetailsConcurrently(ctx, pending, func(ctx context.Context, i int) error {
+		sha := githubCommits[i].GetSHA()
+		commitStats, err := fetchCommitStats(ctx, ghRepo.Client, ownerLogin, repositoryName, sha)
+		if isRateLimitError(err) {
+			return err
+		}
+		if err != nil {
+			return nil // Stats stay zero, as for a single commit.
+		}
+		stats[i] = commitStats
+		ghRepo.cacheCommitStats(ownerLogin, repositoryName, sha, commitStats)
+		return nil
+	})
+}
+
+// fetchDetailsConcurrently calls fetch for every index in pending with up to DetailWorkers calls
+// at once. The first error stops all workers and is returned.
+func (ghRepo *GitHubRepo) fetchDetailsConcurrently(ctx context.Context, pending []int, fetch func(ctx context.Context, i int) error) error {
+	return fetchConcurrently(ctx, ghRepo.DetailWorkers, pending, fetch)
+}
+
+// fetchConcurrently calls fetch for every index in pending with up to workers calls at once,
+// DefaultCommitDetailWorkers if workers is not positive. The first error stops all workers and
+// is returned.
+func fetchConcurrently(ctx context.Context, workers int, pending []int, fetch func(ctx context.Context, i int) error) error {
+	if workers <= 0 {
+		workers = DefaultCommitDetailWorkers
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var firstErr error
+	var errOnce sync.Once
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fetch(ctx, i); err != nil {
+					errOnce.Do(func() { firstErr = err })
+					cancel()
+				}
+			}
+		}()
+	}
+	for _, i := range pending {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// cachedCommitStats returns the cached stats of a commit, or nil without StatsCache or on a miss.
+func (ghRepo *GitHubRepo) cachedCommitStats(ownerLogin, repoName, sha string) *github.CommitStats {
+	if ghRepo.StatsCache == nil || sha == "" {
+		return nil
+	}
+	data, err := ghRepo.StatsCache.Get(commitStatsCacheKey(ownerLogin, repoName, sha))
+	if err != nil || data == nil {
+		return nil
+	}
+	var commitStats github.CommitStats
+	if err := json.Unmarshal(data, &commitStats); err != nil {
+		return nil
+	}
+	return &commitStats
+}
+
+// cacheCommitStats stores the stats of a commit in StatsCache, if set. Failures only cost a
+// refetch later, so they are ignored.
+func (ghRepo *GitHubRepo) cacheCommitStats(ownerLogin, repoName, sha string, commitStats *github.CommitStats) {
+	if ghRepo.StatsCache == nil || sha == "" || commitStats == nil {
+		return
+	}
+	data, err := json.Marshal(commitStats)
+	if err != nil {
+		return
+	}
+	_ = ghRepo.StatsCache.Set(commitStatsCacheKey(ownerLogin, repoName, sha), data, commitStatsCacheTTL)
+}
+
+// cachedTaggedCommit returns the cached author and date of a commit, or nil without StatsCache or
+// on a miss.
+func (ghRepo *GitHubRepo) cachedTaggedCommit(ownerLogin, repoName, sha string) *taggedCommit {
+	if ghRepo.StatsCache == nil || sha == "" {
+		return nil
+	}
+	data, err := ghRepo.StatsCache.Get(taggedCommitCacheKey(ownerLogin, repoName, sha))
+	if err != nil || data == nil {
+		return nil
+	}
+	var commit taggedCommit
+	if err := json.Unmarshal(data, &commit); err != nil {
+		return nil
+	}
+	return &commit
+}
+
+// cacheTaggedCommit stores the author and date of a commit in StatsCache, if set. Like stats,
+// they never change.
+func (ghRepo *GitHubRepo) cacheTaggedCommit(ownerLogin, repoName, sha string, commit *taggedCommit) {
+	if ghRepo.StatsCache == nil || sha == "" {
+		return
+	}
+	data, err := json.Marshal(commit)
+	if err != nil {
+		return
+	}
+	_ = ghRepo.StatsCache.Set(taggedCommitCacheKey(ownerLogin, repoName, sha), data, commitStatsCacheTTL)
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// mapStatsCache is an in-memory storage.InMemoryDB.
+type mapStatsCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (c *mapStatsCache) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key], nil
+}
+
+func (c *mapStatsCache) Set(key string, value interface{}, duration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = value.([]byte)
+	return nil
+}
+
+func (c *mapStatsCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return nil
+}
+
+// commitDetailsMux serves /repos/o/r and a listing of count commits c0..c<count-1>, newest
+// first. Every detail request sleeps briefly so that fetches overlap, and is counted in fetched.
+func commitDetailsMux(count int, fetched *int32, inFlight, maxInFlight *int32) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"r","owner":{"login":"o"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/commits", func(w http.ResponseWriter, r *http.Request) {
+		entries := make([]string, count)
+		for i := range entries {
+			entries[i] = fmt.Sprintf(`{"sha":"c%d"}`, i)
+		}
+		fmt.Fprint(w, "["+strings.Join(entries, ",")+"]")
+	})
+	mux.HandleFunc("/repos/o/r/commits/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(fetched, 1)
+		current := atomic.AddInt32(inFlight, 1)
+		defer atomic.AddInt32(inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		var n int
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/repos/o/r/commits/c"), "%d", &n)
+		fmt.Fprintf(w, `{"sha":"c%d","stats":{"additions":%d,"deletions":1,"total":%d}}`, n, n, n+1)
+	})
+	return mux
+}
+
+func TestGitHubRepo_GetProjectCommits_FetchesDetailsConcurrentlyInOrder(t *testing.T) {
+	var fetched, inFlight, maxInFlight int32
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, commitDetailsMux(12, &fetched, &inFlight, &maxInFlight)))
+	ghRepo.DetailWorkers = 3
+
+	commits, err := ghRepo.GetProjectCommits("o/r", nil)
+	if err != nil {
+		t.Fatalf("GetProjectCommits() returned error: %v", err)
+	}
+	if len(commits) != 12 {
+		t.Fatalf("GetProjectCommits() returned %d commits, want 12", len(commits))
+	}
+	for i, commit := range commits {
+		if commit.SHA != fmt.Sprintf("c%d", i) || commit.Stats.Additions != i || commit.Stats.Total != i+1 {
+			t.Errorf("commit %d = %s with stats %+v, want c%d with its own stats", i, commit.SHA, commit.Stats, i)
+		}
+	}
+	if maxInFlight < 2 || maxInFlight > 3 {
+		t.Errorf("expected between 2 and 3 concurrent detail requests, got %d", maxInFlight)
+	}
+}
+
+func TestGitHubRepo_GetProjectCommits_SkipsCachedStats(t *testing.T) {
+	var fetched, inFlight, maxInFlight int32
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, commitDetailsMux(5, &fetched, &inFlight, &maxInFlight)))
+	ghRepo.StatsCache = &mapStatsCache{entries: map[string][]byte{
+		commitStatsCacheKey("o", "r", "c2"): []byte(`{"additions":20,"deletions":2,"total":22}`),
+	}}
+
+	commits, err := ghRepo.GetProjectCommits("o/r", nil)
+	if err != nil {
+		t.Fatalf("GetProjectCommits() returned error: %v", err)
+	}
+	if fetched != 4 || commits[2].Stats.Additions != 20 {
+		t.Errorf("expected 4 fetches and the cached stats for c2, got %d fetches and %+v", fetched, commits[2].Stats)
+	}
+
+	if _, err := ghRepo.GetProjectCommits("o/r", nil); err != nil {
+		t.Fatalf("second GetProjectCommits() returned error: %v", err)
+	}
+	if fetched != 4 {
+		t.Errorf("expected no detail fetches once every SHA is cached, got %d in total", fetched)
+	}
+}
+
+func TestGitHubRepo_GetProjectCommits_NoStatsSkipsDetails(t *testing.T) {
+	var fetched, inFlight, maxInFlight int32
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, commitDetailsMux(5, &fetched, &inFlight, &maxInFlight)))
+
+	commits, err := ghRepo.GetProjectCommits("o/r", &interfaces.CommitListOptions{NoStats: true})
+	if err != nil {
+		t.Fatalf("GetProjectCommits() returned error: %v", err)
+	}
+	if len(commits) != 5 || fetched != 0 {
+		t.Errorf("expected 5 commits without detail fetches, got %d commits and %d fetches", len(commits), fetched)
+	}
+}
+
+func TestGitHubRepo_GetProjectCommits_StopsOnRateLimit(t *testing.T) {
+	var fetched int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"r","owner":{"login":"o"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/commits", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"sha":"c0"},{"sha":"c1"},{"sha":"c2"},{"sha":"c3"}]`)
+	})
+	mux.HandleFunc("/repos/o/r/commits/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetched, 1)
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"API rate limit exceeded"}`)
+	})
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, mux))
+	ghRepo.DetailWorkers = 1
+	ghRepo.StatsCache = &mapStatsCache{entries: map[string][]byte{}}
+
+	_, err := ghRepo.GetProjectCommits("o/r", nil)
+	if !isRateLimitError(err) {
+		t.Fatalf("expected a rate limit error, got %v", err)
+	}
+	if fetched != 1 {
+		t.Errorf("expected the workers to stop after the first rate limited fetch, got %d fetches", fetched)
+	}
+}
+
+func TestGitHubRepo_ListTags_FetchesTaggedCommitsConcurrentlyOnce(t *testing.T) {
+	var fetched, inFlight, maxInFlight int32
+	mux := commitDetailsMux(0, &fetched, &inFlight, &maxInFlight)
+	mux.HandleFunc("/repos/o/r/tags", func(w http.ResponseWriter, r *http.Request) {
+		entries := make([]string, 6)
+		for i := range entries {
+			entries[i] = fmt.Sprintf(`{"name":"v%d","commit":{"sha":"c%d"}}`, i, i)
+		}
+		fmt.Fprint(w, "["+strings.Join(entries, ",")+"]")
+	})
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, mux))
+	ghRepo.DetailWorkers = 3
+	ghRepo.StatsCache = &mapStatsCache{entries: map[string][]byte{}}
+
+	tags, err := ghRepo.ListTags("o/r")
+	if err != nil {
+		t.Fatalf("ListTags() returned error: %v", err)
+	}
+	if len(tags) != 6 || tags[4].Name != "v4" || tags[4].CommitSHA != "c4" {
+		t.Fatalf("expected the tags in listing order, got %+v", tags)
+	}
+	if fetched != 6 || maxInFlight < 2 || maxInFlight > 3 {
+		t.Errorf("expected 6 fetches, 2 to 3 at once, got %d with %d at once", fetched, maxInFlight)
+	}
+
+	if _, err := ghRepo.ListTags("o/r"); err != nil {
+		t.Fatalf("second ListTags() returned error: %v", err)
+	}
+	if fetched != 6 {
+		t.Errorf("expected no fetches once every tagged commit is cached, got %d in total", fetched)
+	}
+}
+
+func TestGitHubRepo_ListBranches_InspectsBranchesConcurrentlyInOrder(t *testing.T) {
+	var fetched, inFlight, maxInFlight int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"r","owner":{"login":"o"},"default_branch":"main"}`)
+	})
+	mux.HandleFunc("/repos/o/r/branches", func(w http.ResponseWriter, r *http.Request) {
+		entries := []string{`{"name":"main"}`}
+		for i := 1; i <= 5; i++ {
+			entries = append(entries, fmt.Sprintf(`{"name":"b%d"}`, i))
+		}
+		fmt.Fprint(w, "["+strings.Join(entries, ",")+"]")
+	})
+	mux.HandleFunc("/repos/o/r/branches/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetched, 1)
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintf(w, `{"name":%q,"commit":{"sha":"s"}}`, strings.TrimPrefix(r.URL.Path, "/repos/o/r/branches/"))
+	})
+	mux.HandleFunc("/repos/o/r/compare/", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/repos/o/r/compare/main...b"), "%d", &n)
+		fmt.Fprintf(w, `{"ahead_by":%d,"behind_by":%d}`, n-1, 2*n)
+	})
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, mux))
+	ghRepo.DetailWorkers = 3
+
+	branches, err := ghRepo.ListBranches("o/r")
+	if err != nil {
+		t.Fatalf("ListBranches() returned error: %v", err)
+	}
+	if len(branches) != 6 || !branches[0].Default || branches[0].Name != "main" {
+		t.Fatalf("expected the default branch first, got %+v", branches)
+	}
+	for i, branch := range branches[1:] {
+		n := i + 1
+		if branch.Name != fmt.Sprintf("b%d", n) || branch.Ahead != n-1 || branch.Behind != 2*n || branch.Merged != (n == 1) {
+			t.Errorf("branch %d: unexpected %+v", n, branch)
+		}
+	}
+	if fetched != 6 || maxInFlight < 2 || maxInFlight > 3 {
+		t.Errorf("expected 6 branch fetches, 2 to 3 at once, got %d with %d at once", fetched, maxInFlight)
+	}
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	appMetrics "github.com/ahmetk3436/git-stats-golang/pkg/prometheus"
+)
+
+// DefaultETagCacheTTL is how long an ETagCache keeps a provider response for revalidation.
+const DefaultETagCacheTTL = 24 * time.Hour
+
+// maxETagBodyBytes bounds the responses an ETagCache keeps; larger ones are passed through.
+const maxETagBodyBytes = 8 << 20
+
+// ETagCache keeps provider responses together with their ETag and Last-Modified, and sends
+// repeated GET requests conditionally. The provider answers unchanged data with 304 Not Modified,
+// which GitHub does not count against the rate limit, and the kept response is returned instead.
+// Responses are kept per credentials, so callers never see each other's data. It is safe for
+// concurrent use. Its Transport must sit below the provider client and above the rate limits.
+type ETagCache struct {
+	Provider string             // "github" or "gitlab".
+	Cache    storage.InMemoryDB // Where responses are kept.
+	TTL      time.Duration      // How long a response is kept for revalidation.
+}
+
+// NewETagCache returns an ETagCache for provider keeping responses in cache for DefaultETagCacheTTL.
+func NewETagCache(provider string, cache storage.InMemoryDB) *ETagCache {
+	return &ETagCache{Provider: provider, Cache: cache, TTL: DefaultETagCacheTTL}
+}
+
+// etagEntry is a provider response kept by an ETagCache.
+type etagEntry struct {
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"lastModified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+}
+
+// Transport returns an http.RoundTripper that sends requests through base, conditionally when c
+// keeps a response for them.
+func (c *ETagCache) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &etagTransport{cache: c, base: base}
+}
+
+// key returns the cache key of the response to req: the URL, qualified by the credentials and
+// media type the response depends on.
+func (c *ETagCache) key(req *http.Request) string {
+	variant := sha256.Sum256([]byte(req.Header.Get("Authorization") + "\n" + req.Header.Get("PRIVATE-TOKEN") + "\n" + req.Header.Get("Accept")))
+	return fmt.Sprintf("%s:etag:%s:%s", c.Provider, hex.EncodeToString(variant[:8]), req.URL.String())
+}
+
+// etagTransport is the http.RoundTripper of ETagCache.Transport.
+type etagTransport struct {
+	cache *ETagCache
+	base  http.RoundTripper
+}
+
+func (rt *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests that are conditional or partial already are the caller's business.
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" || req.Header.Get("Range") != "" {
+		return rt.base.RoundTrip(req)
+	}
+	c := rt.cache
+	key := c.key(req)
+	entry := c.load(key)
+	if entry != nil {
+		req = req.Clone(req.Context()) // RoundTrippers must not modify the request.
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		} else {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := rt.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		outcome := "modified"
+		if resp.StatusCode == http.StatusNotModified {
+			outcome = "not_modified"
+		}
+		appMetrics.ProviderConditionalRequestsTotal.WithLabelValues(c.Provider, outcome).Inc()
+	}
+	if entry != nil && resp.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return entry.response(req, resp), nil
+	}
+	if resp.StatusCode != http.StatusOK || (resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") ||
+		strings.Contains(resp.Header.Get("Cache-Control"), "no-store") || resp.ContentLength > maxETagBodyBytes {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxETagBodyBytes+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxETagBodyBytes {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	c.store(key, &etagEntry{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified"), Header: resp.Header.Clone(), Body: body})
+	return resp, nil
+}
+
+// load returns the response kept under key, or nil if there is none.
+func (c *ETagCache) load(key string) *etagEntry {
+	data, err := c.Cache.Get(key)
+	if err != nil || data == nil {
+		return nil
+	}
+	var entry etagEntry
+	if err := json.Unmarshal(data, &entry); err != nil || (entry.ETag == "" && entry.LastModified == "") {
+		return nil
+	}
+	return &entry
+}
+
+// store keeps entry under key. Failures only cost the next request its condition.
+func (c *ETagCache) store(key string, entry *etagEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	c.Cache.Set(key, data, c.TTL)
+}
+
+// response returns the kept response as the answer to req, with the headers of notModified, such
+// as the current rate limit, taking precedence over the kept ones.
+func (e *etagEntry) response(req *http.Request, notModified *http.Response) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for name, values := range notModified.Header {
+		header[name] = values
+	}
+	header.Del("Content-Encoding")
+	header.Set("Content-Length", strconv.Itoa(len(e.Body)))
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v56/github"
+)
+
+func TestETagCache_RevalidatesUnchangedResponses(t *testing.T) {
+	var mu sync.Mutex
+	name, etag := "hello-world", `"v1"`
+	var conditions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		conditions = append(conditions, r.Header.Get("If-None-Match"))
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "` + name + `"}`))
+	}))
+	defer server.Close()
+	etags := NewETagCache("github", &mapStatsCache{entries: map[string][]byte{}})
+	newClient := func(token string) *github.Client {
+		client := github.NewClient(&http.Client{Transport: etags.Transport(nil)}).WithAuthToken(token)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		return client
+	}
+	getName := func(client *github.Client) string {
+		t.Helper()
+		repo, _, err := client.Repositories.Get(context.Background(), "octo-org", "hello-world")
+		if err != nil {
+			t.Fatalf("expected the repository, got %v", err)
+		}
+		return repo.GetName()
+	}
+
+	client := newClient("server-token")
+	if getName(client) != "hello-world" || getName(client) != "hello-world" {
+		t.Fatal("expected the repository both times")
+	}
+	if getName(newClient("caller-token")) != "hello-world" {
+		t.Fatal("expected the repository for another token")
+	}
+	mu.Lock()
+	name, etag = "renamed", `"v2"`
+	mu.Unlock()
+	if got := getName(client); got != "renamed" {
+		t.Errorf("expected the changed repository, got %s", got)
+	}
+	if got := getName(client); got != "renamed" {
+		t.Errorf("expected the changed repository to be kept, got %s", got)
+	}
+
+	want := []string{"", `"v1"`, "", `"v1"`, `"v2"`}
+	if len(conditions) != len(want) {
+		t.Fatalf("expected conditions %q, got %q", want, conditions)
+	}
+	for i := range want {
+		if conditions[i] != want[i] {
+			t.Errorf("request %d: expected If-None-Match %q, got %q", i, want[i], conditions[i])
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	"github.com/google/go-github/v56/github"
+)
+
+// Backends GitHubRepo.GetProjectCommits can list commits with.
+const (
+	CommitsBackendREST    = "rest"    // One ListCommits page, then one GetCommit call per commit for its stats.
+	CommitsBackendGraphQL = "graphql" // The history connection, which carries the stats of up to 100 commits per query.
+)
+
+// graphQLHistoryQuery lists the history of a ref together with the stats REST needs a GetCommit
+// call per commit for.
+const graphQLHistoryQuery = `query($owner: String!, $name: String!, $ref: String!, $first: Int!, $after: String, $since: GitTimestamp, $until: GitTimestamp, $path: String, $author: CommitAuthor) {
+  repository(owner: $owner, name: $name) {
+    object(expression: $ref) {
+      ... on Commit {
+        history(first: $first, after: $after, since: $since, until: $until, path: $path, author: $author) {
+          pageInfo { hasNextPage endCursor }
+          nodes {
+            oid
+            message
+            url
+            additions
+            deletions
+            author { name email date user { login } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// graphQLUserIDQuery resolves a login to the node ID the history author filter takes.
+const graphQLUserIDQuery = `query($login: String!) { user(login: $login) { id } }`
+
+// GraphQLError is an error the GitHub GraphQL API reports next to a successful HTTP status.
+type GraphQLError struct {
+	Type    string // e.g. "NOT_FOUND", "FORBIDDEN" or "RATE_LIMITED"; empty if the API gave none.
+	Message string
+}
+
+func (e *GraphQLError) Error() string {
+	if e.Type == "" {
+		return "github graphql: " + e.Message
+	}
+	return fmt.Sprintf("github graphql: %s: %s", e.Type, e.Message)
+}
+
+// graphQLRequest is the body of a GraphQL call.
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// graphQLCommitNode is a commit of the history connection.
+type graphQLCommitNode struct {
+	OID       string `json:"oid"`
+	Message   string `json:"message"`
+	URL       string `json:"url"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Author    *struct {
+		Name  string     `json:"name"`
+		Email string     `json:"email"`
+		Date  *time.Time `json:"date"`
+		User  *struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"author"`
+}
+
+// graphQLHistoryData is the data of graphQLHistoryQuery.
+type graphQLHistoryData struct {
+	Repository *struct {
+		Object *struct {
+			History *struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []graphQLCommitNode `json:"nodes"`
+			} `json:"history"`
+		} `json:"object"`
+	} `json:"repository"`
+}
+
+// graphQLCommits lists the commits of ownerLogin/repositoryName like the REST path of
+// GetProjectCommits, from options.SHA or else defaultBranch, with one query per 100 commits.
+// Pages before options.Page are walked by cursor, as GraphQL has no page numbers.
+func (ghRepo *GitHubRepo) graphQLCommits(ctx context.Context, ownerLogin, repositoryName, defaultBranch string, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+	variables := map[string]interface{}{"owner": ownerLogin, "name": repositoryName, "ref": "HEAD", "first": 100}
+	if defaultBranch != "" {
+		variables["ref"] = defaultBranch
+	}
+	page := 1
+	if options != nil {
+		if options.SHA != "" {
+			variables["ref"] = options.SHA
+		}
+		if options.Path != "" {
+			variables["path"] = options.Path
+		}
+		if !options.Since.IsZero() {
+			variables["since"] = options.Since.UTC().Format(time.RFC3339)
+		}
+		if !options.Until.IsZero() {
+			variables["until"] = options.Until.UTC().Format(time.RFC3339)
+		}
+		if options.PerPage > 0 && options.PerPage < 100 {
+			variables["first"] = options.PerPage
+		}
+		if options.Page > 1 && !options.WalksAllPages() {
+			page = options.Page
+		}
+		if options.Author != "" {
+			author, found, err := ghRepo.graphQLAuthorFilter(ctx, options.Author)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				return []*common_types.Commit{}, nil // REST lists no commits for an unknown author either.
+			}
+			variables["author"] = author
+		}
+	}
+
+	commonCommits := []*common_types.Commit{}
+	for current := 1; ; current++ {
+		var data graphQLHistoryData
+		if err := ghRepo.graphQL(ctx, graphQLHistoryQuery, variables, &data); err != nil {
+			return nil, fmt.Errorf("failed to list github commits for %s/%s: %w", ownerLogin, repositoryName, err)
+		}
+		if data.Repository == nil || data.Repository.Object == nil || data.Repository.Object.History == nil {
+			return nil, fmt.Errorf("failed to list github commits for %s/%s: %w", ownerLogin, repositoryName,
+				&GraphQLError{Type: "NOT_FOUND", Message: fmt.Sprintf("ref %v is not a commit", variables["ref"])})
+		}
+		history := data.Repository.Object.History
+		if current >= page {
+			for _, node := range history.Nodes {
+				githubCommit, stats := node.toRepositoryCommit()
+				commonCommits = append(commonCommits, commitWithStats(githubCommit, stats))
+			}
+		}
+		// Like REST, a plain listing returns a single page and a bounded range all of them.
+		if !history.PageInfo.HasNextPage || (current >= page && !options.WalksAllPages()) {
+			return commonCommits, nil
+		}
+		variables["after"] = history.PageInfo.EndCursor
+	}
+}
+
+// graphQLAuthorFilter returns the history author filter for author, an email or a login, and
+// whether such an author exists.
+func (ghRepo *GitHubRepo) graphQLAuthorFilter(ctx context.Context, author string) (map[string]interface{}, bool, error) {
+	if strings.Contains(author, "@") {
+		return map[string]interface{}{"emails": []string{author}}, true, nil
+	}
+	var data struct {
+		User *struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}
+	err := ghRepo.graphQL(ctx, graphQLUserIDQuery, map[string]interface{}{"login": author}, &data)
+	var graphQLErr *GraphQLError
+	if errors.As(err, &graphQLErr) && graphQLErr.Type == "NOT_FOUND" {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up github user %s: %w", author, err)
+	}
+	if data.User == nil {
+		return nil, false, nil
+	}
+	return map[string]interface{}{"id": data.User.ID}, true, nil
+}
+
+// toRepositoryCommit returns node as the REST commit it stands for and its stats, so that both
+// backends share commitWithStats.
+func (node graphQLCommitNode) toRepositoryCommit() (*github.RepositoryCommit, *github.CommitStats) {
+	githubCommit := &github.RepositoryCommit{
+		SHA:     github.String(node.OID),
+		HTMLURL: github.String(node.URL),
+		Commit:  &github.Commit{Message: github.String(node.Message)},
+	}
+	if node.Author != nil {
+		githubCommit.Commit.Author = &github.CommitAuthor{Name: github.String(node.Author.Name), Email: github.String(node.Author.Email)}
+		if node.Author.Date != nil {
+			githubCommit.Commit.Author.Date = &github.Timestamp{Time: *node.Author.Date}
+		}
+		if node.Author.User != nil {
+			githubCommit.Author = &github.User{Login: github.String(node.Author.User.Login)}
+		}
+	}
+	stats := &github.CommitStats{
+		Additions: github.Int(node.Additions),
+		Deletions: github.Int(node.Deletions),
+		Total:     github.Int(node.Additions + node.Deletions),
+	}
+	return githubCommit, stats
+}
+
+// graphQL runs query with variables and decodes its data into data. The request goes through
+// the REST client, so it shares its token, transport and error types.
+func (ghRepo *GitHubRepo) graphQL(ctx context.Context, query string, variables map[string]interface{}, data interface{}) error {
+	req, err := ghRepo.Client.NewRequest(http.MethodPost, graphQLURL(ghRepo.Client.BaseURL.String()), &graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return fmt.Errorf("failed to build github graphql request: %w", err)
+	}
+	var body struct {
+		Data   interface{} `json:"data"`
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	body.Data = data
+	if _, err := ghRepo.Client.Do(ctx, req, &body); err != nil {
+		return err
+	}
+	if len(body.Errors) > 0 {
+		return &GraphQLError{Type: body.Errors[0].Type, Message: body.Errors[0].Message}
+	}
+	return nil
+}
+
+// graphQLURL returns the GraphQL endpoint of the REST API at baseURL: /graphql next to the REST
+// root on github.com, /api/graphql on GitHub Enterprise Server.
+func graphQLURL(baseURL string) string {
+	if strings.HasSuffix(baseURL, "/api/v3/") {
+		return strings.TrimSuffix(baseURL, "v3/") + "graphql"
+	}
+	return baseURL + "graphql"
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+	"github.com/google/go-github/v56/github"
+)
+
+// parityCommit is one commit of the fixture both the REST and the GraphQL handlers serve.
+type parityCommit struct {
+	sha, message, name, email, login string
+	date                             time.Time
+	additions, deletions             int
+}
+
+var parityCommits = []parityCommit{
+	{"c3", "feat(api)!: drop v0\n\nBREAKING CHANGE: v0 is gone", "Jane Doe", "jane@example.com", "jane", time.Date(2024, 3, 3, 10, 0, 0, 0, time.UTC), 120, 40},
+	{"c2", "fix: handle empty pages", "John Roe", "john@example.com", "", time.Date(2024, 3, 2, 9, 30, 0, 0, time.UTC), 3, 1},
+	{"c1", "Initial commit", "Jane Doe", "jane@example.com", "jane", time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC), 500, 0},
+}
+
+// restJSON is the commit as the REST list view returns it; stats are only in the detail view.
+func (c parityCommit) restJSON(withStats bool) map[string]interface{} {
+	commit := map[string]interface{}{
+		"sha":      c.sha,
+		"html_url": "https://github.com/o/r/commit/" + c.sha,
+		"commit": map[string]interface{}{
+			"message": c.message,
+			"author":  map[string]interface{}{"name": c.name, "email": c.email, "date": c.date.Format(time.RFC3339)},
+		},
+	}
+	if c.login != "" {
+		commit["author"] = map[string]interface{}{"login": c.login}
+	}
+	if withStats {
+		commit["stats"] = map[string]interface{}{"additions": c.additions, "deletions": c.deletions, "total": c.additions + c.deletions}
+	}
+	return commit
+}
+
+// graphQLJSON is the commit as a node of the history connection.
+func (c parityCommit) graphQLJSON() map[string]interface{} {
+	author := map[string]interface{}{"name": c.name, "email": c.email, "date": c.date.Format(time.RFC3339), "user": nil}
+	if c.login != "" {
+		author["user"] = map[string]interface{}{"login": c.login}
+	}
+	return map[string]interface{}{
+		"oid": c.sha, "message": c.message, "url": "https://github.com/o/r/commit/" + c.sha,
+		"additions": c.additions, "deletions": c.deletions, "author": author,
+	}
+}
+
+// parityMux serves parityCommits over REST and GraphQL. GraphQL pages hold pageSize commits and
+// every decoded query is passed to onQuery.
+func parityMux(t *testing.T, pageSize int, onQuery func(variables map[string]interface{})) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"r","owner":{"login":"o"},"default_branch":"main"}`)
+	})
+	mux.HandleFunc("/repos/o/r/commits", func(w http.ResponseWriter, r *http.Request) {
+		list := make([]map[string]interface{}, len(parityCommits))
+		for i, c := range parityCommits {
+			list[i] = c.restJSON(false)
+		}
+		json.NewEncoder(w).Encode(list)
+	})
+	mux.HandleFunc("/repos/o/r/commits/", func(w http.ResponseWriter, r *http.Request) {
+		sha := strings.TrimPrefix(r.URL.Path, "/repos/o/r/commits/")
+		for _, c := range parityCommits {
+			if c.sha == sha {
+				json.NewEncoder(w).Encode(c.restJSON(true))
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode GraphQL request: %v", err)
+		}
+		if onQuery != nil {
+			onQuery(body.Variables)
+		}
+		if strings.Contains(body.Query, "user(login") {
+			if body.Variables["login"] == "jane" {
+				fmt.Fprint(w, `{"data":{"user":{"id":"U_jane"}}}`)
+				return
+			}
+			fmt.Fprint(w, `{"data":{"user":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a User"}]}`)
+			return
+		}
+		if body.Variables["ref"] == "missing" {
+			fmt.Fprint(w, `{"data":{"repository":{"object":null}}}`)
+			return
+		}
+		start := 0
+		if after, ok := body.Variables["after"].(string); ok {
+			fmt.Sscanf(after, "cursor%d", &start)
+		}
+		end := start + pageSize
+		if end > len(parityCommits) {
+			end = len(parityCommits)
+		}
+		nodes := []map[string]interface{}{}
+		for _, c := range parityCommits[start:end] {
+			nodes = append(nodes, c.graphQLJSON())
+		}
+		history := map[string]interface{}{
+			"pageInfo": map[string]interface{}{"hasNextPage": end < len(parityCommits), "endCursor": fmt.Sprintf("cursor%d", end)},
+			"nodes":    nodes,
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"repository": map[string]interface{}{"object": map[string]interface{}{"history": history}}}})
+	})
+	return mux
+}
+
+func TestGitHubRepo_GetProjectCommits_GraphQLMatchesREST(t *testing.T) {
+	client := newTestGitHubClient(t, parityMux(t, 100, nil))
+	restRepo, _ := NewGithubRepo(client)
+	graphQLRepo, _ := NewGithubRepo(client)
+	graphQLRepo.CommitsBackend = CommitsBackendGraphQL
+
+	restCommits, err := restRepo.GetProjectCommits("o/r", nil)
+	if err != nil {
+		t.Fatalf("REST GetProjectCommits() returned error: %v", err)
+	}
+	graphQLCommits, err := graphQLRepo.GetProjectCommits("o/r", nil)
+	if err != nil {
+		t.Fatalf("GraphQL GetProjectCommits() returned error: %v", err)
+	}
+	if len(restCommits) != len(parityCommits) {
+		t.Fatalf("REST listed %d commits, want %d", len(restCommits), len(parityCommits))
+	}
+	for i := range restCommits {
+		if !reflect.DeepEqual(graphQLCommits[i], restCommits[i]) {
+			t.Errorf("commit %d differs:\nGraphQL: %+v\nREST:    %+v", i, graphQLCommits[i], restCommits[i])
+		}
+		listed, _ := json.Marshal(parityCommits[i].restJSON(false))
+		var githubCommit github.RepositoryCommit
+		if err := json.Unmarshal(listed, &githubCommit); err != nil {
+			t.Fatalf("failed to decode fixture: %v", err)
+		}
+		single, err := toCommonCommit(&githubCommit, client, "o", "r", githubCommit.GetSHA())
+		if err != nil || !reflect.DeepEqual(graphQLCommits[i], single) {
+			t.Errorf("commit %d differs from toCommonCommit:\nGraphQL:        %+v\ntoCommonCommit: %+v (%v)", i, graphQLCommits[i], single, err)
+		}
+	}
+	if len(graphQLCommits) != len(restCommits) {
+		t.Errorf("GraphQL listed %d commits, REST %d", len(graphQLCommits), len(restCommits))
+	}
+}
+
+func TestGitHubRepo_GetProjectCommits_GraphQLPaging(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  *interfaces.CommitListOptions
+		expected []string
+		queries  int
+	}{
+		{"first page only", &interfaces.CommitListOptions{PerPage: 2}, []string{"c3", "c2"}, 1},
+		{"page walked by cursor", &interfaces.CommitListOptions{PerPage: 2, Page: 2}, []string{"c1"}, 2},
+		{"bounded range walks every page", &interfaces.CommitListOptions{PerPage: 2, Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, []string{"c3", "c2", "c1"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := 0
+			ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, parityMux(t, tt.options.PerPage, func(variables map[string]interface{}) {
+				queries++
+				if variables["ref"] != "main" || variables["first"] != float64(tt.options.PerPage) {
+					t.Errorf("unexpected variables %v", variables)
+				}
+			})))
+			ghRepo.CommitsBackend = CommitsBackendGraphQL
+
+			commits, err := ghRepo.GetProjectCommits("o/r", tt.options)
+			if err != nil {
+				t.Fatalf("GetProjectCommits() returned error: %v", err)
+			}
+			var shas []string
+			for _, commit := range commits {
+				shas = append(shas, commit.SHA)
+			}
+			if !reflect.DeepEqual(shas, tt.expected) || queries != tt.queries {
+				t.Errorf("got %v in %d queries, want %v in %d", shas, queries, tt.expected, tt.queries)
+			}
+		})
+	}
+}
+
+func TestGitHubRepo_GetProjectCommits_GraphQLFilters(t *testing.T) {
+	var lastVariables map[string]interface{}
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, parityMux(t, 100, func(variables map[string]interface{}) { lastVariables = variables })))
+	ghRepo.CommitsBackend = CommitsBackendGraphQL
+
+	if _, err := ghRepo.GetProjectCommits("o/r", &interfaces.CommitListOptions{SHA: "release", Path: "cmd", Author: "jane"}); err != nil {
+		t.Fatalf("GetProjectCommits() returned error: %v", err)
+	}
+	author, _ := lastVariables["author"].(map[string]interface{})
+	if lastVariables["ref"] != "release" || lastVariables["path"] != "cmd" || author["id"] != "U_jane" {
+		t.Errorf("unexpected variables %v", lastVariables)
+	}
+
+	if _, err := ghRepo.GetProjectCommits("o/r", &interfaces.CommitListOptions{Author: "jane@example.com"}); err != nil {
+		t.Fatalf("GetProjectCommits() returned error: %v", err)
+	}
+	author, _ = lastVariables["author"].(map[string]interface{})
+	if emails, _ := author["emails"].([]interface{}); len(emails) != 1 || emails[0] != "jane@example.com" {
+		t.Errorf("expected an email author filter, got %v", lastVariables["author"])
+	}
+
+	commits, err := ghRepo.GetProjectCommits("o/r", &interfaces.CommitListOptions{Author: "nobody"})
+	if err != nil || len(commits) != 0 {
+		t.Errorf("expected no commits for an unknown login, got %v, %v", commits, err)
+	}
+
+	_, err = ghRepo.GetProjectCommits("o/r", &interfaces.CommitListOptions{SHA: "missing"})
+	var graphQLErr *GraphQLError
+	if !errors.As(err, &graphQLErr) || graphQLErr.Type != "NOT_FOUND" {
+		t.Errorf("expected a NOT_FOUND GraphQLError for an unknown ref, got %v", err)
+	}
+}
+
+func TestGraphQLURL(t *testing.T) {
+	tests := map[string]string{
+		"https://api.github.com/":            "https://api.github.com/graphql",
+		"https://github.example.com/api/v3/": "https://github.example.com/api/graphql",
+		"http://127.0.0.1:8080/":             "http://127.0.0.1:8080/graphql",
+	}
+	for baseURL, expected := range tests {
+		if got := graphQLURL(baseURL); got != expected {
+			t.Errorf("graphQLURL(%q) = %q, want %q", baseURL, got, expected)
+		}
+	}
+}
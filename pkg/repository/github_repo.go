@@ -3,10 +3,13 @@ package repository
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"net/http"
+	"sort"
 	"strings"
 	"time"
 
+	storage "github.com/ahmetk3436/git-stats-golang/internal"
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
 	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
 	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
 	"github.com/google/go-github/v56/github"
@@ -15,7 +18,10 @@ import (
 // GitHubRepo implements the interfaces.GitService for GitHub.
 // It uses the go-github client to interact with the GitHub API.
 type GitHubRepo struct {
-	Client *github.Client // Client is the GitHub API client.
+	Client         *github.Client     // Client is the GitHub API client.
+	CommitsBackend string             // CommitsBackendREST (when empty) or CommitsBackendGraphQL.
+	DetailWorkers  int                // Concurrent GetCommit calls for commit stats; DefaultCommitDetailWorkers when zero.
+	StatsCache     storage.InMemoryDB // Caches commit stats by SHA so they are fetched once; optional.
 }
 
 // NewGithubRepo creates a new GitHubRepo instance.
@@ -30,13 +36,32 @@ func NewGithubRepo(gitHubClient *github.Client) (*GitHubRepo, error) {
 }
 
 // ConnectGithub creates a new GitHub API client authenticated with the provided token.
+// Its requests go through rateLimits, or through a tracker of their own if rateLimits is nil,
+// and are sent conditionally with the responses etags keeps unless it is nil.
 // This is a helper function for initializing the GitHubRepo and is not part of the GitService interface.
-func ConnectGithub(token string) *github.Client {
+func ConnectGithub(token string, rateLimits *RateLimitTracker, etags *ETagCache) *github.Client {
 	// TODO: Consider adding a context for cancellation or timeout if needed for client creation.
-	client := github.NewClient(nil).WithAuthToken(token)
+	if rateLimits == nil {
+		rateLimits = NewRateLimitTracker("github")
+	}
+	transport := rateLimits.Transport(nil)
+	if etags != nil {
+		transport = etags.Transport(transport)
+	}
+	client := github.NewClient(&http.Client{Transport: transport}).WithAuthToken(token)
 	return client
 }
 
+// checkRateBudget returns a RateLimitError if calls more requests do not fit into the quota
+// that resp reports, so that fetching details one request per item fails up front instead of
+// exhausting the quota halfway.
+func checkRateBudget(resp *github.Response, calls int) error {
+	if resp == nil || resp.Rate.Limit == 0 || calls <= resp.Rate.Remaining || !resp.Rate.Reset.After(time.Now()) {
+		return nil
+	}
+	return &RateLimitError{Provider: "github", Resource: "core", Reset: resp.Rate.Reset.Time}
+}
+
 // toCommonRepository converts a GitHub specific repository object to the common_types.Repository.
 func toCommonRepository(ghRepo *github.Repository) *common_types.Repository {
 	if ghRepo == nil {
@@ -49,17 +74,19 @@ func toCommonRepository(ghRepo *github.Repository) *common_types.Repository {
 	// Ensure timestamps are correctly handled if they can be nil from the API.
 	// GetCreatedAt() and GetUpdatedAt() return github.Timestamp, which has a .Time field.
 	return &common_types.Repository{
-		ID:          ghRepo.GetID(), // GetID returns int64, matching common_types
-		Name:        ghRepo.GetName(),
-		Owner:       ownerLogin,
-		HTMLURL:     ghRepo.GetHTMLURL(),
-		CloneURL:    ghRepo.GetCloneURL(),
-		Description: ghRepo.GetDescription(),
-		CreatedAt:   ghRepo.GetCreatedAt().Time, // .Time is already a time.Time
-		UpdatedAt:   ghRepo.GetUpdatedAt().Time, // .Time is already a time.Time
-		Stars:       ghRepo.GetStargazersCount(),
-		Forks:       ghRepo.GetForksCount(),
-		OpenIssues:  ghRepo.GetOpenIssuesCount(),
+		ID:            ghRepo.GetID(), // GetID returns int64, matching common_types
+		Name:          ghRepo.GetName(),
+		Owner:         ownerLogin,
+		FullName:      ghRepo.GetFullName(),
+		HTMLURL:       ghRepo.GetHTMLURL(),
+		CloneURL:      ghRepo.GetCloneURL(),
+		Description:   ghRepo.GetDescription(),
+		CreatedAt:     ghRepo.GetCreatedAt().Time, // .Time is already a time.Time
+		UpdatedAt:     ghRepo.GetUpdatedAt().Time, // .Time is already a time.Time
+		Stars:         ghRepo.GetStargazersCount(),
+		Forks:         ghRepo.GetForksCount(),
+		OpenIssues:    ghRepo.GetOpenIssuesCount(),
+		DefaultBranch: ghRepo.GetDefaultBranch(),
 	}
 }
 
@@ -70,29 +97,42 @@ func toCommonCommit(ghCommit *github.RepositoryCommit, ghClient *github.Client,
 	if ghCommit == nil {
 		return nil, fmt.Errorf("github repository commit is nil")
 	}
-	if ghClient == nil {
-		return nil, fmt.Errorf("github client is nil, cannot fetch detailed commit stats")
-	}
 
 	// The ListCommits endpoint (which often provides ghCommit) may not populate stats.
 	// A separate call to GetCommit is usually needed for detailed stats.
 	// This makes an additional API call per commit, which can be a performance consideration.
-	// For now, we prioritize getting complete data.
-	// TODO: Add logging for this operation, e.g., log.Debugf("Fetching detailed commit for SHA %s", commitSHA)
-	detailedCommit, _, err := ghClient.Repositories.GetCommit(context.Background(), ownerLogin, repoName, commitSHA, nil)
+	// For now, we prioritize getting complete data. A nil client skips the detail fetch
+	// and maps only what the list view provides.
+	var detailedStats *github.CommitStats
+	if ghClient != nil {
+		var err error
+		detailedStats, err = fetchCommitStats(context.Background(), ghClient, ownerLogin, repoName, commitSHA)
+		if isRateLimitError(err) {
+			// Zero stats for the rest of the listing would be silently wrong.
+			return nil, err
+		}
+		// If fetching the detailed commit fails otherwise, proceed with basic info. Stats will be zero.
+	}
+	return commitWithStats(ghCommit, detailedStats), nil
+}
+
+// fetchCommitStats fetches the stats of one commit, which list views leave out.
+func fetchCommitStats(ctx context.Context, ghClient *github.Client, ownerLogin, repoName, commitSHA string) (*github.CommitStats, error) {
+	detailedCommit, _, err := ghClient.Repositories.GetCommit(ctx, ownerLogin, repoName, commitSHA, nil)
 	if err != nil {
-		// If fetching detailed commit fails, log the error and proceed with basic info. Stats will be zero.
-		// Consider how critical stats are; if absolutely required, this could return an error.
-		// log.Warnf("Could not fetch detailed commit for %s/%s SHA %s: %v. Proceeding with basic info.", owner, repoName, commitSHA, err)
-		// For now, we'll allow creation of common_types.Commit even if detailed stats are missing.
+		return nil, fmt.Errorf("failed to get github commit %s for %s/%s: %w", commitSHA, ownerLogin, repoName, err)
 	}
+	return detailedCommit.GetStats(), nil
+}
 
+// commitWithStats converts ghCommit to the common_types.Commit, using detailedStats when known.
+func commitWithStats(ghCommit *github.RepositoryCommit, detailedStats *github.CommitStats) *common_types.Commit {
 	var stats common_types.CommitStats
-	if detailedCommit != nil && detailedCommit.Stats != nil {
+	if detailedStats != nil {
 		stats = common_types.CommitStats{
-			Additions: detailedCommit.Stats.GetAdditions(),
-			Deletions: detailedCommit.Stats.GetDeletions(),
-			Total:     detailedCommit.Stats.GetTotal(),
+			Additions: detailedStats.GetAdditions(),
+			Deletions: detailedStats.GetDeletions(),
+			Total:     detailedStats.GetTotal(),
 		}
 	} else if ghCommit.GetStats() != nil { // Fallback to stats from the list view if available (rarely populated)
 		stats = common_types.CommitStats{
@@ -123,12 +163,13 @@ func toCommonCommit(ghCommit *github.RepositoryCommit, ghClient *github.Client,
 	}
 
 	return &common_types.Commit{
-		SHA:     ghCommit.GetSHA(),
-		Author:  author,
-		Message: ghCommit.GetCommit().GetMessage(),
-		HTMLURL: ghCommit.GetHTMLURL(),
-		Stats:   stats,
-	}, nil
+		SHA:          ghCommit.GetSHA(),
+		Author:       author,
+		Message:      ghCommit.GetCommit().GetMessage(),
+		HTMLURL:      ghCommit.GetHTMLURL(),
+		Stats:        stats,
+		Conventional: analytics.ParseConventionalCommit(ghCommit.GetCommit().GetMessage()),
+	}
 }
 
 // toCommonUser converts a GitHub specific user object to the common_types.User.
@@ -145,6 +186,72 @@ func toCommonUser(ghUser *github.User) *common_types.User {
 	}
 }
 
+// toCommonIssue converts a GitHub specific issue object to the common_types.Issue.
+func toCommonIssue(ghIssue *github.Issue) *common_types.Issue {
+	if ghIssue == nil {
+		return nil
+	}
+	labels := make([]string, 0, len(ghIssue.Labels))
+	for _, label := range ghIssue.Labels {
+		labels = append(labels, label.GetName())
+	}
+	assignees := make([]string, 0, len(ghIssue.Assignees))
+	for _, assignee := range ghIssue.Assignees {
+		assignees = append(assignees, assignee.GetLogin())
+	}
+	return &common_types.Issue{
+		Number:    ghIssue.GetNumber(),
+		Title:     ghIssue.GetTitle(),
+		State:     ghIssue.GetState(), // GitHub already reports "open" / "closed".
+		Author:    ghIssue.GetUser().GetLogin(),
+		Labels:    labels,
+		Assignees: assignees,
+		HTMLURL:   ghIssue.GetHTMLURL(),
+		CreatedAt: ghIssue.GetCreatedAt().Time,
+		ClosedAt:  ghIssue.GetClosedAt().Time,
+	}
+}
+
+// toCommonBranch converts a GitHub specific branch object to the common_types.Branch.
+// Ahead/behind counts and the default/merged flags are filled in by the caller.
+func toCommonBranch(ghBranch *github.Branch) *common_types.Branch {
+	if ghBranch == nil {
+		return nil
+	}
+	headAuthor := ghBranch.GetCommit().GetCommit().GetAuthor()
+	return &common_types.Branch{
+		Name:             ghBranch.GetName(),
+		LastCommitSHA:    ghBranch.GetCommit().GetSHA(),
+		LastCommitDate:   headAuthor.GetDate().Time,
+		LastCommitAuthor: headAuthor.GetName(),
+		Protected:        ghBranch.GetProtected(),
+	}
+}
+
+// toCommonFileChange converts a GitHub specific commit file to the common_types.FileChange.
+func toCommonFileChange(ghFile *github.CommitFile) *common_types.FileChange {
+	if ghFile == nil {
+		return nil
+	}
+	return &common_types.FileChange{
+		Filename:         ghFile.GetFilename(),
+		PreviousFilename: ghFile.GetPreviousFilename(),
+		Status:           ghFile.GetStatus(),
+		Additions:        ghFile.GetAdditions(),
+		Deletions:        ghFile.GetDeletions(),
+	}
+}
+
+// sortLanguages orders languages by share, largest first, then by name. Shared by both providers.
+func sortLanguages(languages []*common_types.Language) {
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Share != languages[j].Share {
+			return languages[i].Share > languages[j].Share
+		}
+		return languages[i].Name < languages[j].Name
+	})
+}
+
 // GetAllRepos implements interfaces.GitService.
 // It retrieves repositories based on the provided owner string.
 // If owner is empty, it lists repositories for the authenticated user (owner, collaborator, org member).
@@ -235,6 +342,10 @@ func (ghRepo *GitHubRepo) GetProjectCommits(repoIdentifier interface{}, options
 	if ownerLogin == "" || repositoryName == "" { // Should not happen if GetRepo succeeded with a valid repo.
 		return nil, fmt.Errorf("could not determine owner and repository name for identifier: %v", repoIdentifier)
 	}
+	// GraphQL has no compare, so ranges between two refs always use REST.
+	if ghRepo.CommitsBackend == CommitsBackendGraphQL && (options == nil || options.BaseRef == "") {
+		return ghRepo.graphQLCommits(ctx, ownerLogin, repositoryName, targetRepo.DefaultBranch, options)
+	}
 
 	commitListOpts := github.CommitsListOptions{
 		ListOptions: github.ListOptions{PerPage: 100}, // Default PerPage.
@@ -257,26 +368,105 @@ func (ghRepo *GitHubRepo) GetProjectCommits(repoIdentifier interface{}, options
 		if options.PerPage > 0 {
 			commitListOpts.ListOptions.PerPage = options.PerPage
 		}
+		commitListOpts.Since = options.Since
+		commitListOpts.Until = options.Until
 	}
 
-	githubCommits, _, err := ghRepo.Client.Repositories.ListCommits(ctx, ownerLogin, repositoryName, &commitListOpts)
+	var githubCommits []*github.RepositoryCommit
+	var lastResp *github.Response
+	if options != nil && options.BaseRef != "" {
+		headRef := options.SHA
+		if headRef == "" {
+			headRef = targetRepo.DefaultBranch
+		}
+		githubCommits, _, lastResp, err = ghRepo.compareRange(ctx, ownerLogin, repositoryName, options.BaseRef, headRef)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if options.WalksAllPages() {
+			commitListOpts.ListOptions.Page = 0
+		}
+		for {
+			page, resp, err := ghRepo.Client.Repositories.ListCommits(ctx, ownerLogin, repositoryName, &commitListOpts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list github commits for %s/%s: %w", ownerLogin, repositoryName, err)
+			}
+			githubCommits = append(githubCommits, page...)
+			lastResp = resp
+			// Only a bounded range is walked to the end; plain listings return a single page.
+			if !options.WalksAllPages() || resp == nil || resp.NextPage == 0 {
+				break
+			}
+			commitListOpts.ListOptions.Page = resp.NextPage
+		}
+	}
+
+	commonCommits, err := ghRepo.toCommonCommits(ctx, githubCommits, lastResp, ownerLogin, repositoryName, options != nil && options.NoStats)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list github commits for %s/%s: %w", ownerLogin, repositoryName, err)
+		return nil, err
 	}
+	return commonCommits, nil
+}
 
-	commonCommits := make([]*common_types.Commit, 0, len(githubCommits))
-	for _, githubCommit := range githubCommits {
-		commonCommit, conversionErr := toCommonCommit(githubCommit, ghRepo.Client, ownerLogin, repositoryName, githubCommit.GetSHA())
-		if conversionErr != nil {
-			// Log or handle error converting individual commit.
-			// For now, skip bad ones and log a warning.
-			// log.Warnf("Could not convert commit %s for %s/%s: %v", githubCommit.GetSHA(), ownerLogin, repositoryName, conversionErr)
-			fmt.Printf("Warning: Could not convert commit %s for %s/%s: %v\n", githubCommit.GetSHA(), ownerLogin, repositoryName, conversionErr) // Temporary logging
-			continue
+// compareRange returns the commits reachable from headRef but not from baseRef, newest first,
+// the files changed between the two refs and the response of the last page.
+func (ghRepo *GitHubRepo) compareRange(ctx context.Context, ownerLogin, repositoryName, baseRef, headRef string) ([]*github.RepositoryCommit, []*github.CommitFile, *github.Response, error) {
+	listOptions := &github.ListOptions{PerPage: 100}
+	var rangeCommits []*github.RepositoryCommit
+	var changedFiles []*github.CommitFile
+	var lastResp *github.Response
+	for {
+		comparison, resp, err := ghRepo.Client.Repositories.CompareCommits(ctx, ownerLogin, repositoryName, baseRef, headRef, listOptions)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to compare github refs %s...%s for %s/%s: %w", baseRef, headRef, ownerLogin, repositoryName, err)
 		}
-		commonCommits = append(commonCommits, commonCommit)
+		lastResp = resp
+		rangeCommits = append(rangeCommits, comparison.Commits...)
+		if listOptions.Page <= 1 { // Every page repeats the same (capped) file list.
+			changedFiles = comparison.Files
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		listOptions.Page = resp.NextPage
 	}
-	return commonCommits, nil
+	// The compare API lists commits oldest first, ListCommits newest first.
+	for i, j := 0, len(rangeCommits)-1; i < j; i, j = i+1, j-1 {
+		rangeCommits[i], rangeCommits[j] = rangeCommits[j], rangeCommits[i]
+	}
+	return rangeCommits, changedFiles, lastResp, nil
+}
+
+// CompareRefs implements interfaces.GitService.
+// repoIdentifier can be an int64 (GitHub Repository ID) or a string "ownerLogin/repoName".
+// GitHub caps the file list of a comparison at 300 files.
+func (ghRepo *GitHubRepo) CompareRefs(repoIdentifier interface{}, baseRef, headRef string) (*common_types.Comparison, error) {
+	ctx := context.Background() // TODO: Pass context.
+	ownerLogin, repositoryName, err := ghRepo.resolveOwnerAndName(repoIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	githubCommits, githubFiles, lastResp, err := ghRepo.compareRange(ctx, ownerLogin, repositoryName, baseRef, headRef)
+	if err != nil {
+		return nil, err
+	}
+	commonCommits, err := ghRepo.toCommonCommits(ctx, githubCommits, lastResp, ownerLogin, repositoryName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &common_types.Comparison{
+		BaseRef: baseRef,
+		HeadRef: headRef,
+		Commits: commonCommits,
+		Files:   make([]*common_types.FileChange, 0, len(githubFiles)),
+	}
+	for _, githubFile := range githubFiles {
+		comparison.Files = append(comparison.Files, toCommonFileChange(githubFile))
+	}
+	return comparison, nil
 }
 
 // GetRepoContributors implements interfaces.GitService.
@@ -342,6 +532,236 @@ func (ghRepo *GitHubRepo) GetRepoContributors(repoIdentifier interface{}) ([]*co
 	return commonContributors, nil
 }
 
+// ListIssues implements interfaces.GitService.
+// repoIdentifier can be an int64 (GitHub Repository ID) or a string "ownerLogin/repoName".
+// GitHub's issues endpoint also returns pull requests; those are filtered out here.
+func (ghRepo *GitHubRepo) ListIssues(repoIdentifier interface{}, options *interfaces.IssueListOptions) ([]*common_types.Issue, error) {
+	ctx := context.Background() // TODO: Pass context.
+
+	ownerLogin, repositoryName, err := ghRepo.resolveOwnerAndName(repoIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	issueListOpts := github.IssueListByRepoOptions{
+		State:       interfaces.IssueStateAll,
+		ListOptions: github.ListOptions{PerPage: 100}, // Default PerPage.
+	}
+	walkAllPages := true
+	if options != nil {
+		if options.State != "" {
+			issueListOpts.State = options.State
+		}
+		if len(options.Labels) > 0 {
+			issueListOpts.Labels = options.Labels
+		}
+		if !options.Since.IsZero() {
+			issueListOpts.Since = options.Since
+		}
+		if options.Page > 0 {
+			issueListOpts.ListOptions.Page = options.Page
+			walkAllPages = false
+		}
+		if options.PerPage > 0 {
+			issueListOpts.ListOptions.PerPage = options.PerPage
+		}
+	}
+
+	commonIssues := make([]*common_types.Issue, 0)
+	for {
+		githubIssues, resp, err := ghRepo.Client.Issues.ListByRepo(ctx, ownerLogin, repositoryName, &issueListOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list github issues for %s/%s: %w", ownerLogin, repositoryName, err)
+		}
+		for _, githubIssue := range githubIssues {
+			if githubIssue.IsPullRequest() {
+				continue
+			}
+			commonIssues = append(commonIssues, toCommonIssue(githubIssue))
+		}
+		if !walkAllPages || resp == nil || resp.NextPage == 0 {
+			break
+		}
+		issueListOpts.ListOptions.Page = resp.NextPage
+	}
+	return commonIssues, nil
+}
+
+// ListBranches implements interfaces.GitService.
+// repoIdentifier can be an int64 (GitHub Repository ID) or a string "ownerLogin/repoName".
+// GitHub's branch list only carries the head SHA, so every non-default branch costs
+// one GetBranch call (head commit details) and one CompareCommits call (ahead/behind);
+// these are made for DetailWorkers branches at a time.
+func (ghRepo *GitHubRepo) ListBranches(repoIdentifier interface{}) ([]*common_types.Branch, error) {
+	ctx := context.Background() // TODO: Pass context.
+
+	targetRepo, err := ghRepo.GetRepo(repoIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository details for listing branches (identifier: '%v'): %w", repoIdentifier, err)
+	}
+	ownerLogin, repositoryName, defaultBranch := targetRepo.Owner, targetRepo.Name, targetRepo.DefaultBranch
+
+	branchListOpts := github.BranchListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var githubBranches []*github.Branch
+	var lastResp *github.Response
+	for {
+		page, resp, err := ghRepo.Client.Repositories.ListBranches(ctx, ownerLogin, repositoryName, &branchListOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list github branches for %s/%s: %w", ownerLogin, repositoryName, err)
+		}
+		githubBranches = append(githubBranches, page...)
+		lastResp = resp
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		branchListOpts.ListOptions.Page = resp.NextPage
+	}
+
+	// Every branch costs a GetBranch and, unless it is the default branch, a CompareCommits call.
+	if err := checkRateBudget(lastResp, 2*len(githubBranches)-1); err != nil {
+		return nil, fmt.Errorf("not enough rate limit left to inspect %d branches of %s/%s: %w", len(githubBranches), ownerLogin, repositoryName, err)
+	}
+	commonBranches := make([]*common_types.Branch, len(githubBranches))
+	pending := make([]int, len(githubBranches))
+	for i := range pending {
+		pending[i] = i
+	}
+	err = ghRepo.fetchDetailsConcurrently(ctx, pending, func(ctx context.Context, i int) error {
+		branchName := githubBranches[i].GetName()
+		detailedBranch, _, err := ghRepo.Client.Repositories.GetBranch(ctx, ownerLogin, repositoryName, branchName, 1)
+		if err != nil {
+			return fmt.Errorf("failed to get github branch %s for %s/%s: %w", branchName, ownerLogin, repositoryName, err)
+		}
+		branch := toCommonBranch(detailedBranch)
+		branch.Default = branchName == defaultBranch
+
+		if !branch.Default && defaultBranch != "" {
+			comparison, _, err := ghRepo.Client.Repositories.CompareCommits(ctx, ownerLogin, repositoryName, defaultBranch, branchName, &github.ListOptions{PerPage: 1})
+			if err != nil {
+				return fmt.Errorf("failed to compare github branch %s with %s for %s/%s: %w", branchName, defaultBranch, ownerLogin, repositoryName, err)
+			}
+			branch.Ahead = comparison.GetAheadBy()
+			branch.Behind = comparison.GetBehindBy()
+			branch.Merged = branch.Ahead == 0 // Every commit on the branch is reachable from the default branch.
+		}
+		commonBranches[i] = branch
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commonBranches, nil
+}
+
+// ListTags implements interfaces.GitService.
+// repoIdentifier can be an int64 (GitHub Repository ID) or a string "ownerLogin/repoName".
+// GitHub's tag list only carries the commit SHA, so every tagged commit not in StatsCache costs
+// one GetCommit call; these are made DetailWorkers at a time.
+func (ghRepo *GitHubRepo) ListTags(repoIdentifier interface{}) ([]*common_types.Tag, error) {
+	ctx := context.Background() // TODO: Pass context.
+
+	ownerLogin, repositoryName, err := ghRepo.resolveOwnerAndName(repoIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := github.ListOptions{PerPage: 100}
+	commonTags := make([]*common_types.Tag, 0)
+	for {
+		githubTags, resp, err := ghRepo.Client.Repositories.ListTags(ctx, ownerLogin, repositoryName, &listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list github tags for %s/%s: %w", ownerLogin, repositoryName, err)
+		}
+		pageTags := make([]*common_types.Tag, len(githubTags))
+		var pending []int
+		for i, githubTag := range githubTags {
+			tag := &common_types.Tag{Name: githubTag.GetName(), CommitSHA: githubTag.GetCommit().GetSHA()}
+			pageTags[i] = tag
+			if tag.CommitSHA == "" {
+				continue
+			}
+			if cached := ghRepo.cachedTaggedCommit(ownerLogin, repositoryName, tag.CommitSHA); cached != nil {
+				tag.CommitAuthor, tag.CommitDate = cached.Author, cached.Date
+				continue
+			}
+			pending = append(pending, i)
+		}
+		// Every tagged commit not cached yet costs one GetCommit call.
+		if err := checkRateBudget(resp, len(pending)); err != nil {
+			return nil, fmt.Errorf("not enough rate limit left to fetch %d tagged commits for %s/%s: %w", len(pending), ownerLogin, repositoryName, err)
+		}
+		err = ghRepo.fetchDetailsConcurrently(ctx, pending, func(ctx context.Context, i int) error {
+			tag := pageTags[i]
+			taggedGithubCommit, _, err := ghRepo.Client.Repositories.GetCommit(ctx, ownerLogin, repositoryName, tag.CommitSHA, nil)
+			if err != nil {
+				return fmt.Errorf("failed to get tagged commit %s for %s/%s: %w", tag.CommitSHA, ownerLogin, repositoryName, err)
+			}
+			commit := &taggedCommit{Author: taggedGithubCommit.GetCommit().GetAuthor().GetName(), Date: taggedGithubCommit.GetCommit().GetAuthor().GetDate().Time}
+			tag.CommitAuthor, tag.CommitDate = commit.Author, commit.Date
+			ghRepo.cacheTaggedCommit(ownerLogin, repositoryName, tag.CommitSHA, commit)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		commonTags = append(commonTags, pageTags...)
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+	return commonTags, nil
+}
+
+// ListLanguages implements interfaces.GitService.
+// repoIdentifier can be an int64 (GitHub Repository ID) or a string "ownerLogin/repoName".
+func (ghRepo *GitHubRepo) ListLanguages(repoIdentifier interface{}) ([]*common_types.Language, error) {
+	ctx := context.Background() // TODO: Pass context.
+
+	ownerLogin, repositoryName, err := ghRepo.resolveOwnerAndName(repoIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	githubLanguages, _, err := ghRepo.Client.Repositories.ListLanguages(ctx, ownerLogin, repositoryName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list github languages for %s/%s: %w", ownerLogin, repositoryName, err)
+	}
+
+	var totalBytes int64
+	for _, languageBytes := range githubLanguages {
+		totalBytes += int64(languageBytes)
+	}
+	commonLanguages := make([]*common_types.Language, 0, len(githubLanguages))
+	for name, languageBytes := range githubLanguages {
+		language := &common_types.Language{Name: name, Bytes: int64(languageBytes)}
+		if totalBytes > 0 {
+			language.Share = float64(languageBytes) / float64(totalBytes)
+		}
+		commonLanguages = append(commonLanguages, language)
+	}
+	sortLanguages(commonLanguages)
+	return commonLanguages, nil
+}
+
+// resolveOwnerAndName resolves a repository identifier (int64 ID or "ownerLogin/repoName")
+// into the owner login and repository name that most GitHub endpoints expect.
+func (ghRepo *GitHubRepo) resolveOwnerAndName(repoIdentifier interface{}) (string, string, error) {
+	if id, ok := repoIdentifier.(string); ok {
+		parts := strings.Split(id, "/")
+		if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+			return parts[0], parts[1], nil // No lookup needed for "owner/name".
+		}
+	}
+	targetRepo, err := ghRepo.GetRepo(repoIdentifier)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get repository details (identifier: '%v'): %w", repoIdentifier, err)
+	}
+	if targetRepo == nil || targetRepo.Owner == "" || targetRepo.Name == "" {
+		return "", "", fmt.Errorf("could not determine owner and repository name for identifier: %v", repoIdentifier)
+	}
+	return targetRepo.Owner, targetRepo.Name, nil
+}
+
 // Ensure GitHubRepo implements GitService.
 // This line will cause a compile-time error if the interface is not properly implemented.
 var _ interfaces.GitService = (*GitHubRepo)(nil)
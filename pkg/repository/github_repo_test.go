@@ -3,13 +3,15 @@ package repository
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
-	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces" // For CommitListOptions if needed later
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
 	"github.com/google/go-github/v56/github"
-	"net/http"
 )
 
 // Helper function to create a pointer to a string
@@ -140,53 +142,6 @@ func TestToCommonRepository(t *testing.T) {
 	}
 }
 
-// MockGitHubClient is a minimal mock for the parts of github.Client used in toCommonCommit.
-// This is a simplified mock because we can't use gomock here.
-type MockGitHubClient struct {
-	GetCommitFunc func(ctx context.Context, owner, repo, sha string, opts *github.ListOptions) (*github.Commit, *github.Response, error)
-}
-
-func (m *MockGitHubClient) GetRepositories() *github.RepositoriesService {
-	// This is tricky. Repositories is a struct, not an interface.
-	// We need to return something that has a GetCommit method.
-	// For this specific test, we can embed a mock RepositoriesService.
-	return &github.RepositoriesService{
-		client: &github.Client{}, // Dummy client
-		Commits: &github.CommitsService{ // This is not how it works, Commits is not on RepositoriesService this way
-			// This approach is getting complicated due to go-github's structure.
-			// A better way is to define an interface for the *part* of the client we use.
-			// For now, let's assume GetCommit is directly on a client-like interface for simplicity of this mock.
-			// This part of the mock is NOT a good example for go-github.
-			// The actual toCommonCommit takes *github.Client, not a RepositoriesService.
-		},
-	}
-	// The test for toCommonCommit will need to be adapted or the mocking strategy rethought
-	// if we cannot directly mock `client.Repositories.GetCommit`.
-	// For now, I will assume `toCommonCommit` is refactored to take an interface that can be mocked,
-	// or the ghClient passed to it is our mock that directly implements GetCommit if that were possible.
-	// Let's adjust the mock to be more direct for the test's purpose.
-	// The `Repositories` field of `github.Client` provides `RepositoriesService`.
-	// `RepositoriesService` has a `GetCommit` method.
-	// So, the mock needs to mock this chain if we are passing the real `github.Client`.
-	// This is where full mocking libraries shine.
-	// For this test, I'll simplify and assume the GetCommit call is what we need to mock.
-	// The current toCommonCommit takes `ghClient *github.Client`.
-	// So, our mock needs to be a *github.Client that somehow has its `Repositories.GetCommit` mocked.
-	// This is not straightforward without a mocking library or interface wrapping.
-	// I will proceed by creating a dummy github.Client and overriding its behavior
-	// ONLY for the methods that matter in the test, if possible.
-	// This is hard. I will assume for the test that the GetCommit call is made via an interface.
-	// Or, I will make a very specific mock for the GetCommit call.
-	// Let's assume `Repositories.GetCommit` is the target.
-	// The real solution here is to wrap the github client calls in an interface within GitHubRepo itself.
-	// Since I can't change that now, I'll focus on the data mapping part of toCommonCommit.
-	// The GetCommit call inside toCommonCommit is the tricky part to mock without a library.
-	// I will provide a dummy client and focus on testing the mapping logic where GetCommit returns nil or a specific error.
-	// For a successful GetCommit, I'll have to construct a detailed github.Commit.
-// return nil // This part is problematic. // Removing this problematic mock part for now.
-}
-
-
 // --- Mocks for GitHub Client interactions ---
 
 // mockGithubRepositoriesService is a mock for github.RepositoriesService.
@@ -555,3 +510,187 @@ func TestToCommonUser(t *testing.T) {
 		})
 	}
 }
+
+// newTestGitHubClient returns a github.Client whose requests are served by handler.
+func newTestGitHubClient(t *testing.T, handler http.Handler) *github.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	client.BaseURL = baseURL
+	return client
+}
+
+func TestToCommonIssue(t *testing.T) {
+	created := time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC)
+	closed := created.Add(48 * time.Hour)
+
+	result := toCommonIssue(&github.Issue{
+		Number:    Int(42),
+		Title:     String("Crash on start"),
+		State:     String("closed"),
+		User:      &github.User{Login: String("reporter")},
+		Labels:    []*github.Label{{Name: String("bug")}, {Name: String("p1")}},
+		Assignees: []*github.User{{Login: String("dev1")}},
+		HTMLURL:   String("https://github.com/o/r/issues/42"),
+		CreatedAt: &github.Timestamp{Time: created},
+		ClosedAt:  &github.Timestamp{Time: closed},
+	})
+
+	if result.Number != 42 || result.Title != "Crash on start" || result.State != "closed" || result.Author != "reporter" {
+		t.Errorf("toCommonIssue() basic fields mismatch: %+v", result)
+	}
+	if len(result.Labels) != 2 || result.Labels[0] != "bug" || result.Labels[1] != "p1" {
+		t.Errorf("toCommonIssue() labels mismatch: %v", result.Labels)
+	}
+	if len(result.Assignees) != 1 || result.Assignees[0] != "dev1" {
+		t.Errorf("toCommonIssue() assignees mismatch: %v", result.Assignees)
+	}
+	if !result.CreatedAt.Equal(created) || !result.ClosedAt.Equal(closed) {
+		t.Errorf("toCommonIssue() timestamps mismatch: created=%v closed=%v", result.CreatedAt, result.ClosedAt)
+	}
+	if toCommonIssue(nil) != nil {
+		t.Errorf("toCommonIssue(nil) should return nil")
+	}
+}
+
+func TestGitHubRepo_ListIssues_SkipsPullRequestsAndPaginates(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/issues", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("state"); got != "all" {
+			t.Errorf("expected state=all, got %q", got)
+		}
+		switch r.URL.Query().Get("page") {
+		case "", "1":
+			w.Header().Set("Link", `<`+"http://"+r.Host+`/repos/o/r/issues?page=2>; rel="next"`)
+			fmt.Fprint(w, `[{"number":1,"state":"open"},{"number":2,"state":"open","pull_request":{"url":"x"}}]`)
+		case "2":
+			fmt.Fprint(w, `[{"number":3,"state":"closed"}]`)
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	})
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, mux))
+
+	issues, err := ghRepo.ListIssues("o/r", nil)
+	if err != nil {
+		t.Fatalf("ListIssues() returned error: %v", err)
+	}
+	if len(issues) != 2 || issues[0].Number != 1 || issues[1].Number != 3 {
+		t.Errorf("ListIssues() = %+v, want issues 1 and 3", issues)
+	}
+}
+
+func TestToCommonBranch(t *testing.T) {
+	committed := time.Date(2024, 4, 2, 12, 0, 0, 0, time.UTC)
+
+	result := toCommonBranch(&github.Branch{
+		Name:      String("feature/login"),
+		Protected: github.Bool(true),
+		Commit: &github.RepositoryCommit{
+			SHA: String("abc123"),
+			Commit: &github.Commit{
+				Author: &github.CommitAuthor{Name: String("Jane"), Date: &github.Timestamp{Time: committed}},
+			},
+		},
+	})
+
+	if result.Name != "feature/login" || result.LastCommitSHA != "abc123" || result.LastCommitAuthor != "Jane" || !result.Protected {
+		t.Errorf("toCommonBranch() basic fields mismatch: %+v", result)
+	}
+	if !result.LastCommitDate.Equal(committed) {
+		t.Errorf("toCommonBranch() LastCommitDate = %v, want %v", result.LastCommitDate, committed)
+	}
+	if toCommonBranch(nil) != nil {
+		t.Errorf("toCommonBranch(nil) should return nil")
+	}
+}
+
+func TestGitHubRepo_GetProjectCommits_BaseRefUsesCompare(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"r","owner":{"login":"o"},"default_branch":"main"}`)
+	})
+	mux.HandleFunc("/repos/o/r/compare/v1.0...main", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"commits":[{"sha":"old","commit":{"message":"feat: first"}},{"sha":"new","commit":{"message":"fix: second"}}]}`)
+	})
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, mux))
+
+	commits, err := ghRepo.GetProjectCommits("o/r", &interfaces.CommitListOptions{BaseRef: "v1.0"})
+	if err != nil {
+		t.Fatalf("GetProjectCommits() returned error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].SHA != "new" || commits[1].SHA != "old" {
+		t.Errorf("GetProjectCommits() = %+v, want commits new and old, newest first", commits)
+	}
+}
+
+func TestGitHubRepo_CompareRefs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/compare/v1.0...v1.1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"commits":[{"sha":"old","commit":{"message":"feat: first"}},{"sha":"new","commit":{"message":"fix: second"}}],
+			"files":[{"filename":"b.go","previous_filename":"a.go","status":"renamed","additions":2,"deletions":1}]}`)
+	})
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, mux))
+
+	comparison, err := ghRepo.CompareRefs("o/r", "v1.0", "v1.1")
+	if err != nil {
+		t.Fatalf("CompareRefs() returned error: %v", err)
+	}
+	if len(comparison.Commits) != 2 || comparison.Commits[0].SHA != "new" {
+		t.Errorf("CompareRefs() commits = %+v, want new and old, newest first", comparison.Commits)
+	}
+	expectedFile := common_types.FileChange{Filename: "b.go", PreviousFilename: "a.go", Status: "renamed", Additions: 2, Deletions: 1}
+	if len(comparison.Files) != 1 || *comparison.Files[0] != expectedFile {
+		t.Errorf("CompareRefs() files = %+v, want %+v", comparison.Files, expectedFile)
+	}
+}
+
+func TestGitHubRepo_GetProjectCommits_SinceWalksAllPages(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"r","owner":{"login":"o"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/commits", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("since") == "" {
+			t.Error("expected the since query parameter to be set")
+		}
+		switch r.URL.Query().Get("page") {
+		case "", "1":
+			w.Header().Set("Link", `<`+"http://"+r.Host+`/repos/o/r/commits?page=2>; rel="next"`)
+			fmt.Fprint(w, `[{"sha":"b"}]`)
+		case "2":
+			fmt.Fprint(w, `[{"sha":"a"}]`)
+		}
+	})
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, mux))
+
+	commits, err := ghRepo.GetProjectCommits("o/r", &interfaces.CommitListOptions{Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("GetProjectCommits() returned error: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Errorf("GetProjectCommits() returned %d commits, want 2 across both pages", len(commits))
+	}
+}
+
+func TestGitHubRepo_ListLanguages(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/languages", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Shell":250,"Go":750}`)
+	})
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, mux))
+
+	languages, err := ghRepo.ListLanguages("o/r")
+	if err != nil {
+		t.Fatalf("ListLanguages() returned error: %v", err)
+	}
+	expected := []common_types.Language{{Name: "Go", Bytes: 750, Share: 0.75}, {Name: "Shell", Bytes: 250, Share: 0.25}}
+	if len(languages) != len(expected) || *languages[0] != expected[0] || *languages[1] != expected[1] {
+		t.Errorf("ListLanguages() = %+v, want %+v", languages, expected)
+	}
+}
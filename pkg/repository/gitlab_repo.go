@@ -1,10 +1,13 @@
 package repository
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
 	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
 	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
 	"github.com/xanzy/go-gitlab"
@@ -13,7 +16,8 @@ import (
 // Gitlab implements the interfaces.GitService for GitLab.
 // It uses the go-gitlab client to interact with the GitLab API.
 type Gitlab struct {
-	Client *gitlab.Client // Client is the GitLab API client.
+	Client        *gitlab.Client // Client is the GitLab API client.
+	DetailWorkers int            // Concurrent ahead/behind counts of ListBranches; DefaultCommitDetailWorkers when zero.
 }
 
 // NewGitlabClient creates a new Gitlab service instance.
@@ -30,18 +34,39 @@ func NewGitlabClient(gitlabAPIClient *gitlab.Client) (*Gitlab, error) {
 // ConnectGitlab creates a new GitLab API client.
 // token is the GitLab personal access token.
 // host can be a pointer to a string for GitLab self-managed instances, or nil/empty for GitLab.com.
+// Requests go through rateLimits, or through a tracker of their own if rateLimits is nil, and are
+// sent conditionally with the responses etags keeps unless it is nil.
 // This is a helper function for initializing the Gitlab service and is not part of the GitService interface.
-func ConnectGitlab(token string, hostURL *string) (*gitlab.Client, error) {
+func ConnectGitlab(token string, hostURL *string, rateLimits *RateLimitTracker, etags *ETagCache) (*gitlab.Client, error) {
 	var gitlabClient *gitlab.Client
 	var err error
 
+	if rateLimits == nil {
+		rateLimits = NewRateLimitTracker("gitlab")
+	}
+	transport := rateLimits.Transport(nil)
+	if etags != nil {
+		transport = etags.Transport(transport)
+	}
+	options := []gitlab.ClientOptionFunc{
+		gitlab.WithHTTPClient(&http.Client{Transport: transport}),
+		// Rate limits are handled by the tracker, which bounds the wait; go-gitlab would sleep until
+		// RateLimit-Reset, up to five times. Server errors are still retried.
+		gitlab.WithCustomRetry(func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			if err != nil {
+				return false, err
+			}
+			return resp.StatusCode >= http.StatusInternalServerError, nil
+		}),
+	}
 	if hostURL != nil && *hostURL != "" {
 		// Use custom host URL for self-managed GitLab.
-		gitlabClient, err = gitlab.NewClient(token, gitlab.WithBaseURL(*hostURL))
-	} else {
-		// Use default GitLab.com URL.
-		gitlabClient, err = gitlab.NewClient(token)
+		options = append(options, gitlab.WithBaseURL(*hostURL))
 	}
+	gitlabClient, err = gitlab.NewClient(token, options...)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gitlab client: %w", err)
@@ -78,17 +103,19 @@ func toCommonRepositoryGL(glProject *gitlab.Project) *common_types.Repository {
 	}
 
 	return &common_types.Repository{
-		ID:          int64(glProject.ID), // Ensure ID conversion is safe if GitLab IDs can exceed int64 range (unlikely).
-		Name:        glProject.Name,
-		Owner:       ownerLogin,
-		HTMLURL:     glProject.WebURL,
-		CloneURL:    glProject.HTTPURLToRepo, // Or SSHURLToRepo depending on preference.
-		Description: glProject.Description,
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
-		Stars:       glProject.StarCount,
-		Forks:       glProject.ForksCount,
-		OpenIssues:  glProject.OpenIssuesCount,
+		ID:            int64(glProject.ID), // Ensure ID conversion is safe if GitLab IDs can exceed int64 range (unlikely).
+		Name:          glProject.Name,
+		Owner:         ownerLogin,
+		FullName:      glProject.PathWithNamespace,
+		HTMLURL:       glProject.WebURL,
+		CloneURL:      glProject.HTTPURLToRepo, // Or SSHURLToRepo depending on preference.
+		Description:   glProject.Description,
+		CreatedAt:     createdAt,
+		UpdatedAt:     updatedAt,
+		Stars:         glProject.StarCount,
+		Forks:         glProject.ForksCount,
+		OpenIssues:    glProject.OpenIssuesCount,
+		DefaultBranch: glProject.DefaultBranch,
 	}
 }
 
@@ -119,9 +146,10 @@ func toCommonCommitGL(glCommit *gitlab.Commit) *common_types.Commit {
 			Email: glCommit.AuthorEmail,
 			Date:  authoredAt,
 		},
-		Message: glCommit.Message,
-		HTMLURL: glCommit.WebURL, // GitLab Commit has a WebURL.
-		Stats:   commitStats,
+		Message:      glCommit.Message,
+		HTMLURL:      glCommit.WebURL, // GitLab Commit has a WebURL.
+		Stats:        commitStats,
+		Conventional: analytics.ParseConventionalCommit(glCommit.Message),
 	}
 }
 
@@ -140,13 +168,139 @@ func toCommonUserGL(glUser *gitlab.User) *common_types.User {
 	}
 }
 
+// toCommonIssueGL converts a GitLab specific issue object to the common_types.Issue.
+// GitLab reports open issues as "opened"; the state is normalised to "open".
+func toCommonIssueGL(glIssue *gitlab.Issue) *common_types.Issue {
+	if glIssue == nil {
+		return nil
+	}
+
+	state := glIssue.State
+	if state == "opened" {
+		state = interfaces.IssueStateOpen
+	}
+
+	var author string
+	if glIssue.Author != nil {
+		author = glIssue.Author.Username
+	}
+	assignees := make([]string, 0, len(glIssue.Assignees))
+	for _, assignee := range glIssue.Assignees {
+		if assignee != nil {
+			assignees = append(assignees, assignee.Username)
+		}
+	}
+	labels := make([]string, 0, len(glIssue.Labels))
+	labels = append(labels, glIssue.Labels...)
+
+	var createdAt, closedAt time.Time
+	if glIssue.CreatedAt != nil {
+		createdAt = *glIssue.CreatedAt
+	}
+	if glIssue.ClosedAt != nil {
+		closedAt = *glIssue.ClosedAt
+	}
+
+	return &common_types.Issue{
+		Number:    glIssue.IID, // IID is the project-scoped number shown in the UI.
+		Title:     glIssue.Title,
+		State:     state,
+		Author:    author,
+		Labels:    labels,
+		Assignees: assignees,
+		HTMLURL:   glIssue.WebURL,
+		CreatedAt: createdAt,
+		ClosedAt:  closedAt,
+	}
+}
+
+// toCommonBranchGL converts a GitLab specific branch object to the common_types.Branch.
+// GitLab reports the default and merged flags itself; ahead/behind counts are filled in by the caller.
+func toCommonBranchGL(glBranch *gitlab.Branch) *common_types.Branch {
+	if glBranch == nil {
+		return nil
+	}
+	branch := &common_types.Branch{
+		Name:      glBranch.Name,
+		Protected: glBranch.Protected,
+		Default:   glBranch.Default,
+		Merged:    glBranch.Merged,
+	}
+	if glBranch.Commit != nil {
+		branch.LastCommitSHA = glBranch.Commit.ID
+		branch.LastCommitAuthor = glBranch.Commit.AuthorName
+		if glBranch.Commit.AuthoredDate != nil {
+			branch.LastCommitDate = *glBranch.Commit.AuthoredDate
+		}
+	}
+	return branch
+}
+
+// toCommonTagGL converts a GitLab specific tag object to the common_types.Tag.
+func toCommonTagGL(glTag *gitlab.Tag) *common_types.Tag {
+	if glTag == nil {
+		return nil
+	}
+	tag := &common_types.Tag{Name: glTag.Name}
+	if glTag.Commit != nil {
+		tag.CommitSHA = glTag.Commit.ID
+		tag.CommitAuthor = glTag.Commit.AuthorName
+		if glTag.Commit.AuthoredDate != nil {
+			tag.CommitDate = *glTag.Commit.AuthoredDate
+		}
+	}
+	return tag
+}
+
+// toCommonFileChangeGL converts a GitLab specific diff to the common_types.FileChange.
+// GitLab does not report line counts, so they are counted from the unified diff.
+func toCommonFileChangeGL(glDiff *gitlab.Diff) *common_types.FileChange {
+	if glDiff == nil {
+		return nil
+	}
+	fileChange := &common_types.FileChange{Filename: glDiff.NewPath, Status: "modified"}
+	switch {
+	case glDiff.NewFile:
+		fileChange.Status = "added"
+	case glDiff.DeletedFile:
+		fileChange.Status = "removed"
+	case glDiff.RenamedFile:
+		fileChange.Status = "renamed"
+		fileChange.PreviousFilename = glDiff.OldPath
+	}
+	// GitLab diffs start at the first "@@" hunk header, without "---"/"+++" file headers.
+	for _, line := range strings.Split(glDiff.Diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			fileChange.Additions++
+		case strings.HasPrefix(line, "-"):
+			fileChange.Deletions++
+		}
+	}
+	return fileChange
+}
+
+// toProjectID normalises a repository identifier into the int or string project ID go-gitlab expects.
+func toProjectID(repoIdentifier interface{}) (interface{}, error) {
+	switch id := repoIdentifier.(type) {
+	case int:
+		return id, nil
+	case int64: // go-gitlab typically expects int for project ID.
+		return int(id), nil
+	case string:
+		return id, nil
+	default:
+		return nil, fmt.Errorf("unsupported repoIdentifier type: %T (expected int or string)", repoIdentifier)
+	}
+}
+
 // GetAllRepos implements interfaces.GitService.
 // For GitLab, 'ownerLogin' can be a username or a group's path/name.
 // If ownerLogin is empty, it lists projects accessible by the authenticated user (considering membership).
 func (g *Gitlab) GetAllRepos(ownerLogin string) ([]*common_types.Repository, error) {
 	// Options for listing projects. Includes pagination and sorting.
 	listProjectsOptions := &gitlab.ListProjectsOptions{
-		ListOptions: gitlab.ListOptions{PerPage: 100}, // Fetch 100 items per page.
+		ListOptions: gitlab.ListOptions{PerPage: 100},  // Fetch 100 items per page.
 		OrderBy:     gitlab.String("last_activity_at"), // Sort by last activity.
 		Sort:        gitlab.String("desc"),             // Descending order.
 		Membership:  gitlab.Bool(true),                 // Include projects where the user is a member.
@@ -210,7 +364,7 @@ func (g *Gitlab) GetRepo(identifier interface{}) (*common_types.Repository, erro
 func (g *Gitlab) GetProjectCommits(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
 	listCommitsOptions := &gitlab.ListCommitsOptions{
 		ListOptions: gitlab.ListOptions{PerPage: 100}, // Default PerPage.
-		WithStats:   gitlab.Bool(true),                 // Request commit stats.
+		WithStats:   gitlab.Bool(true),                // Request commit stats.
 	}
 
 	if options != nil {
@@ -228,6 +382,25 @@ func (g *Gitlab) GetProjectCommits(repoIdentifier interface{}, options *interfac
 		if options.PerPage > 0 {
 			listCommitsOptions.ListOptions.PerPage = options.PerPage
 		}
+		if options.BaseRef != "" { // GitLab accepts a "base..head" revision range as ref_name.
+			headRef := options.SHA
+			if headRef == "" {
+				headRef = "HEAD"
+			}
+			listCommitsOptions.RefName = gitlab.String(options.BaseRef + ".." + headRef)
+		}
+		if !options.Since.IsZero() {
+			listCommitsOptions.Since = gitlab.Time(options.Since)
+		}
+		if !options.Until.IsZero() {
+			listCommitsOptions.Until = gitlab.Time(options.Until)
+		}
+		if options.WalksAllPages() {
+			listCommitsOptions.ListOptions.Page = 0
+		}
+		if options.NoStats {
+			listCommitsOptions.WithStats = nil
+		}
 	}
 
 	// Ensure repoIdentifier is suitable for ListCommits (int or string).
@@ -243,9 +416,18 @@ func (g *Gitlab) GetProjectCommits(repoIdentifier interface{}, options *interfac
 		return nil, fmt.Errorf("unsupported repoIdentifier type for GetProjectCommits: %T (expected int or string)", repoIdentifier)
 	}
 
-	gitlabCommits, _, err := g.Client.Commits.ListCommits(projectIDForCommits, listCommitsOptions)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list gitlab commits for repo '%v': %w", repoIdentifier, err)
+	var gitlabCommits []*gitlab.Commit
+	for {
+		page, resp, err := g.Client.Commits.ListCommits(projectIDForCommits, listCommitsOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list gitlab commits for repo '%v': %w", repoIdentifier, err)
+		}
+		gitlabCommits = append(gitlabCommits, page...)
+		// Only a commit range is walked to the end; plain listings return a single page.
+		if !options.WalksAllPages() || resp == nil || resp.NextPage == 0 {
+			break
+		}
+		listCommitsOptions.ListOptions.Page = resp.NextPage
 	}
 
 	commonCommits := make([]*common_types.Commit, 0, len(gitlabCommits))
@@ -305,6 +487,225 @@ func (g *Gitlab) GetRepoContributors(repoIdentifier interface{}) ([]*common_type
 	return commonUsers, nil
 }
 
+// ListIssues implements interfaces.GitService.
+// repoIdentifier can be an int (GitLab Project ID) or a string "namespace/project_path".
+func (g *Gitlab) ListIssues(repoIdentifier interface{}, options *interfaces.IssueListOptions) ([]*common_types.Issue, error) {
+	projectID, err := toProjectID(repoIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	listIssuesOptions := &gitlab.ListProjectIssuesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100}, // Default PerPage.
+	}
+	walkAllPages := true
+	if options != nil {
+		switch options.State {
+		case interfaces.IssueStateOpen:
+			listIssuesOptions.State = gitlab.String("opened")
+		case interfaces.IssueStateClosed:
+			listIssuesOptions.State = gitlab.String("closed")
+		}
+		// IssueStateAll (or empty) leaves State unset, which GitLab treats as "all".
+		if len(options.Labels) > 0 {
+			labels := gitlab.Labels(options.Labels)
+			listIssuesOptions.Labels = &labels
+		}
+		if !options.Since.IsZero() {
+			listIssuesOptions.UpdatedAfter = gitlab.Time(options.Since)
+		}
+		if options.Page > 0 {
+			listIssuesOptions.ListOptions.Page = options.Page
+			walkAllPages = false
+		}
+		if options.PerPage > 0 {
+			listIssuesOptions.ListOptions.PerPage = options.PerPage
+		}
+	}
+
+	commonIssues := make([]*common_types.Issue, 0)
+	for {
+		gitlabIssues, resp, err := g.Client.Issues.ListProjectIssues(projectID, listIssuesOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list gitlab issues for repo '%v': %w", repoIdentifier, err)
+		}
+		for _, glIssue := range gitlabIssues {
+			commonIssues = append(commonIssues, toCommonIssueGL(glIssue))
+		}
+		if !walkAllPages || resp == nil || resp.NextPage == 0 {
+			break
+		}
+		listIssuesOptions.ListOptions.Page = resp.NextPage
+	}
+	return commonIssues, nil
+}
+
+// ListBranches implements interfaces.GitService.
+// repoIdentifier can be an int (GitLab Project ID) or a string "namespace/project_path".
+// GitLab has no ahead/behind field, so both are counted with commit listings for every
+// non-default branch, DetailWorkers counts at a time.
+func (g *Gitlab) ListBranches(repoIdentifier interface{}) ([]*common_types.Branch, error) {
+	projectID, err := toProjectID(repoIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	listBranchesOptions := &gitlab.ListBranchesOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	var gitlabBranches []*gitlab.Branch
+	for {
+		page, resp, err := g.Client.Branches.ListBranches(projectID, listBranchesOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list gitlab branches for repo '%v': %w", repoIdentifier, err)
+		}
+		gitlabBranches = append(gitlabBranches, page...)
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		listBranchesOptions.ListOptions.Page = resp.NextPage
+	}
+
+	var defaultBranch string
+	for _, glBranch := range gitlabBranches {
+		if glBranch.Default {
+			defaultBranch = glBranch.Name
+			break
+		}
+	}
+
+	commonBranches := make([]*common_types.Branch, len(gitlabBranches))
+	var pending []int // Two per compared branch: 2i counts Ahead, 2i+1 Behind.
+	for i, glBranch := range gitlabBranches {
+		commonBranches[i] = toCommonBranchGL(glBranch)
+		if !commonBranches[i].Default && defaultBranch != "" {
+			pending = append(pending, 2*i, 2*i+1)
+		}
+	}
+	err = fetchConcurrently(context.Background(), g.DetailWorkers, pending, func(ctx context.Context, n int) error {
+		branch := commonBranches[n/2]
+		if n%2 == 0 {
+			count, err := g.countCommitsBetween(ctx, projectID, defaultBranch, branch.Name)
+			branch.Ahead = count
+			return err
+		}
+		count, err := g.countCommitsBetween(ctx, projectID, branch.Name, defaultBranch)
+		branch.Behind = count
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commonBranches, nil
+}
+
+// CompareRefs implements interfaces.GitService.
+// repoIdentifier can be an int (GitLab Project ID) or a string "namespace/project_path".
+// Commits come from GetProjectCommits so they carry line stats; the Compare diffs only
+// contribute the file list, with line counts taken from the unified diff text.
+func (g *Gitlab) CompareRefs(repoIdentifier interface{}, baseRef, headRef string) (*common_types.Comparison, error) {
+	projectID, err := toProjectID(repoIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := g.GetProjectCommits(projectID, &interfaces.CommitListOptions{SHA: headRef, BaseRef: baseRef})
+	if err != nil {
+		return nil, err
+	}
+	glComparison, _, err := g.Client.Repositories.Compare(projectID, &gitlab.CompareOptions{
+		From: gitlab.String(baseRef),
+		To:   gitlab.String(headRef),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare gitlab refs %s...%s for repo '%v': %w", baseRef, headRef, repoIdentifier, err)
+	}
+
+	comparison := &common_types.Comparison{
+		BaseRef: baseRef,
+		HeadRef: headRef,
+		Commits: commits,
+		Files:   make([]*common_types.FileChange, 0, len(glComparison.Diffs)),
+	}
+	for _, glDiff := range glComparison.Diffs {
+		comparison.Files = append(comparison.Files, toCommonFileChangeGL(glDiff))
+	}
+	return comparison, nil
+}
+
+// countCommitsBetween returns the number of commits reachable from 'to' but not from 'from'.
+// It lists the range instead of comparing the refs: Compare downloads every diff and caps the
+// commits it returns, which under-counts large divergences. The listing's X-Total header is used
+// when GitLab sends it; otherwise every page is counted.
+func (g *Gitlab) countCommitsBetween(ctx context.Context, projectID interface{}, from, to string) (int, error) {
+	listCommitsOptions := &gitlab.ListCommitsOptions{
+		RefName:     gitlab.String(from + ".." + to),
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+	}
+	count := 0
+	for {
+		page, resp, err := g.Client.Commits.ListCommits(projectID, listCommitsOptions, gitlab.WithContext(ctx))
+		if err != nil {
+			return 0, fmt.Errorf("failed to count gitlab commits in %s..%s for repo '%v': %w", from, to, projectID, err)
+		}
+		if resp != nil && resp.TotalItems > 0 {
+			return resp.TotalItems, nil
+		}
+		count += len(page)
+		if resp == nil || resp.NextPage == 0 {
+			return count, nil
+		}
+		listCommitsOptions.ListOptions.Page = resp.NextPage
+	}
+}
+
+// ListTags implements interfaces.GitService.
+// repoIdentifier can be an int (GitLab Project ID) or a string "namespace/project_path".
+func (g *Gitlab) ListTags(repoIdentifier interface{}) ([]*common_types.Tag, error) {
+	projectID, err := toProjectID(repoIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	listTagsOptions := &gitlab.ListTagsOptions{ListOptions: gitlab.ListOptions{PerPage: 100}}
+	commonTags := make([]*common_types.Tag, 0)
+	for {
+		gitlabTags, resp, err := g.Client.Tags.ListTags(projectID, listTagsOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list gitlab tags for repo '%v': %w", repoIdentifier, err)
+		}
+		for _, glTag := range gitlabTags {
+			commonTags = append(commonTags, toCommonTagGL(glTag))
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		listTagsOptions.ListOptions.Page = resp.NextPage
+	}
+	return commonTags, nil
+}
+
+// ListLanguages implements interfaces.GitService.
+// repoIdentifier can be an int (GitLab Project ID) or a string "namespace/project_path".
+// GitLab only reports percentages, so Bytes is always 0.
+func (g *Gitlab) ListLanguages(repoIdentifier interface{}) ([]*common_types.Language, error) {
+	projectID, err := toProjectID(repoIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	gitlabLanguages, _, err := g.Client.Projects.GetProjectLanguages(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gitlab languages for repo '%v': %w", repoIdentifier, err)
+	}
+
+	commonLanguages := make([]*common_types.Language, 0)
+	if gitlabLanguages != nil {
+		for name, percentage := range *gitlabLanguages {
+			commonLanguages = append(commonLanguages, &common_types.Language{Name: name, Share: float64(percentage) / 100})
+		}
+	}
+	sortLanguages(commonLanguages)
+	return commonLanguages, nil
+}
+
 // Ensure Gitlab implements GitService.
 // This line provides a compile-time check that the Gitlab struct
 // correctly implements all methods of the interfaces.GitService interface.
@@ -1,15 +1,14 @@
 package repository
 
 import (
-	"testing"
-	"time"
-
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"strings"
+	"testing"
+	"time"
 
 	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
 	"github.com/xanzy/go-gitlab"
 )
 
@@ -49,7 +48,7 @@ func TestToCommonRepositoryGL(t *testing.T) {
 				Owner: &gitlab.User{
 					Username: "gitlabowner",
 				},
-				Namespace: &gitlab.Namespace{
+				Namespace: &gitlab.ProjectNamespace{
 					Path: "gitlabowner",
 					Name: "GitLab Owner Group",
 				},
@@ -79,7 +78,7 @@ func TestToCommonRepositoryGL(t *testing.T) {
 				ID:   11,
 				Name: "repo-ns-owner",
 				Owner: nil,
-				Namespace: &gitlab.Namespace{Path: "groupname"},
+				Namespace: &gitlab.ProjectNamespace{Path: "groupname"},
 				WebURL:"https://gitlab.com/groupname/repo-ns-owner",
 			},
 			expected: &common_types.Repository{
@@ -292,3 +291,232 @@ func TestToCommonUserGL(t *testing.T) {
 		})
 	}
 }
+
+// newTestGitlabClient returns a gitlab.Client whose requests are served by handler.
+func newTestGitlabClient(t *testing.T, handler http.Handler) *gitlab.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := gitlab.NewClient("test-token", gitlab.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create gitlab client: %v", err)
+	}
+	return client
+}
+
+func TestToCommonIssueGL(t *testing.T) {
+	created := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	result := toCommonIssueGL(&gitlab.Issue{
+		IID:       7,
+		Title:     "Slow pipeline",
+		State:     "opened",
+		Author:    &gitlab.IssueAuthor{Username: "reporter"},
+		Assignees: []*gitlab.IssueAssignee{{Username: "dev1"}, nil},
+		Labels:    gitlab.Labels{"ci"},
+		WebURL:    "https://gitlab.com/g/p/-/issues/7",
+		CreatedAt: &created,
+	})
+
+	if result.Number != 7 || result.State != "open" || result.Author != "reporter" || result.HTMLURL != "https://gitlab.com/g/p/-/issues/7" {
+		t.Errorf("toCommonIssueGL() basic fields mismatch: %+v", result)
+	}
+	if len(result.Assignees) != 1 || result.Assignees[0] != "dev1" || len(result.Labels) != 1 || result.Labels[0] != "ci" {
+		t.Errorf("toCommonIssueGL() labels/assignees mismatch: %+v", result)
+	}
+	if !result.CreatedAt.Equal(created) || !result.ClosedAt.IsZero() {
+		t.Errorf("toCommonIssueGL() timestamps mismatch: %+v", result)
+	}
+	if toCommonIssueGL(nil) != nil {
+		t.Errorf("toCommonIssueGL(nil) should return nil")
+	}
+}
+
+func TestGitlab_ListIssues_MapsStateFilter(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/12/issues", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("state"); got != "opened" {
+			t.Errorf("expected state=opened, got %q", got)
+		}
+		fmt.Fprint(w, `[{"id":100,"iid":1,"state":"opened","labels":["bug"]}]`)
+	})
+	glRepo, _ := NewGitlabClient(newTestGitlabClient(t, mux))
+
+	issues, err := glRepo.ListIssues(int64(12), &interfaces.IssueListOptions{State: interfaces.IssueStateOpen})
+	if err != nil {
+		t.Fatalf("ListIssues() returned error: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Number != 1 || issues[0].State != "open" {
+		t.Errorf("ListIssues() = %+v, want a single open issue", issues)
+	}
+}
+
+func TestToCommonBranchAndTagGL(t *testing.T) {
+	authored := time.Date(2024, 4, 2, 12, 0, 0, 0, time.UTC)
+	commit := &gitlab.Commit{ID: "def456", AuthorName: "Jane", AuthoredDate: &authored}
+
+	branch := toCommonBranchGL(&gitlab.Branch{Name: "main", Default: true, Protected: true, Commit: commit})
+	if branch.Name != "main" || !branch.Default || !branch.Protected || branch.LastCommitSHA != "def456" ||
+		branch.LastCommitAuthor != "Jane" || !branch.LastCommitDate.Equal(authored) {
+		t.Errorf("toCommonBranchGL() mismatch: %+v", branch)
+	}
+
+	tag := toCommonTagGL(&gitlab.Tag{Name: "v1.0.0", Commit: commit})
+	if tag.Name != "v1.0.0" || tag.CommitSHA != "def456" || tag.CommitAuthor != "Jane" || !tag.CommitDate.Equal(authored) {
+		t.Errorf("toCommonTagGL() mismatch: %+v", tag)
+	}
+
+	if toCommonBranchGL(nil) != nil || toCommonTagGL(nil) != nil {
+		t.Errorf("toCommonBranchGL(nil) and toCommonTagGL(nil) should return nil")
+	}
+}
+
+func TestGitlab_ListBranches_CountsAheadAndBehind(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/12/repository/branches", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"main","default":true},{"name":"feature","merged":false}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/12/repository/commits", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("ref_name") {
+		case "main..feature": // Commits on feature that main lacks, counted page by page.
+			if r.URL.Query().Get("page") == "2" {
+				fmt.Fprint(w, `[{"id":"c"}]`)
+				return
+			}
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"id":"a"},{"id":"b"}]`)
+		case "feature..main": // Commits on main that feature lacks, counted by X-Total.
+			w.Header().Set("X-Total", "250")
+			fmt.Fprint(w, `[{"id":"d"}]`)
+		default:
+			t.Errorf("unexpected range %q", r.URL.Query().Get("ref_name"))
+			fmt.Fprint(w, `[]`)
+		}
+	})
+	glRepo, _ := NewGitlabClient(newTestGitlabClient(t, mux))
+	glRepo.DetailWorkers = 2
+
+	branches, err := glRepo.ListBranches(12)
+	if err != nil {
+		t.Fatalf("ListBranches() returned error: %v", err)
+	}
+	if len(branches) != 2 {
+		t.Fatalf("ListBranches() returned %d branches, want 2", len(branches))
+	}
+	if branches[0].Ahead != 0 || branches[0].Behind != 0 {
+		t.Errorf("default branch should not be compared: %+v", branches[0])
+	}
+	if branches[1].Ahead != 3 || branches[1].Behind != 250 {
+		t.Errorf("feature branch ahead/behind = %d/%d, want 3/250", branches[1].Ahead, branches[1].Behind)
+	}
+}
+
+func TestToCommonCommitGL_ParsesConventionalMessage(t *testing.T) {
+	result := toCommonCommitGL(&gitlab.Commit{ID: "abc", Message: "feat(cli)!: add branches command\n\nCloses #9"})
+	parsed := result.Conventional
+	if parsed == nil || !parsed.Conforms || parsed.Type != "feat" || parsed.Scope != "cli" || !parsed.Breaking {
+		t.Fatalf("toCommonCommitGL() Conventional = %+v, want a breaking feat(cli) commit", parsed)
+	}
+	if len(parsed.IssueKeys) != 1 || parsed.IssueKeys[0] != "#9" {
+		t.Errorf("toCommonCommitGL() IssueKeys = %v, want [#9]", parsed.IssueKeys)
+	}
+}
+
+func TestGitlab_GetProjectCommits_BaseRefWalksRange(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/12/repository/commits", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("ref_name"); got != "v1.0..v1.1" {
+			t.Errorf("expected ref_name=v1.0..v1.1, got %q", got)
+		}
+		switch r.URL.Query().Get("page") {
+		case "", "1":
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"id":"b","message":"fix: second"}]`)
+		case "2":
+			fmt.Fprint(w, `[{"id":"a","message":"feat: first"}]`)
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	})
+	glRepo, _ := NewGitlabClient(newTestGitlabClient(t, mux))
+
+	commits, err := glRepo.GetProjectCommits(12, &interfaces.CommitListOptions{SHA: "v1.1", BaseRef: "v1.0"})
+	if err != nil {
+		t.Fatalf("GetProjectCommits() returned error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].SHA != "b" || commits[1].SHA != "a" {
+		t.Errorf("GetProjectCommits() = %+v, want commits b and a", commits)
+	}
+}
+
+func TestToCommonFileChangeGL(t *testing.T) {
+	tests := []struct {
+		name     string
+		diff     *gitlab.Diff
+		expected common_types.FileChange
+	}{
+		{
+			name:     "modified",
+			diff:     &gitlab.Diff{OldPath: "a.go", NewPath: "a.go", Diff: "@@ -1,2 +1,2 @@\n-old\n+new\n+more\n context\n"},
+			expected: common_types.FileChange{Filename: "a.go", Status: "modified", Additions: 2, Deletions: 1},
+		},
+		{
+			name:     "renamed",
+			diff:     &gitlab.Diff{OldPath: "old.go", NewPath: "new.go", RenamedFile: true},
+			expected: common_types.FileChange{Filename: "new.go", PreviousFilename: "old.go", Status: "renamed"},
+		},
+		{
+			name:     "deleted",
+			diff:     &gitlab.Diff{OldPath: "gone.go", NewPath: "gone.go", DeletedFile: true, Diff: "@@ -1 +0,0 @@\n-bye\n"},
+			expected: common_types.FileChange{Filename: "gone.go", Status: "removed", Deletions: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toCommonFileChangeGL(tt.diff); *got != tt.expected {
+				t.Errorf("toCommonFileChangeGL() = %+v, want %+v", *got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGitlab_CompareRefs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/12/repository/commits", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("ref_name"); got != "v1.0..v1.1" {
+			t.Errorf("expected ref_name=v1.0..v1.1, got %q", got)
+		}
+		fmt.Fprint(w, `[{"id":"b","author_name":"Jane","stats":{"additions":3,"deletions":1,"total":4}}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/12/repository/compare", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"commits":[{"id":"b"}],"diffs":[{"old_path":"a.go","new_path":"a.go","diff":"@@ -1 +1,3 @@\n-x\n+y\n+z\n+w\n"}]}`)
+	})
+	glRepo, _ := NewGitlabClient(newTestGitlabClient(t, mux))
+
+	comparison, err := glRepo.CompareRefs(12, "v1.0", "v1.1")
+	if err != nil {
+		t.Fatalf("CompareRefs() returned error: %v", err)
+	}
+	if len(comparison.Commits) != 1 || comparison.Commits[0].Stats.Additions != 3 {
+		t.Errorf("CompareRefs() commits = %+v, want one commit with stats", comparison.Commits)
+	}
+	if len(comparison.Files) != 1 || comparison.Files[0].Additions != 3 || comparison.Files[0].Deletions != 1 {
+		t.Errorf("CompareRefs() files = %+v, want a.go +3/-1", comparison.Files)
+	}
+}
+
+func TestGitlab_ListLanguages(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/12/languages", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Ruby":20.0,"Go":80.0}`)
+	})
+	glRepo, _ := NewGitlabClient(newTestGitlabClient(t, mux))
+
+	languages, err := glRepo.ListLanguages(12)
+	if err != nil {
+		t.Fatalf("ListLanguages() returned error: %v", err)
+	}
+	if len(languages) != 2 || languages[0].Name != "Go" || languages[0].Share != 0.8 || languages[0].Bytes != 0 || languages[1].Name != "Ruby" {
+		t.Errorf("ListLanguages() = %+v, want Go 0.8 then Ruby 0.2 without byte counts", languages)
+	}
+}
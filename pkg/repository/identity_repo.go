@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// identityResolvingService wraps a GitService and maps commit authors onto their canonical
+// identity, so aliases are merged in every statistic built from its commits.
+type identityResolvingService struct {
+	interfaces.GitService
+	resolver *analytics.IdentityResolver
+}
+
+// WithIdentityAliases returns gitService with the commit authors it returns resolved through
+// resolver. gitService is returned unchanged when resolver is nil.
+func WithIdentityAliases(gitService interfaces.GitService, resolver *analytics.IdentityResolver) interfaces.GitService {
+	if resolver == nil {
+		return gitService
+	}
+	return &identityResolvingService{GitService: gitService, resolver: resolver}
+}
+
+// GetProjectCommits implements interfaces.GitService.
+func (s *identityResolvingService) GetProjectCommits(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+	commits, err := s.GitService.GetProjectCommits(repoIdentifier, options)
+	if err != nil {
+		return nil, err
+	}
+	s.resolver.ResolveCommits(commits)
+	return commits, nil
+}
+
+// CompareRefs implements interfaces.GitService.
+func (s *identityResolvingService) CompareRefs(repoIdentifier interface{}, baseRef, headRef string) (*common_types.Comparison, error) {
+	comparison, err := s.GitService.CompareRefs(repoIdentifier, baseRef, headRef)
+	if err != nil {
+		return nil, err
+	}
+	if comparison != nil {
+		s.resolver.ResolveCommits(comparison.Commits)
+	}
+	return comparison, nil
+}
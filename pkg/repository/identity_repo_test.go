@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/ahmetk3436/git-stats-golang/pkg/analytics"
+	"github.com/ahmetk3436/git-stats-golang/pkg/common_types"
+	"github.com/ahmetk3436/git-stats-golang/pkg/interfaces"
+)
+
+// commitsOnlyService returns canned commits and comparisons.
+type commitsOnlyService struct {
+	interfaces.GitService
+	commits []*common_types.Commit
+}
+
+func (s *commitsOnlyService) GetProjectCommits(repoIdentifier interface{}, options *interfaces.CommitListOptions) ([]*common_types.Commit, error) {
+	return s.commits, nil
+}
+
+func (s *commitsOnlyService) CompareRefs(repoIdentifier interface{}, baseRef, headRef string) (*common_types.Comparison, error) {
+	return &common_types.Comparison{Commits: s.commits}, nil
+}
+
+func TestWithIdentityAliases(t *testing.T) {
+	resolver := analytics.NewIdentityResolver([]analytics.IdentityAlias{{Name: "Jane", Email: "jane@example.com", Aliases: []string{"jane@laptop"}}})
+	inner := &commitsOnlyService{}
+	service := WithIdentityAliases(inner, resolver)
+
+	inner.commits = []*common_types.Commit{{Author: common_types.CommitAuthor{Name: "jd", Email: "jane@laptop"}}}
+	commits, err := service.GetProjectCommits("o/r", nil)
+	if err != nil || commits[0].Author.Email != "jane@example.com" || commits[0].Author.Name != "Jane" {
+		t.Errorf("GetProjectCommits() = %+v, %v; want the canonical author", commits[0].Author, err)
+	}
+
+	inner.commits = []*common_types.Commit{{Author: common_types.CommitAuthor{Name: "jd", Email: "jane@laptop"}}}
+	comparison, err := service.CompareRefs("o/r", "v1", "v2")
+	if err != nil || comparison.Commits[0].Author.Email != "jane@example.com" {
+		t.Errorf("CompareRefs() = %+v, %v; want the canonical author", comparison.Commits[0].Author, err)
+	}
+
+	if WithIdentityAliases(inner, nil) != interfaces.GitService(inner) {
+		t.Error("WithIdentityAliases(nil) should return the service unchanged")
+	}
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	appMetrics "github.com/ahmetk3436/git-stats-golang/pkg/prometheus"
+	"github.com/google/go-github/v56/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// DefaultRateLimitMaxWait is how long a request waits at most for a provider rate limit to
+// reset before it fails with a RateLimitError.
+const DefaultRateLimitMaxWait = 30 * time.Second
+
+// defaultRateLimitResource names the rate limit of providers that report only one, like GitLab.
+const defaultRateLimitResource = "default"
+
+// maxRateLimitRetries is how often one request is retried after backing off.
+const maxRateLimitRetries = 3
+
+// RateLimit is the state of one provider rate limit, as reported by the last response.
+type RateLimit struct {
+	Provider  string    // "github" or "gitlab".
+	Resource  string    // GitHub resource such as "core", "search" or "graphql"; "default" for GitLab.
+	Limit     int       // Requests allowed per window.
+	Remaining int       // Requests left in the current window.
+	Reset     time.Time // When the current window ends.
+}
+
+// RateLimitError is returned instead of sending a request while the provider rate limit is
+// exhausted and resets later than the tracker is willing to wait, or when an operation needs
+// more requests than the quota has left.
+type RateLimitError struct {
+	Provider string
+	Resource string
+	Reset    time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s %s rate limit exhausted until %s", e.Provider, e.Resource, e.Reset.UTC().Format(time.RFC3339))
+}
+
+// RetryAfter returns how long until the rate limit resets.
+func (e *RateLimitError) RetryAfter() time.Duration {
+	return time.Until(e.Reset)
+}
+
+// RateLimitTracker records the rate limits reported in provider response headers and keeps
+// requests within them: it waits out short limits, retries requests rejected by a secondary
+// limit and fails requests fast while the quota is exhausted. It is safe for concurrent use.
+// Its Transport must sit below the provider client.
+type RateLimitTracker struct {
+	Provider string        // "github" or "gitlab".
+	MaxWait  time.Duration // Longest wait before a request fails with RateLimitError.
+	Metrics  bool          // Whether to publish the limits as Prometheus gauges; set for the server token only.
+
+	mu     sync.Mutex
+	limits map[string]RateLimit // By resource.
+
+	now   func() time.Time                                 // Replaced by tests.
+	sleep func(ctx context.Context, d time.Duration) error // Replaced by tests.
+}
+
+// NewRateLimitTracker returns a tracker for provider that waits at most DefaultRateLimitMaxWait.
+func NewRateLimitTracker(provider string) *RateLimitTracker {
+	return &RateLimitTracker{Provider: provider, MaxWait: DefaultRateLimitMaxWait, limits: map[string]RateLimit{}, now: time.Now, sleep: sleepContext}
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Limits returns the last known state of every rate limit, ordered by resource.
+func (t *RateLimitTracker) Limits() []RateLimit {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limits := make([]RateLimit, 0, len(t.limits))
+	for _, limit := range t.limits {
+		limits = append(limits, limit)
+	}
+	sort.Slice(limits, func(i, j int) bool { return limits[i].Resource < limits[j].Resource })
+	return limits
+}
+
+// observe records the rate limit headers of resp and returns the limit they describe.
+func (t *RateLimitTracker) observe(resp *http.Response) (RateLimit, bool) {
+	limit := RateLimit{Provider: t.Provider, Resource: resp.Header.Get("X-RateLimit-Resource")}
+	if limit.Resource == "" {
+		limit.Resource = resourceOf(t.Provider, resp.Request)
+	}
+	var ok bool
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} { // GitHub, GitLab.
+		if limit.Remaining, ok = headerInt(resp.Header, prefix+"Remaining"); ok {
+			limit.Limit, _ = headerInt(resp.Header, prefix+"Limit")
+			reset, _ := headerInt(resp.Header, prefix+"Reset")
+			limit.Reset = time.Unix(int64(reset), 0)
+			break
+		}
+	}
+	if !ok {
+		return RateLimit{}, false
+	}
+
+	t.mu.Lock()
+	t.limits[limit.Resource] = limit
+	t.mu.Unlock()
+	if t.Metrics {
+		appMetrics.ProviderRateLimitLimit.WithLabelValues(t.Provider, limit.Resource).Set(float64(limit.Limit))
+		appMetrics.ProviderRateLimitRemaining.WithLabelValues(t.Provider, limit.Resource).Set(float64(limit.Remaining))
+		appMetrics.ProviderRateLimitReset.WithLabelValues(t.Provider, limit.Resource).Set(float64(limit.Reset.Unix()))
+	}
+	return limit, true
+}
+
+// exhaustedUntil returns when the quota of resource resets if it is known to be used up.
+func (t *RateLimitTracker) exhaustedUntil(resource string) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limit, known := t.limits[resource]
+	if !known || limit.Remaining > 0 || !limit.Reset.After(t.now()) {
+		return time.Time{}, false
+	}
+	return limit.Reset, true
+}
+
+// retryAfter returns how long to wait before retrying the request of resp, if resp was
+// rejected by a rate limit: a 429, or a GitHub 403 that sets Retry-After (secondary limit) or
+// reports no remaining requests (primary limit).
+func (t *RateLimitTracker) retryAfter(resp *http.Response, limit RateLimit, observed bool) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusForbidden {
+		return 0, false
+	}
+	if seconds, ok := headerInt(resp.Header, "Retry-After"); ok {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if observed && limit.Remaining == 0 {
+		return limit.Reset.Sub(t.now()), true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return time.Second, true // No hint; back off briefly.
+	}
+	return 0, false
+}
+
+// Transport returns an http.RoundTripper that sends requests through base and applies the
+// rate limits t tracks.
+func (t *RateLimitTracker) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitTransport{tracker: t, base: base}
+}
+
+// rateLimitTransport is the http.RoundTripper of RateLimitTracker.Transport.
+type rateLimitTransport struct {
+	tracker *RateLimitTracker
+	base    http.RoundTripper
+}
+
+func (rt *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t := rt.tracker
+	resource := resourceOf(t.Provider, req)
+	if reset, exhausted := t.exhaustedUntil(resource); exhausted {
+		if err := rt.wait(req.Context(), reset.Sub(t.now()), resource, reset); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := rt.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		limit, observed := t.observe(resp)
+		wait, limited := t.retryAfter(resp, limit, observed)
+		replayable := req.Body == nil || req.Body == http.NoBody
+		if !limited || !replayable || attempt >= maxRateLimitRetries || wait > t.MaxWait {
+			if limited {
+				appMetrics.ProviderRateLimitedTotal.WithLabelValues(t.Provider, "rejected").Inc()
+			}
+			return resp, nil // The client turns the response into its own rate limit error.
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		appMetrics.ProviderRateLimitedTotal.WithLabelValues(t.Provider, "waited").Inc()
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait blocks for d if that is within MaxWait, and fails with a RateLimitError otherwise.
+func (rt *rateLimitTransport) wait(ctx context.Context, d time.Duration, resource string, reset time.Time) error {
+	t := rt.tracker
+	if d > t.MaxWait {
+		appMetrics.ProviderRateLimitedTotal.WithLabelValues(t.Provider, "rejected").Inc()
+		return &RateLimitError{Provider: t.Provider, Resource: resource, Reset: reset}
+	}
+	appMetrics.ProviderRateLimitedTotal.WithLabelValues(t.Provider, "waited").Inc()
+	return t.sleep(ctx, d)
+}
+
+// resourceOf returns the rate limit resource req counts against, before the response says so.
+func resourceOf(provider string, req *http.Request) string {
+	if provider != "github" || req == nil {
+		return defaultRateLimitResource
+	}
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/search/") || strings.Contains(req.URL.Path, "/api/v3/search/"):
+		return "search"
+	case strings.HasSuffix(req.URL.Path, "/graphql"):
+		return "graphql"
+	default:
+		return "core"
+	}
+}
+
+// isRateLimitError reports whether err, possibly wrapped, means a provider rate limit is exhausted.
+func isRateLimitError(err error) bool {
+	var trackerErr *RateLimitError
+	var githubRateLimit *github.RateLimitError
+	var githubAbuseLimit *github.AbuseRateLimitError
+	var gitlabResponse *gitlab.ErrorResponse
+	var graphQLErr *GraphQLError
+	return errors.As(err, &trackerErr) || errors.As(err, &githubRateLimit) || errors.As(err, &githubAbuseLimit) ||
+		(errors.As(err, &gitlabResponse) && gitlabResponse.Response != nil && gitlabResponse.Response.StatusCode == http.StatusTooManyRequests) ||
+		(errors.As(err, &graphQLErr) && graphQLErr.Type == "RATE_LIMITED")
+}
+
+// headerInt parses the integer header name.
+func headerInt(header http.Header, name string) (int, bool) {
+	value, err := strconv.Atoi(strings.TrimSpace(header.Get(name)))
+	return value, err == nil
+}
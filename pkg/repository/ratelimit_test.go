@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v56/github"
+)
+
+// newTrackedGitHubClient returns a GitHub client for a test server running handler whose
+// requests go through tracker. Sleeps are recorded in the returned slice instead of waited.
+func newTrackedGitHubClient(t *testing.T, tracker *RateLimitTracker, handler http.Handler) (*github.Client, *[]time.Duration) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	var slept []time.Duration
+	tracker.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	client := github.NewClient(&http.Client{Transport: tracker.Transport(nil)})
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	client.BaseURL = baseURL
+	return client, &slept
+}
+
+func TestRateLimitTracker_ObservesHeaders(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name     string
+		provider string
+		headers  map[string]string
+		path     string
+		expected RateLimit
+	}{
+		{
+			name:     "GitHub",
+			provider: "github",
+			headers:  map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "4999", "X-RateLimit-Reset": strconv.FormatInt(reset.Unix(), 10), "X-RateLimit-Resource": "core"},
+			path:     "/repos/o/r",
+			expected: RateLimit{Provider: "github", Resource: "core", Limit: 5000, Remaining: 4999, Reset: reset},
+		},
+		{
+			name:     "GitHub search without resource header",
+			provider: "github",
+			headers:  map[string]string{"X-RateLimit-Limit": "30", "X-RateLimit-Remaining": "12", "X-RateLimit-Reset": strconv.FormatInt(reset.Unix(), 10)},
+			path:     "/search/commits",
+			expected: RateLimit{Provider: "github", Resource: "search", Limit: 30, Remaining: 12, Reset: reset},
+		},
+		{
+			name:     "GitLab",
+			provider: "gitlab",
+			headers:  map[string]string{"RateLimit-Limit": "2000", "RateLimit-Remaining": "1500", "RateLimit-Reset": strconv.FormatInt(reset.Unix(), 10)},
+			path:     "/api/v4/projects/1",
+			expected: RateLimit{Provider: "gitlab", Resource: "default", Limit: 2000, Remaining: 1500, Reset: reset},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for name, value := range tt.headers {
+					w.Header().Set(name, value)
+				}
+			}))
+			defer server.Close()
+			tracker := NewRateLimitTracker(tt.provider)
+			client := &http.Client{Transport: tracker.Transport(nil)}
+
+			resp, err := client.Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("request returned error: %v", err)
+			}
+			resp.Body.Close()
+
+			limits := tracker.Limits()
+			if len(limits) != 1 || limits[0] != tt.expected {
+				t.Errorf("Limits() = %+v, want [%+v]", limits, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRateLimitTracker_RetriesSecondaryLimit(t *testing.T) {
+	calls := 0
+	tracker := NewRateLimitTracker("github")
+	client, slept := newTrackedGitHubClient(t, tracker, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit."}`)
+			return
+		}
+		fmt.Fprint(w, `{"name":"r","owner":{"login":"o"}}`)
+	}))
+
+	repo, _, err := client.Repositories.Get(context.Background(), "o", "r")
+	if err != nil {
+		t.Fatalf("Get() returned error: %v", err)
+	}
+	if repo.GetName() != "r" || calls != 2 {
+		t.Errorf("expected the request to succeed on the second call, got %d calls", calls)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 2*time.Second {
+		t.Errorf("expected one 2s back-off, slept %v", *slept)
+	}
+}
+
+func TestRateLimitTracker_GivesUpOnLongSecondaryLimit(t *testing.T) {
+	tracker := NewRateLimitTracker("github")
+	client, slept := newTrackedGitHubClient(t, tracker, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit.","documentation_url":"https://docs.github.com/rest/overview/resources-in-the-rest-api#secondary-rate-limits"}`)
+	}))
+
+	_, _, err := client.Repositories.Get(context.Background(), "o", "r")
+	var abuseErr *github.AbuseRateLimitError
+	if !errors.As(err, &abuseErr) || abuseErr.GetRetryAfter() != 120*time.Second {
+		t.Fatalf("expected an AbuseRateLimitError with Retry-After 120s, got %v", err)
+	}
+	if len(*slept) != 0 {
+		t.Errorf("expected no back-off beyond MaxWait, slept %v", *slept)
+	}
+}
+
+func TestRateLimitTracker_FailsFastWhenExhausted(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("RateLimit-Limit", "2000")
+		w.Header().Set("RateLimit-Remaining", "0")
+		w.Header().Set("RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: NewRateLimitTracker("gitlab").Transport(nil)}
+
+	resp, err := client.Get(server.URL + "/api/v4/projects/1")
+	if err != nil {
+		t.Fatalf("first request returned error: %v", err)
+	}
+	resp.Body.Close()
+	_, err = client.Get(server.URL + "/api/v4/projects/1")
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) || rateLimitErr.Provider != "gitlab" {
+		t.Fatalf("expected a RateLimitError for gitlab, got %v", err)
+	}
+	if rateLimitErr.RetryAfter() <= 59*time.Minute {
+		t.Errorf("RetryAfter() = %v, want about an hour", rateLimitErr.RetryAfter())
+	}
+	if calls != 1 {
+		t.Errorf("expected the exhausted request not to reach the provider, got %d calls", calls)
+	}
+}
+
+func TestGitHubRepo_GetProjectCommits_ChecksRateBudget(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"r","owner":{"login":"o"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/commits", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "2")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		fmt.Fprint(w, `[{"sha":"c"},{"sha":"b"},{"sha":"a"}]`)
+	})
+	mux.HandleFunc("/repos/o/r/commits/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("expected no per-commit request, got %s", r.URL.Path)
+	})
+	ghRepo, _ := NewGithubRepo(newTestGitHubClient(t, mux))
+
+	_, err := ghRepo.GetProjectCommits("o/r", nil)
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected a RateLimitError for 3 commits with 2 requests left, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+// Package schedule runs tasks periodically, at times given by cron expressions, and records the
+// outcome and duration of every run.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spec tells when a task runs next.
+type Spec interface {
+	// Next returns the first run time strictly after after.
+	Next(after time.Time) time.Time
+}
+
+// descriptors are the cron shorthands Parse accepts besides "@every <duration>".
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a five-field cron expression (minute, hour, day of month, month, day of week),
+// a shorthand such as "@daily", or "@every <duration>" such as "@every 30m". Fields accept
+// "*", values, ranges "a-b", lists "a,b" and steps "*/n" or "a-b/n"; months and weekdays also
+// take three-letter names, and Sunday is 0 or 7. As in Vixie cron, when both day of month and day
+// of week are restricted, a day matching either runs the task.
+func Parse(expression string) (Spec, error) {
+	expression = strings.TrimSpace(expression)
+	if interval, ok := strings.CutPrefix(expression, "@every "); ok {
+		duration, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil || duration < time.Minute {
+			return nil, fmt.Errorf("invalid interval in %q: must be a duration of at least 1m", expression)
+		}
+		return everySpec{interval: duration}, nil
+	}
+	if strings.HasPrefix(expression, "@") {
+		fields, ok := descriptors[expression]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron shorthand %q", expression)
+		}
+		expression = fields
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expression, len(fields))
+	}
+	var spec cronSpec
+	var err error
+	if spec.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute of %q: %w", expression, err)
+	}
+	if spec.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour of %q: %w", expression, err)
+	}
+	if spec.dayOfMonth, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month of %q: %w", expression, err)
+	}
+	if spec.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("month of %q: %w", expression, err)
+	}
+	if spec.dayOfWeek, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("day of week of %q: %w", expression, err)
+	}
+	if spec.dayOfWeek&(1<<7) != 0 {
+		spec.dayOfWeek |= 1 // 7 is Sunday too.
+	}
+	spec.anyDayOfMonth = fields[2] == "*"
+	spec.anyDayOfWeek = fields[4] == "*"
+	return spec, nil
+}
+
+var (
+	monthNames = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	dayNames   = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// parseField returns the values field selects between min and max as a bit set.
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		valueRange, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+		}
+
+		low, high := min, max
+		if valueRange != "*" {
+			lowText, highText, isRange := strings.Cut(valueRange, "-")
+			var err error
+			if low, err = fieldValue(lowText, names); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = fieldValue(highText, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = max // "a/n" steps from a to the end.
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// fieldValue parses a number or one of names.
+func fieldValue(text string, names map[string]int) (int, error) {
+	if value, ok := names[strings.ToLower(text)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", text)
+	}
+	return value, nil
+}
+
+// cronSpec is a parsed cron expression; every field is a bit set of the values it matches.
+type cronSpec struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+	anyDayOfMonth, anyDayOfWeek                bool
+}
+
+// Next returns the first minute after after that the expression matches, in the location of
+// after. An expression that never matches, such as "0 0 31 2 *", yields the zero time.
+func (s cronSpec) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether the day of t matches the day of month and day of week fields.
+func (s cronSpec) matchesDay(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.anyDayOfMonth || s.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// everySpec runs a task at a fixed interval.
+type everySpec struct {
+	interval time.Duration
+}
+
+func (s everySpec) Next(after time.Time) time.Time {
+	return after.Add(s.interval)
+}
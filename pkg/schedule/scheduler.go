@@ -0,0 +1,124 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	appMetrics "github.com/ahmetk3436/git-stats-golang/pkg/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// Task is the work of a scheduled entry. The context is canceled when the scheduler stops.
+type Task func(ctx context.Context) error
+
+// Run records one run of a scheduled task.
+type Run struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	Err       error // Nil when the run succeeded.
+}
+
+// Scheduler runs tasks at the times of their Spec. Runs of the same task never overlap: a run
+// that takes longer than the interval delays the next one instead.
+type Scheduler struct {
+	mu       sync.Mutex
+	entries  []entry
+	lastRuns map[string]Run
+
+	now func() time.Time // Stubbed by tests.
+}
+
+// entry is a task added to a Scheduler.
+type entry struct {
+	name string
+	spec Spec
+	task Task
+}
+
+// New returns an empty Scheduler.
+func New() *Scheduler {
+	return &Scheduler{lastRuns: map[string]Run{}, now: time.Now}
+}
+
+// Add schedules task under name, which labels its metrics and log lines. Tasks added after Run
+// started are not run.
+func (s *Scheduler) Add(name string, spec Spec, task Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry{name: name, spec: spec, task: task})
+}
+
+// LastRun returns the last finished run of the task named name.
+func (s *Scheduler) LastRun(name string) (Run, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.lastRuns[name]
+	return run, ok
+}
+
+// Run runs the scheduled tasks until ctx is canceled and returns once all of them stopped.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	entries := append([]entry(nil), s.entries...)
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, e := range entries {
+		wg.Add(1)
+		go func(e entry) {
+			defer wg.Done()
+			s.loop(ctx, e)
+		}(e)
+	}
+	wg.Wait()
+}
+
+// loop runs e at every time of its spec until ctx is canceled.
+func (s *Scheduler) loop(ctx context.Context, e entry) {
+	logCtx := logrus.WithField("schedule", e.name)
+	for {
+		next := e.spec.Next(s.now().UTC())
+		if next.IsZero() {
+			logCtx.Warn("Schedule never fires again; stopping it.")
+			return
+		}
+		appMetrics.ScheduledRunNext.WithLabelValues(e.name).Set(float64(next.Unix()))
+		timer := time.NewTimer(next.Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.run(ctx, e)
+	}
+}
+
+// run runs e once and records the outcome.
+func (s *Scheduler) run(ctx context.Context, e entry) {
+	logCtx := logrus.WithField("schedule", e.name)
+	logCtx.Info("Scheduled run started.")
+	run := Run{StartedAt: s.now()}
+	run.Err = e.task(ctx)
+	run.Duration = s.now().Sub(run.StartedAt)
+
+	s.mu.Lock()
+	s.lastRuns[e.name] = run
+	s.mu.Unlock()
+
+	status := "success"
+	if run.Err != nil {
+		status = "failure"
+	}
+	appMetrics.ScheduledRunsTotal.WithLabelValues(e.name, status).Inc()
+	appMetrics.ScheduledRunDuration.WithLabelValues(e.name).Observe(run.Duration.Seconds())
+	appMetrics.ScheduledRunLast.WithLabelValues(e.name, status).Set(float64(run.StartedAt.Unix()))
+
+	logCtx = logCtx.WithField("duration", run.Duration)
+	if run.Err != nil {
+		logCtx.WithField("error", run.Err).Error("Scheduled run failed.")
+		return
+	}
+	logCtx.Info("Scheduled run succeeded.")
+}
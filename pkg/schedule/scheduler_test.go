@@ -0,0 +1,80 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tick fires every interval.
+type tick time.Duration
+
+func (s tick) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(s))
+}
+
+func TestScheduler_RunsTasksAndRecordsOutcome(t *testing.T) {
+	var okRuns, failedRuns int32
+	s := New()
+	s.Add("ok", tick(5*time.Millisecond), func(ctx context.Context) error {
+		atomic.AddInt32(&okRuns, 1)
+		return nil
+	})
+	s.Add("broken", tick(5*time.Millisecond), func(ctx context.Context) error {
+		atomic.AddInt32(&failedRuns, 1)
+		return errors.New("provider unavailable")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&okRuns) < 2 || atomic.LoadInt32(&failedRuns) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("tasks did not run twice: ok %d, broken %d", okRuns, failedRuns)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the context was canceled")
+	}
+
+	if run, ok := s.LastRun("ok"); !ok || run.Err != nil || run.StartedAt.IsZero() {
+		t.Errorf("unexpected last run of ok: %+v, %v", run, ok)
+	}
+	if run, ok := s.LastRun("broken"); !ok || run.Err == nil || run.Err.Error() != "provider unavailable" {
+		t.Errorf("unexpected last run of broken: %+v, %v", run, ok)
+	}
+	if _, ok := s.LastRun("missing"); ok {
+		t.Error("expected no run of an unknown task")
+	}
+}
+
+func TestScheduler_RunsDoNotOverlap(t *testing.T) {
+	var running, overlaps, runs int32
+	s := New()
+	s.Add("slow", tick(time.Millisecond), func(ctx context.Context) error {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+	if runs == 0 || overlaps != 0 {
+		t.Errorf("expected sequential runs, got %d runs and %d overlaps", runs, overlaps)
+	}
+}
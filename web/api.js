@@ -3,9 +3,125 @@
 let fetchedData = [];
 
 // API_BASE_URL is the base URL for all backend API calls.
-// Assumes the backend is running on localhost:1323.
-// Adjust this if your backend is hosted elsewhere.
-const API_BASE_URL = 'http://localhost:1323/api';
+// It defaults to the API of the server that served this page and is replaced by
+// the apiBaseUrl returned from /api/config in loadConfig.
+let API_BASE_URL = '/api/v1';
+
+// enabledProviders lists the Git providers the backend has tokens for, e.g. ["github"].
+let enabledProviders = [];
+
+// providerEndpoints maps each enabled provider to the endpoints it supports, e.g. {"github": ["/loc", ...]}.
+let providerEndpoints = {};
+
+// authRequired is true when the backend rejects API requests without an API key or bearer token.
+let authRequired = false;
+
+// CREDENTIAL_STORAGE_KEY is the localStorage key the API key or bearer token is kept under.
+const CREDENTIAL_STORAGE_KEY = 'gitstatsCredential';
+
+/**
+ * Loads the server capabilities (API base URL, enabled providers and their endpoints) from the
+ * /api/config endpoint of the server that served this page.
+ */
+async function loadConfig() {
+    const response = await fetch('/api/config');
+    if (!response.ok) {
+        throw new Error(`Failed to load configuration: ${response.status} ${response.statusText}`);
+    }
+    const config = await response.json();
+    API_BASE_URL = config.apiBaseUrl || API_BASE_URL;
+    enabledProviders = config.providers || [];
+    providerEndpoints = config.endpoints || {};
+    authRequired = Boolean(config.auth && config.auth.required);
+}
+
+/**
+ * Fetches an API URL, authenticating with the stored API key or bearer token when the backend
+ * requires it. The user is asked for one if none is stored; a rejected one is forgotten so the
+ * next request asks again.
+ * @param {string} url - API URL.
+ * @returns {Promise<Response>}
+ */
+async function apiFetch(url) {
+    const headers = {};
+    if (authRequired) {
+        let credential = localStorage.getItem(CREDENTIAL_STORAGE_KEY);
+        if (!credential) {
+            credential = (window.prompt('This server requires authentication. Enter your API key or bearer token:') || '').trim();
+            if (credential) {
+                localStorage.setItem(CREDENTIAL_STORAGE_KEY, credential);
+            }
+        }
+        if (credential) {
+            headers['Authorization'] = `Bearer ${credential}`;
+        }
+    }
+    const response = await fetch(url, { headers });
+    if (response.status === 401) {
+        localStorage.removeItem(CREDENTIAL_STORAGE_KEY);
+    }
+    return response;
+}
+
+// JOB_POLL_INTERVAL_MS is how often the status of a background job is polled.
+const JOB_POLL_INTERVAL_MS = 1000;
+
+/**
+ * Fetches an API URL like apiFetch, following the job of a 202 Accepted response: expensive
+ * endpoints such as /loc run as background jobs, whose status is polled until the job finished
+ * and whose result is then returned as the response.
+ * @param {string} url - API URL.
+ * @returns {Promise<Response>}
+ */
+async function apiFetchResult(url) {
+    const response = await apiFetch(url);
+    if (response.status !== 202 || !response.headers.get('Location')) {
+        return response;
+    }
+    const jobUrl = new URL(response.headers.get('Location'), new URL(url, window.location.href)).href;
+    for (;;) {
+        await new Promise(resolve => setTimeout(resolve, JOB_POLL_INTERVAL_MS));
+        const statusResponse = await apiFetch(jobUrl);
+        if (!statusResponse.ok) {
+            return statusResponse;
+        }
+        const job = await statusResponse.json();
+        if (['succeeded', 'failed', 'canceled'].includes(job.status)) {
+            return apiFetch(`${jobUrl}/result`);
+        }
+    }
+}
+
+/**
+ * Builds an Error for a failed API response. The /api/v1 routes answer errors with
+ * {"error": {"code", "message", "provider", "requestId"}}; its message and request ID are used
+ * when present, the HTTP status text otherwise.
+ * @param {Response} response - The failed response.
+ * @param {string} what - What could not be retrieved, e.g. "Commit data".
+ * @returns {Promise<Error>}
+ */
+async function responseError(response, what) {
+    let detail = `${response.status} ${response.statusText}`;
+    try {
+        const body = await response.json();
+        if (body && body.error && body.error.message) {
+            detail = `${body.error.message} (request ID ${body.error.requestId})`;
+        }
+    } catch (e) {
+        // Not a JSON error envelope; keep the status text.
+    }
+    return new Error(`${what} could not be retrieved: ${detail}`);
+}
+
+/**
+ * Reports whether the backend registered the given endpoint for a provider.
+ * @param {string} provider - Provider name, e.g. "github".
+ * @param {string} endpoint - Endpoint path relative to the provider, e.g. "/loc".
+ * @returns {boolean}
+ */
+function supports(provider, endpoint) {
+    return (providerEndpoints[provider] || []).includes(endpoint);
+}
 
 /**
  * Fetches the list of available GitHub repositories from the backend.
@@ -18,10 +134,15 @@ async function getProjectInfo() {
     const projectInfoDiv = document.getElementById("projectInfo"); // For error display
 
     try {
+        await loadConfig();
+        if (!enabledProviders.includes('github')) {
+            throw new Error('GitHub is not enabled on this server; set a GitHub token to browse repositories');
+        }
+
         // Fetch repositories from the backend's GitHub proxy endpoint.
-        const response = await fetch(`${API_BASE_URL}/github/repos`);
+        const response = await apiFetch(`${API_BASE_URL}/github/repos`);
         if (!response.ok) {
-            throw new Error(`Failed to fetch repositories: ${response.status} ${response.statusText}`);
+            throw await responseError(response, 'Repositories');
         }
         const data = await response.json(); // Expects an array of common_types.Repository.
 
@@ -87,17 +208,19 @@ async function getProjectData() {
     try {
         const projectOwner = selectedProject.owner;
         const projectName = selectedProject.name;
-        const projectCloneURL = selectedProject.cloneURL;
 
         // Fetch commits
-        const responseCommits = await fetch(`${API_BASE_URL}/github/commits?projectOwner=${projectOwner}&repoName=${projectName}`);
-        if (!responseCommits.ok) throw new Error(`Commit data could not be retrieved: ${responseCommits.statusText}`);
+        const responseCommits = await apiFetch(`${API_BASE_URL}/github/commits?owner=${projectOwner}&repo=${projectName}`);
+        if (!responseCommits.ok) throw await responseError(responseCommits, 'Commit data');
         const commitsData = await responseCommits.json();
 
-        // Fetch LOC
-        const responseLOC = await fetch(`${API_BASE_URL}/github/loc?repoUrl=${projectCloneURL}`);
-        if (!responseLOC.ok) throw new Error(`LOC data could not be retrieved: ${responseLOC.statusText}`);
-        const locJson = await responseLOC.json();
+        // Fetch LOC, if the backend supports it.
+        let locJson = {};
+        if (supports('github', '/loc')) {
+            const responseLOC = await apiFetchResult(`${API_BASE_URL}/github/loc?owner=${projectOwner}&repo=${projectName}`);
+            if (!responseLOC.ok) throw await responseError(responseLOC, 'LOC data');
+            locJson = await responseLOC.json();
+        }
 
         // Aggregate commit stats
         let commitsMap = new Map();
@@ -111,10 +234,13 @@ async function getProjectData() {
             commitsMap.set(authorKey, userStats);
         }
 
-        // Fetch contributors
-        const contributorsResponse = await fetch(`${API_BASE_URL}/github/contributors?owner=${projectOwner}&repoName=${projectName}`);
-        if (!contributorsResponse.ok) throw new Error(`Contributor data could not be retrieved: ${contributorsResponse.statusText}`);
-        const contributorsData = await contributorsResponse.json();
+        // Fetch contributors, if the backend supports it.
+        let contributorsData = [];
+        if (supports('github', '/contributors')) {
+            const contributorsResponse = await apiFetch(`${API_BASE_URL}/github/contributors?owner=${projectOwner}&repo=${projectName}`);
+            if (!contributorsResponse.ok) throw await responseError(contributorsResponse, 'Contributor data');
+            contributorsData = await contributorsResponse.json();
+        }
 
         // --- Build UI with DOM elements ---
         projectInfoDiv.innerHTML = ''; // Clear loading message
@@ -0,0 +1,10 @@
+// Package web holds the dashboard's static assets, embedded so that `gitstats serve` can
+// serve them without a separate web server.
+package web
+
+import "embed"
+
+// Assets contains index.html and api.js at its root.
+//
+//go:embed index.html api.js
+var Assets embed.FS
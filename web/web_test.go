@@ -0,0 +1,23 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAssets_ServedByFileServer(t *testing.T) {
+	server := http.FileServerFS(Assets)
+
+	for path, want := range map[string]string{"/": "<script src=\"api.js\"></script>", "/api.js": "/api/config"} {
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", path, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("GET %s: expected body to contain %q", path, want)
+		}
+	}
+}